@@ -0,0 +1,232 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * adapters.go: Building items from existing data
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+type ErrInvalidInput struct {
+	reason string
+}
+
+func (ex ErrInvalidInput) Error() string {
+	return fmt.Sprintf("Invalid input: %s", ex.reason)
+}
+
+// Returns the reason the input was rejected
+func (ex ErrInvalidInput) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrInvalidInput) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidInput", map[string]interface{}{"reason": ex.reason}}
+}
+
+// Returns an item per entry of the map, weighted by its value and holding its
+// key, in ascending order of the keys so that the store built from them doesn't
+// depend on the iteration order of the map
+func WeightedFromMap(m map[string]uint64) []Weighted {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ret := make([]Weighted, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, DefaultWeightedValue{m[k], k})
+	}
+	return ret
+}
+
+// Returns an item per element of slice, which must be a slice of structs or of
+// pointers to structs, taking the weight from the field named weightField and the
+// value from the field named valueField. The weight field may be of any integer
+// type, but mustn't be negative. An empty valueField uses each element as its
+// value. Returns ErrInvalidInput if slice or its elements don't fit.
+func WeightedFromSlice(slice interface{}, weightField, valueField string) ([]Weighted, error) {
+	s := reflect.ValueOf(slice)
+	if s.Kind() != reflect.Slice {
+		return nil, ErrInvalidInput{fmt.Sprintf("expected a slice, got %T", slice)}
+	}
+	ret := make([]Weighted, 0, s.Len())
+	for i := 0; i < s.Len(); i += 1 {
+		elem := s.Index(i)
+		st := elem
+		if st.Kind() == reflect.Ptr {
+			if st.IsNil() {
+				return nil, ErrInvalidInput{fmt.Sprintf("element %d is nil", i)}
+			}
+			st = st.Elem()
+		}
+		if st.Kind() != reflect.Struct {
+			return nil, ErrInvalidInput{fmt.Sprintf("element %d is a %s, not a struct", i, st.Type())}
+		}
+
+		wf := st.FieldByName(weightField)
+		if !wf.IsValid() {
+			return nil, ErrInvalidInput{fmt.Sprintf("element %d has no field %s", i, weightField)}
+		}
+		var weight uint64
+		switch wf.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			weight = wf.Uint()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if wf.Int() < 0 {
+				return nil, ErrInvalidInput{fmt.Sprintf("element %d has a negative weight %d", i, wf.Int())}
+			}
+			weight = uint64(wf.Int())
+		default:
+			return nil, ErrInvalidInput{fmt.Sprintf("field %s of element %d is a %s, not an integer", weightField, i, wf.Type())}
+		}
+
+		value := elem.Interface()
+		if valueField != "" {
+			vf := st.FieldByName(valueField)
+			if !vf.IsValid() {
+				return nil, ErrInvalidInput{fmt.Sprintf("element %d has no field %s", i, valueField)}
+			}
+			if !vf.CanInterface() {
+				return nil, ErrInvalidInput{fmt.Sprintf("field %s of element %d is unexported", valueField, i)}
+			}
+			value = vf.Interface()
+		}
+		ret = append(ret, DefaultWeightedValue{weight, value})
+	}
+	return ret, nil
+}
+
+// Returns n items, the i-th of which has the weight and value returned by the
+// functions for i, for data which is already held in some other shape
+func WeightedFunc(n int, weight func(i int) uint64, value func(i int) interface{}) []Weighted {
+	ret := make([]Weighted, 0, n)
+	for i := 0; i < n; i += 1 {
+		ret = append(ret, DefaultWeightedValue{weight(i), value(i)})
+	}
+	return ret
+}
+
+// Returns an item per index, covering [mins[i], maxes[i]] with values[i]. Returns
+// ErrLengthMismatch unless the slices have the same length.
+func RangedFromPairs(mins, maxes []uint64, values []interface{}) ([]Ranged, error) {
+	if len(maxes) != len(mins) {
+		return nil, ErrLengthMismatch{len(mins), len(maxes)}
+	}
+	if len(values) != len(mins) {
+		return nil, ErrLengthMismatch{len(mins), len(values)}
+	}
+	ret := make([]Ranged, 0, len(mins))
+	for i := range mins {
+		ret = append(ret, RangeEntry{mins[i], maxes[i], values[i]})
+	}
+	return ret, nil
+}
+
+// Returns contiguous items between the boundaries, the i-th covering the keys
+// from boundaries[i] up to, but not including, boundaries[i+1] with values[i],
+// so there must be one more boundary than values. The boundaries must be
+// strictly increasing, otherwise ErrOverlap is returned, and since the last one
+// is excluded, the items can't reach math.MaxUint64.
+func RangedFromBoundaries(boundaries []uint64, values []interface{}) ([]Ranged, error) {
+	if len(boundaries) != len(values)+1 {
+		return nil, ErrLengthMismatch{len(values) + 1, len(boundaries)}
+	}
+	ret := make([]Ranged, 0, len(values))
+	for i, v := range values {
+		if boundaries[i+1] <= boundaries[i] {
+			return nil, ErrOverlap{boundaries[i], boundaries[i+1]}
+		}
+		ret = append(ret, RangeEntry{boundaries[i], boundaries[i+1] - 1, v})
+	}
+	return ret, nil
+}
+
+// Returns n items, the i-th of which has the bounds and value returned by the
+// functions for i
+func RangedFunc(n int, min, max func(i int) uint64, value func(i int) interface{}) []Ranged {
+	ret := make([]Ranged, 0, n)
+	for i := 0; i < n; i += 1 {
+		ret = append(ret, RangeEntry{min(i), max(i), value(i)})
+	}
+	return ret
+}
+
+// The values between two bucket boundaries of a histogram, above Lower and up to
+// and including Upper, as held by the items returned by WeightedFromHistogram
+type ObservedBucket struct {
+	Lower, Upper float64
+}
+
+// Returns a value drawn uniformly from the bucket, the way histogram_quantile
+// interpolates within a bucket. A bucket without an upper bound returns its
+// lower bound.
+func (b ObservedBucket) Sample(r RandSource) float64 {
+	if math.IsInf(b.Upper, 1) {
+		return b.Lower
+	}
+	f := float64(r.Uint64()>>11) / (1 << 53)
+	return b.Upper - f*(b.Upper-b.Lower)
+}
+
+// Returns an item per bucket of a cumulative histogram, as exported by
+// Prometheus, with the upper boundaries le and the cumulative counts of the
+// observations up to and including each boundary. Each item holds an
+// ObservedBucket, weighted by the observations between its boundaries, so that
+// picking from the store built from them and sampling the bucket reproduces
+// the observed distribution. As with histogram_quantile, the first bucket starts
+// at 0 unless its boundary is negative, in which case it holds only that
+// boundary. Buckets without observations are left out.
+//
+// The boundaries must be strictly increasing and the counts mustn't decrease,
+// otherwise ErrInvalidInput is returned, and ErrLengthMismatch is returned
+// unless there are as many counts as boundaries, and ErrEmptyInput if there are
+// none.
+func WeightedFromHistogram(le []float64, counts []uint64) ([]Weighted, error) {
+	if len(counts) != len(le) {
+		return nil, ErrLengthMismatch{len(le), len(counts)}
+	}
+	if len(le) == 0 {
+		return nil, ErrEmptyInput{}
+	}
+	ret := make([]Weighted, 0, len(le))
+	lower, prev := math.Min(0, le[0]), uint64(0)
+	for i, upper := range le {
+		if math.IsNaN(upper) || (i > 0 && upper <= le[i-1]) {
+			return nil, ErrInvalidInput{fmt.Sprintf("boundary %d, %v, doesn't increase", i, upper)}
+		}
+		if counts[i] < prev {
+			return nil, ErrInvalidInput{fmt.Sprintf("count %d, %d, is less than the one before it", i, counts[i])}
+		}
+		if counts[i] > prev {
+			ret = append(ret, DefaultWeightedValue{counts[i] - prev, ObservedBucket{lower, upper}})
+		}
+		lower, prev = upper, counts[i]
+	}
+	return ret, nil
+}
@@ -0,0 +1,179 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * adapters_test.go: Tests for building items from existing data
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWeightedFromMap(t *testing.T) {
+	items := WeightedFromMap(map[string]uint64{"B": 70, "A": 25, "C": 5})
+	expected := []Weighted{DefaultWeightedValue{25, "A"}, DefaultWeightedValue{70, "B"}, DefaultWeightedValue{5, "C"}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("Wrong items: %v", items)
+	}
+}
+
+type testUpstream struct {
+	Host   string
+	Weight int
+}
+
+func TestWeightedFromSlice(t *testing.T) {
+	upstreams := []testUpstream{{"a.example", 1}, {"b.example", 3}}
+	items, err := WeightedFromSlice(upstreams, "Weight", "Host")
+	if err != nil {
+		t.Fatalf("Error while adapting the slice: %s", err.Error())
+	}
+	w, err := NewWeightedStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing weighted store: %s", err.Error())
+	}
+	if v, _ := w.RangeSearch(2); v != "b.example" || w.Total() != 4 {
+		t.Fatalf("Wrong store built from the slice: %v", v)
+	}
+
+	// Pointers to structs, with the elements themselves as values
+	items, err = WeightedFromSlice([]*testUpstream{&upstreams[0]}, "Weight", "")
+	if err != nil || items[0].GetValue() != &upstreams[0] || items[0].GetWeight() != 1 {
+		t.Fatalf("Wrong items from a slice of pointers: %v", items)
+	}
+
+	for name, slice := range map[string]interface{}{
+		"not a slice":     upstreams[0],
+		"not structs":     []int{1},
+		"nil pointer":     []*testUpstream{nil},
+		"negative weight": []testUpstream{{"a.example", -1}},
+	} {
+		if _, err := WeightedFromSlice(slice, "Weight", "Host"); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidInput" {
+			t.Fatalf("Expected ErrInvalidInput for %s, got %v", name, err)
+		}
+	}
+	if _, err := WeightedFromSlice(upstreams, "Host", "Host"); err == nil {
+		t.Fatalf("Expected an error for a weight which isn't an integer")
+	}
+	if _, err := WeightedFromSlice(upstreams, "Weight", "Port"); err == nil {
+		t.Fatalf("Expected an error for a missing field")
+	}
+}
+
+func TestWeightedFunc(t *testing.T) {
+	names := []string{"A", "B"}
+	weights := []uint64{1, 2}
+	items := WeightedFunc(len(names), func(i int) uint64 { return weights[i] }, func(i int) interface{} { return names[i] })
+	if !reflect.DeepEqual(items, []Weighted{DefaultWeightedValue{1, "A"}, DefaultWeightedValue{2, "B"}}) {
+		t.Fatalf("Wrong items: %v", items)
+	}
+}
+
+func TestRangedFromPairs(t *testing.T) {
+	items, err := RangedFromPairs([]uint64{0, 10}, []uint64{9, 19}, []interface{}{"A", "B"})
+	if err != nil {
+		t.Fatalf("Error while adapting pairs: %s", err.Error())
+	}
+	if !reflect.DeepEqual(items, []Ranged{RangeEntry{0, 9, "A"}, RangeEntry{10, 19, "B"}}) {
+		t.Fatalf("Wrong items: %v", items)
+	}
+	if _, err := RangedFromPairs([]uint64{0, 10}, []uint64{9}, []interface{}{"A", "B"}); err == nil || reflect.TypeOf(err).Name() != "ErrLengthMismatch" {
+		t.Fatalf("Expected ErrLengthMismatch, got %v", err)
+	}
+	if _, err := RangedFromPairs([]uint64{0}, []uint64{9}, []interface{}{"A", "B"}); err == nil || reflect.TypeOf(err).Name() != "ErrLengthMismatch" {
+		t.Fatalf("Expected ErrLengthMismatch, got %v", err)
+	}
+}
+
+func TestRangedFromBoundaries(t *testing.T) {
+	items, err := RangedFromBoundaries([]uint64{0, 10, 100}, []interface{}{"A", "B"})
+	if err != nil {
+		t.Fatalf("Error while adapting boundaries: %s", err.Error())
+	}
+	if !reflect.DeepEqual(items, []Ranged{RangeEntry{0, 9, "A"}, RangeEntry{10, 99, "B"}}) {
+		t.Fatalf("Wrong items: %v", items)
+	}
+	if _, err := NewRangeStoreFromSorted(items); err != nil {
+		t.Fatalf("Expected the items to be contiguous: %s", err.Error())
+	}
+	if _, err := RangedFromBoundaries([]uint64{0, 10}, []interface{}{"A", "B"}); err == nil || reflect.TypeOf(err).Name() != "ErrLengthMismatch" {
+		t.Fatalf("Expected ErrLengthMismatch, got %v", err)
+	}
+	if _, err := RangedFromBoundaries([]uint64{0, 10, 10}, []interface{}{"A", "B"}); err == nil || reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected ErrOverlap, got %v", err)
+	}
+}
+
+func TestRangedFunc(t *testing.T) {
+	items := RangedFunc(2, func(i int) uint64 { return uint64(i * 10) }, func(i int) uint64 { return uint64(i*10 + 9) }, func(i int) interface{} { return i })
+	if !reflect.DeepEqual(items, []Ranged{RangeEntry{0, 9, 0}, RangeEntry{10, 19, 1}}) {
+		t.Fatalf("Wrong items: %v", items)
+	}
+}
+
+func TestWeightedFromHistogram(t *testing.T) {
+	le := []float64{0.1, 0.5, 1, math.Inf(1)}
+	items, err := WeightedFromHistogram(le, []uint64{10, 40, 40, 50})
+	if err != nil {
+		t.Fatalf("Error while adapting the histogram: %s", err.Error())
+	}
+	// The empty bucket between 0.5 and 1 is left out
+	expected := []Weighted{
+		DefaultWeightedValue{10, ObservedBucket{0, 0.1}},
+		DefaultWeightedValue{30, ObservedBucket{0.1, 0.5}},
+		DefaultWeightedValue{10, ObservedBucket{1, math.Inf(1)}},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("Wrong items: %v", items)
+	}
+	w, err := NewWeightedStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing weighted store: %s", err.Error())
+	}
+	if w.Total() != 50 {
+		t.Fatalf("Wrong total weight: %d", w.Total())
+	}
+
+	b := ObservedBucket{0.1, 0.5}
+	if v := b.Sample(&sequenceSource{values: []uint64{0}}); v != 0.5 {
+		t.Fatalf("Wrong sample at the top of the bucket: %v", v)
+	}
+	if v := b.Sample(&sequenceSource{values: []uint64{math.MaxUint64}}); v < 0.1 || v > 0.5 {
+		t.Fatalf("Sample outside of the bucket: %v", v)
+	}
+	if v := expected[2].GetValue().(ObservedBucket).Sample(&sequenceSource{values: []uint64{1 << 63}}); v != 1 {
+		t.Fatalf("Expected the open bucket to return its lower bound, got %v", v)
+	}
+
+	if _, err := WeightedFromHistogram([]float64{1, 1}, []uint64{1, 2}); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidInput" {
+		t.Fatalf("Expected ErrInvalidInput for repeated boundaries, got %v", err)
+	}
+	if _, err := WeightedFromHistogram([]float64{1, 2}, []uint64{5, 2}); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidInput" {
+		t.Fatalf("Expected ErrInvalidInput for decreasing counts, got %v", err)
+	}
+	if _, err := WeightedFromHistogram([]float64{1, 2}, []uint64{5}); err == nil || reflect.TypeOf(err).Name() != "ErrLengthMismatch" {
+		t.Fatalf("Expected ErrLengthMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * adaptive.go: Self optimizing store based on observed lookups
+ */
+
+package rangestore
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A range store which records how often each range is hit and can rebuild
+// itself to match the observed distribution of lookups. Useful for long running
+// services where the access pattern isn't known up front.
+//
+// AdaptiveStore is safe for concurrent use.
+type AdaptiveStore struct {
+	mu   sync.RWMutex
+	root *Node
+}
+
+// A range along with the number of lookups which hit it
+type RangeHit struct {
+	Entry RangeEntry
+	Count uint64
+}
+
+// Wraps each stored value with a hit counter, so that the counts survive rebuilds
+type countedValue struct {
+	hits  uint64
+	value interface{}
+}
+
+// Creates an adaptive store from the items, which must satisfy the same constraints
+// as NewRangeStoreFromSorted. Until RebuildOptimized is called, the tree has the
+// same shape as the one produced by NewRangeStoreFromSorted.
+func NewAdaptiveRangeStore(items []Ranged) (*AdaptiveStore, error) {
+	wrapped := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		wrapped = append(wrapped, DefaultRangedValue{item.GetMin(), item.GetMax(), &countedValue{value: item.GetValue()}})
+	}
+	n, err := NewRangeStoreFromSorted(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return &AdaptiveStore{root: n}, nil
+}
+
+// Searches for the range which contains the specified key, recording the hit
+func (a *AdaptiveStore) RangeSearch(val uint64) (interface{}, error) {
+	a.mu.RLock()
+	root := a.root
+	a.mu.RUnlock()
+	v, err := root.RangeSearch(val)
+	if err != nil {
+		return nil, err
+	}
+	cv := v.(*countedValue)
+	atomic.AddUint64(&cv.hits, 1)
+	return cv.value, nil
+}
+
+// Reconstructs the tree using the observed hit counts as the access frequencies,
+// so that the most frequently hit ranges move towards the root. Hit counts are
+// retained across rebuilds. If nothing has been looked up yet, the tree is left
+// untouched.
+func (a *AdaptiveStore) RebuildOptimized() error {
+	a.mu.RLock()
+	root := a.root
+	a.mu.RUnlock()
+
+	items := make([]Ranged, 0)
+	freq := make([]uint64, 0)
+	total := uint64(0)
+	root.walk(func(n *Node) {
+		hits := atomic.LoadUint64(&n.value.(*countedValue).hits)
+		items = append(items, DefaultRangedValue{n.min, n.max, n.value})
+		freq = append(freq, hits)
+		total += hits
+	})
+	if total == 0 {
+		return nil
+	}
+
+	n, err := NewRangeStoreFromSortedWithFrequencies(items, freq)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.root = n
+	a.mu.Unlock()
+	return nil
+}
+
+// Returns the number of lookups which hit each range since it was created or the
+// counts were last reset, in ascending key order, e.g. to find ranges which are
+// no longer used
+func (a *AdaptiveStore) HitCounts() []RangeHit {
+	return a.collectCounts(func(hits *uint64) uint64 {
+		return atomic.LoadUint64(hits)
+	})
+}
+
+// Resets the hit counts to zero, returning the counts as they were, so that
+// periodic exports don't lose hits made between reading the counts and resetting
+// them. Until ranges are hit again, RebuildOptimized leaves the tree untouched,
+// so resetting lets the next rebuild follow recent lookups only.
+func (a *AdaptiveStore) ResetCounts() []RangeHit {
+	return a.collectCounts(func(hits *uint64) uint64 {
+		return atomic.SwapUint64(hits, 0)
+	})
+}
+
+func (a *AdaptiveStore) collectCounts(read func(hits *uint64) uint64) []RangeHit {
+	a.mu.RLock()
+	root := a.root
+	a.mu.RUnlock()
+	ret := make([]RangeHit, 0)
+	root.walk(func(n *Node) {
+		cv := n.value.(*countedValue)
+		ret = append(ret, RangeHit{RangeEntry{n.min, n.max, cv.value}, read(&cv.hits)})
+	})
+	return ret
+}
@@ -0,0 +1,135 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * adaptive_test.go: Tests on the adaptive store
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAdaptiveStore_RebuildOptimized(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 99, "A"})
+	items = append(items, DefaultRangedValue{100, 199, "B"})
+	items = append(items, DefaultRangedValue{200, 299, "C"})
+	items = append(items, DefaultRangedValue{300, 300, "D"})
+
+	a, err := NewAdaptiveRangeStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	for i := 0; i < 100; i += 1 {
+		v, err := a.RangeSearch(300)
+		if err != nil {
+			t.Fatalf("Got an error while searching: %s", err.Error())
+		}
+		if v != "D" {
+			t.Fatalf("Got invalid value back %s [%s]", v, "D")
+		}
+	}
+	if _, err := a.RangeSearch(150); err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+
+	if err := a.RebuildOptimized(); err != nil {
+		t.Fatalf("Error while rebuilding: %s", err.Error())
+	}
+	if a.root.value.(*countedValue).value != "D" {
+		t.Fatalf("Expected the hot range D at the root after rebuilding")
+	}
+
+	for key, expected := range map[uint64]string{0: "A", 150: "B", 250: "C", 300: "D"} {
+		v, err := a.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching: %s", err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back %s [%s]", v, expected)
+		}
+	}
+	if _, err := a.RangeSearch(301); err == nil {
+		t.Fatalf("Expected an error while performing an out of range search, got nothing")
+	}
+}
+
+func TestAdaptiveStore_RebuildWithoutHits(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+
+	a, err := NewAdaptiveRangeStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	root := a.root
+	if err := a.RebuildOptimized(); err != nil {
+		t.Fatalf("Error while rebuilding: %s", err.Error())
+	}
+	if a.root != root {
+		t.Fatalf("Expected the tree to be left untouched without any hits")
+	}
+}
+
+func TestAdaptiveStore_Empty(t *testing.T) {
+	_, err := NewAdaptiveRangeStore(make([]Ranged, 0))
+	if err == nil {
+		t.Fatalf("Error while constructing range store: Expected an error, but none generated")
+	}
+}
+
+func TestAdaptiveStore_HitCounts(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	a, err := NewAdaptiveRangeStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	for _, key := range []uint64{1, 2, 15, 25} {
+		a.RangeSearch(key)
+	}
+	expected := []RangeHit{{RangeEntry{0, 9, "A"}, 2}, {RangeEntry{10, 19, "B"}, 1}}
+	if got := a.HitCounts(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong hit counts: %v", got)
+	}
+
+	if got := a.ResetCounts(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected the counts before resetting, got %v", got)
+	}
+	a.RangeSearch(15)
+	if got := a.HitCounts(); got[0].Count != 0 || got[1].Count != 1 {
+		t.Fatalf("Wrong hit counts after resetting: %v", got)
+	}
+	// Counts survive rebuilds
+	if err := a.RebuildOptimized(); err != nil {
+		t.Fatalf("Error while rebuilding: %s", err.Error())
+	}
+	if got := a.HitCounts(); got[1].Count != 1 {
+		t.Fatalf("Wrong hit counts after rebuilding: %v", got)
+	}
+}
@@ -0,0 +1,256 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * aggregate.go: Aggregates of numeric values over key intervals
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+type ErrNonNumericValue struct {
+	v interface{}
+}
+
+func (ex ErrNonNumericValue) Error() string {
+	return fmt.Sprintf("Value %v of type %T isn't a non-negative integer", ex.v, ex.v)
+}
+
+// Returns the value which isn't a number
+func (ex ErrNonNumericValue) Value() interface{} {
+	return ex.v
+}
+
+func (ex ErrNonNumericValue) Details() ErrorDetails {
+	return ErrorDetails{"ErrNonNumericValue", map[string]interface{}{"value": ex.v}}
+}
+
+type ErrSumOverflow struct {
+	a, b uint64
+}
+
+func (ex ErrSumOverflow) Error() string {
+	return fmt.Sprintf("The sum over [%d, %d] doesn't fit in 64 bits", ex.a, ex.b)
+}
+
+// Returns the keys which were summed over
+func (ex ErrSumOverflow) Range() (a, b uint64) {
+	return ex.a, ex.b
+}
+
+func (ex ErrSumOverflow) Details() ErrorDetails {
+	return ErrorDetails{"ErrSumOverflow", map[string]interface{}{"a": ex.a, "b": ex.b}}
+}
+
+// Answers aggregate queries over the keys of a store with numeric values, such
+// as a price per unit over ranges of IDs, in O(log n). Each covered key counts
+// as one instance of the value of its range, so a range of 10 keys holding 3
+// adds 30 to a sum. The annotations live beside the tree rather than in its
+// nodes, the way the lookup backends do, so stores which never aggregate don't
+// pay for them.
+//
+// Aggregates are a snapshot of the tree as it was when NewAggregates was called.
+// Later changes to the tree, such as Append or UpdateOver, aren't reflected;
+// build new aggregates after changing the tree.
+type Aggregates struct {
+	mins, maxs []uint64
+	values     []uint64
+	// Running totals of value times width, as 128 bit numbers, since even the
+	// widths alone can add up to 2^64
+	sumHi, sumLo []uint64
+	// Segment trees over the values, with the leaves from len(values) onwards
+	low, high []uint64
+}
+
+// Builds the aggregates of the store, whose values must all be integers, signed
+// or unsigned, and not negative; otherwise ErrNonNumericValue is returned
+func NewAggregates(n *Node) (*Aggregates, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	count := n.Len()
+	a := &Aggregates{
+		mins:   make([]uint64, 0, count),
+		maxs:   make([]uint64, 0, count),
+		values: make([]uint64, 0, count),
+		sumHi:  make([]uint64, 1, count+1),
+		sumLo:  make([]uint64, 1, count+1),
+		low:    make([]uint64, 2*count),
+		high:   make([]uint64, 2*count),
+	}
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		v, ok := numericValue(c.value)
+		if !ok {
+			err = ErrNonNumericValue{c.value}
+			return
+		}
+		hi, lo := rangeSum(v, c.min, c.max)
+		last := len(a.sumLo) - 1
+		hi, lo = add128(a.sumHi[last], a.sumLo[last], hi, lo)
+		a.sumHi, a.sumLo = append(a.sumHi, hi), append(a.sumLo, lo)
+		a.mins, a.maxs, a.values = append(a.mins, c.min), append(a.maxs, c.max), append(a.values, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	copy(a.low[count:], a.values)
+	copy(a.high[count:], a.values)
+	for i := count - 1; i > 0; i -= 1 {
+		a.low[i], a.high[i] = a.low[2*i], a.high[2*i]
+		if a.low[2*i+1] < a.low[i] {
+			a.low[i] = a.low[2*i+1]
+		}
+		if a.high[2*i+1] > a.high[i] {
+			a.high[i] = a.high[2*i+1]
+		}
+	}
+	return a, nil
+}
+
+// Converts integers which aren't negative to uint64
+func numericValue(v interface{}) (uint64, bool) {
+	switch x := v.(type) {
+	case uint:
+		return uint64(x), true
+	case uint8:
+		return uint64(x), true
+	case uint16:
+		return uint64(x), true
+	case uint32:
+		return uint64(x), true
+	case uint64:
+		return x, true
+	case int:
+		return uint64(x), x >= 0
+	case int8:
+		return uint64(x), x >= 0
+	case int16:
+		return uint64(x), x >= 0
+	case int32:
+		return uint64(x), x >= 0
+	case int64:
+		return uint64(x), x >= 0
+	}
+	return 0, false
+}
+
+// Returns v times the number of keys from min to max, as a 128 bit number
+func rangeSum(v, min, max uint64) (hi, lo uint64) {
+	hi, lo = bits.Mul64(v, max-min)
+	return add128(hi, lo, 0, v)
+}
+
+func add128(ahi, alo, bhi, blo uint64) (hi, lo uint64) {
+	lo, carry := bits.Add64(alo, blo, 0)
+	hi, _ = bits.Add64(ahi, bhi, carry)
+	return hi, lo
+}
+
+func sub128(ahi, alo, bhi, blo uint64) (hi, lo uint64) {
+	lo, borrow := bits.Sub64(alo, blo, 0)
+	hi, _ = bits.Sub64(ahi, bhi, borrow)
+	return hi, lo
+}
+
+// Returns the indices of the first and last ranges intersecting [a, b], with
+// first > last if there are none
+func (ag *Aggregates) span(a, b uint64) (first, last int) {
+	first = sort.Search(len(ag.maxs), func(i int) bool { return ag.maxs[i] >= a })
+	last = sort.Search(len(ag.mins), func(i int) bool { return ag.mins[i] > b }) - 1
+	return first, last
+}
+
+// Returns the sum of the values of every covered key from a to b, which is 0 if
+// none are covered. Returns ErrInvertedRange if a is greater than b, and
+// ErrSumOverflow if the sum doesn't fit a uint64.
+func (ag *Aggregates) SumOver(a, b uint64) (uint64, error) {
+	if a > b {
+		return 0, ErrInvertedRange{a, b}
+	}
+	first, last := ag.span(a, b)
+	if first > last {
+		return 0, nil
+	}
+	hi, lo := sub128(ag.sumHi[last+1], ag.sumLo[last+1], ag.sumHi[first], ag.sumLo[first])
+	// Take out the parts of the outer ranges which lie outside of [a, b]
+	if a > ag.mins[first] {
+		phi, plo := rangeSum(ag.values[first], ag.mins[first], a-1)
+		hi, lo = sub128(hi, lo, phi, plo)
+	}
+	if b < ag.maxs[last] {
+		phi, plo := rangeSum(ag.values[last], b+1, ag.maxs[last])
+		hi, lo = sub128(hi, lo, phi, plo)
+	}
+	if hi != 0 {
+		return 0, ErrSumOverflow{a, b}
+	}
+	return lo, nil
+}
+
+// Returns the smallest and largest values of the covered keys from a to b.
+// Returns ErrInvertedRange if a is greater than b, and ErrOutOfRange if none of
+// the keys are covered.
+func (ag *Aggregates) MinMaxOver(a, b uint64) (min, max uint64, err error) {
+	if a > b {
+		return 0, 0, ErrInvertedRange{a, b}
+	}
+	first, last := ag.span(a, b)
+	if first > last {
+		return 0, 0, ErrOutOfRange{a}
+	}
+	min, max = ag.values[first], ag.values[first]
+	// Walk up the segment trees from both ends of the half open [first, last+1)
+	count := len(ag.values)
+	for l, r := first+count, last+1+count; l < r; l, r = l/2, r/2 {
+		if l%2 == 1 {
+			min, max = minUint(min, ag.low[l]), maxUint(max, ag.high[l])
+			l += 1
+		}
+		if r%2 == 1 {
+			r -= 1
+			min, max = minUint(min, ag.low[r]), maxUint(max, ag.high[r])
+		}
+	}
+	return min, max, nil
+}
+
+func minUint(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,126 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * aggregate_test.go: Tests for aggregates over key intervals
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAggregates(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, 3})
+	items = append(items, DefaultRangedValue{20, 24, uint8(7)})
+	items = append(items, DefaultRangedValue{40, 49, int64(1)})
+	items = append(items, DefaultRangedValue{50, 50, uint64(9)})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	ag, err := NewAggregates(n)
+	if err != nil {
+		t.Fatalf("Error while building aggregates: %s", err.Error())
+	}
+
+	// Compare against summing key by key
+	for _, q := range [][2]uint64{{0, 100}, {15, 22}, {10, 10}, {19, 20}, {25, 39}, {0, 9}, {45, 50}, {24, 40}} {
+		want, first := uint64(0), true
+		var lo, hi uint64
+		for k := q[0]; k <= q[1]; k += 1 {
+			v, err := n.RangeSearch(k)
+			if err != nil {
+				continue
+			}
+			x, _ := numericValue(v)
+			want += x
+			if first || x < lo {
+				lo = x
+			}
+			if first || x > hi {
+				hi = x
+			}
+			first = false
+		}
+		if sum, err := ag.SumOver(q[0], q[1]); err != nil || sum != want {
+			t.Fatalf("Wrong sum over %v: %d, expected %d", q, sum, want)
+		}
+		min, max, err := ag.MinMaxOver(q[0], q[1])
+		if first {
+			if err == nil || reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+				t.Fatalf("Expected ErrOutOfRange over %v, got %v", q, err)
+			}
+			continue
+		}
+		if err != nil || min != lo || max != hi {
+			t.Fatalf("Wrong min and max over %v: %d, %d, expected %d, %d", q, min, max, lo, hi)
+		}
+	}
+
+	if _, err := ag.SumOver(5, 4); err == nil || reflect.TypeOf(err).Name() != "ErrInvertedRange" {
+		t.Fatalf("Expected ErrInvertedRange, got %v", err)
+	}
+	if _, _, err := ag.MinMaxOver(5, 4); err == nil || reflect.TypeOf(err).Name() != "ErrInvertedRange" {
+		t.Fatalf("Expected ErrInvertedRange, got %v", err)
+	}
+}
+
+func TestAggregates_Overflow(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, math.MaxUint64 - 1, 2})
+	items = append(items, DefaultRangedValue{math.MaxUint64, math.MaxUint64, 5})
+	n, _ := NewRangeStoreFromSorted(items)
+	ag, err := NewAggregates(n)
+	if err != nil {
+		t.Fatalf("Error while building aggregates: %s", err.Error())
+	}
+	_, err = ag.SumOver(0, math.MaxUint64)
+	if err == nil || reflect.TypeOf(err).Name() != "ErrSumOverflow" {
+		t.Fatalf("Expected ErrSumOverflow, got %v", err)
+	}
+	if a, b := err.(ErrSumOverflow).Range(); a != 0 || b != math.MaxUint64 {
+		t.Fatalf("Wrong range in the overflow: [%d, %d]", a, b)
+	}
+	// Narrow enough queries still fit, even though the totals don't
+	if sum, err := ag.SumOver(math.MaxUint64-2, math.MaxUint64); err != nil || sum != 9 {
+		t.Fatalf("Wrong sum at the top of the key space: %d, %v", sum, err)
+	}
+}
+
+func TestNewAggregates_NonNumeric(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, 1})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	n, _ := NewRangeStoreFromSorted(items)
+	if _, err := NewAggregates(n); err == nil || reflect.TypeOf(err).Name() != "ErrNonNumericValue" {
+		t.Fatalf("Expected ErrNonNumericValue, got %v", err)
+	}
+	items[1] = DefaultRangedValue{10, 19, -1}
+	n, _ = NewRangeStoreFromSorted(items)
+	if _, err := NewAggregates(n); err == nil || reflect.TypeOf(err).Name() != "ErrNonNumericValue" {
+		t.Fatalf("Expected ErrNonNumericValue for a negative value, got %v", err)
+	}
+	if _, err := NewAggregates(nil); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+		t.Fatalf("Expected ErrEmptyStore, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * allocator.go: Allocating free blocks of keys
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+type ErrNoFreeBlock struct {
+	size uint64
+}
+
+func (ex ErrNoFreeBlock) Error() string {
+	return fmt.Sprintf("No free block of %d keys", ex.size)
+}
+
+// Returns the number of keys which were requested
+func (ex ErrNoFreeBlock) Size() uint64 {
+	return ex.size
+}
+
+func (ex ErrNoFreeBlock) Details() ErrorDetails {
+	return ErrorDetails{"ErrNoFreeBlock", map[string]interface{}{"size": ex.size}}
+}
+
+type ErrNotAllocated struct {
+	min, max uint64
+}
+
+func (ex ErrNotAllocated) Error() string {
+	return fmt.Sprintf("Range [%d, %d] isn't within an allocated block", ex.min, ex.max)
+}
+
+// Returns the block which was released
+func (ex ErrNotAllocated) Range() (min, max uint64) {
+	return ex.min, ex.max
+}
+
+func (ex ErrNotAllocated) Details() ErrorDetails {
+	return ErrorDetails{"ErrNotAllocated", map[string]interface{}{"min": ex.min, "max": ex.max}}
+}
+
+type ErrZeroSize struct{}
+
+func (ex ErrZeroSize) Error() string {
+	return "Block size must be positive"
+}
+
+func (ex ErrZeroSize) Details() ErrorDetails {
+	return ErrorDetails{"ErrZeroSize", map[string]interface{}{}}
+}
+
+// A gap between allocated blocks
+type freeBlock struct {
+	min, max uint64
+}
+
+// Hands out blocks of consecutive keys from a key space, such as ID ranges or
+// address blocks, recording each allocated block as a range with a value, e.g.
+// its owner. The free space is kept as a sorted list of gaps, so allocation
+// doesn't need to walk the allocated blocks.
+//
+// Allocator is safe for concurrent use.
+type Allocator struct {
+	mu       sync.Mutex
+	min, max uint64
+	blocks   []RangeEntry
+	free     []freeBlock
+}
+
+// Creates an allocator over the keys from min to max inclusive, with the ranges
+// of blocks, which may be nil, already allocated. Returns an ErrOutOfRange for
+// the first key of blocks outside of the key space.
+func NewAllocator(blocks *Node, min, max uint64) (*Allocator, error) {
+	if min > max {
+		return nil, ErrInvertedRange{min, max}
+	}
+	a := &Allocator{min: min, max: max, blocks: make([]RangeEntry, 0)}
+	if blocks != nil {
+		a.blocks = blocks.Ranges()
+	}
+	next := min
+	for i, b := range a.blocks {
+		if b.Min < min {
+			return nil, ErrOutOfRange{b.Min}
+		}
+		if b.Max > max {
+			return nil, ErrOutOfRange{b.Max}
+		}
+		if b.Min > next {
+			a.free = append(a.free, freeBlock{next, b.Min - 1})
+		}
+		if b.Max == max {
+			// Nothing can follow a block ending at the last key
+			if i != len(a.blocks)-1 {
+				return nil, ErrOutOfRange{a.blocks[i+1].Min}
+			}
+			return a, nil
+		}
+		next = b.Max + 1
+	}
+	a.free = append(a.free, freeBlock{next, max})
+	return a, nil
+}
+
+// Allocates the first free block of size keys, recording it with the value, and
+// returns its bounds. Returns ErrNoFreeBlock if no gap is large enough.
+func (a *Allocator) AllocateBlock(size uint64, value interface{}) (min, max uint64, err error) {
+	return a.allocate(size, value, false)
+}
+
+// Same as AllocateBlock, but takes the block from the smallest gap which is
+// large enough, leaving larger gaps for larger blocks
+func (a *Allocator) AllocateBlockBestFit(size uint64, value interface{}) (min, max uint64, err error) {
+	return a.allocate(size, value, true)
+}
+
+func (a *Allocator) allocate(size uint64, value interface{}, best bool) (min, max uint64, err error) {
+	if size == 0 {
+		return 0, 0, ErrZeroSize{}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	found := -1
+	for i, f := range a.free {
+		// Widths are compared less one, so a gap of 2^64 keys can't overflow
+		if f.max-f.min < size-1 {
+			continue
+		}
+		if !best {
+			found = i
+			break
+		}
+		if found < 0 || f.max-f.min < a.free[found].max-a.free[found].min {
+			found = i
+		}
+	}
+	if found < 0 {
+		return 0, 0, ErrNoFreeBlock{size}
+	}
+
+	f := &a.free[found]
+	min, max = f.min, f.min+(size-1)
+	if max == f.max {
+		a.free = append(a.free[:found], a.free[found+1:]...)
+	} else {
+		f.min = max + 1
+	}
+	i := sort.Search(len(a.blocks), func(i int) bool {
+		return a.blocks[i].Min > min
+	})
+	a.blocks = append(a.blocks, RangeEntry{})
+	copy(a.blocks[i+1:], a.blocks[i:])
+	a.blocks[i] = RangeEntry{min, max, value}
+	return min, max, nil
+}
+
+// Frees the keys from min to max inclusive, which must lie within a single
+// allocated block, returning ErrNotAllocated otherwise. Releasing part of a
+// block leaves the rest of it allocated with the same value. The freed keys are
+// merged with any free space either side of them, so they can be allocated as
+// part of a larger block.
+func (a *Allocator) Release(min, max uint64) error {
+	if min > max {
+		return ErrInvertedRange{min, max}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	i := sort.Search(len(a.blocks), func(i int) bool {
+		return a.blocks[i].Max >= min
+	})
+	if i == len(a.blocks) || a.blocks[i].Min > min || a.blocks[i].Max < max {
+		return ErrNotAllocated{min, max}
+	}
+
+	b := a.blocks[i]
+	remaining := make([]RangeEntry, 0, 2)
+	if b.Min < min {
+		remaining = append(remaining, RangeEntry{b.Min, min - 1, b.Value})
+	}
+	if b.Max > max {
+		remaining = append(remaining, RangeEntry{max + 1, b.Max, b.Value})
+	}
+	blocks := append(append(make([]RangeEntry, 0, len(a.blocks)+1), a.blocks[:i]...), remaining...)
+	a.blocks = append(blocks, a.blocks[i+1:]...)
+
+	j := sort.Search(len(a.free), func(j int) bool {
+		return a.free[j].min > max
+	})
+	// Coalesces with the gaps ending just before min and starting just after max
+	merged := freeBlock{min, max}
+	lo, hi := j, j
+	if j > 0 && a.free[j-1].max+1 == min {
+		merged.min = a.free[j-1].min
+		lo = j - 1
+	}
+	if j < len(a.free) && max+1 == a.free[j].min {
+		merged.max = a.free[j].max
+		hi = j + 1
+	}
+	free := append(append(make([]freeBlock, 0, len(a.free)+1), a.free[:lo]...), merged)
+	a.free = append(free, a.free[hi:]...)
+	return nil
+}
+
+// Returns the number of free keys, or math.MaxUint64 if the whole key space of
+// 2^64 keys is free
+func (a *Allocator) FreeSpace() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	total := uint64(0)
+	for _, f := range a.free {
+		width := f.max - f.min + 1
+		if width == 0 || total+width < total {
+			return math.MaxUint64
+		}
+		total += width
+	}
+	return total
+}
+
+// Returns the number of gaps the free space is split into
+func (a *Allocator) FreeBlocks() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.free)
+}
+
+// Returns the value of the allocated block containing the key
+func (a *Allocator) RangeSearch(val uint64) (interface{}, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	i := sort.Search(len(a.blocks), func(i int) bool {
+		return a.blocks[i].Max >= val
+	})
+	if i == len(a.blocks) || a.blocks[i].Min > val {
+		return nil, ErrOutOfRange{val}
+	}
+	return a.blocks[i].Value, nil
+}
+
+// Returns the number of allocated blocks
+func (a *Allocator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.blocks)
+}
+
+// Returns the allocated blocks in ascending key order
+func (a *Allocator) Blocks() []RangeEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]RangeEntry(nil), a.blocks...)
+}
+
+// Builds a sparse store of the allocated blocks, or returns ErrEmptyInput if
+// there are none
+func (a *Allocator) Tree() (*Node, error) {
+	a.mu.Lock()
+	items := make([]Ranged, 0, len(a.blocks))
+	for _, b := range a.blocks {
+		items = append(items, b)
+	}
+	a.mu.Unlock()
+	return rangeStoreFromSortedChecked(items, false, true)
+}
@@ -0,0 +1,167 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * allocator_test.go: Tests on the block allocator
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAllocator_AllocateBlock(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "a"})
+	items = append(items, DefaultRangedValue{25, 29, "b"})
+	items = append(items, DefaultRangedValue{60, 99, "c"})
+	blocks, _ := NewSparseRangeStoreFromSorted(items)
+	a, err := NewAllocator(blocks, 0, 99)
+	if err != nil {
+		t.Fatalf("Error while creating allocator: %s", err.Error())
+	}
+
+	// The first fit for 8 keys is the gap before the first block
+	if min, max, err := a.AllocateBlock(8, "d"); err != nil || min != 0 || max != 7 {
+		t.Fatalf("Wrong block: %d, %d, %v", min, max, err)
+	}
+	// The best fit for 4 keys is the gap of 5 between a and b, not the 2
+	// keys left before a, nor the 30 after b
+	if min, max, err := a.AllocateBlockBestFit(4, "e"); err != nil || min != 20 || max != 23 {
+		t.Fatalf("Wrong best fit block: %d, %d, %v", min, max, err)
+	}
+	if min, max, err := a.AllocateBlock(30, "f"); err != nil || min != 30 || max != 59 {
+		t.Fatalf("Wrong block: %d, %d, %v", min, max, err)
+	}
+	if _, _, err := a.AllocateBlock(3, "g"); reflect.TypeOf(err).Name() != "ErrNoFreeBlock" {
+		t.Fatalf("Expected an ErrNoFreeBlock, got %v", err)
+	}
+	if _, _, err := a.AllocateBlock(0, "g"); reflect.TypeOf(err).Name() != "ErrZeroSize" {
+		t.Fatalf("Expected an ErrZeroSize, got %v", err)
+	}
+	if v, err := a.RangeSearch(21); err != nil || v != "e" {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+	if _, err := a.RangeSearch(9); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected an ErrOutOfRange, got %v", err)
+	}
+
+	tree, err := a.Tree()
+	if err != nil {
+		t.Fatalf("Error while building tree: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 7, "d"}, {10, 19, "a"}, {20, 23, "e"}, {25, 29, "b"}, {30, 59, "f"}, {60, 99, "c"}}
+	if !reflect.DeepEqual(tree.Ranges(), expected) || a.Len() != 6 {
+		t.Fatalf("Wrong blocks: %v", tree.Ranges())
+	}
+}
+
+func TestNewAllocator(t *testing.T) {
+	a, err := NewAllocator(nil, 0, math.MaxUint64)
+	if err != nil {
+		t.Fatalf("Error while creating allocator: %s", err.Error())
+	}
+	if min, max, err := a.AllocateBlock(math.MaxUint64, "all but one"); err != nil || min != 0 || max != math.MaxUint64-1 {
+		t.Fatalf("Wrong block: %d, %d, %v", min, max, err)
+	}
+	if min, max, err := a.AllocateBlock(1, "last"); err != nil || min != math.MaxUint64 || max != math.MaxUint64 {
+		t.Fatalf("Wrong block: %d, %d, %v", min, max, err)
+	}
+	if _, _, err := a.AllocateBlock(1, "none"); reflect.TypeOf(err).Name() != "ErrNoFreeBlock" {
+		t.Fatalf("Expected an ErrNoFreeBlock, got %v", err)
+	}
+	if _, err := (&Allocator{}).Tree(); reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected an ErrEmptyInput, got %v", err)
+	}
+
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "a"})
+	blocks, _ := NewSparseRangeStoreFromSorted(items)
+	if _, err := NewAllocator(blocks, 12, 100); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected an ErrOutOfRange, got %v", err)
+	}
+	if _, err := NewAllocator(blocks, 0, 15); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected an ErrOutOfRange, got %v", err)
+	}
+	// A block ending at the last key leaves no gap after it
+	a, _ = NewAllocator(blocks, 0, 19)
+	if _, _, err := a.AllocateBlock(11, "b"); reflect.TypeOf(err).Name() != "ErrNoFreeBlock" {
+		t.Fatalf("Expected an ErrNoFreeBlock, got %v", err)
+	}
+}
+
+func TestAllocator_Release(t *testing.T) {
+	a, _ := NewAllocator(nil, 0, 99)
+	for _, owner := range []string{"a", "b", "c", "d"} {
+		a.AllocateBlock(10, owner)
+	}
+	if a.FreeSpace() != 60 || a.FreeBlocks() != 1 {
+		t.Fatalf("Wrong free space: %d in %d blocks", a.FreeSpace(), a.FreeBlocks())
+	}
+
+	// Releasing b leaves a gap between a and c
+	if err := a.Release(10, 19); err != nil {
+		t.Fatalf("Error while releasing block: %s", err.Error())
+	}
+	if a.FreeSpace() != 70 || a.FreeBlocks() != 2 {
+		t.Fatalf("Wrong free space: %d in %d blocks", a.FreeSpace(), a.FreeBlocks())
+	}
+	// Releasing c extends that gap, rather than adding another
+	if err := a.Release(20, 29); err != nil {
+		t.Fatalf("Error while releasing block: %s", err.Error())
+	}
+	if a.FreeBlocks() != 2 {
+		t.Fatalf("Gaps weren't merged: %d", a.FreeBlocks())
+	}
+	// Releasing d merges the middle gap with the free space at the end
+	if err := a.Release(30, 39); err != nil {
+		t.Fatalf("Error while releasing block: %s", err.Error())
+	}
+	if a.FreeSpace() != 90 || a.FreeBlocks() != 1 {
+		t.Fatalf("Wrong free space: %d in %d blocks", a.FreeSpace(), a.FreeBlocks())
+	}
+	if min, max, err := a.AllocateBlock(90, "e"); err != nil || min != 10 || max != 99 {
+		t.Fatalf("Wrong block after merging: %d, %d, %v", min, max, err)
+	}
+
+	// Part of a block can be released, keeping the rest of it
+	if err := a.Release(3, 5); err != nil {
+		t.Fatalf("Error while releasing block: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 2, "a"}, {6, 9, "a"}, {10, 99, "e"}}
+	if !reflect.DeepEqual(a.Blocks(), expected) || a.FreeSpace() != 3 {
+		t.Fatalf("Wrong blocks: %v", a.Blocks())
+	}
+
+	for _, r := range [][2]uint64{{3, 5}, {8, 12}, {4, 7}} {
+		if err := a.Release(r[0], r[1]); reflect.TypeOf(err).Name() != "ErrNotAllocated" {
+			t.Fatalf("Expected an ErrNotAllocated for %v, got %v", r, err)
+		}
+	}
+
+	a, _ = NewAllocator(nil, 0, math.MaxUint64)
+	if a.FreeSpace() != math.MaxUint64 {
+		t.Fatalf("Wrong free space: %d", a.FreeSpace())
+	}
+}
@@ -0,0 +1,142 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * append.go: Append only extension of a store
+ */
+
+package rangestore
+
+// The number of consecutively appended ranges tolerated as a plain chain before
+// the tail of the tree is rebalanced
+const appendChainLimit = 8
+
+// Attaches a new range above the current maximum of the store, without
+// rebuilding the whole tree. The range must start after the current maximum;
+// if it doesn't start immediately after it, the store becomes sparse.
+//
+// Appended ranges hang off the right edge of the tree, so once the chain of
+// appended ranges gets deeper than the tree above it, the right edge of the
+// tree is rebuilt. Only the right heavy part of the tree is rebuilt, which keeps
+// the amortized cost of appending low. Rebuilt portions are balanced by the
+// number of ranges rather than their widths.
+//
+// The root node remains the root of the store, so existing references to it
+// stay valid. Append must not be called concurrently with searches.
+func (n *Node) Append(r Ranged) error {
+	if n == nil {
+		return ErrEmptyStore{}
+	}
+	min, max := r.GetMin(), r.GetMax()
+	if min > max {
+		return ErrInvertedRange{min, max}
+	}
+	spine := make([]*Node, 0)
+	for c := n; c != nil; c = c.right {
+		spine = append(spine, c)
+	}
+	last := spine[len(spine)-1]
+	if min <= last.max {
+		return ErrOverlap{last.max, min}
+	}
+	last.right = &Node{min: min, max: max, value: r.GetValue()}
+	spine = append(spine, last.right)
+
+	// Measure the chain of nodes without left children at the bottom of the spine
+	chain := 0
+	for i := len(spine) - 1; i >= 0 && spine[i].left == nil; i -= 1 {
+		chain += 1
+	}
+	top := len(spine) - chain
+	if chain > appendChainLimit && chain > top {
+		n.rebalanceSpine(spine, top, chain)
+	}
+	return nil
+}
+
+// Rebuilds the highest subtree on the spine, starting from spine[top], whose
+// ancestors are all right heavy, i.e. hold more than twice as many nodes to
+// the right as to the left
+func (n *Node) rebalanceSpine(spine []*Node, top, size int) {
+	i := top
+	for i > 0 {
+		// Stop as soon as the left subtree holds at least half as many nodes
+		// as everything to the right, counting no further than we need to
+		// find that out
+		ls := spine[i-1].left.countUpTo((size + 1) / 2)
+		if 2*ls >= size {
+			break
+		}
+		size += ls + 1
+		i -= 1
+	}
+
+	items := make([]Ranged, 0, size)
+	spine[i].walk(func(c *Node) {
+		items = append(items, RangeEntry{c.min, c.max, c.value})
+	})
+	cum := make([]uint64, len(items)+1)
+	for idx := range items {
+		cum[idx+1] = uint64(idx + 1)
+	}
+	rebuilt := buildFromCumulative(items, cum, 0, len(items))
+	if i == 0 {
+		*n = *rebuilt
+	} else {
+		spine[i-1].right = rebuilt
+	}
+}
+
+// Counts the nodes in the tree, stopping once the count reaches limit
+func (n *Node) countUpTo(limit int) int {
+	if n == nil || limit <= 0 {
+		return 0
+	}
+	count := 1 + n.left.countUpTo(limit-1)
+	return count + n.right.countUpTo(limit-count)
+}
+
+// Attaches a new range above the current maximum of the store, updating the
+// store metadata. See (*Node).Append. The first range appended to an empty store
+// becomes its root. Stores using LookupTableBackend or FlatBackend, or with a
+// directory or a miss filter, rebuild those on every append. Stores built with
+// WithRebuildThreshold may rebuild the whole tree.
+func (s *RangeStore) Append(r Ranged) error {
+	// Stores built with MultiValue hold the values of each range in a slice
+	if s.opts.multi {
+		r = RangeEntry{r.GetMin(), r.GetMax(), []interface{}{r.GetValue()}}
+	}
+	if s.root == nil {
+		if r.GetMin() > r.GetMax() {
+			return ErrInvertedRange{r.GetMin(), r.GetMax()}
+		}
+		s.root = &Node{min: r.GetMin(), max: r.GetMax(), value: r.GetValue()}
+		s.min = r.GetMin()
+	} else if err := s.root.Append(r); err != nil {
+		return err
+	}
+	s.max = r.GetMax()
+	s.span += (r.GetMax() - r.GetMin()) + 1
+	s.count += 1
+	s.maybeRebuild()
+	s.useBackend()
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * append_test.go: Tests on appending to a store
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_Append(t *testing.T) {
+	n, err := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, 0}})
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	root := n
+	for i := 1; i < 1000; i += 1 {
+		if err := n.Append(DefaultRangedValue{uint64(i * 10), uint64(i*10 + 9), i}); err != nil {
+			t.Fatalf("Error while appending: %s", err.Error())
+		}
+	}
+	if n != root {
+		t.Fatalf("Expected the root to remain in place")
+	}
+	if n.Len() != 1000 {
+		t.Fatalf("Expected 1000 ranges, got %d", n.Len())
+	}
+	// A balanced tree of 1000 nodes has a depth of 10, allow some slack for the tail
+	if d := n.Depth(); d > 30 {
+		t.Fatalf("Expected the tree to be kept shallow, got a depth of %d", d)
+	}
+	for i := 0; i < 1000; i += 1 {
+		v, err := n.RangeSearch(uint64(i*10 + 5))
+		if err != nil {
+			t.Fatalf("Got an error while searching: %s", err.Error())
+		}
+		if v != i {
+			t.Fatalf("Got invalid value back %v [%d]", v, i)
+		}
+	}
+	if _, err := rangeStoreFromSortedChecked(nodeRanged(n), true, false); err != nil {
+		t.Fatalf("Expected the ranges to remain continuous: %s", err.Error())
+	}
+}
+
+func TestNode_AppendInvalid(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "A"}})
+
+	err := n.Append(DefaultRangedValue{5, 19, "B"})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+	err = n.Append(DefaultRangedValue{20, 15, "B"})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvertedRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvertedRange, but got something else")
+	}
+	if n.Len() != 1 {
+		t.Fatalf("Expected failed appends to leave the store untouched")
+	}
+}
+
+func TestRangeStore_Append(t *testing.T) {
+	s, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "A"}})
+	if err := s.Append(DefaultRangedValue{20, 29, "B"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	min, max := s.Bounds()
+	if s.Len() != 2 || s.Span() != 20 || min != 0 || max != 29 {
+		t.Fatalf("Wrong store metadata after appending")
+	}
+	if v, _ := s.RangeSearch(25); v != "B" {
+		t.Fatalf("Got invalid value back %s [%s]", v, "B")
+	}
+}
+
+func TestRangeStore_AppendMultiValue(t *testing.T) {
+	s, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{0, 9, "B"}}, MultiValue())
+	if err := s.Append(DefaultRangedValue{20, 29, "C"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if v, err := s.RangeSearch(25); err != nil || v != "C" {
+		t.Fatalf("Wrong value for an appended range: %v", v)
+	}
+	if vs := s.RangeSearchAll(25); !reflect.DeepEqual(vs, []interface{}{"C"}) {
+		t.Fatalf("Wrong values for an appended range: %v", vs)
+	}
+
+	// The first range appended to an empty store is wrapped too
+	s = NewEmptyRangeStore(MultiValue())
+	if err := s.Append(DefaultRangedValue{0, 9, "A"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if v, err := s.RangeSearch(5); err != nil || v != "A" {
+		t.Fatalf("Wrong value for an appended range: %v", v)
+	}
+	if vs := s.RangeSearchAll(5); !reflect.DeepEqual(vs, []interface{}{"A"}) {
+		t.Fatalf("Wrong values for an appended range: %v", vs)
+	}
+}
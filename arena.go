@@ -0,0 +1,39 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * arena.go: Contiguous allocation of tree nodes
+ */
+
+package rangestore
+
+// Builds the tree for the already validated items, taking all of the nodes
+// from a single slice. The nodes are laid out in pre-order, so a search walks
+// forward through the slice.
+func buildArena(items []Ranged, cum []uint64) *Node {
+	arena := make([]Node, len(items))
+	next := 0
+	return buildWithAlloc(items, cum, 0, len(items), func() *Node {
+		n := &arena[next]
+		next += 1
+		return n
+	})
+}
@@ -0,0 +1,104 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * arena_test.go: Tests and benchmarks on arena allocation
+ */
+
+package rangestore
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+func TestBuilder_WithArena(t *testing.T) {
+	plain, err := NewBuilder().Add(0, 9, "A").Add(10, 99, "B").Add(100, 109, "C").Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	arena, err := NewBuilder(WithArena()).Add(0, 9, "A").Add(10, 99, "B").Add(100, 109, "C").Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	if plain.String() != arena.String() {
+		t.Fatalf("Arena tree has a different shape:\n%s", arena.String())
+	}
+
+	// Every node should come out of the same backing slice, in pre-order
+	root := arena.Root()
+	size := unsafe.Sizeof(Node{})
+	if addr(root.left) != addr(root)+size || addr(root.right) != addr(root)+2*size {
+		t.Fatalf("Nodes weren't allocated contiguously")
+	}
+
+	if err := arena.Append(DefaultRangedValue{110, 119, "D"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if v, err := arena.RangeSearch(115); err != nil || v != "D" {
+		t.Fatalf("Wrong value after appending to an arena store")
+	}
+}
+
+func addr(n *Node) uintptr {
+	return uintptr(unsafe.Pointer(n))
+}
+
+func arenaItems(count int) []Ranged {
+	items := make([]Ranged, 0, count)
+	for i := 0; i < count; i += 1 {
+		items = append(items, DefaultRangedValue{uint64(i * 10), uint64(i*10 + 9), i})
+	}
+	return items
+}
+
+func benchmarkGC(b *testing.B, opts ...Option) {
+	s, err := NewRangeStore(arenaItems(1000000), opts...)
+	if err != nil {
+		b.Fatalf("Got an error while building: %s", err.Error())
+	}
+	runtime.GC()
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		runtime.GC()
+	}
+	b.StopTimer()
+	runtime.KeepAlive(s)
+}
+
+func Benchmark_GC_Tree(b *testing.B) {
+	benchmarkGC(b)
+}
+
+func Benchmark_GC_Arena(b *testing.B) {
+	benchmarkGC(b, WithArena())
+}
+
+func Benchmark_Build_Arena(b *testing.B) {
+	items := arenaItems(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		if _, err := NewRangeStore(items, WithArena()); err != nil {
+			b.Fatalf("Got an error while building: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * batch.go: Looking up many keys at once
+ */
+
+package rangestore
+
+// Looks up each of the keys, storing the value found for keys[i] in values[i],
+// or nil if the key isn't covered, and returns the number of keys which weren't.
+// values must be at least as long as keys.
+func (s *RangeStore) RangeSearchBatch(keys []uint64, values []interface{}) int {
+	misses := 0
+	for idx, key := range keys {
+		v, err := s.RangeSearch(key)
+		if err != nil {
+			misses += 1
+		}
+		values[idx] = v
+	}
+	return misses
+}
+
+// Looks up each of the keys as RangeSearchBatch does. On platforms with a fast
+// path, the keys are searched four at a time in lock step, so that the memory
+// accesses of the four searches overlap rather than each waiting on the last.
+func (s *BranchlessStore) RangeSearchBatch(keys []uint64, values []interface{}) int {
+	idx := make([]int, len(keys))
+	lowerBoundBatch(s.maxs, keys, idx)
+	misses := 0
+	for i, key := range keys {
+		if j := idx[i]; key <= s.maxs[j] && key >= s.mins[j] {
+			values[i] = s.values[j]
+		} else {
+			values[i] = nil
+			misses += 1
+		}
+	}
+	return misses
+}
+
+// Computes the lower bound of each key one at a time, as the portable path and
+// for the keys left over by the fast path
+func lowerBoundEach(maxs, keys []uint64, idx []int) {
+	s := BranchlessStore{maxs: maxs}
+	for i, key := range keys {
+		idx[i] = s.lowerBound(key)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !rangestore_core && (amd64 || arm64)
+// +build !rangestore_core
+// +build amd64 arm64
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * batch_lanes.go: Searching four keys in lock step
+ */
+
+package rangestore
+
+// Computes the lower bound of each key in maxs, as BranchlessStore.lowerBound
+// does, four keys at a time. Every search takes the same number of steps, so the
+// four lanes advance together, and the loads of each step are independent of
+// one another, which lets the CPU have all four in flight at once.
+func lowerBoundBatch(maxs, keys []uint64, idx []int) {
+	n := len(maxs)
+	i := 0
+	for ; i+4 <= len(keys); i += 4 {
+		k0, k1, k2, k3 := keys[i], keys[i+1], keys[i+2], keys[i+3]
+		b0, b1, b2, b3 := 0, 0, 0, 0
+		for m := n; m > 1; {
+			half := m >> 1
+			b0 = pick(maxs[b0+half-1] < k0, b0+half, b0)
+			b1 = pick(maxs[b1+half-1] < k1, b1+half, b1)
+			b2 = pick(maxs[b2+half-1] < k2, b2+half, b2)
+			b3 = pick(maxs[b3+half-1] < k3, b3+half, b3)
+			m -= half
+		}
+		idx[i], idx[i+1], idx[i+2], idx[i+3] = b0, b1, b2, b3
+	}
+	lowerBoundEach(maxs, keys[i:], idx[i:])
+}
@@ -0,0 +1,32 @@
+//go:build !rangestore_core && !amd64 && !arm64
+// +build !rangestore_core,!amd64,!arm64
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * batch_portable.go: Searching keys one at a time
+ */
+
+package rangestore
+
+// Computes the lower bound of each key in maxs one at a time, on platforms
+// without a fast path
+func lowerBoundBatch(maxs, keys []uint64, idx []int) {
+	lowerBoundEach(maxs, keys, idx)
+}
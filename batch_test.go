@@ -0,0 +1,112 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * batch_test.go: Tests on batch lookups
+ */
+
+package rangestore
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRangeStore_RangeSearchBatch(t *testing.T) {
+	s, err := NewBuilder().AllowGaps().Add(0, 9, "A").Add(20, 29, "B").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	keys := []uint64{5, 15, 25, 35}
+	values := make([]interface{}, len(keys))
+	if misses := s.RangeSearchBatch(keys, values); misses != 2 {
+		t.Fatalf("Wrong number of misses: %d", misses)
+	}
+	if values[0] != "A" || values[1] != nil || values[2] != "B" || values[3] != nil {
+		t.Fatalf("Wrong values: %v", values)
+	}
+}
+
+func TestBranchlessStore_RangeSearchBatch(t *testing.T) {
+	items := make([]Ranged, 0)
+	for i := uint64(0); i < 1000; i += 1 {
+		if i%3 == 2 {
+			items = append(items, DefaultRangedValue{i * 10, i*10 + 4, i})
+		} else {
+			items = append(items, DefaultRangedValue{i * 10, i*10 + 9, i})
+		}
+	}
+	s, err := NewRangeStoreBranchless(items)
+	if err != nil {
+		t.Fatalf("Error while constructing branchless store: %s", err.Error())
+	}
+	// An odd number of keys, so some are left over by the lanes
+	keys := make([]uint64, 1003)
+	for idx := range keys {
+		keys[idx] = uint64(rand.Int63n(10100))
+	}
+	values := make([]interface{}, len(keys))
+	misses := s.RangeSearchBatch(keys, values)
+	expectedMisses := 0
+	for idx, key := range keys {
+		want, err := s.RangeSearch(key)
+		if err != nil {
+			expectedMisses += 1
+		}
+		if values[idx] != want {
+			t.Fatalf("Wrong value for %d: %v, expected %v", key, values[idx], want)
+		}
+	}
+	if misses != expectedMisses {
+		t.Fatalf("Wrong number of misses: %d, expected %d", misses, expectedMisses)
+	}
+}
+
+func benchmarkBranchlessKeys(b *testing.B) (*BranchlessStore, []uint64) {
+	s, err := NewRangeStoreBranchless(arenaItems(1000000))
+	if err != nil {
+		b.Fatalf("Got an error while building: %s", err.Error())
+	}
+	keys := make([]uint64, 1024)
+	for idx := range keys {
+		keys[idx] = uint64(rand.Int63n(10000000))
+	}
+	return s, keys
+}
+
+func Benchmark_RangeSearchBatch_Branchless(b *testing.B) {
+	s, keys := benchmarkBranchlessKeys(b)
+	values := make([]interface{}, len(keys))
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(keys) {
+		s.RangeSearchBatch(keys, values)
+	}
+}
+
+func Benchmark_RangeSearchBatch_BranchlessOneByOne(b *testing.B) {
+	s, keys := benchmarkBranchlessKeys(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(keys) {
+		for _, key := range keys {
+			s.RangeSearch(key)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * benchtool/benchtool.go: Comparing backends on real data
+ */
+
+// Package benchtool measures how the lookup backends of the range store perform
+// on a given dataset, so a backend can be picked on the basis of the data it will
+// actually serve rather than synthetic benchmarks.
+package benchtool
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// The backends compared by CompareBackends, in the order they're reported
+var Backends = []rangestore.Backend{
+	rangestore.TreeBackend,
+	rangestore.LookupTableBackend,
+	rangestore.FlatBackend,
+}
+
+// Returns a readable name for the backend
+func BackendName(b rangestore.Backend) string {
+	switch b {
+	case rangestore.TreeBackend:
+		return "tree"
+	case rangestore.LookupTableBackend:
+		return "lookup-table"
+	case rangestore.FlatBackend:
+		return "flat"
+	default:
+		return fmt.Sprintf("backend-%d", int(b))
+	}
+}
+
+// The measurements taken for one backend. Err is set if the store couldn't be
+// built, in which case nothing else is.
+type Result struct {
+	Backend   rangestore.Backend
+	BuildTime time.Duration
+	// The growth of the live heap caused by building the store
+	HeapBytes uint64
+	// The average time taken by a single lookup
+	Lookup time.Duration
+	// The number of lookups which didn't find a range
+	Misses int
+	Err    error
+}
+
+// The results of CompareBackends, one per backend
+type Report struct {
+	Ranges  int
+	Keys    int
+	Results []Result
+}
+
+// Builds a store from the items with each of the backends, measuring how long
+// the build takes and how much memory the store holds on to, then looks up each
+// of the keys in turn, measuring the average lookup latency. Keys should be
+// drawn from real traffic where possible, as the ordering and repetition of keys
+// affects caching. Note that LookupTableBackend falls back to the tree for
+// stores spanning more than DefaultLookupTableLimit keys.
+func CompareBackends(items []rangestore.Ranged, keys []uint64) Report {
+	ret := Report{Ranges: len(items), Keys: len(keys)}
+	for _, b := range Backends {
+		ret.Results = append(ret.Results, measure(items, keys, b))
+	}
+	return ret
+}
+
+func measure(items []rangestore.Ranged, keys []uint64, b rangestore.Backend) Result {
+	ret := Result{Backend: b}
+	before := heapInUse()
+	start := time.Now()
+	s, err := rangestore.NewRangeStore(items, rangestore.WithBackend(b))
+	ret.BuildTime = time.Since(start)
+	if err != nil {
+		ret.Err = err
+		return ret
+	}
+	if after := heapInUse(); after > before {
+		ret.HeapBytes = after - before
+	}
+
+	start = time.Now()
+	for _, key := range keys {
+		if _, err := s.RangeSearch(key); err != nil {
+			ret.Misses += 1
+		}
+	}
+	if len(keys) > 0 {
+		ret.Lookup = time.Since(start) / time.Duration(len(keys))
+	}
+	runtime.KeepAlive(s)
+	return ret
+}
+
+// Returns the number of bytes held by live objects, after a collection
+func heapInUse() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+// Formats the report as a table
+func (r Report) String() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%d ranges, %d keys\n", r.Ranges, r.Keys)
+	tw := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "backend\tbuild\theap\tlookup\tmisses")
+	for _, res := range r.Results {
+		if res.Err != nil {
+			fmt.Fprintf(tw, "%s\terror: %s\t\t\t\n", BackendName(res.Backend), res.Err.Error())
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\n", BackendName(res.Backend), res.BuildTime, res.HeapBytes, res.Lookup, res.Misses)
+	}
+	tw.Flush()
+	return buf.String()
+}
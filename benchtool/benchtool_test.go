@@ -0,0 +1,80 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * benchtool/benchtool_test.go: Tests on backend comparison
+ */
+
+package benchtool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+func TestCompareBackends(t *testing.T) {
+	items := make([]rangestore.Ranged, 0)
+	for i := uint64(0); i < 1000; i += 1 {
+		items = append(items, rangestore.RangeEntry{Min: i * 10, Max: i*10 + 9, Value: i})
+	}
+	keys := make([]uint64, 0)
+	for i := uint64(0); i < 10005; i += 7 {
+		keys = append(keys, i)
+	}
+
+	report := CompareBackends(items, keys)
+	if report.Ranges != 1000 || report.Keys != len(keys) {
+		t.Fatalf("Wrong report totals: %d ranges, %d keys", report.Ranges, report.Keys)
+	}
+	if len(report.Results) != len(Backends) {
+		t.Fatalf("Expected one result per backend, got %d", len(report.Results))
+	}
+	for idx, res := range report.Results {
+		if res.Backend != Backends[idx] {
+			t.Fatalf("Results out of order: %v", res.Backend)
+		}
+		if res.Err != nil {
+			t.Fatalf("Error building %s: %s", BackendName(res.Backend), res.Err.Error())
+		}
+		// 10003 is past the end
+		if res.Misses != 1 {
+			t.Fatalf("Wrong number of misses for %s: %d", BackendName(res.Backend), res.Misses)
+		}
+	}
+	out := report.String()
+	for _, name := range []string{"tree", "lookup-table", "flat"} {
+		if !strings.Contains(out, name) {
+			t.Fatalf("Backend %s missing from the table:\n%s", name, out)
+		}
+	}
+}
+
+func TestCompareBackends_Error(t *testing.T) {
+	items := []rangestore.Ranged{rangestore.RangeEntry{Min: 0, Max: 9, Value: "A"}, rangestore.RangeEntry{Min: 5, Max: 14, Value: "B"}}
+	report := CompareBackends(items, nil)
+	for _, res := range report.Results {
+		if res.Err == nil {
+			t.Fatalf("Expected overlapping ranges to fail for %s", BackendName(res.Backend))
+		}
+	}
+	if !strings.Contains(report.String(), "error:") {
+		t.Fatalf("Expected the errors in the table:\n%s", report.String())
+	}
+}
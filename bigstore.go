@@ -0,0 +1,225 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * bigstore.go: Stores keyed by arbitrary precision integers
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// A range whose bounds are arbitrary precision, non negative integers, for key
+// spaces wider than 64 bits such as the output of SHA-256
+type BigRanged interface {
+	GetBigMin() *big.Int
+	GetBigMax() *big.Int
+	GetValue() interface{}
+}
+
+// A range with arbitrary precision bounds. Implements BigRanged.
+type BigRangeEntry struct {
+	Min, Max *big.Int
+	Value    interface{}
+}
+
+func (r BigRangeEntry) GetBigMin() *big.Int {
+	return r.Min
+}
+func (r BigRangeEntry) GetBigMax() *big.Int {
+	return r.Max
+}
+func (r BigRangeEntry) GetValue() interface{} {
+	return r.Value
+}
+
+type ErrInvalidBigRange struct {
+	index  int
+	reason string
+}
+
+func (ex ErrInvalidBigRange) Error() string {
+	return fmt.Sprintf("Range %d: %s", ex.index, ex.reason)
+}
+
+// Returns the index of the offending item
+func (ex ErrInvalidBigRange) Index() int {
+	return ex.index
+}
+
+// Returns why the item was rejected
+func (ex ErrInvalidBigRange) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrInvalidBigRange) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidBigRange", map[string]interface{}{"index": ex.index, "reason": ex.reason}}
+}
+
+type ErrBigOutOfRange struct {
+	key *big.Int
+}
+
+func (ex ErrBigOutOfRange) Error() string {
+	return fmt.Sprintf("Value %s is out of range", ex.key.String())
+}
+
+// Returns the key which was looked up
+func (ex ErrBigOutOfRange) Key() *big.Int {
+	return ex.key
+}
+
+func (ex ErrBigOutOfRange) Details() ErrorDetails {
+	return ErrorDetails{"ErrBigOutOfRange", map[string]interface{}{"key": ex.key}}
+}
+
+// A node of a store keyed by arbitrary precision integers. The tree is built
+// and searched the same way as the uint64 keyed one; since the arithmetic can't
+// overflow, there's no limit on the width of the ranges or of the store.
+type BigNode struct {
+	min, max    *big.Int
+	value       interface{}
+	left, right *BigNode
+}
+
+// Builds a store from the items, which must be sorted, continuous and not
+// overlap, with the same balancing as NewRangeStoreFromSorted. Bounds must be
+// non nil and non negative. The bounds are copied, so the items may be reused.
+func NewBigRangeStoreFromSorted(items []BigRanged) (*BigNode, error) {
+	return bigRangeStoreFromSorted(items, false)
+}
+
+// Same as NewBigRangeStoreFromSorted, but permits gaps between the ranges
+func NewSparseBigRangeStoreFromSorted(items []BigRanged) (*BigNode, error) {
+	return bigRangeStoreFromSorted(items, true)
+}
+
+func bigRangeStoreFromSorted(items []BigRanged, gaps bool) (*BigNode, error) {
+	if len(items) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	one := big.NewInt(1)
+	entries := make([]BigRangeEntry, len(items))
+	cum := make([]*big.Int, len(items)+1)
+	cum[0] = new(big.Int)
+	for idx, item := range items {
+		min, max := item.GetBigMin(), item.GetBigMax()
+		switch {
+		case min == nil || max == nil:
+			return nil, ErrInvalidBigRange{idx, "missing bound"}
+		case min.Sign() < 0:
+			return nil, ErrInvalidBigRange{idx, "negative bound"}
+		case min.Cmp(max) > 0:
+			return nil, ErrInvalidBigRange{idx, fmt.Sprintf("minimum %s is greater than maximum %s", min, max)}
+		}
+		if idx > 0 {
+			prev := entries[idx-1].Max
+			if min.Cmp(prev) <= 0 {
+				return nil, ErrInvalidBigRange{idx, fmt.Sprintf("overlap detected between %s -> %s", prev, min)}
+			}
+			if !gaps && new(big.Int).Add(prev, one).Cmp(min) != 0 {
+				return nil, ErrInvalidBigRange{idx, fmt.Sprintf("discontinuity detected from %s -> %s", prev, min)}
+			}
+		}
+		entries[idx] = BigRangeEntry{new(big.Int).Set(min), new(big.Int).Set(max), item.GetValue()}
+		width := new(big.Int).Sub(max, min)
+		cum[idx+1] = width.Add(width, one).Add(width, cum[idx])
+	}
+	return buildBig(entries, cum, 0, len(entries)), nil
+}
+
+// Builds the tree for entries[lo:hi], choosing pivots as pivotIndex does
+func buildBig(entries []BigRangeEntry, cum []*big.Int, lo, hi int) *BigNode {
+	ridx := lo
+	if hi-lo > 1 {
+		pivot := new(big.Int).Sub(cum[hi], cum[lo])
+		pivot.Rsh(pivot, 1)
+		ridx = lo + sort.Search(hi-lo, func(i int) bool {
+			return new(big.Int).Sub(cum[lo+i], cum[lo]).Cmp(pivot) >= 0
+		}) - 1
+		if ridx < lo {
+			ridx = lo
+		}
+	}
+	n := &BigNode{min: entries[ridx].Min, max: entries[ridx].Max, value: entries[ridx].Value}
+	if ridx != lo {
+		n.left = buildBig(entries, cum, lo, ridx)
+	}
+	if ridx != hi-1 {
+		n.right = buildBig(entries, cum, ridx+1, hi)
+	}
+	return n
+}
+
+// Searches for the range which contains the key and returns the associated
+// value, or ErrBigOutOfRange if there is none
+func (n *BigNode) RangeSearch(key *big.Int) (interface{}, error) {
+	for c := n; c != nil; {
+		if c.max.Cmp(key) < 0 {
+			c = c.right
+		} else if c.min.Cmp(key) > 0 {
+			c = c.left
+		} else {
+			return c.value, nil
+		}
+	}
+	return nil, ErrBigOutOfRange{new(big.Int).Set(key)}
+}
+
+// Same as RangeSearch, taking the key as big endian bytes, such as a hash digest
+func (n *BigNode) RangeSearchBytes(key []byte) (interface{}, error) {
+	return n.RangeSearch(new(big.Int).SetBytes(key))
+}
+
+// Returns the smallest and largest keys covered by the store. The returned
+// values are copies.
+func (n *BigNode) Bounds() (min, max *big.Int) {
+	lft := n
+	for lft.left != nil {
+		lft = lft.left
+	}
+	rht := n
+	for rht.right != nil {
+		rht = rht.right
+	}
+	return new(big.Int).Set(lft.min), new(big.Int).Set(rht.max)
+}
+
+// Returns all of the ranges in the store in ascending key order. The bounds
+// are copies.
+func (n *BigNode) Ranges() []BigRangeEntry {
+	ret := make([]BigRangeEntry, 0)
+	var walk func(c *BigNode)
+	walk = func(c *BigNode) {
+		if c == nil {
+			return
+		}
+		walk(c.left)
+		ret = append(ret, BigRangeEntry{new(big.Int).Set(c.min), new(big.Int).Set(c.max), c.value})
+		walk(c.right)
+	}
+	walk(n)
+	return ret
+}
@@ -0,0 +1,136 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * bigstore_test.go: Tests on arbitrary precision stores
+ */
+
+package rangestore
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// Splits the 256 bit key space into count equal ranges
+func bigQuarters(count int64) []BigRanged {
+	space := new(big.Int).Lsh(big.NewInt(1), 256)
+	step := new(big.Int).Div(space, big.NewInt(count))
+	items := make([]BigRanged, 0)
+	for i := int64(0); i < count; i += 1 {
+		min := new(big.Int).Mul(step, big.NewInt(i))
+		max := new(big.Int).Add(min, step)
+		max.Sub(max, big.NewInt(1))
+		items = append(items, BigRangeEntry{min, max, i})
+	}
+	return items
+}
+
+func TestBigRangeStore_Search(t *testing.T) {
+	n, err := NewBigRangeStoreFromSorted(bigQuarters(4))
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	min, max := n.Bounds()
+	if min.Sign() != 0 || max.BitLen() != 256 || max.Cmp(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))) != 0 {
+		t.Fatalf("Wrong bounds: %s, %s", min, max)
+	}
+	if len(n.Ranges()) != 4 {
+		t.Fatalf("Wrong number of ranges: %d", len(n.Ranges()))
+	}
+
+	digest := sha256.Sum256([]byte("hello"))
+	v, err := n.RangeSearchBytes(digest[:])
+	if err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	// The digest starts with 0x2c, so it's in the first quarter
+	if v != int64(0) {
+		t.Fatalf("Wrong value: %v", v)
+	}
+	high := new(big.Int).Lsh(big.NewInt(3), 254)
+	if v, _ := n.RangeSearch(high); v != int64(3) {
+		t.Fatalf("Wrong value: %v", v)
+	}
+	_, err = n.RangeSearch(new(big.Int).Lsh(big.NewInt(1), 256))
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrBigOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrBigOutOfRange, but got something else")
+	}
+}
+
+func TestBigRangeStore_Invalid(t *testing.T) {
+	items := make([]BigRanged, 0)
+	items = append(items, BigRangeEntry{big.NewInt(0), big.NewInt(9), "A"})
+	items = append(items, BigRangeEntry{big.NewInt(20), big.NewInt(29), "B"})
+
+	_, err := NewBigRangeStoreFromSorted(items)
+	if err == nil || err.Error() != "Range 1: discontinuity detected from 9 -> 20" {
+		t.Fatalf("Expecting a discontinuity, got %v", err)
+	}
+	sparse, err := NewSparseBigRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing sparse store: %s", err.Error())
+	}
+	if _, err := sparse.RangeSearch(big.NewInt(15)); err == nil {
+		t.Fatalf("Expected a miss in the gap")
+	}
+
+	items = append(items, BigRangeEntry{big.NewInt(25), big.NewInt(39), "C"})
+	_, err = NewSparseBigRangeStoreFromSorted(items)
+	if err == nil || err.Error() != "Range 2: overlap detected between 29 -> 25" {
+		t.Fatalf("Expecting an overlap, got %v", err)
+	}
+	_, err = NewBigRangeStoreFromSorted([]BigRanged{BigRangeEntry{big.NewInt(-1), big.NewInt(9), "A"}})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvalidBigRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvalidBigRange, but got something else")
+	}
+}
+
+func TestBigRangeStore_Balance(t *testing.T) {
+	// The same ranges balance the same way as the uint64 keyed tree
+	items := make([]Ranged, 0)
+	bigItems := make([]BigRanged, 0)
+	for i, w := range []uint64{1, 1, 98, 3, 7, 40} {
+		min := uint64(0)
+		if i > 0 {
+			min = items[i-1].GetMax() + 1
+		}
+		items = append(items, DefaultRangedValue{min, min + w - 1, i})
+		bigItems = append(bigItems, BigRangeEntry{new(big.Int).SetUint64(min), new(big.Int).SetUint64(min + w - 1), i})
+	}
+	n, _ := NewRangeStoreFromSorted(items)
+	b, err := NewBigRangeStoreFromSorted(bigItems)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	var same func(a *Node, b *BigNode) bool
+	same = func(a *Node, b *BigNode) bool {
+		if a == nil || b == nil {
+			return a == nil && b == nil
+		}
+		return a.value == b.value && same(a.left, b.left) && same(a.right, b.right)
+	}
+	if !same(n, b) {
+		t.Fatalf("Trees are shaped differently")
+	}
+}
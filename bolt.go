@@ -0,0 +1,206 @@
+//go:build !rangestore_core && bbolt
+// +build !rangestore_core,bbolt
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * bolt.go: Stores kept in bbolt buckets
+ */
+
+package rangestore
+
+import (
+	"encoding/binary"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// This file is only built with the bbolt tag, so that the package has no
+// dependencies unless the adapter is wanted:
+//
+//	go get go.etcd.io/bbolt
+//	go build -tags bbolt
+
+// Each range is stored under its max as 8 big endian bytes, so keys sort in
+// the same order as the ranges, with its min as 8 big endian bytes followed by
+// the encoded value. Seeking to a key then lands on the only range which can
+// contain it.
+const boltRangeHeader = 8
+
+// Writes the ranges of the store to the bucket, replacing anything already in
+// it, in a single transaction. The values are encoded by codec, or StringCodec
+// if it's nil.
+func WriteBolt(db *bolt.DB, bucket []byte, n *Node, codec ValueCodec) error {
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucket) != nil {
+			if err := tx.DeleteBucket(bucket); err != nil {
+				return err
+			}
+		}
+		b, err := tx.CreateBucket(bucket)
+		if err != nil {
+			return err
+		}
+		for _, r := range n.Ranges() {
+			encoded, err := codec.Encode(r.Value)
+			if err != nil {
+				return err
+			}
+			var key [8]byte
+			binary.BigEndian.PutUint64(key[:], r.Max)
+			value := make([]byte, boltRangeHeader+len(encoded))
+			binary.BigEndian.PutUint64(value, r.Min)
+			copy(value[boltRangeHeader:], encoded)
+			if err := b.Put(key[:], value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// A store answering lookups from a bbolt bucket written by WriteBolt, so that
+// stores too large for memory can be served from disk. Each lookup is a read
+// transaction and a cursor seek, optionally behind an LRU cache of results.
+//
+// BoltStore is safe for concurrent use, as is the database.
+type BoltStore struct {
+	db     *bolt.DB
+	bucket []byte
+	codec  ValueCodec
+	cache  *lookupCache
+}
+
+// Serves lookups from the bucket, decoding values with codec, or StringCodec
+// if it's nil. The results of the last cacheSize lookups are kept in memory;
+// use 0 for no cache. The cache isn't told about later writes to the bucket,
+// see Purge. Returns bolt.ErrBucketNotFound if there's no such bucket.
+func OpenBolt(db *bolt.DB, bucket []byte, codec ValueCodec, cacheSize int) (*BoltStore, error) {
+	err := db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucket) == nil {
+			return bolt.ErrBucketNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	s := &BoltStore{db: db, bucket: bucket, codec: codec}
+	if cacheSize > 0 {
+		s.cache = newLookupCache(cacheSize)
+	}
+	return s, nil
+}
+
+// Returns the value of the range containing the key, or ErrOutOfRange if there
+// is none. Errors from the database, and ErrCorruptStore for records which
+// weren't written by WriteBolt, are returned as they are and not cached.
+func (s *BoltStore) RangeSearch(val uint64) (interface{}, error) {
+	if s.cache == nil {
+		return s.lookup(val)
+	}
+	if e, ok := s.cache.get(val); ok {
+		return e.value, e.err
+	}
+	v, err := s.lookup(val)
+	if _, ok := err.(ErrOutOfRange); err == nil || ok {
+		s.cache.add(val, v, err)
+	}
+	return v, err
+}
+
+func (s *BoltStore) lookup(val uint64) (interface{}, error) {
+	var ret interface{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b == nil {
+			return bolt.ErrBucketNotFound
+		}
+		var seek [8]byte
+		binary.BigEndian.PutUint64(seek[:], val)
+		key, value := b.Cursor().Seek(seek[:])
+		if key == nil {
+			return ErrOutOfRange{val}
+		}
+		if len(key) != 8 || len(value) < boltRangeHeader {
+			return ErrCorruptStore{"malformed record"}
+		}
+		if binary.BigEndian.Uint64(value) > val {
+			return ErrOutOfRange{val}
+		}
+		// The bytes are only valid during the transaction, so the value must
+		// be decoded here
+		var err error
+		ret, err = s.codec.Decode(value[boltRangeHeader:])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Drops the cached results, e.g. after the bucket has been rewritten
+func (s *BoltStore) Purge() {
+	if s.cache != nil {
+		s.cache.purge()
+	}
+}
+
+// Returns the statistics of the cache, all zeroes if there is none
+func (s *BoltStore) CacheStats() CacheStats {
+	if s.cache == nil {
+		return CacheStats{}
+	}
+	return s.cache.stats()
+}
+
+// Reads every range of the bucket back into a store. The ranges may have gaps
+// between them.
+func (s *BoltStore) Tree() (*Node, error) {
+	items := make([]Ranged, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b == nil {
+			return bolt.ErrBucketNotFound
+		}
+		c := b.Cursor()
+		for key, value := c.First(); key != nil; key, value = c.Next() {
+			if len(key) != 8 || len(value) < boltRangeHeader {
+				return ErrCorruptStore{"malformed record"}
+			}
+			v, err := s.codec.Decode(value[boltRangeHeader:])
+			if err != nil {
+				return err
+			}
+			items = append(items, RangeEntry{binary.BigEndian.Uint64(value), binary.BigEndian.Uint64(key), v})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rangeStoreFromSortedChecked(items, true, true)
+}
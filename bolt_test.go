@@ -0,0 +1,108 @@
+//go:build !rangestore_core && bbolt
+// +build !rangestore_core,bbolt
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * bolt_test.go: Tests on the bbolt adapter
+ */
+
+package rangestore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestBolt(t *testing.T) (*bolt.DB, func()) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	db, err := bolt.Open(filepath.Join(dir, "store.db"), 0600, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Error while opening database: %s", err.Error())
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestBoltStore_RangeSearch(t *testing.T) {
+	db, cleanup := openTestBolt(t)
+	defer cleanup()
+	bucket := []byte("ranges")
+	if err := WriteBolt(db, bucket, mappedTestStore(t), nil); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	s, err := OpenBolt(db, bucket, nil, 16)
+	if err != nil {
+		t.Fatalf("Error while opening store: %s", err.Error())
+	}
+	for key, expected := range map[uint64]string{0: "A", 9: "A", 10: "B", 99: "B", 150: "C", 250: "A", 299: "A"} {
+		if v, err := s.RangeSearch(key); err != nil || v != expected {
+			t.Fatalf("Wrong value for %d: %v, %v", key, v, err)
+		}
+	}
+	for _, key := range []uint64{100, 149, 300} {
+		if _, err := s.RangeSearch(key); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+			t.Fatalf("Expected an ErrOutOfRange for %d, got %v", key, err)
+		}
+	}
+	s.RangeSearch(0)
+	if stats := s.CacheStats(); stats.Hits != 1 || stats.Entries != 10 {
+		t.Fatalf("Wrong cache statistics: %+v", stats)
+	}
+
+	tree, err := s.Tree()
+	if err != nil {
+		t.Fatalf("Error while reading store: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 9, "A"}, {10, 99, "B"}, {150, 199, "C"}, {200, 299, "A"}}
+	if !reflect.DeepEqual(tree.Ranges(), expected) {
+		t.Fatalf("Wrong ranges: %v", tree.Ranges())
+	}
+
+	// Rewriting the bucket replaces its contents
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 1000, "Z"})
+	n, _ := NewRangeStoreFromSorted(items)
+	if err := WriteBolt(db, bucket, n, nil); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	s.Purge()
+	if v, err := s.RangeSearch(500); err != nil || v != "Z" {
+		t.Fatalf("Wrong value after rewriting: %v, %v", v, err)
+	}
+}
+
+func TestOpenBolt_MissingBucket(t *testing.T) {
+	db, cleanup := openTestBolt(t)
+	defer cleanup()
+	if _, err := OpenBolt(db, []byte("missing"), nil, 0); err != bolt.ErrBucketNotFound {
+		t.Fatalf("Expected ErrBucketNotFound, got %v", err)
+	}
+}
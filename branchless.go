@@ -0,0 +1,106 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * branchless.go: Branch free searches over sorted bounds
+ */
+
+package rangestore
+
+// A store searched with a branch free lower bound over the sorted maximums of
+// the ranges. Every lookup runs the same number of iterations, and the loop
+// body only selects between two offsets, which the compiler turns into a
+// conditional move, so there are no mispredicted branches to pay for no matter
+// how random the keys are. Suited to workloads doing a very large number of
+// lookups over a store which fits in cache.
+type BranchlessStore struct {
+	mins, maxs []uint64
+	values     []interface{}
+}
+
+// Builds a branchless store from the items, which must be sorted and not
+// overlap, but may leave gaps
+func NewRangeStoreBranchless(items []Ranged) (*BranchlessStore, error) {
+	if _, err := cumulativeWidths(items, true, true); err != nil {
+		return nil, err
+	}
+	s := &BranchlessStore{
+		mins:   make([]uint64, len(items)),
+		maxs:   make([]uint64, len(items)),
+		values: make([]interface{}, len(items)),
+	}
+	for idx, item := range items {
+		s.mins[idx], s.maxs[idx], s.values[idx] = item.GetMin(), item.GetMax(), item.GetValue()
+	}
+	return s, nil
+}
+
+// Searches for the range which contains the specified key and returns the
+// associated value, or ErrOutOfRange if there is none
+func (s *BranchlessStore) RangeSearch(val uint64) (interface{}, error) {
+	idx := s.lowerBound(val)
+	if val > s.maxs[idx] || val < s.mins[idx] {
+		return nil, ErrOutOfRange{val}
+	}
+	return s.values[idx], nil
+}
+
+// Returns the index of the first range whose maximum is at least val, or the
+// last range if there is none. The search halves the candidates four times per
+// iteration while plenty are left, then finishes one halving at a time.
+func (s *BranchlessStore) lowerBound(val uint64) int {
+	maxs := s.maxs
+	base, n := 0, len(maxs)
+	for n > 16 {
+		half := n >> 1
+		base = pick(maxs[base+half-1] < val, base+half, base)
+		n -= half
+		half = n >> 1
+		base = pick(maxs[base+half-1] < val, base+half, base)
+		n -= half
+		half = n >> 1
+		base = pick(maxs[base+half-1] < val, base+half, base)
+		n -= half
+		half = n >> 1
+		base = pick(maxs[base+half-1] < val, base+half, base)
+		n -= half
+	}
+	for n > 1 {
+		half := n >> 1
+		base = pick(maxs[base+half-1] < val, base+half, base)
+		n -= half
+	}
+	return base
+}
+
+// Returns a if cond holds and b otherwise. Small enough to be inlined, where it
+// compiles to a conditional move.
+func pick(cond bool, a, b int) int {
+	if cond {
+		return a
+	}
+	return b
+}
+
+// Returns the number of ranges in the store
+func (s *BranchlessStore) Len() int {
+	return len(s.maxs)
+}
@@ -0,0 +1,100 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * branchless_test.go: Tests on branchless stores
+ */
+
+package rangestore
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBranchlessStore_Search(t *testing.T) {
+	items := make([]Ranged, 0)
+	for i := uint64(0); i < 1000; i += 1 {
+		// Leave a gap after every third range
+		if i%3 == 2 {
+			items = append(items, DefaultRangedValue{i * 10, i*10 + 4, i})
+		} else {
+			items = append(items, DefaultRangedValue{i * 10, i*10 + 9, i})
+		}
+	}
+	s, err := NewRangeStoreBranchless(items)
+	if err != nil {
+		t.Fatalf("Error while constructing branchless store: %s", err.Error())
+	}
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	for key := uint64(0); key < 10020; key += 1 {
+		want, wantErr := n.RangeSearch(key)
+		got, err := s.RangeSearch(key)
+		if got != want || reflect.TypeOf(err) != reflect.TypeOf(wantErr) {
+			t.Fatalf("Wrong result for %d: %v, %v", key, got, err)
+		}
+	}
+	if s.Len() != 1000 {
+		t.Fatalf("Wrong length: %d", s.Len())
+	}
+
+	_, err = NewRangeStoreBranchless([]Ranged{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{5, 14, "B"}})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+}
+
+func TestBranchlessStore_Sizes(t *testing.T) {
+	// Every size up to a few times the unrolled step, to cover the tail loop
+	for size := 1; size < 70; size += 1 {
+		items := arenaItems(size)
+		s, err := NewRangeStoreBranchless(items)
+		if err != nil {
+			t.Fatalf("Error while constructing branchless store: %s", err.Error())
+		}
+		for key := uint64(0); key < uint64(size*10); key += 1 {
+			if v, err := s.RangeSearch(key); err != nil || v != int(key/10) {
+				t.Fatalf("Wrong value for %d in a store of %d: %v", key, size, v)
+			}
+		}
+		if _, err := s.RangeSearch(uint64(size * 10)); err == nil {
+			t.Fatalf("Expected the key past the end to be out of range")
+		}
+	}
+}
+
+func Benchmark_RangeSearch_LargeBranchless(b *testing.B) {
+	s, err := NewRangeStoreBranchless(arenaItems(1000000))
+	if err != nil {
+		b.Fatalf("Got an error while building: %s", err.Error())
+	}
+	keys := make([]uint64, 1024)
+	for idx := range keys {
+		keys[idx] = uint64(rand.Int63n(10000000))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		if _, err := s.RangeSearch(keys[i%len(keys)]); err != nil {
+			b.Fatalf("Got an error while searching: %s", err.Error())
+		}
+	}
+}
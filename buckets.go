@@ -0,0 +1,168 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * buckets.go: Contiguous buckets defined by their upper bounds
+ */
+
+package rangestore
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Builds a continuous store of buckets from 0 up to the last of the splits, the
+// i-th bucket ending at splits[i] and starting just above the end of the one
+// before it, holding values[i]. The splits must be strictly increasing, otherwise
+// ErrOverlap is returned, and there must be one value per split, otherwise
+// ErrLengthMismatch is returned. Keys above the last split aren't covered.
+func NewBucketStore(splits []uint64, values []interface{}) (*Node, error) {
+	if len(values) != len(splits) {
+		return nil, ErrLengthMismatch{len(splits), len(values)}
+	}
+	items, err := bucketItems(splits, func(idx int) interface{} {
+		return values[idx]
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rangeStoreFromSortedChecked(items, false, false)
+}
+
+// Builds a store of n buckets covering the entire key space, split at the
+// empirical quantiles of the samples, so that each bucket holds about the same
+// number of samples, e.g. deciles for an n of 10. Bucket i ends at the sample
+// below which a fraction of (i+1)/n of the samples lie, and the last bucket is
+// open ended. When many samples are equal, a bucket can end where the one before
+// it does; it is then left out, and its samples go to the earlier bucket. The
+// buckets hold the labels, which must have n entries, or their index if labels
+// is nil. Returns ErrEmptyInput if there are no samples or n isn't positive.
+func NewQuantileBuckets(samples []uint64, n int, labels []interface{}) (*Node, error) {
+	if len(samples) == 0 || n < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	if labels != nil && len(labels) != n {
+		return nil, ErrLengthMismatch{n, len(labels)}
+	}
+	sorted := append([]uint64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	upper := make([]uint64, 0, n)
+	kept := make([]int, 0, n)
+	for i := 0; i < n; i += 1 {
+		max := uint64(math.MaxUint64)
+		if i < n-1 {
+			// The last of the first ceil((i+1)/n) of the samples
+			max = sorted[((i+1)*len(sorted)+n-1)/n-1]
+		}
+		if len(upper) > 0 && max <= upper[len(upper)-1] {
+			continue
+		}
+		upper = append(upper, max)
+		kept = append(kept, i)
+	}
+	items, _ := bucketItems(upper, func(idx int) interface{} {
+		if labels == nil {
+			return kept[idx]
+		}
+		return labels[kept[idx]]
+	})
+	return rangeStoreFromSortedChecked(items, false, false)
+}
+
+// Returns the buckets ending at each of the upper bounds, starting from 0, with
+// the values returned by value for their indices
+func bucketItems(upper []uint64, value func(idx int) interface{}) ([]Ranged, error) {
+	items := make([]Ranged, 0, len(upper))
+	min := uint64(0)
+	for idx, max := range upper {
+		if idx > 0 && max <= upper[idx-1] {
+			return nil, ErrOverlap{upper[idx-1], max}
+		}
+		items = append(items, RangeEntry{min, max, value(idx)})
+		min = max + 1
+	}
+	return items, nil
+}
+
+// Builds a continuous store from each of the thresholds up to just below the
+// next one, with the last one open ended, holding the values of the thresholds
+func thresholdStore(thresholds map[uint64]interface{}) (*Node, error) {
+	starts := make([]uint64, 0, len(thresholds))
+	for start := range thresholds {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	items := make([]Ranged, 0, len(starts))
+	for idx, start := range starts {
+		max := uint64(math.MaxUint64)
+		if idx+1 < len(starts) {
+			max = starts[idx+1] - 1
+		}
+		items = append(items, RangeEntry{start, max, thresholds[start]})
+	}
+	return rangeStoreFromSortedChecked(items, false, false)
+}
+
+// Classifies durations, such as request latencies, by the thresholds they reach
+type DurationBuckets struct {
+	store *Node
+}
+
+// Creates buckets starting at each of the thresholds and ending just below the
+// next one, holding the value of the threshold, e.g.
+//
+//	NewDurationBuckets(map[time.Duration]interface{}{
+//		0: "fast", 100 * time.Millisecond: "ok", time.Second: "slow",
+//	})
+//
+// The last bucket is open ended. Returns ErrEmptyInput if there are no
+// thresholds, and ErrInvalidInput if any of them are negative.
+func NewDurationBuckets(thresholds map[time.Duration]interface{}) (*DurationBuckets, error) {
+	ns := make(map[uint64]interface{}, len(thresholds))
+	for d, v := range thresholds {
+		if d < 0 {
+			return nil, ErrInvalidInput{"negative duration threshold " + d.String()}
+		}
+		ns[uint64(d)] = v
+	}
+	store, err := thresholdStore(ns)
+	if err != nil {
+		return nil, err
+	}
+	return &DurationBuckets{store}, nil
+}
+
+// Returns the value of the bucket the duration falls into. Negative durations
+// count as 0. Returns ErrOutOfRange for durations below the smallest threshold.
+func (b *DurationBuckets) SearchDuration(d time.Duration) (interface{}, error) {
+	if d < 0 {
+		d = 0
+	}
+	return b.store.RangeSearch(uint64(d))
+}
+
+// Returns the underlying store, whose keys are nanoseconds
+func (b *DurationBuckets) Root() *Node {
+	return b.store
+}
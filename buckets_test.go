@@ -0,0 +1,128 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * buckets_test.go: Tests for bucket stores
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewBucketStore(t *testing.T) {
+	n, err := NewBucketStore([]uint64{9, 99, 999}, []interface{}{"small", "medium", "large"})
+	if err != nil {
+		t.Fatalf("Error while constructing bucket store: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 9, "small"}, {10, 99, "medium"}, {100, 999, "large"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong buckets: %v", got)
+	}
+	if _, err := n.RangeSearch(1000); err == nil {
+		t.Fatalf("Expected keys above the last split to be out of range")
+	}
+
+	for name, test := range map[string]struct {
+		splits []uint64
+		values []interface{}
+		err    string
+	}{
+		"mismatch":   {[]uint64{9, 99}, []interface{}{"A"}, "ErrLengthMismatch"},
+		"decreasing": {[]uint64{99, 9}, []interface{}{"A", "B"}, "ErrOverlap"},
+		"repeated":   {[]uint64{9, 9}, []interface{}{"A", "B"}, "ErrOverlap"},
+		"empty":      {nil, nil, "ErrEmptyInput"},
+	} {
+		if _, err := NewBucketStore(test.splits, test.values); err == nil || reflect.TypeOf(err).Name() != test.err {
+			t.Fatalf("Expected %s for %s, got %v", test.err, name, err)
+		}
+	}
+}
+
+func TestDurationBuckets(t *testing.T) {
+	b, err := NewDurationBuckets(map[time.Duration]interface{}{
+		time.Second: "slow", 10 * time.Millisecond: "fast", 100 * time.Millisecond: "ok",
+	})
+	if err != nil {
+		t.Fatalf("Error while constructing duration buckets: %s", err.Error())
+	}
+	for d, expected := range map[time.Duration]string{
+		10 * time.Millisecond: "fast", 100*time.Millisecond - 1: "fast", 100 * time.Millisecond: "ok",
+		time.Second: "slow", 24 * time.Hour: "slow", time.Duration(math.MaxInt64): "slow",
+	} {
+		if v, err := b.SearchDuration(d); err != nil || v != expected {
+			t.Fatalf("Wrong bucket for %s: %v [%s]", d, v, expected)
+		}
+	}
+	if _, err := b.SearchDuration(time.Millisecond); err == nil || reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected ErrOutOfRange below the smallest threshold, got %v", err)
+	}
+	if b.Root().Len() != 3 {
+		t.Fatalf("Wrong number of buckets")
+	}
+
+	b, _ = NewDurationBuckets(map[time.Duration]interface{}{0: "any"})
+	if v, err := b.SearchDuration(-time.Second); err != nil || v != "any" {
+		t.Fatalf("Expected negative durations to count as 0")
+	}
+	if _, err := NewDurationBuckets(map[time.Duration]interface{}{-1: "A"}); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidInput" {
+		t.Fatalf("Expected ErrInvalidInput, got %v", err)
+	}
+	if _, err := NewDurationBuckets(nil); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected ErrEmptyInput, got %v", err)
+	}
+}
+
+func TestNewQuantileBuckets(t *testing.T) {
+	samples := make([]uint64, 0)
+	for i := uint64(100); i > 0; i -= 1 {
+		samples = append(samples, i*10)
+	}
+	n, err := NewQuantileBuckets(samples, 4, []interface{}{"Q1", "Q2", "Q3", "Q4"})
+	if err != nil {
+		t.Fatalf("Error while constructing quantile buckets: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 250, "Q1"}, {251, 500, "Q2"}, {501, 750, "Q3"}, {751, math.MaxUint64, "Q4"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong buckets: %v", got)
+	}
+
+	// Mostly equal samples leave no room for some of the buckets
+	n, err = NewQuantileBuckets([]uint64{5, 5, 5, 5, 5, 5, 5, 9}, 4, nil)
+	if err != nil {
+		t.Fatalf("Error while constructing quantile buckets: %s", err.Error())
+	}
+	expected = []RangeEntry{{0, 5, 0}, {6, math.MaxUint64, 3}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong buckets: %v", got)
+	}
+
+	if _, err := NewQuantileBuckets(nil, 4, nil); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected ErrEmptyInput, got %v", err)
+	}
+	if _, err := NewQuantileBuckets(samples, 4, []interface{}{"A"}); err == nil || reflect.TypeOf(err).Name() != "ErrLengthMismatch" {
+		t.Fatalf("Expected ErrLengthMismatch, got %v", err)
+	}
+}
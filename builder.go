@@ -0,0 +1,406 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * builder.go: Builder and construction options
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+	"unsafe"
+)
+
+// Selects the structure used to answer lookups
+type Backend int
+
+const (
+	// The pointer based tree built by NewRangeStoreFromSorted
+	TreeBackend Backend = iota
+	// A dense LookupTable, used when the store spans few enough keys and the
+	// tree otherwise. See WithLookupTableLimit.
+	LookupTableBackend
+	// The tree laid out as parallel slices of bounds, child indices and values,
+	// which is more compact and more cache friendly than the pointer based tree
+	FlatBackend
+)
+
+// Configures how a store is built. Options can be passed to NewBuilder,
+// Builder.With and NewRangeStore.
+type Option func(*options)
+
+type options struct {
+	gaps       bool
+	halfOpen   bool
+	overlaps   bool
+	hasDefault bool
+	def        interface{}
+	backend    Backend
+	tableLimit uint64
+	arena      bool
+	workers    int
+	multi      bool
+	cacheSize  int
+	dirBuckets int
+	missFilter bool
+
+	rebuildFactor float64
+
+	memoryBudget uintptr
+	valueSizer   func(v interface{}) uintptr
+
+	dropZeroWeights bool
+	zeroBased       bool
+	keyHasher       KeyHasher
+	dedupe          bool
+
+	intern     bool
+	internHash func(v interface{}) uint64
+	internEq   func(a, b interface{}) bool
+
+	observer *BuildObserver
+}
+
+type ErrMemoryBudgetExceeded struct {
+	footprint, budget uintptr
+}
+
+func (ex ErrMemoryBudgetExceeded) Error() string {
+	return fmt.Sprintf("Store would take about %d bytes, over the budget of %d", ex.footprint, ex.budget)
+}
+
+// Returns the estimated footprint of the store, in bytes
+func (ex ErrMemoryBudgetExceeded) Footprint() uintptr {
+	return ex.footprint
+}
+
+// Returns the budget which was exceeded, in bytes
+func (ex ErrMemoryBudgetExceeded) Budget() uintptr {
+	return ex.budget
+}
+
+func (ex ErrMemoryBudgetExceeded) Details() ErrorDetails {
+	return ErrorDetails{"ErrMemoryBudgetExceeded", map[string]interface{}{"footprint": ex.footprint, "budget": ex.budget}}
+}
+
+// Permits gaps between ranges, producing a sparse store
+func AllowGaps() Option {
+	return func(o *options) {
+		o.gaps = true
+	}
+}
+
+// Interprets the bounds passed to Builder.Add as half open, [min, max), rather
+// than inclusive. Doesn't affect ranges passed to AddRanged.
+func HalfOpen() Option {
+	return func(o *options) {
+		o.halfOpen = true
+	}
+}
+
+// Accepts overlapping ranges, resolving each overlap in favor of the range which
+// was added last
+func ResolveOverlaps() Option {
+	return func(o *options) {
+		o.overlaps = true
+	}
+}
+
+// Returns v for keys which aren't covered by any range, instead of ErrOutOfRange
+func WithDefault(v interface{}) Option {
+	return func(o *options) {
+		o.hasDefault = true
+		o.def = v
+	}
+}
+
+// Drops ranges added more than once with the same bounds and equal values, as
+// compared with reflect.DeepEqual, keeping the first. The same bounds with
+// different values still fail with ErrDuplicateRange.
+func DedupeIdentical() Option {
+	return func(o *options) {
+		o.dedupe = true
+	}
+}
+
+// Reports whether both items have the same bounds and equal values
+func sameRange(a, b Ranged) bool {
+	return a.GetMin() == b.GetMin() && a.GetMax() == b.GetMax() && reflect.DeepEqual(a.GetValue(), b.GetValue())
+}
+
+// Selects the structure used to answer lookups
+func WithBackend(b Backend) Option {
+	return func(o *options) {
+		o.backend = b
+	}
+}
+
+// Sets the largest number of keys LookupTableBackend will materialize, instead
+// of DefaultLookupTableLimit
+func WithLookupTableLimit(limit uint64) Option {
+	return func(o *options) {
+		o.tableLimit = limit
+	}
+}
+
+// Allocates all of the nodes of the tree out of a single contiguous slice,
+// rather than one at a time, which cuts down on the number of objects the
+// garbage collector has to track for large stores
+func WithArena() Option {
+	return func(o *options) {
+		o.arena = true
+	}
+}
+
+// Builds large trees using up to n goroutines. The resulting tree is identical
+// to the one built by a single goroutine.
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
+// Fails construction with ErrMemoryBudgetExceeded if the estimated footprint of
+// the tree, as reported by MemoryFootprint, would exceed budget bytes. The
+// estimate is checked before the tree is built, so an oversized store is never
+// allocated. See WithValueSizer for values which don't implement Sizer.
+func WithMemoryBudget(budget uintptr) Option {
+	return func(o *options) {
+		o.memoryBudget = budget
+	}
+}
+
+// Sizes the values for WithMemoryBudget using size, as MemoryFootprintFunc
+// does, instead of relying on them implementing Sizer
+func WithValueSizer(size func(v interface{}) uintptr) Option {
+	return func(o *options) {
+		o.valueSizer = size
+	}
+}
+
+// Collects ranges and options and builds a RangeStore from them, e.g.
+//
+//	store, err := NewBuilder().Add(0, 9, "A").Add(20, 29, "B").AllowGaps().Build()
+//
+// Ranges may be added in any order. The first error encountered while adding
+// ranges is reported by Build.
+type Builder struct {
+	opts  options
+	items []Ranged
+	err   error
+}
+
+// Creates a builder with the given options
+func NewBuilder(opts ...Option) *Builder {
+	return (&Builder{items: make([]Ranged, 0)}).With(opts...)
+}
+
+// Applies further options to the builder
+func (b *Builder) With(opts ...Option) *Builder {
+	for _, opt := range opts {
+		opt(&b.opts)
+	}
+	return b
+}
+
+// Adds a range mapping the keys from min to max to v. The bounds are inclusive,
+// unless the builder was configured with HalfOpen.
+func (b *Builder) Add(min, max uint64, v interface{}) *Builder {
+	if b.opts.halfOpen {
+		if max <= min {
+			if b.err == nil {
+				b.err = ErrInvertedRange{min, max}
+			}
+			return b
+		}
+		max -= 1
+	}
+	b.items = append(b.items, RangeEntry{min, max, v})
+	return b
+}
+
+// Adds existing ranges to the builder
+func (b *Builder) AddRanged(items ...Ranged) *Builder {
+	b.items = append(b.items, items...)
+	return b
+}
+
+// Shorthand for With(AllowGaps())
+func (b *Builder) AllowGaps() *Builder {
+	return b.With(AllowGaps())
+}
+
+// Shorthand for With(WithDefault(v))
+func (b *Builder) Default(v interface{}) *Builder {
+	return b.With(WithDefault(v))
+}
+
+// Shorthand for With(WithBackend(be))
+func (b *Builder) Backend(be Backend) *Builder {
+	return b.With(WithBackend(be))
+}
+
+// Validates the ranges and builds the store
+func (b *Builder) Build() (*RangeStore, error) {
+	if b.opts.observer == nil {
+		return b.build()
+	}
+	start := time.Now()
+	s, err := b.build()
+	b.opts.observer.complete(s, err, time.Since(start))
+	return s, err
+}
+
+func (b *Builder) build() (*RangeStore, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	items := append([]Ranged(nil), b.items...)
+	for _, item := range items {
+		if item.GetMin() > item.GetMax() {
+			return nil, ErrInvertedRange{item.GetMin(), item.GetMax()}
+		}
+	}
+	if b.opts.intern {
+		items = InternValues(items, b.opts.internHash, b.opts.internEq)
+	}
+	if b.opts.multi {
+		items = groupIdentical(items)
+	}
+	// Where each of the sorted items was added, if known
+	var origin []int
+
+	if b.opts.overlaps {
+		// Ranges added later take precedence
+		prioritized := make([]Prioritized, 0, len(items))
+		for idx, item := range items {
+			prioritized = append(prioritized, DefaultPrioritizedValue{item.GetMin(), item.GetMax(), idx, item.GetValue()})
+		}
+		resolved, err := NewRangeStoreFromPrioritized(prioritized)
+		if err != nil {
+			return nil, err
+		}
+		// Resolution leaves gaps alone, so they still need to be checked below
+		items = nodeRanged(resolved)
+	} else {
+		// Remember where each item was added, so that errors can refer to it
+		order := make([]int, len(items))
+		for idx := range order {
+			order[idx] = idx
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return lessRanged(items[order[i]], items[order[j]])
+		})
+		sorted := make([]Ranged, 0, len(items))
+		for _, pos := range order {
+			if b.opts.dedupe && len(sorted) > 0 && sameRange(sorted[len(sorted)-1], items[pos]) {
+				continue
+			}
+			sorted = append(sorted, items[pos])
+			origin = append(origin, pos)
+		}
+		items = sorted
+	}
+	cum, err := cumulativeWidthsProgress(items, true, b.opts.gaps, b.opts.observer.progress(len(items)))
+	if dup, ok := err.(ErrDuplicateRange); ok && origin != nil {
+		dup.index1, dup.index2 = origin[dup.index1], origin[dup.index2]
+		return nil, dup
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.opts.memoryBudget > 0 {
+		size := b.opts.valueSizer
+		if size == nil {
+			size = sizerSize
+		}
+		footprint := uintptr(0)
+		for _, item := range items {
+			footprint += unsafe.Sizeof(Node{}) + size(item.GetValue())
+		}
+		if footprint > b.opts.memoryBudget {
+			return nil, ErrMemoryBudgetExceeded{footprint, b.opts.memoryBudget}
+		}
+	}
+	var n *Node
+	if b.opts.workers > 1 {
+		n = buildParallel(items, cum, b.opts.workers, b.opts.arena)
+	} else if b.opts.arena {
+		n = buildArena(items, cum)
+	} else {
+		n = buildFromCumulative(items, cum, 0, len(items))
+	}
+	b.opts.observer.pivots(n)
+
+	s := WrapNode(n)
+	s.opts = b.opts
+	s.useBackend()
+	return s, nil
+}
+
+// Points the lookups of the store at the configured backend
+func (s *RangeStore) useBackend() {
+	s.search = s.root
+	switch {
+	case s.root == nil:
+		s.search = emptySearcher{}
+	case s.opts.backend == LookupTableBackend:
+		limit := s.opts.tableLimit
+		if limit == 0 {
+			limit = DefaultLookupTableLimit
+		}
+		if t, err := NewLookupTableWithLimit(nodeRanged(s.root), limit); err == nil {
+			s.search = t
+		}
+	case s.opts.backend == FlatBackend:
+		s.search = newFlatTree(s.root)
+	default:
+		if s.opts.dirBuckets > 1 {
+			s.search = newDirectory(s.root, s.opts.dirBuckets)
+		} else if s.opts.dirBuckets == 0 && s.count >= DirectoryThreshold {
+			s.search = newDirectory(s.root, DefaultDirectoryBuckets)
+		}
+	}
+	s.filter = nil
+	if s.opts.missFilter && s.root != nil {
+		s.filter = newMissFilter(s.root, s.count)
+	}
+	if s.opts.cacheSize > 0 {
+		if s.cache == nil {
+			s.cache = newLookupCache(s.opts.cacheSize)
+		} else {
+			s.cache.purge()
+		}
+	}
+}
+
+// Returns the ranges of a store as a slice of Ranged, ready to be rebuilt
+func nodeRanged(n *Node) []Ranged {
+	ret := make([]Ranged, 0)
+	n.walk(func(c *Node) {
+		ret = append(ret, RangeEntry{c.min, c.max, c.value})
+	})
+	return ret
+}
@@ -0,0 +1,154 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * builder_test.go: Tests on the builder
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_Basic(t *testing.T) {
+	s, err := NewBuilder().Add(20, 29, "C").Add(0, 9, "A").Add(10, 19, "B").Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	if s.Root().String() != "-B [max: 19]\n |-A [max: 9]\n !-C [max: 29]\n" {
+		t.Fatalf("Wrong tree shape:\n%s", s.Root().String())
+	}
+
+	_, err = NewBuilder().Add(0, 9, "A").Add(20, 29, "C").Build()
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDiscontinuity{}).Name() {
+		t.Fatalf("Expecting an ErrDiscontinuity, but got something else")
+	}
+}
+
+func TestBuilder_Options(t *testing.T) {
+	s, err := NewBuilder(HalfOpen()).Add(0, 10, "A").Add(20, 30, "B").AllowGaps().Default("none").Backend(TreeBackend).Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	for key, expected := range map[uint64]string{0: "A", 9: "A", 10: "none", 29: "B", 30: "none"} {
+		v, err := s.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back for %d: %s [%s]", key, v, expected)
+		}
+	}
+
+	_, err = NewBuilder(HalfOpen()).Add(10, 10, "A").Add(20, 30, "B").Build()
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvertedRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvertedRange, but got something else")
+	}
+	_, err = NewBuilder().Add(10, 5, "A").Build()
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvertedRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvertedRange, but got something else")
+	}
+}
+
+func TestBuilder_ResolveOverlaps(t *testing.T) {
+	s, err := NewBuilder(ResolveOverlaps()).Add(0, 99, "default").Add(10, 19, "override").Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 9, "default"}, {10, 19, "override"}, {20, 99, "default"}}
+	if got := s.Root().Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong resolved ranges: %v", got)
+	}
+
+	_, err = NewBuilder(ResolveOverlaps()).Add(0, 9, "A").Add(20, 29, "B").Build()
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDiscontinuity{}).Name() {
+		t.Fatalf("Expecting an ErrDiscontinuity, but got something else")
+	}
+}
+
+func TestNewRangeStore_Options(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{20, 29, "B"})
+
+	if _, err := NewRangeStore(items); err == nil {
+		t.Fatalf("Expected an error for a sparse store without AllowGaps")
+	}
+	s, err := NewRangeStore(items, AllowGaps())
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	if s.Len() != 2 || s.Span() != 20 {
+		t.Fatalf("Wrong store metadata: %d ranges, span %d", s.Len(), s.Span())
+	}
+}
+
+func TestBuilder_DuplicateRange(t *testing.T) {
+	_, err := NewBuilder().Add(10, 19, "B").Add(0, 9, "A").Add(20, 29, "C").Add(0, 9, "A").Build()
+	dup, ok := err.(ErrDuplicateRange)
+	if !ok {
+		t.Fatalf("Expecting an ErrDuplicateRange, but got %v", err)
+	}
+	// The indices are those the ranges were added at
+	if i, j := dup.Indices(); i != 1 || j != 3 {
+		t.Fatalf("Wrong indices: %d, %d", i, j)
+	}
+	if msg := err.Error(); msg != "Items 1 and 3 are both the range [0, 9]" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+
+	s, err := NewBuilder(DedupeIdentical()).Add(10, 19, "B").Add(0, 9, "A").Add(0, 9, "A").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	if s.Len() != 2 {
+		t.Fatalf("Expected the duplicate to be dropped: %v", s.Ranges())
+	}
+	_, err = NewBuilder(DedupeIdentical()).Add(0, 9, "A").Add(0, 9, "Z").Build()
+	if _, ok := err.(ErrDuplicateRange); !ok {
+		t.Fatalf("Expecting an ErrDuplicateRange for differing values, but got %v", err)
+	}
+}
+
+func TestBuilder_MemoryBudget(t *testing.T) {
+	large := sizedValue(make([]byte, 1<<20))
+	_, err := NewBuilder(WithMemoryBudget(1<<16)).Add(0, 9, large).Add(10, 19, "B").Build()
+	if err == nil || reflect.TypeOf(err).Name() != "ErrMemoryBudgetExceeded" {
+		t.Fatalf("Expected ErrMemoryBudgetExceeded, got %v", err)
+	}
+	if _, err := NewBuilder(WithMemoryBudget(2<<20)).Add(0, 9, large).Add(10, 19, "B").Build(); err != nil {
+		t.Fatalf("Error while building within the budget: %s", err.Error())
+	}
+
+	// Values which don't implement Sizer need a sizer of their own
+	b := NewBuilder(WithMemoryBudget(1<<16)).Add(0, 9, string(large))
+	if _, err := b.Build(); err != nil {
+		t.Fatalf("Expected strings to be opaque without a sizer, got %v", err)
+	}
+	b.With(WithValueSizer(func(v interface{}) uintptr {
+		return uintptr(len(v.(string)))
+	}))
+	if _, err := b.Build(); err == nil || reflect.TypeOf(err).Name() != "ErrMemoryBudgetExceeded" {
+		t.Fatalf("Expected ErrMemoryBudgetExceeded with the sizer, got %v", err)
+	}
+}
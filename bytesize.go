@@ -0,0 +1,141 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * bytesize.go: Tiers of byte sizes
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type ErrInvalidByteSize struct {
+	s string
+}
+
+func (ex ErrInvalidByteSize) Error() string {
+	return fmt.Sprintf("Invalid byte size %q", ex.s)
+}
+
+// Returns the string which couldn't be parsed
+func (ex ErrInvalidByteSize) Input() string {
+	return ex.s
+}
+
+func (ex ErrInvalidByteSize) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidByteSize", map[string]interface{}{"input": ex.s}}
+}
+
+// The multipliers of the units ParseByteSize understands, with the longest units
+// first so that "KiB" isn't mistaken for "B"
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier uint64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40}, {"pib", 1 << 50}, {"eib", 1 << 60},
+	{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9}, {"tb", 1e12}, {"pb", 1e15}, {"eb", 1e18},
+	{"b", 1},
+}
+
+// Parses a human readable byte size, such as "512", "64KiB", "1.5 GB" or "1mib",
+// into a number of bytes. Units ending in iB are powers of 1024, the other ones
+// powers of 1000, and their case doesn't matter. Fractions of a byte are rounded
+// down. Returns ErrInvalidByteSize if s can't be parsed or doesn't fit a uint64.
+func ParseByteSize(s string) (uint64, error) {
+	number := strings.TrimSpace(s)
+	multiplier := uint64(1)
+	lower := strings.ToLower(number)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(lower, unit.suffix) {
+			number = strings.TrimSpace(number[:len(number)-len(unit.suffix)])
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	// Only plain decimal numbers, which big.Rat would also accept as fractions
+	// like "1/2" or with exponents
+	if number == "" || strings.IndexFunc(number, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	}) >= 0 {
+		return 0, ErrInvalidByteSize{s}
+	}
+	r, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return 0, ErrInvalidByteSize{s}
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).SetUint64(multiplier)))
+	bytes := new(big.Int).Quo(r.Num(), r.Denom())
+	if !bytes.IsUint64() {
+		return 0, ErrInvalidByteSize{s}
+	}
+	return bytes.Uint64(), nil
+}
+
+// Classifies byte sizes, such as uploads or objects, into tiers by the
+// thresholds they reach
+type SizeTiers struct {
+	store *Node
+}
+
+// Creates tiers starting at each of the thresholds, given as strings understood
+// by ParseByteSize, and ending just below the next one, holding the value of the
+// threshold, e.g.
+//
+//	NewSizeTiers(map[string]interface{}{
+//		"0": "small", "64KiB": "medium", "1MiB": "large", "1GiB": "huge",
+//	})
+//
+// The last tier is open ended. Returns ErrEmptyInput if there are no
+// thresholds, ErrInvalidByteSize if any of them can't be parsed, and ErrOverlap
+// if two of them are the same size.
+func NewSizeTiers(thresholds map[string]interface{}) (*SizeTiers, error) {
+	sizes := make(map[uint64]interface{}, len(thresholds))
+	for s, v := range thresholds {
+		size, err := ParseByteSize(s)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := sizes[size]; ok {
+			return nil, ErrOverlap{size, size}
+		}
+		sizes[size] = v
+	}
+	store, err := thresholdStore(sizes)
+	if err != nil {
+		return nil, err
+	}
+	return &SizeTiers{store}, nil
+}
+
+// Returns the value of the tier a size of n bytes falls into. Returns
+// ErrOutOfRange for sizes below the smallest threshold.
+func (t *SizeTiers) SearchSize(n uint64) (interface{}, error) {
+	return t.store.RangeSearch(n)
+}
+
+// Returns the underlying store, whose keys are numbers of bytes
+func (t *SizeTiers) Root() *Node {
+	return t.store
+}
@@ -0,0 +1,74 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * bytesize_test.go: Tests for byte size tiers
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for s, expected := range map[string]uint64{
+		"0": 0, "512": 512, "512B": 512, "64KiB": 65536, "64 kib": 65536, "1MiB": 1 << 20, "1.5GiB": 3 << 29,
+		"1KB": 1000, "2.5mb": 2500000, "1.5b": 1, " 15EiB ": 15 << 60,
+	} {
+		size, err := ParseByteSize(s)
+		if err != nil {
+			t.Fatalf("Error while parsing %q: %s", s, err.Error())
+		}
+		if size != expected {
+			t.Fatalf("Wrong size for %q: %d [%d]", s, size, expected)
+		}
+	}
+	// 16EiB is one more than fits
+	for _, s := range []string{"", "KiB", "16EiB", "-1", "1/2", "1e3", "1.2.3", "12 apples", "."} {
+		if _, err := ParseByteSize(s); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidByteSize" {
+			t.Fatalf("Expected ErrInvalidByteSize for %q, got %v", s, err)
+		}
+	}
+}
+
+func TestSizeTiers(t *testing.T) {
+	tiers, err := NewSizeTiers(map[string]interface{}{"0": "small", "64KiB": "medium", "1MiB": "large", "1GiB": "huge"})
+	if err != nil {
+		t.Fatalf("Error while constructing size tiers: %s", err.Error())
+	}
+	for n, expected := range map[uint64]string{0: "small", 65535: "small", 65536: "medium", 1 << 20: "large", 1 << 40: "huge", ^uint64(0): "huge"} {
+		if v, err := tiers.SearchSize(n); err != nil || v != expected {
+			t.Fatalf("Wrong tier for %d: %v [%s]", n, v, expected)
+		}
+	}
+	if tiers.Root().Len() != 4 {
+		t.Fatalf("Wrong number of tiers")
+	}
+
+	if _, err := NewSizeTiers(map[string]interface{}{"1KiB": "A", "1024": "B"}); err == nil || reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected ErrOverlap for thresholds of the same size, got %v", err)
+	}
+	if _, err := NewSizeTiers(map[string]interface{}{"lots": "A"}); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidByteSize" {
+		t.Fatalf("Expected ErrInvalidByteSize, got %v", err)
+	}
+}
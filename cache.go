@@ -0,0 +1,140 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * cache.go: Memoizing lookups
+ */
+
+package rangestore
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// Point in time statistics of the lookup cache of a RangeStore
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// Caches the results of lookups, keeping the most recently used size keys. Misses
+// are cached as well, since a hot key which isn't covered costs as much to look
+// up as one which is.
+type lookupCache struct {
+	hits   uint64
+	misses uint64
+	mu     sync.Mutex
+	size   int
+	lru    *list.List
+	items  map[uint64]*list.Element
+}
+
+type cacheEntry struct {
+	key   uint64
+	value interface{}
+	err   error
+}
+
+// Puts a bounded LRU cache of size keys in front of RangeSearch, for traffic
+// which keeps hitting the same few keys. The cache is cleared whenever the store
+// is modified; a store replaced through a SwappableStore brings its own cache.
+func WithLookupCache(size int) Option {
+	return func(o *options) {
+		o.cacheSize = size
+	}
+}
+
+func newLookupCache(size int) *lookupCache {
+	return &lookupCache{size: size, lru: list.New(), items: make(map[uint64]*list.Element)}
+}
+
+func (c *lookupCache) get(key uint64) (*cacheEntry, bool) {
+	c.mu.Lock()
+	el, ok := c.items[key]
+	if !ok {
+		c.mu.Unlock()
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	e := el.Value.(*cacheEntry)
+	c.mu.Unlock()
+	atomic.AddUint64(&c.hits, 1)
+	return e, true
+}
+
+// Same as get, without counting the lookup or refreshing the entry
+func (c *lookupCache) peek(key uint64) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	return el.Value.(*cacheEntry), true
+}
+
+func (c *lookupCache) add(key uint64, value interface{}, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.lru.MoveToFront(el)
+		el.Value = &cacheEntry{key, value, err}
+		return
+	}
+	c.items[key] = c.lru.PushFront(&cacheEntry{key, value, err})
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Drops every cached entry, keeping the counters
+func (c *lookupCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.items = make(map[uint64]*list.Element)
+}
+
+func (c *lookupCache) stats() CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	return CacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: entries,
+	}
+}
+
+// Returns the statistics of the lookup cache. Stores built without
+// WithLookupCache report all zeroes.
+func (s *RangeStore) CacheStats() CacheStats {
+	if s.cache == nil {
+		return CacheStats{}
+	}
+	return s.cache.stats()
+}
@@ -0,0 +1,96 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * cache_test.go: Tests on the lookup cache
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeStore_LookupCache(t *testing.T) {
+	s, err := NewBuilder(WithLookupCache(2)).Add(0, 9, "A").Add(10, 19, "B").Add(20, 29, "C").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	for _, key := range []uint64{5, 5, 15, 5, 25, 15} {
+		if _, err := s.RangeSearch(key); err != nil {
+			t.Fatalf("Got an error while searching: %s", err.Error())
+		}
+	}
+	// 15 was evicted by 25, having been used less recently than 5
+	expected := CacheStats{Hits: 2, Misses: 4, Entries: 2}
+	if got := s.CacheStats(); got != expected {
+		t.Fatalf("Wrong cache stats: %+v", got)
+	}
+
+	_, err = s.RangeSearch(99)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+	_, err = s.RangeSearch(99)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting a cached ErrOutOfRange, but got something else")
+	}
+	if got := s.CacheStats(); got.Hits != 3 {
+		t.Fatalf("Expected the miss to be cached: %+v", got)
+	}
+}
+
+func TestRangeStore_LookupCacheInvalidation(t *testing.T) {
+	s, err := NewBuilder(WithLookupCache(16)).Add(0, 9, "A").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	if _, err := s.RangeSearch(15); err == nil {
+		t.Fatalf("Expected a miss before appending")
+	}
+	if err := s.Append(DefaultRangedValue{10, 19, "B"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if got := s.CacheStats(); got.Entries != 0 {
+		t.Fatalf("Expected the cache to be cleared: %+v", got)
+	}
+	if v, err := s.RangeSearch(15); err != nil || v != "B" {
+		t.Fatalf("Wrong value after appending: %v", v)
+	}
+}
+
+func TestRangeStore_NoLookupCache(t *testing.T) {
+	s, _ := NewBuilder().Add(0, 9, "A").Build()
+	s.RangeSearch(5)
+	if got := s.CacheStats(); got != (CacheStats{}) {
+		t.Fatalf("Expected empty cache stats: %+v", got)
+	}
+}
+
+func BenchmarkRangeStore_LookupCache(b *testing.B) {
+	items := arenaItems(1 << 16)
+	s, _ := NewRangeStore(items, WithLookupCache(64))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.RangeSearch(uint64(i%64) * 1000)
+	}
+}
@@ -0,0 +1,122 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * canonical.go: Shape independent dumps of stores
+ */
+
+package rangestore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+)
+
+// Writes one line per range to w, in ascending key order, of the form
+//
+//	<min>\t<max>\t<value>
+//
+// with the bounds in decimal and the value formatted using %v and quoted as a Go
+// string literal. Unlike String, the output only depends on the ranges and their
+// values, not on the shape of the tree, so it stays the same when the balancing
+// changes and is suitable for comparing against golden files.
+func (n *Node) DumpCanonical(w io.Writer) error {
+	return n.DumpCanonicalFunc(w, func(v interface{}) string {
+		return fmt.Sprintf("%v", v)
+	})
+}
+
+// Same as DumpCanonical, but uses the supplied function to format each of the
+// values before quoting them
+func (n *Node) DumpCanonicalFunc(w io.Writer, format func(v interface{}) string) error {
+	bw := bufio.NewWriter(w)
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		_, err = fmt.Fprintf(bw, "%d\t%d\t%s\n", c.min, c.max, strconv.Quote(format(c.value)))
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Returns one line per range, in ascending key order, of the form
+//
+//	[<min>, <max>] <value>
+//
+// with the bounds right aligned in columns and the values formatted using %v.
+// Like DumpCanonical, and unlike String, the output doesn't depend on the shape
+// of the tree, so it stays the same when the balancing changes.
+func (n *Node) StringByRange() string {
+	minWidth, maxWidth := 0, 0
+	n.walk(func(c *Node) {
+		if w := len(strconv.FormatUint(c.min, 10)); w > minWidth {
+			minWidth = w
+		}
+		if w := len(strconv.FormatUint(c.max, 10)); w > maxWidth {
+			maxWidth = w
+		}
+	})
+	var buf bytes.Buffer
+	n.walk(func(c *Node) {
+		fmt.Fprintf(&buf, "[%*d, %*d] %v\n", minWidth, c.min, maxWidth, c.max, c.value)
+	})
+	return buf.String()
+}
+
+// Returns a hash of the ranges and their values, such as with fnv.New64a(), which
+// only depends on the ranges in key order, like DumpCanonical, so stores built
+// from the same data match however their trees are shaped. Comparing the
+// fingerprints of a reloaded dataset and the one being served tells whether
+// anything changed. Values are hashed by their type and %v formatting, so values
+// whose formatting leaves out what distinguishes them, such as pointers to
+// structs with unexported fields which differ, need FingerprintFunc. h is reset
+// first.
+func (n *Node) Fingerprint(h hash.Hash64) uint64 {
+	return n.FingerprintFunc(h, func(v interface{}) []byte {
+		return []byte(fmt.Sprintf("%T\x00%v", v, v))
+	})
+}
+
+// Same as Fingerprint, but hashes the bytes returned by the supplied function
+// for each of the values
+func (n *Node) FingerprintFunc(h hash.Hash64, value func(v interface{}) []byte) uint64 {
+	h.Reset()
+	var hdr [24]byte
+	n.walk(func(c *Node) {
+		b := value(c.value)
+		// The length keeps the boundaries between values from shifting
+		binary.BigEndian.PutUint64(hdr[0:], c.min)
+		binary.BigEndian.PutUint64(hdr[8:], c.max)
+		binary.BigEndian.PutUint64(hdr[16:], uint64(len(b)))
+		h.Write(hdr[:])
+		h.Write(b)
+	})
+	return h.Sum64()
+}
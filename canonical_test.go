@@ -0,0 +1,123 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * canonical_test.go: Tests on canonical dumps
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"hash/fnv"
+	"testing"
+)
+
+func TestNode_DumpCanonical(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B\tb"})
+	items = append(items, DefaultRangedValue{20, 29, 3})
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	buf := new(bytes.Buffer)
+	if err := n.DumpCanonical(buf); err != nil {
+		t.Fatalf("Error while dumping: %s", err.Error())
+	}
+	expected := "0\t9\t\"A\"\n10\t19\t\"B\\tb\"\n20\t29\t\"3\"\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong dump:\n%s", buf.String())
+	}
+
+	// A differently shaped tree holding the same ranges dumps identically
+	freq, err := NewRangeStoreFromSortedWithFrequencies(items, []uint64{1, 1, 1000})
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	if freq.String() == n.String() {
+		t.Fatalf("Expected the trees to be shaped differently")
+	}
+	other := new(bytes.Buffer)
+	freq.DumpCanonical(other)
+	if other.String() != expected {
+		t.Fatalf("Wrong dump of the reshaped tree:\n%s", other.String())
+	}
+}
+
+func TestNode_StringByRange(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 99, "B"})
+	items = append(items, DefaultRangedValue{100, 1999, 3})
+	balanced, _ := NewRangeStoreFromSorted(items)
+	skewed, _ := NewRangeStoreFromSortedWithFrequencies(items, []uint64{1000, 1, 1})
+	if balanced.String() == skewed.String() {
+		t.Fatalf("Expected trees of different shapes")
+	}
+
+	expected := "[  0,    9] A\n[ 10,   99] B\n[100, 1999] 3\n"
+	for _, n := range []*Node{balanced, skewed} {
+		if got := n.StringByRange(); got != expected {
+			t.Fatalf("Wrong output:\n%s", got)
+		}
+	}
+	var empty *Node
+	if got := empty.StringByRange(); got != "" {
+		t.Fatalf("Expected no output for an empty store: %q", got)
+	}
+}
+
+func TestNode_Fingerprint(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 99, "C"})
+	balanced, _ := NewRangeStoreFromSorted(items)
+	// The same ranges in a differently shaped tree
+	chained, _ := NewRangeStoreFromSorted(items[:1])
+	chained.Append(items[1])
+	chained.Append(items[2])
+
+	h := fnv.New64a()
+	if balanced.Fingerprint(h) != chained.Fingerprint(h) {
+		t.Fatalf("Expected the same ranges to have the same fingerprint")
+	}
+	for _, changed := range [][]Ranged{
+		{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{10, 19, "X"}, DefaultRangedValue{20, 99, "C"}},
+		{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{10, 20, "B"}, DefaultRangedValue{21, 99, "C"}},
+		{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{10, 19, "B"}, DefaultRangedValue{20, 99, 'C'}},
+	} {
+		n, _ := NewRangeStoreFromSorted(changed)
+		if n.Fingerprint(h) == balanced.Fingerprint(h) {
+			t.Fatalf("Expected a different fingerprint for %v", changed)
+		}
+	}
+
+	// Only what the function returns is hashed
+	ignore := func(v interface{}) []byte { return nil }
+	x, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "X"}})
+	y, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "Y"}})
+	if x.FingerprintFunc(h, ignore) != y.FingerprintFunc(h, ignore) {
+		t.Fatalf("Expected the value function to be used")
+	}
+}
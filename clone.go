@@ -0,0 +1,88 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * clone.go: Copying and comparing stores
+ */
+
+package rangestore
+
+import (
+	"reflect"
+)
+
+// Returns a deep copy of the tree. The values themselves are shared between
+// the copies; use CloneFunc to copy them as well.
+func (n *Node) Clone() *Node {
+	return n.CloneFunc(nil)
+}
+
+// Returns a deep copy of the tree, passing each of the values through clone.
+// A nil clone function copies the values as they are.
+func (n *Node) CloneFunc(clone func(v interface{}) interface{}) *Node {
+	if n == nil {
+		return nil
+	}
+	c := &Node{min: n.min, max: n.max, value: n.value}
+	if clone != nil {
+		c.value = clone(n.value)
+	}
+	if n.left != nil {
+		c.left = n.left.CloneFunc(clone)
+	}
+	if n.right != nil {
+		c.right = n.right.CloneFunc(clone)
+	}
+	return c
+}
+
+// Reports whether both stores contain exactly the same ranges mapped to equal
+// values. The shape of the trees isn't considered, so stores built from the same
+// ranges with different balancing are still equal. Values are compared using
+// valueEq, or reflect.DeepEqual if it is nil.
+func (n *Node) Equal(other *Node, valueEq func(a, b interface{}) bool) bool {
+	if n == nil || other == nil {
+		return n == other
+	}
+	if valueEq == nil {
+		valueEq = reflect.DeepEqual
+	}
+	a := make([]*Node, 0)
+	n.walk(func(c *Node) {
+		a = append(a, c)
+	})
+	b := make([]*Node, 0, len(a))
+	other.walk(func(c *Node) {
+		b = append(b, c)
+	})
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx].min != b[idx].min || a[idx].max != b[idx].max {
+			return false
+		}
+		if !valueEq(a[idx].value, b[idx].value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * clone_test.go: Tests on copying and comparing stores
+ */
+
+package rangestore
+
+import (
+	"testing"
+)
+
+func TestNode_Clone(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	c := n.Clone()
+	if c == n || c.left == n.left || c.right == n.right {
+		t.Fatalf("Expected the clone to have its own nodes")
+	}
+	if c.String() != n.String() {
+		t.Fatalf("Expected the clone to have the same shape:\n%s\n%s", c.String(), n.String())
+	}
+	if !c.Equal(n, nil) {
+		t.Fatalf("Expected the clone to equal the original")
+	}
+
+	c.left.value = "Z"
+	if n.left.value != "A" {
+		t.Fatalf("Expected changes to the clone not to affect the original")
+	}
+	if c.Equal(n, nil) {
+		t.Fatalf("Expected the modified clone not to equal the original")
+	}
+}
+
+func TestNode_CloneFunc(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, []int{1}})
+	items = append(items, DefaultRangedValue{10, 19, []int{2}})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	c := n.CloneFunc(func(v interface{}) interface{} {
+		return append([]int(nil), v.([]int)...)
+	})
+	c.value.([]int)[0] = 42
+	if n.value.([]int)[0] != 1 {
+		t.Fatalf("Expected the values to be copied")
+	}
+}
+
+func TestNode_Equal(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+
+	a, _ := NewRangeStoreFromSorted(items)
+	// Same ranges, different shape
+	b, _ := NewRangeStoreFromSortedWithFrequencies(items, []uint64{100, 1, 1})
+	if a.String() == b.String() {
+		t.Fatalf("Expected the trees to have different shapes")
+	}
+	if !a.Equal(b, nil) {
+		t.Fatalf("Expected stores with the same ranges to be equal")
+	}
+
+	caseless := func(x, y interface{}) bool {
+		return x.(string) == y.(string) || x.(string) == "A" && y.(string) == "a"
+	}
+	items[0] = DefaultRangedValue{0, 9, "a"}
+	c, _ := NewRangeStoreFromSorted(items)
+	if a.Equal(c, nil) {
+		t.Fatalf("Expected stores with different values not to be equal")
+	}
+	if !a.Equal(c, caseless) {
+		t.Fatalf("Expected the value comparison function to be used")
+	}
+
+	items[2] = DefaultRangedValue{20, 30, "C"}
+	d, _ := NewRangeStoreFromSorted(items)
+	if a.Equal(d, nil) {
+		t.Fatalf("Expected stores with different ranges not to be equal")
+	}
+	if a.Equal(a.left, nil) {
+		t.Fatalf("Expected stores with different lengths not to be equal")
+	}
+	if a.Equal(nil, nil) {
+		t.Fatalf("Expected a store not to equal nil")
+	}
+}
@@ -0,0 +1,283 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * cmd/rangestore/main.go: Command line tool for building and querying stores
+ */
+
+// Builds serialized range stores from CSV, TSV or JSON definitions, queries them,
+// and dumps their structure:
+//
+//	rangestore build [-format csv|tsv|json] [-header] [-cidr] [-sort] [-gaps] -o store.bin input
+//	rangestore query store.bin key...
+//	rangestore dump [-dot | -stats | -svg | -html] store.bin
+//	rangestore migrate -o new.bin old.bin
+//
+// The input may be - for stdin. JSON input is an array of {"min", "max", "value"}
+// objects. Values are stored as strings. Stores written by older versions are
+// upgraded to the current format with migrate.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+const usage = `usage:
+  rangestore build [-format csv|tsv|json] [-header] [-cidr] [-sort] [-gaps] -o store.bin input
+  rangestore query store.bin key...
+  rangestore dump [-dot | -stats | -svg | -html] store.bin
+  rangestore migrate -o new.bin old.bin
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// Runs the command, returning the exit code: 1 for errors, 2 for bad usage
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var cmd func([]string, io.Reader, io.Writer) error
+	switch args[0] {
+	case "build":
+		cmd = build
+	case "query":
+		cmd = query
+	case "dump":
+		cmd = dump
+	case "migrate":
+		cmd = migrate
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err := cmd(args[1:], stdin, stdout); err != nil {
+		if err == flag.ErrHelp || err == errUsage {
+			fmt.Fprint(stderr, usage)
+			return 2
+		}
+		fmt.Fprintf(stderr, "rangestore %s: %s\n", args[0], err.Error())
+		return 1
+	}
+	return 0
+}
+
+var errUsage = errors.New("bad usage")
+
+func newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return fs
+}
+
+func build(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlags("build")
+	format := fs.String("format", "csv", "input format: csv, tsv or json")
+	header := fs.Bool("header", false, "skip the first row of CSV/TSV input")
+	cidr := fs.Bool("cidr", false, "read cidr,value rows instead of min,max,value")
+	sorted := fs.Bool("sort", false, "sort the ranges instead of requiring them in order")
+	gaps := fs.Bool("gaps", false, "allow gaps between ranges")
+	out := fs.String("o", "", "output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *out == "" {
+		return errUsage
+	}
+
+	in := stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var n *rangestore.Node
+	var err error
+	switch *format {
+	case "csv", "tsv":
+		opts := rangestore.CSVOptions{Header: *header, CIDR: *cidr, Sort: *sorted, AllowGaps: *gaps}
+		if *format == "tsv" {
+			opts.Comma = '\t'
+		}
+		n, err = rangestore.LoadCSV(in, opts)
+	case "json":
+		n, err = loadJSON(in, *sorted, *gaps)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := rangestore.WriteMapped(f, n); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %d ranges to %s\n", n.Len(), *out)
+	return nil
+}
+
+func loadJSON(r io.Reader, sorted, gaps bool) (*rangestore.Node, error) {
+	entries := make([]rangestore.RangeEntry, 0)
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	b := rangestore.NewBuilder()
+	if gaps {
+		b.AllowGaps()
+	}
+	for idx, e := range entries {
+		if _, ok := e.Value.(string); !ok {
+			e.Value = fmt.Sprint(e.Value)
+		}
+		if !sorted && idx > 0 && e.Min <= entries[idx-1].Max {
+			return nil, fmt.Errorf("range %d isn't in order, use -sort", idx)
+		}
+		b.Add(e.Min, e.Max, e.Value)
+	}
+	s, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return s.Root(), nil
+}
+
+func query(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	m, err := rangestore.OpenMapped(args[0])
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	for _, arg := range args[1:] {
+		key, err := strconv.ParseUint(arg, 0, 64)
+		if err != nil {
+			return fmt.Errorf("bad key %q", arg)
+		}
+		v, err := m.RangeSearch(key)
+		if err != nil {
+			fmt.Fprintf(stdout, "%d\terror: %s\n", key, err.Error())
+			continue
+		}
+		fmt.Fprintf(stdout, "%d\t%s\n", key, v)
+	}
+	return nil
+}
+
+func dump(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlags("dump")
+	dot := fs.Bool("dot", false, "write Graphviz DOT output")
+	stats := fs.Bool("stats", false, "write statistics about the tree")
+	svg := fs.Bool("svg", false, "write an SVG image of the key space")
+	page := fs.Bool("html", false, "write an HTML page showing the key space")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	modes := 0
+	for _, set := range []bool{*dot, *stats, *svg, *page} {
+		if set {
+			modes += 1
+		}
+	}
+	if fs.NArg() != 1 || modes > 1 {
+		return errUsage
+	}
+	m, err := rangestore.OpenMapped(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	n, err := m.Tree()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *dot:
+		return n.DOT(stdout)
+	case *svg:
+		return n.KeyspaceSVG(stdout, rangestore.KeyspaceOptions{})
+	case *page:
+		return n.KeyspaceHTML(stdout, rangestore.KeyspaceOptions{Title: fs.Arg(0)})
+	case *stats:
+		min, max := n.Bounds()
+		fmt.Fprintf(stdout, "ranges: %d\ndepth: %d\nmin: %d\nmax: %d\nmemory: %d\n", n.Len(), n.Depth(), min, max, n.MemoryFootprint())
+	default:
+		fmt.Fprint(stdout, n.String())
+	}
+	return nil
+}
+
+func migrate(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlags("migrate")
+	out := fs.String("o", "", "output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *out == "" {
+		return errUsage
+	}
+
+	in := stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := rangestore.Migrate(in, f); err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "migrated %s to %s\n", fs.Arg(0), *out)
+	return nil
+}
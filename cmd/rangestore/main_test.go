@@ -0,0 +1,152 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * cmd/rangestore/main_test.go: Tests on the command line tool
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_BuildQueryDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "store.bin")
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("min,max,value\n10,19,B\n0,9,A\n30,39,C\n")
+	if code := run([]string{"build", "-header", "-sort", "-gaps", "-o", out, "-"}, stdin, &stdout, &stderr); code != 0 {
+		t.Fatalf("Build failed with %d: %s", code, stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"query", out, "5", "0x10", "25"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Query failed with %d: %s", code, stderr.String())
+	}
+	expected := "5\tA\n16\tB\n25\terror: Value 25 is out of range\n"
+	if stdout.String() != expected {
+		t.Fatalf("Wrong query output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"dump", "-stats", out}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Dump failed with %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "ranges: 3\ndepth: 2\nmin: 0\nmax: 39\n") {
+		t.Fatalf("Wrong stats output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"dump", "-dot", out}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Dump failed with %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "digraph rangestore {") {
+		t.Fatalf("Wrong DOT output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"dump", "-svg", out}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Dump failed with %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "<svg ") {
+		t.Fatalf("Wrong SVG output:\n%s", stdout.String())
+	}
+	if code := run([]string{"dump", "-svg", "-html", out}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("Expected a usage error, got %d", code)
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "store.bin")
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader(`[{"min": 0, "max": 9, "value": "A"}, {"min": 10, "max": 19, "value": 2}]`)
+	if code := run([]string{"build", "-format", "json", "-o", out, "-"}, stdin, &stdout, &stderr); code != 0 {
+		t.Fatalf("Build failed with %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	run([]string{"query", out, "15"}, nil, &stdout, &stderr)
+	if stdout.String() != "15\t2\n" {
+		t.Fatalf("Wrong query output:\n%s", stdout.String())
+	}
+}
+
+func TestRun_Migrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "store.bin")
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("0,9,A\n10,19,B\n")
+	if code := run([]string{"build", "-o", out, "-"}, stdin, &stdout, &stderr); code != 0 {
+		t.Fatalf("Build failed with %d: %s", code, stderr.String())
+	}
+	migrated := filepath.Join(dir, "migrated.bin")
+	if code := run([]string{"migrate", "-o", migrated, out}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Migrate failed with %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	run([]string{"query", migrated, "15"}, nil, &stdout, &stderr)
+	if stdout.String() != "15\tB\n" {
+		t.Fatalf("Wrong query output:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"migrate", "-o", migrated, "-"}, strings.NewReader("garbage"), &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected an error, got %d", code)
+	}
+	if _, err := os.Stat(migrated); !os.IsNotExist(err) {
+		t.Fatalf("Expected the output of a failed migration to be removed")
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"frobnicate"}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("Expected a usage error, got %d", code)
+	}
+	if code := run([]string{"build", "input.csv"}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("Expected a usage error, got %d", code)
+	}
+	stderr.Reset()
+	if code := run([]string{"query", "/nonexistent/store.bin", "1"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected an error, got %d", code)
+	}
+	if !strings.HasPrefix(stderr.String(), "rangestore query: ") {
+		t.Fatalf("Wrong error output: %s", stderr.String())
+	}
+}
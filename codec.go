@@ -0,0 +1,134 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * codec.go: Encoding values for persistence
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+)
+
+// Converts values to bytes and back, for the formats which persist stores:
+// WriteMappedCodec, MappedStore.SetCodec, SaveSnapshotCodec and
+// LoadSnapshotCodec. Implement it to store values of your own types.
+type ValueCodec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(b []byte) (interface{}, error)
+}
+
+// Stores strings and byte slices as their bytes, decoding them as strings. Other
+// values fail with ErrUnserializableValue. The default codec.
+type StringCodec struct{}
+
+func (StringCodec) Encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+	return nil, ErrUnserializableValue{v}
+}
+
+func (StringCodec) Decode(b []byte) (interface{}, error) {
+	return string(b), nil
+}
+
+// Stores integers of any size as signed varints, decoding them as int64.
+// Unsigned values above math.MaxInt64 and other values fail with
+// ErrUnserializableValue.
+type IntCodec struct{}
+
+func (IntCodec) Encode(v interface{}) ([]byte, error) {
+	var i int64
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > 1<<63-1 {
+			return nil, ErrUnserializableValue{v}
+		}
+		i = int64(rv.Uint())
+	default:
+		return nil, ErrUnserializableValue{v}
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutVarint(buf, i)], nil
+}
+
+func (IntCodec) Decode(b []byte) (interface{}, error) {
+	i, n := binary.Varint(b)
+	if n <= 0 || n != len(b) {
+		return nil, ErrCorruptStore{"invalid integer value"}
+	}
+	return i, nil
+}
+
+// Stores values as JSON. Values are decoded into the value returned by New,
+// which should be a pointer, and which is returned as it is, or if New is nil,
+// into an interface{}, giving maps, slices, strings, float64s and so on.
+type JSONCodec struct {
+	New func() interface{}
+}
+
+func (c JSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c JSONCodec) Decode(b []byte) (interface{}, error) {
+	if c.New == nil {
+		var v interface{}
+		err := json.Unmarshal(b, &v)
+		return v, err
+	}
+	v := c.New()
+	err := json.Unmarshal(b, v)
+	return v, err
+}
+
+// Stores values with encoding/gob, keeping their types. As the values are
+// encoded as interfaces, any types which aren't basic types must be registered
+// with gob.Register.
+type GobCodec struct{}
+
+type gobValue struct {
+	V interface{}
+}
+
+func (GobCodec) Encode(v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := gob.NewEncoder(buf).Encode(gobValue{v})
+	return buf.Bytes(), err
+}
+
+func (GobCodec) Decode(b []byte) (interface{}, error) {
+	var v gobValue
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return v.V, err
+}
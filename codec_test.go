@@ -0,0 +1,159 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * codec_test.go: Tests on the value codecs
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type codecTestValue struct {
+	Name  string
+	Count int
+}
+
+func TestValueCodec_RoundTrip(t *testing.T) {
+	cases := []struct {
+		codec   ValueCodec
+		in, out interface{}
+	}{
+		{StringCodec{}, "abc", "abc"},
+		{StringCodec{}, []byte("abc"), "abc"},
+		{IntCodec{}, -5, int64(-5)},
+		{IntCodec{}, uint32(70000), int64(70000)},
+		{JSONCodec{}, map[string]interface{}{"a": 1.5}, map[string]interface{}{"a": 1.5}},
+		{JSONCodec{New: func() interface{} { return new(codecTestValue) }}, codecTestValue{"x", 2}, &codecTestValue{"x", 2}},
+		{GobCodec{}, "abc", "abc"},
+		{GobCodec{}, int64(7), int64(7)},
+	}
+	for i, c := range cases {
+		b, err := c.codec.Encode(c.in)
+		if err != nil {
+			t.Fatalf("Error while encoding case %d: %s", i, err.Error())
+		}
+		v, err := c.codec.Decode(b)
+		if err != nil {
+			t.Fatalf("Error while decoding case %d: %s", i, err.Error())
+		}
+		if !reflect.DeepEqual(v, c.out) {
+			t.Fatalf("Case %d decoded to %#v", i, v)
+		}
+	}
+
+	if _, err := (StringCodec{}).Encode(1); reflect.TypeOf(err).Name() != "ErrUnserializableValue" {
+		t.Fatalf("Expected an ErrUnserializableValue, got %v", err)
+	}
+	if _, err := (IntCodec{}).Encode(uint64(1) << 63); reflect.TypeOf(err).Name() != "ErrUnserializableValue" {
+		t.Fatalf("Expected an ErrUnserializableValue, got %v", err)
+	}
+	if _, err := (IntCodec{}).Decode([]byte{0x80}); reflect.TypeOf(err).Name() != "ErrCorruptStore" {
+		t.Fatalf("Expected an ErrCorruptStore, got %v", err)
+	}
+}
+
+func TestMappedStore_Codec(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, 100})
+	items = append(items, DefaultRangedValue{10, 19, -3})
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	if err := WriteMapped(ioutil.Discard, n); reflect.TypeOf(err).Name() != "ErrUnserializableValue" {
+		t.Fatalf("Expected an ErrUnserializableValue, got %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.bin")
+	var buf bytes.Buffer
+	if err := WriteMappedCodec(&buf, n, IntCodec{}); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Error while writing file: %s", err.Error())
+	}
+	m, err := OpenMapped(path)
+	if err != nil {
+		t.Fatalf("Error while opening store: %s", err.Error())
+	}
+	defer m.Close()
+	m.SetCodec(IntCodec{})
+	if v, err := m.RangeSearch(15); err != nil || v != int64(-3) {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+	tree, err := m.Tree()
+	if err != nil {
+		t.Fatalf("Error while copying store: %s", err.Error())
+	}
+	if v, err := tree.RangeSearch(5); err != nil || v != int64(100) {
+		t.Fatalf("Wrong value in copy: %v, %v", v, err)
+	}
+}
+
+func TestNode_SaveSnapshotCodec(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, codecTestValue{"a", 1}})
+	items = append(items, DefaultRangedValue{10, 19, codecTestValue{"b", 2}})
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.snap")
+
+	codec := JSONCodec{New: func() interface{} { return new(codecTestValue) }}
+	if err := n.SaveSnapshotCodec(path, codec); err != nil {
+		t.Fatalf("Error while saving snapshot: %s", err.Error())
+	}
+	loaded, err := LoadSnapshotCodec(path, codec)
+	if err != nil {
+		t.Fatalf("Error while loading snapshot: %s", err.Error())
+	}
+	if v, err := loaded.RangeSearch(12); err != nil || !reflect.DeepEqual(v, &codecTestValue{"b", 2}) {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+
+	// Without the codec the values come back encoded
+	raw, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("Error while loading snapshot: %s", err.Error())
+	}
+	if v, _ := raw.RangeSearch(12); !reflect.DeepEqual(v, []byte(`{"Name":"b","Count":2}`)) {
+		t.Fatalf("Wrong raw value: %v", v)
+	}
+}
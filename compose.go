@@ -0,0 +1,59 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * compose.go: Composing stores
+ */
+
+package rangestore
+
+// Precomputes the composition of two stores, for keys which are looked up in a
+// and then, after translating the value found there into a key, in b, as with ID
+// translation tables. The result maps each range of a to the value b holds for
+// the key its value translates to, so a lookup takes a single descent rather
+// than two.
+//
+// Ranges of a whose translated key isn't covered by b are left out, making the
+// result sparse. An error returned by translate stops the composition and is
+// returned. If no range is left, ErrEmptyInput is returned.
+func Compose(a, b *Node, translate func(valA interface{}) (uint64, error)) (*Node, error) {
+	items := make([]Ranged, 0)
+	var err error
+	a.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		var key uint64
+		if key, err = translate(c.value); err != nil {
+			return
+		}
+		v, missing := b.RangeSearch(key)
+		if missing != nil {
+			return
+		}
+		items = append(items, RangeEntry{c.min, c.max, v})
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The ranges come from a valid store, so there's no need to check them again
+	return rangeStoreFromSortedChecked(items, false, true)
+}
@@ -0,0 +1,79 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * compose_test.go: Tests on composing stores
+ */
+
+package rangestore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	// Maps external IDs to internal ones
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 99, uint64(5)})
+	items = append(items, DefaultRangedValue{100, 199, uint64(15)})
+	items = append(items, DefaultRangedValue{200, 299, uint64(50)})
+	a, _ := NewRangeStoreFromSorted(items)
+
+	// Maps internal IDs to owners
+	items = make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "alice"})
+	items = append(items, DefaultRangedValue{10, 19, "bob"})
+	b, _ := NewRangeStoreFromSorted(items)
+
+	c, err := Compose(a, b, func(v interface{}) (uint64, error) {
+		return v.(uint64), nil
+	})
+	if err != nil {
+		t.Fatalf("Error while composing: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 99, "alice"}, {100, 199, "bob"}}
+	if got := c.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	for key := uint64(0); key < 300; key += 7 {
+		internal, _ := a.RangeSearch(key)
+		want, wantErr := b.RangeSearch(internal.(uint64))
+		got, err := c.RangeSearch(key)
+		if got != want || (err == nil) != (wantErr == nil) {
+			t.Fatalf("Composition disagrees with chained lookups for %d: %v, %v", key, got, want)
+		}
+	}
+}
+
+func TestCompose_Error(t *testing.T) {
+	a, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "x"}})
+	b, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "y"}})
+	broken := errors.New("broken")
+	if _, err := Compose(a, b, func(interface{}) (uint64, error) { return 0, broken }); err != broken {
+		t.Fatalf("Expected the translation error, got %v", err)
+	}
+	_, err := Compose(a, b, func(interface{}) (uint64, error) { return 100, nil })
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+}
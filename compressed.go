@@ -0,0 +1,233 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * compressed.go: Compact serialization with delta encoded boundaries
+ */
+
+package rangestore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// The compressed format stores the boundaries as differences, which are small
+// for sorted ranges, and each distinct value once. Every number is a uvarint:
+//
+//	header      the magic, then the version, the number of ranges, the number
+//	            of distinct values, the raw size and the compressed size
+//	dictionary  the distinct values, each as its length then its bytes
+//	ranges      for each range, the gap since the end of the previous range
+//	            (or the min of the first range), the width minus 1, and the
+//	            index of its value in the dictionary
+//
+// The raw size is what the same store takes with 16 bytes for the bounds of
+// each range, plus the bytes of its value; the compressed size is that of the
+// dictionary and ranges sections. Their ratio is reported by
+// CompressedDecoder.Ratio, before anything else is read.
+const (
+	compressedMagic   = "RNGCMPR\x00"
+	compressedVersion = 1
+)
+
+// Writes the store in the compressed format read by NewCompressedDecoder and
+// ReadCompressed, with the values encoded by codec, or StringCodec if it's nil
+func WriteCompressed(w io.Writer, n *Node, codec ValueCodec) error {
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	var dict, ranges []byte
+	index := make(map[string]uint64)
+	var raw, prev uint64
+	first := true
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		var encoded []byte
+		if encoded, err = codec.Encode(c.value); err != nil {
+			return
+		}
+		idx, ok := index[string(encoded)]
+		if !ok {
+			idx = uint64(len(index))
+			index[string(encoded)] = idx
+			dict = appendProtoVarint(dict, uint64(len(encoded)))
+			dict = append(dict, encoded...)
+		}
+		gap := c.min
+		if !first {
+			gap = c.min - prev - 1
+		}
+		ranges = appendProtoVarint(ranges, gap)
+		ranges = appendProtoVarint(ranges, c.max-c.min)
+		ranges = appendProtoVarint(ranges, idx)
+		raw += 16 + uint64(len(encoded))
+		prev, first = c.max, false
+	})
+	if err != nil {
+		return err
+	}
+
+	header := []byte(compressedMagic)
+	for _, v := range []uint64{compressedVersion, uint64(n.Len()), uint64(len(index)), raw, uint64(len(dict) + len(ranges))} {
+		header = appendProtoVarint(header, v)
+	}
+	for _, section := range [][]byte{header, dict, ranges} {
+		if _, err := w.Write(section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reads the ranges of a compressed store one at a time, without holding them
+// all in memory
+type CompressedDecoder struct {
+	r          *bufio.Reader
+	codec      ValueCodec
+	count      uint64
+	read       uint64
+	raw        uint64
+	compressed uint64
+	dict       []interface{}
+	prev       uint64
+}
+
+// Reads the header and the dictionary from r, decoding the values with codec,
+// or StringCodec if it's nil. Returns an ErrUnsupportedVersion for unknown
+// format versions, and an ErrCorruptStore if the data can't be read.
+func NewCompressedDecoder(r io.Reader, codec ValueCodec) (*CompressedDecoder, error) {
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	d := &CompressedDecoder{r: bufio.NewReader(r), codec: codec}
+	magic := make([]byte, len(compressedMagic))
+	if _, err := io.ReadFull(d.r, magic); err != nil || string(magic) != compressedMagic {
+		return nil, ErrCorruptStore{"bad magic"}
+	}
+	var header [5]uint64
+	for i := range header {
+		v, err := binary.ReadUvarint(d.r)
+		if err != nil {
+			return nil, ErrCorruptStore{"truncated header"}
+		}
+		header[i] = v
+	}
+	if header[0] != compressedVersion {
+		return nil, ErrUnsupportedVersion{uint32(header[0])}
+	}
+	d.count, d.raw, d.compressed = header[1], header[3], header[4]
+	// Every value and range takes at least a byte, which bounds the
+	// allocations a corrupt header can cause
+	if header[2] > d.compressed || d.count > d.compressed {
+		return nil, ErrCorruptStore{"counts don't match the header"}
+	}
+	d.dict = make([]interface{}, 0, header[2])
+	for i := uint64(0); i < header[2]; i++ {
+		length, err := binary.ReadUvarint(d.r)
+		if err != nil || length > d.compressed {
+			return nil, ErrCorruptStore{"truncated dictionary"}
+		}
+		b := make([]byte, length)
+		if _, err := io.ReadFull(d.r, b); err != nil {
+			return nil, ErrCorruptStore{"truncated dictionary"}
+		}
+		v, err := codec.Decode(b)
+		if err != nil {
+			return nil, err
+		}
+		d.dict = append(d.dict, v)
+	}
+	return d, nil
+}
+
+// Returns the number of ranges in the store
+func (d *CompressedDecoder) Len() int {
+	return int(d.count)
+}
+
+// Returns the raw size of the store divided by its compressed size, as recorded
+// in the header
+func (d *CompressedDecoder) Ratio() float64 {
+	if d.compressed == 0 {
+		return 0
+	}
+	return float64(d.raw) / float64(d.compressed)
+}
+
+// Returns the next range, in ascending order, or io.EOF once all of them have
+// been read
+func (d *CompressedDecoder) Next() (RangeEntry, error) {
+	if d.read == d.count {
+		return RangeEntry{}, io.EOF
+	}
+	var fields [3]uint64
+	for i := range fields {
+		v, err := binary.ReadUvarint(d.r)
+		if err != nil {
+			return RangeEntry{}, ErrCorruptStore{"truncated range"}
+		}
+		fields[i] = v
+	}
+	min := fields[0]
+	if d.read > 0 {
+		if d.prev == math.MaxUint64 || fields[0] > math.MaxUint64-d.prev-1 {
+			return RangeEntry{}, ErrCorruptStore{"range is out of bounds"}
+		}
+		min = d.prev + 1 + fields[0]
+	}
+	if fields[1] > math.MaxUint64-min {
+		return RangeEntry{}, ErrCorruptStore{"range is out of bounds"}
+	}
+	if fields[2] >= uint64(len(d.dict)) {
+		return RangeEntry{}, ErrCorruptStore{"value index is out of bounds"}
+	}
+	d.read++
+	d.prev = min + fields[1]
+	return RangeEntry{min, d.prev, d.dict[fields[2]]}, nil
+}
+
+// Reads a compressed store from r and builds a sparse store from it, see
+// NewCompressedDecoder
+func ReadCompressed(r io.Reader, codec ValueCodec) (*Node, error) {
+	d, err := NewCompressedDecoder(r, codec)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Ranged, 0, d.Len())
+	for {
+		e, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	// The encoding can't express overlapping or unsorted ranges
+	return rangeStoreFromSortedChecked(items, false, true)
+}
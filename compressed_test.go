@@ -0,0 +1,119 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * compressed_test.go: Tests on the compressed format
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCompressed(t *testing.T) {
+	var buf bytes.Buffer
+	n := mappedTestStore(t)
+	if err := WriteCompressed(&buf, n, nil); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	d, err := NewCompressedDecoder(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatalf("Error while reading store: %s", err.Error())
+	}
+	if d.Len() != 4 {
+		t.Fatalf("Wrong number of ranges: %d", d.Len())
+	}
+	// 4*16 bounds and 4 value bytes, against "A", "B" and "C" with their
+	// lengths and 4 ranges of 3 bytes each
+	if d.Ratio() != 68.0/18.0 {
+		t.Fatalf("Wrong ratio: %f", d.Ratio())
+	}
+	entries := make([]RangeEntry, 0)
+	for {
+		e, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error while reading range: %s", err.Error())
+		}
+		entries = append(entries, e)
+	}
+	expected := []RangeEntry{{0, 9, "A"}, {10, 99, "B"}, {150, 199, "C"}, {200, 299, "A"}}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("Wrong ranges: %v", entries)
+	}
+
+	back, err := ReadCompressed(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatalf("Error while reading store: %s", err.Error())
+	}
+	if !reflect.DeepEqual(back.Ranges(), expected) {
+		t.Fatalf("Wrong ranges: %v", back.Ranges())
+	}
+}
+
+func TestReadCompressed_Corrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCompressed(&buf, mappedTestStore(t), nil); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	good := buf.Bytes()
+	for _, size := range []int{4, len(compressedMagic) + 2, len(good) - 1} {
+		if _, err := ReadCompressed(bytes.NewReader(good[:size]), nil); reflect.TypeOf(err).Name() != "ErrCorruptStore" {
+			t.Fatalf("Expected an ErrCorruptStore for %d bytes, got %v", size, err)
+		}
+	}
+	version := append([]byte(nil), good...)
+	version[len(compressedMagic)] = 2
+	if _, err := ReadCompressed(bytes.NewReader(version), nil); reflect.TypeOf(err).Name() != "ErrUnsupportedVersion" {
+		t.Fatalf("Expected an ErrUnsupportedVersion, got %v", err)
+	}
+	// The last byte is the value index of the last range
+	index := append([]byte(nil), good...)
+	index[len(index)-1] = 7
+	if _, err := ReadCompressed(bytes.NewReader(index), nil); reflect.TypeOf(err).Name() != "ErrCorruptStore" {
+		t.Fatalf("Expected an ErrCorruptStore, got %v", err)
+	}
+}
+
+func TestWriteCompressed_Size(t *testing.T) {
+	items := arenaItems(10000)
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	var buf bytes.Buffer
+	if err := WriteCompressed(&buf, n, IntCodec{}); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	d, err := NewCompressedDecoder(&buf, IntCodec{})
+	if err != nil {
+		t.Fatalf("Error while reading store: %s", err.Error())
+	}
+	if d.Ratio() < 2 {
+		t.Fatalf("Poor compression ratio: %f", d.Ratio())
+	}
+}
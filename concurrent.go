@@ -0,0 +1,72 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * concurrent.go: Building stores from ranges found by several goroutines
+ */
+
+package rangestore
+
+import (
+	"sync"
+)
+
+// Collects ranges from any number of goroutines and builds a RangeStore from
+// them, as Builder does. Ranges may be added in any order; they're sorted and
+// validated by Finish.
+type ConcurrentBuilder struct {
+	mu    sync.Mutex
+	opts  options
+	items []Ranged
+}
+
+// Creates a builder with the given options, see Builder
+func NewConcurrentBuilder(opts ...Option) *ConcurrentBuilder {
+	c := &ConcurrentBuilder{items: make([]Ranged, 0)}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+// Adds a range. Safe for concurrent use.
+func (c *ConcurrentBuilder) Add(r Ranged) {
+	c.mu.Lock()
+	c.items = append(c.items, r)
+	c.mu.Unlock()
+}
+
+// Returns the number of ranges added so far
+func (c *ConcurrentBuilder) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Builds a store from the ranges added so far, reporting errors as Builder.Build
+// does. Adds may carry on while the store is built; they're left out of it, but
+// included by later calls to Finish.
+func (c *ConcurrentBuilder) Finish() (*RangeStore, error) {
+	c.mu.Lock()
+	b := &Builder{opts: c.opts, items: append([]Ranged(nil), c.items...)}
+	c.mu.Unlock()
+	return b.Build()
+}
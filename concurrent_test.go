@@ -0,0 +1,76 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * concurrent_test.go: Tests on the concurrent builder
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentBuilder(t *testing.T) {
+	c := NewConcurrentBuilder(AllowGaps())
+	var wg sync.WaitGroup
+	for worker := uint64(0); worker < 8; worker += 1 {
+		wg.Add(1)
+		go func(worker uint64) {
+			defer wg.Done()
+			for idx := uint64(0); idx < 100; idx += 1 {
+				key := (idx*8 + worker) * 10
+				c.Add(DefaultRangedValue{key, key + 4, key})
+			}
+		}(worker)
+	}
+	wg.Wait()
+	if c.Len() != 800 {
+		t.Fatalf("Wrong number of ranges: %d", c.Len())
+	}
+	s, err := c.Finish()
+	if err != nil {
+		t.Fatalf("Error while building: %s", err.Error())
+	}
+	if err := s.CheckInvariants(); err != nil {
+		t.Fatalf("Error while checking the store: %s", err.Error())
+	}
+	for key := uint64(0); key < 8000; key += 10 {
+		if v, err := s.RangeSearch(key + 2); err != nil || v != key {
+			t.Fatalf("Wrong value for %d: %v", key+2, v)
+		}
+		if _, err := s.RangeSearch(key + 7); err == nil {
+			t.Fatalf("Expected a gap at %d", key+7)
+		}
+	}
+
+	// Later ranges are included by the next Finish
+	c.Add(DefaultRangedValue{8000, 8004, "last"})
+	if s, err = c.Finish(); err != nil || s.Len() != 801 {
+		t.Fatalf("Expected the later range to be included")
+	}
+	c.Add(DefaultRangedValue{8002, 8002, "overlap"})
+	if _, err := c.Finish(); reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expecting an ErrOverlap, but got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build rangestore_core
+// +build rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * core.go: Options for the lookup only core build
+ */
+
+package rangestore
+
+// Building with the rangestore_core tag leaves out everything but the tree
+// itself: NewRangeStoreFromSorted and its variants, NewRangeStoreFromWeighted,
+// RangeSearch, Ranges, String and the error types, none of which rely on
+// reflection, unsafe, memory mapping or assembly. This is the subset supported
+// under GOOS=js and TinyGo, e.g.
+//
+//	GOOS=js GOARCH=wasm go build -tags rangestore_core
+//	tinygo build -target wasm -tags rangestore_core
+//
+// The full build defines these in builder.go.
+
+// Configures how a store is built. Only the weight options are available in the
+// core build.
+type Option func(*options)
+
+type options struct {
+	dropZeroWeights bool
+	zeroBased       bool
+}
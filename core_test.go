@@ -0,0 +1,64 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * core_test.go: Tests on the subset available in the core build
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Only uses what the rangestore_core build provides, so that it runs under both
+// builds, e.g. go test -tags rangestore_core
+func TestCore_Lookup(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{30, 39, "C"})
+	n, err := NewSparseRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	for key, expected := range map[uint64]string{0: "A", 15: "B", 39: "C"} {
+		if v, err := n.RangeSearch(key); err != nil || v != expected {
+			t.Fatalf("Wrong value for %d: %v [%s]", key, v, expected)
+		}
+	}
+	if _, err := n.RangeSearch(25); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected ErrOutOfRange, got %v", err)
+	}
+	if len(n.Ranges()) != 3 {
+		t.Fatalf("Wrong number of ranges: %d", len(n.Ranges()))
+	}
+
+	weighted := make([]Weighted, 0)
+	weighted = append(weighted, DefaultWeightedValue{10, "A"})
+	weighted = append(weighted, DefaultWeightedValue{0, "X"})
+	weighted = append(weighted, DefaultWeightedValue{5, "B"})
+	w, err := NewRangeStoreFromWeighted(weighted, WithZeroBasedWeights(), WithDropZeroWeights())
+	if err != nil {
+		t.Fatalf("Error while building weighted store: %s", err.Error())
+	}
+	if v, err := w.RangeSearch(10); err != nil || v != "B" {
+		t.Fatalf("Wrong weighted value: %v", v)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * coverage.go: Coverage of the key space
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+type ErrUncovered struct {
+	min, max uint64
+}
+
+func (ex ErrUncovered) Error() string {
+	return fmt.Sprintf("Keys %d to %d aren't covered", ex.min, ex.max)
+}
+
+// Returns the first and last keys which aren't covered
+func (ex ErrUncovered) Range() (min, max uint64) {
+	return ex.min, ex.max
+}
+
+func (ex ErrUncovered) Details() ErrorDetails {
+	return ErrorDetails{"ErrUncovered", map[string]interface{}{"min": ex.min, "max": ex.max}}
+}
+
+// Returns the number of keys covered by the store. A store covering the entire
+// key space covers 2^64 keys, one more than fits, so the count saturates at
+// math.MaxUint64; use CoveredCountBig for the exact number.
+func (n *Node) CoveredCount() uint64 {
+	count := uint64(0)
+	n.walk(func(c *Node) {
+		width := (c.max - c.min) + 1
+		if width == 0 || count+width < count {
+			count = math.MaxUint64
+			return
+		}
+		count += width
+	})
+	return count
+}
+
+// Returns the exact number of keys covered by the store
+func (n *Node) CoveredCountBig() *big.Int {
+	count := new(big.Int)
+	one := big.NewInt(1)
+	n.walk(func(c *Node) {
+		count.Add(count, new(big.Int).SetUint64(c.max-c.min))
+		count.Add(count, one)
+	})
+	return count
+}
+
+// Returns the intervals between the bounds of the store which aren't covered by
+// any range, in ascending order, with nil values. A continuous store has none.
+func (n *Node) Gaps() []RangeEntry {
+	ret := make([]RangeEntry, 0)
+	var prev *Node
+	n.walk(func(c *Node) {
+		if prev != nil && c.min > prev.max+1 {
+			ret = append(ret, RangeEntry{prev.max + 1, c.min - 1, nil})
+		}
+		prev = c
+	})
+	return ret
+}
+
+// Verifies that the store covers exactly the keys from min to max, with no gaps,
+// e.g. at startup to fail fast on a truncated configuration. Returns an
+// ErrUncovered for the first run of keys in [min, max] which isn't covered, an
+// ErrOutOfRange for a covered key outside of it, ErrInvertedRange if min is
+// greater than max, and ErrEmptyStore for a nil store.
+func AssertFullCoverage(store *Node, min, max uint64) error {
+	if min > max {
+		return ErrInvertedRange{min, max}
+	}
+	if store == nil {
+		return ErrEmptyStore{}
+	}
+	lo, hi := store.Bounds()
+	if lo < min {
+		return ErrOutOfRange{lo}
+	}
+	if hi > max {
+		return ErrOutOfRange{hi}
+	}
+	next := min
+	var err error
+	store.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		if c.min > next {
+			err = ErrUncovered{next, c.min - 1}
+		}
+		next = c.max + 1
+	})
+	if err == nil && hi < max {
+		err = ErrUncovered{hi + 1, max}
+	}
+	return err
+}
+
+// Returns the fraction of the keys from min to max which the store covers, from
+// 0 to 1. Returns 0 if min is greater than max.
+func (n *Node) CoverageRatio(min, max uint64) float64 {
+	if min > max {
+		return 0
+	}
+	covered := 0.0
+	n.walkBetween(min, max, func(c *Node) {
+		lo, hi := c.min, c.max
+		if lo < min {
+			lo = min
+		}
+		if hi > max {
+			hi = max
+		}
+		covered += float64(hi-lo) + 1
+	})
+	return covered / (float64(max-min) + 1)
+}
@@ -0,0 +1,122 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * coverage_test.go: Tests on key space coverage
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNode_CoveredCount(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{30, 34, "B"})
+	items = append(items, DefaultRangedValue{100, 100, "C"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	if c := n.CoveredCount(); c != 16 {
+		t.Fatalf("Wrong covered count: %d", c)
+	}
+	if c := n.CoveredCountBig(); c.Uint64() != 16 {
+		t.Fatalf("Wrong exact covered count: %s", c)
+	}
+
+	full, _ := NewRangeStoreFromSorted([]Ranged{AtMost(1<<63-1, "low"), AtLeast(1<<63, "high")})
+	if c := full.CoveredCount(); c != math.MaxUint64 {
+		t.Fatalf("Covered count didn't saturate: %d", c)
+	}
+	if c := full.CoveredCountBig(); c.String() != "18446744073709551616" {
+		t.Fatalf("Wrong exact covered count: %s", c)
+	}
+}
+
+func TestNode_Gaps(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{20, 29, "B"})
+	items = append(items, DefaultRangedValue{35, 39, "C"})
+	items = append(items, DefaultRangedValue{41, 50, "D"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	expected := []RangeEntry{{30, 34, nil}, {40, 40, nil}}
+	if got := n.Gaps(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong gaps: %v", got)
+	}
+
+	n, _ = NewRangeStoreFromSorted(items[:2])
+	if got := n.Gaps(); len(got) != 0 {
+		t.Fatalf("Expected no gaps in a continuous store: %v", got)
+	}
+}
+
+func TestAssertFullCoverage(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{20, 29, "B"})
+	items = append(items, DefaultRangedValue{40, 49, "C"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+
+	if err := AssertFullCoverage(n, 10, 29); err == nil || reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected ErrOutOfRange for keys beyond the interval, got %v", err)
+	}
+	for _, test := range []struct {
+		min, max uint64
+		gap      [2]uint64
+	}{
+		{10, 49, [2]uint64{30, 39}},
+		{5, 49, [2]uint64{5, 9}},
+		{10, 60, [2]uint64{30, 39}},
+	} {
+		err := AssertFullCoverage(n, test.min, test.max)
+		gap, ok := err.(ErrUncovered)
+		if !ok {
+			t.Fatalf("Expected ErrUncovered for [%d, %d], got %v", test.min, test.max, err)
+		}
+		if min, max := gap.Range(); min != test.gap[0] || max != test.gap[1] {
+			t.Fatalf("Wrong gap for [%d, %d]: [%d, %d]", test.min, test.max, min, max)
+		}
+	}
+
+	full, _ := NewRangeStoreFromSorted(items[:2])
+	if err := AssertFullCoverage(full, 10, 29); err != nil {
+		t.Fatalf("Unexpected error for a fully covered interval: %s", err.Error())
+	}
+	if err := AssertFullCoverage(full, 10, 35); err == nil || err.Error() != "Keys 30 to 35 aren't covered" {
+		t.Fatalf("Expected the trailing keys to be reported, got %v", err)
+	}
+	if err := AssertFullCoverage(nil, 0, 10); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+		t.Fatalf("Expected ErrEmptyStore, got %v", err)
+	}
+
+	for interval, expected := range map[[2]uint64]float64{{10, 49}: 0.75, {0, 99}: 0.3, {25, 44}: 0.5, {30, 39}: 0} {
+		if got := n.CoverageRatio(interval[0], interval[1]); got != expected {
+			t.Fatalf("Wrong coverage ratio for %v: %f [%f]", interval, got, expected)
+		}
+	}
+	everything, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, math.MaxUint64, "A"}})
+	if got := everything.CoverageRatio(0, math.MaxUint64); got != 1 {
+		t.Fatalf("Wrong coverage ratio for the entire key space: %f", got)
+	}
+}
@@ -0,0 +1,270 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * csv.go: Loading range definitions from CSV
+ */
+
+package rangestore
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Configures LoadCSV. The zero value reads comma separated min,max,value rows.
+type CSVOptions struct {
+	// The field separator, ',' if left at zero. Use '\t' for TSV.
+	Comma rune
+	// Lines starting with this character are skipped, if set
+	Comment rune
+	// Skips the first row
+	Header bool
+
+	// Reads the keys from a single CIDR column, e.g. 10.0.0.0/8, instead of
+	// separate min and max columns. Only IPv4 networks are supported, with the
+	// address as the key.
+	CIDR bool
+	// The zero based columns holding each field. If they're all left at zero,
+	// the columns are min, max, value, or cidr, value when CIDR is set.
+	MinColumn, MaxColumn, CIDRColumn, ValueColumn int
+
+	// Converts the value field, which is kept as a string if this is nil
+	ParseValue func(field string) (interface{}, error)
+
+	// Sorts the rows by their minimum, rather than requiring them in order
+	Sort bool
+	// Permits gaps between ranges, producing a sparse store
+	AllowGaps bool
+}
+
+type ErrMalformedRecord struct {
+	record int
+	reason string
+}
+
+func (ex ErrMalformedRecord) Error() string {
+	return fmt.Sprintf("Record %d: %s", ex.record, ex.reason)
+}
+
+// Returns the number of the record, counting from 1
+func (ex ErrMalformedRecord) Record() int {
+	return ex.record
+}
+
+// Returns why the record was rejected
+func (ex ErrMalformedRecord) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrMalformedRecord) Details() ErrorDetails {
+	return ErrorDetails{"ErrMalformedRecord", map[string]interface{}{"record": ex.record, "reason": ex.reason}}
+}
+
+type ErrMalformedCSV struct {
+	errs []error
+}
+
+func (ex ErrMalformedCSV) Error() string {
+	return fmt.Sprintf("%d malformed records, the first being: %s", len(ex.errs), ex.errs[0].Error())
+}
+
+// Returns the errors for each of the malformed records
+func (ex ErrMalformedCSV) Errors() []error {
+	return ex.errs
+}
+
+func (ex ErrMalformedCSV) Details() ErrorDetails {
+	return ErrorDetails{"ErrMalformedCSV", map[string]interface{}{"errors": detailsOf(ex.errs)}}
+}
+
+// Builds a store from CSV rows of the form min,max,value, or cidr,value, as
+// configured by opts. Keys may be decimal, or hexadecimal with a 0x prefix. Rather
+// than stopping at the first bad row, every malformed record is reported in a
+// single ErrMalformedCSV. Once the rows have been read, the ranges are checked the
+// same way as NewRangeStoreFromSorted, or NewSparseRangeStoreFromSorted.
+func LoadCSV(r io.Reader, opts CSVOptions) (*Node, error) {
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	reader.Comment = opts.Comment
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if opts.MinColumn == 0 && opts.MaxColumn == 0 && opts.CIDRColumn == 0 && opts.ValueColumn == 0 {
+		if opts.CIDR {
+			opts.ValueColumn = 1
+		} else {
+			opts.MaxColumn, opts.ValueColumn = 1, 2
+		}
+	}
+
+	items := make([]Ranged, 0)
+	errs := make([]error, 0)
+	for record := 1; ; record += 1 {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, err
+			}
+			errs = append(errs, ErrMalformedRecord{record, err.Error()})
+			continue
+		}
+		if record == 1 && opts.Header {
+			continue
+		}
+		item, err := parseCSVRow(row, opts)
+		if err != nil {
+			errs = append(errs, ErrMalformedRecord{record, err.Error()})
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(errs) > 0 {
+		return nil, ErrMalformedCSV{errs}
+	}
+
+	if opts.Sort {
+		SortRanged(items)
+	}
+	return rangeStoreFromSortedChecked(items, true, opts.AllowGaps)
+}
+
+func parseCSVRow(row []string, opts CSVOptions) (Ranged, error) {
+	field := func(col int) (string, error) {
+		if col < 0 || col >= len(row) {
+			return "", fmt.Errorf("missing column %d", col)
+		}
+		return strings.TrimSpace(row[col]), nil
+	}
+
+	var e RangeEntry
+	if opts.CIDR {
+		f, err := field(opts.CIDRColumn)
+		if err != nil {
+			return nil, err
+		}
+		if e.Min, e.Max, err = parseCIDR(f); err != nil {
+			return nil, err
+		}
+	} else {
+		f, err := field(opts.MinColumn)
+		if err != nil {
+			return nil, err
+		}
+		if e.Min, err = strconv.ParseUint(f, 0, 64); err != nil {
+			return nil, fmt.Errorf("bad minimum %q", f)
+		}
+		if f, err = field(opts.MaxColumn); err != nil {
+			return nil, err
+		}
+		if e.Max, err = strconv.ParseUint(f, 0, 64); err != nil {
+			return nil, fmt.Errorf("bad maximum %q", f)
+		}
+		if e.Min > e.Max {
+			return nil, ErrInvertedRange{e.Min, e.Max}
+		}
+	}
+
+	f, err := field(opts.ValueColumn)
+	if err != nil {
+		return nil, err
+	}
+	e.Value = f
+	if opts.ParseValue != nil {
+		if e.Value, err = opts.ParseValue(f); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Returns the first and last IPv4 addresses of the network as keys
+func parseCIDR(s string) (min, max uint64, err error) {
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad network %q", s)
+	}
+	ip := network.IP.To4()
+	if ip == nil {
+		return 0, 0, fmt.Errorf("network %q isn't IPv4", s)
+	}
+	ones, _ := network.Mask.Size()
+	min = uint64(ip[0])<<24 | uint64(ip[1])<<16 | uint64(ip[2])<<8 | uint64(ip[3])
+	return min, min + (1 << uint(32-ones)) - 1, nil
+}
+
+// Reads CSV with a header row, calling fn for each of the other rows with a
+// function returning the fields by column name. Missing columns give an
+// ErrMalformedRecord; errors returned by fn and rows which can't be parsed are
+// returned as ErrMalformedRecords, so that they can be reported together.
+func readNamedCSV(r io.Reader, required []string, fn func(field func(name string) string) error) ([]error, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, ErrMalformedRecord{1, fmt.Sprintf("missing column %q", name)}
+		}
+	}
+
+	errs := make([]error, 0)
+	for record := 2; ; record += 1 {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return errs, nil
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, err
+			}
+			errs = append(errs, ErrMalformedRecord{record, err.Error()})
+			continue
+		}
+		field := func(name string) string {
+			if col, ok := columns[name]; ok && col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		if err := fn(field); err != nil {
+			errs = append(errs, ErrMalformedRecord{record, err.Error()})
+		}
+	}
+}
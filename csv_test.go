@@ -0,0 +1,114 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * csv_test.go: Tests on the CSV loader
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoadCSV_Basic(t *testing.T) {
+	input := "min,max,value\n0,9,A\n10, 0x13 ,B\n# skipped\n20,29,C\n"
+	n, err := LoadCSV(strings.NewReader(input), CSVOptions{Header: true, Comment: '#'})
+	if err != nil {
+		t.Fatalf("Error while loading CSV: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 9, "A"}, {10, 19, "B"}, {20, 29, "C"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
+
+func TestLoadCSV_Options(t *testing.T) {
+	input := "20\t1\t29\n0\t2\t19\n40\t3\t49\n"
+	opts := CSVOptions{
+		Comma:       '\t',
+		MinColumn:   0,
+		MaxColumn:   2,
+		ValueColumn: 1,
+		ParseValue: func(field string) (interface{}, error) {
+			return strconv.Atoi(field)
+		},
+		Sort: true,
+	}
+	_, err := LoadCSV(strings.NewReader(input), opts)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDiscontinuity{}).Name() {
+		t.Fatalf("Expecting an ErrDiscontinuity, but got something else")
+	}
+
+	opts.AllowGaps = true
+	n, err := LoadCSV(strings.NewReader(input), opts)
+	if err != nil {
+		t.Fatalf("Error while loading CSV: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 19, 2}, {20, 29, 1}, {40, 49, 3}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
+
+func TestLoadCSV_CIDR(t *testing.T) {
+	input := "10.0.0.0/8,private\n11.0.0.0/24,public\n11.0.1.0/32,host\n"
+	n, err := LoadCSV(strings.NewReader(input), CSVOptions{CIDR: true, AllowGaps: true})
+	if err != nil {
+		t.Fatalf("Error while loading CSV: %s", err.Error())
+	}
+	expected := []RangeEntry{{0x0a000000, 0x0affffff, "private"}, {0x0b000000, 0x0b0000ff, "public"}, {0x0b000100, 0x0b000100, "host"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
+
+func TestLoadCSV_Malformed(t *testing.T) {
+	input := "0,9,A\nten,19,B\n20,29\n30,39,D\n40,35,E\n"
+	_, err := LoadCSV(strings.NewReader(input), CSVOptions{})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrMalformedCSV{}).Name() {
+		t.Fatalf("Expecting an ErrMalformedCSV, but got something else")
+	}
+	errs := err.(ErrMalformedCSV).Errors()
+	if len(errs) != 3 {
+		t.Fatalf("Wrong number of malformed records: %d", len(errs))
+	}
+	for idx, expected := range []string{
+		`Record 2: bad minimum "ten"`,
+		"Record 3: missing column 2",
+		"Record 5: Range minimum 40 is greater than its maximum 35",
+	} {
+		if msg := errs[idx].Error(); msg != expected {
+			t.Fatalf("Wrong error message: %s", msg)
+		}
+	}
+	if msg := err.Error(); msg != `3 malformed records, the first being: Record 2: bad minimum "ten"` {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+
+	_, err = LoadCSV(strings.NewReader("::1/128,A\n"), CSVOptions{CIDR: true})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrMalformedCSV{}).Name() {
+		t.Fatalf("Expecting an ErrMalformedCSV, but got something else")
+	}
+}
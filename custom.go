@@ -0,0 +1,144 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * custom.go: Stores keyed by any totally ordered type
+ */
+
+package rangestore
+
+import (
+	"fmt"
+)
+
+// A range of keys of any type, ordered by a user supplied comparator
+type CustomRanged interface {
+	GetMinKey() interface{}
+	GetMaxKey() interface{}
+	GetValue() interface{}
+}
+
+// A range of keys of any type. Implements CustomRanged.
+type CustomRangeEntry struct {
+	Min, Max interface{}
+	Value    interface{}
+}
+
+func (r CustomRangeEntry) GetMinKey() interface{} {
+	return r.Min
+}
+func (r CustomRangeEntry) GetMaxKey() interface{} {
+	return r.Max
+}
+func (r CustomRangeEntry) GetValue() interface{} {
+	return r.Value
+}
+
+// Orders keys, returning a negative number if a < b, 0 if a == b and a positive
+// number if a > b. Must be a total order over the keys of the store.
+type Comparator func(a, b interface{}) int
+
+// Returns the key immediately following k, or false if k is the greatest key
+type Successor func(k interface{}) (interface{}, bool)
+
+// A node of a store keyed by any totally ordered type. Built by
+// NewRangeStoreFunc.
+type CustomNode struct {
+	min, max    interface{}
+	value       interface{}
+	left, right *CustomNode
+	cmp         Comparator
+}
+
+// Builds a store from items whose bounds are inclusive and ordered by cmp, for
+// key types other than uint64, such as dates, version numbers or composite keys.
+// The items must be sorted and not overlap. If succ is given, the items must also
+// be continuous, each one starting at the successor of the maximum of the one
+// before it; a nil succ permits gaps. Since the keys are opaque, the tree is
+// balanced by the number of ranges rather than their width.
+func NewRangeStoreFunc(items []CustomRanged, cmp Comparator, succ Successor) (*CustomNode, error) {
+	if len(items) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	nodes := make([]CustomNode, len(items))
+	for idx, item := range items {
+		min, max := item.GetMinKey(), item.GetMaxKey()
+		if cmp(min, max) > 0 {
+			return nil, ErrInvalidKeyRange{idx, fmt.Sprintf("minimum %v is greater than maximum %v", min, max)}
+		}
+		if idx > 0 {
+			prev := nodes[idx-1].max
+			if cmp(min, prev) <= 0 {
+				return nil, ErrInvalidKeyRange{idx, fmt.Sprintf("overlap detected between %v -> %v", prev, min)}
+			}
+			if succ != nil {
+				next, ok := succ(prev)
+				if !ok || cmp(next, min) != 0 {
+					return nil, ErrInvalidKeyRange{idx, fmt.Sprintf("discontinuity detected from %v -> %v", prev, min)}
+				}
+			}
+		}
+		nodes[idx] = CustomNode{min: min, max: max, value: item.GetValue(), cmp: cmp}
+	}
+	return linkCustomNodes(nodes), nil
+}
+
+func linkCustomNodes(nodes []CustomNode) *CustomNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	n := &nodes[mid]
+	n.left = linkCustomNodes(nodes[:mid])
+	n.right = linkCustomNodes(nodes[mid+1:])
+	return n
+}
+
+// Searches for the range which contains the key and returns the associated
+// value, or ErrKeyOutOfRange if there is none
+func (n *CustomNode) RangeSearch(key interface{}) (interface{}, error) {
+	for c := n; c != nil; {
+		if c.cmp(key, c.min) < 0 {
+			c = c.left
+		} else if c.cmp(key, c.max) > 0 {
+			c = c.right
+		} else {
+			return c.value, nil
+		}
+	}
+	return nil, ErrKeyOutOfRange{key}
+}
+
+// Returns the ranges in ascending order
+func (n *CustomNode) Ranges() []CustomRangeEntry {
+	ret := make([]CustomRangeEntry, 0)
+	var walk func(c *CustomNode)
+	walk = func(c *CustomNode) {
+		if c == nil {
+			return
+		}
+		walk(c.left)
+		ret = append(ret, CustomRangeEntry{c.min, c.max, c.value})
+		walk(c.right)
+	}
+	walk(n)
+	return ret
+}
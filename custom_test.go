@@ -0,0 +1,95 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * custom_test.go: Tests on stores with custom key types
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func compareDates(a, b interface{}) int {
+	x, y := a.(time.Time), b.(time.Time)
+	switch {
+	case x.Before(y):
+		return -1
+	case x.After(y):
+		return 1
+	}
+	return 0
+}
+
+func nextDate(k interface{}) (interface{}, bool) {
+	return k.(time.Time).AddDate(0, 0, 1), true
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRangeStoreFunc(t *testing.T) {
+	items := make([]CustomRanged, 0)
+	items = append(items, CustomRangeEntry{date(2018, 1, 1), date(2018, 3, 31), "Q1"})
+	items = append(items, CustomRangeEntry{date(2018, 4, 1), date(2018, 6, 30), "Q2"})
+	items = append(items, CustomRangeEntry{date(2018, 7, 1), date(2018, 9, 30), "Q3"})
+	n, err := NewRangeStoreFunc(items, compareDates, nextDate)
+	if err != nil {
+		t.Fatalf("Error while constructing custom store: %s", err.Error())
+	}
+	if v, err := n.RangeSearch(date(2018, 5, 17)); err != nil || v != "Q2" {
+		t.Fatalf("Wrong value: %v", v)
+	}
+	if v, err := n.RangeSearch(date(2018, 9, 30)); err != nil || v != "Q3" {
+		t.Fatalf("Wrong value for the inclusive maximum: %v", v)
+	}
+	_, err = n.RangeSearch(date(2018, 10, 1))
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrKeyOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrKeyOutOfRange, but got something else")
+	}
+	if got := n.Ranges(); len(got) != 3 || got[0].Value != "Q1" || got[2].Value != "Q3" {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
+
+func TestRangeStoreFunc_Invalid(t *testing.T) {
+	items := make([]CustomRanged, 0)
+	items = append(items, CustomRangeEntry{date(2018, 1, 1), date(2018, 3, 31), "Q1"})
+	items = append(items, CustomRangeEntry{date(2018, 7, 1), date(2018, 9, 30), "Q3"})
+	_, err := NewRangeStoreFunc(items, compareDates, nextDate)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvalidKeyRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvalidKeyRange, but got something else")
+	}
+	// Without a successor, gaps are fine
+	if _, err := NewRangeStoreFunc(items, compareDates, nil); err != nil {
+		t.Fatalf("Error while constructing sparse custom store: %s", err.Error())
+	}
+
+	items = append(items, CustomRangeEntry{date(2018, 9, 1), date(2018, 12, 31), "Q4"})
+	_, err = NewRangeStoreFunc(items, compareDates, nil)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvalidKeyRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvalidKeyRange for the overlap, but got something else")
+	}
+}
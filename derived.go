@@ -0,0 +1,110 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * derived.go: Stores rebuilt from other stores whenever they change
+ */
+
+package rangestore
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Builds a derived store from the current stores of its sources, in the order
+// the sources were given
+type DeriveFunc func(sources []*RangeStore) (*RangeStore, error)
+
+// A store derived from other swappable stores, such as a merge of two tables,
+// which is rebuilt and swapped in whenever one of its sources is swapped, so it
+// never goes stale when a source is hot reloaded. Being a SwappableStore itself,
+// it can serve as the source of further derived stores.
+//
+// The version of each derived store records when it was built, and the
+// generation of every source it was built from in Meta, as "source0",
+// "source1" and so on.
+//
+// DerivedStore is safe for concurrent use.
+type DerivedStore struct {
+	*SwappableStore
+	mu      sync.Mutex
+	sources []*SwappableStore
+	derive  DeriveFunc
+	err     error
+}
+
+// Builds the derived store from the current stores of the sources, and keeps
+// rebuilding it whenever any of them is swapped. Returns the error of derive if
+// the first build fails. The sources keep a reference to the derived store, so
+// it stays alive, and keeps being rebuilt, as long as they do.
+func NewDerivedStore(derive DeriveFunc, sources ...*SwappableStore) (*DerivedStore, error) {
+	d := &DerivedStore{sources: sources, derive: derive}
+	s, v, err := d.build()
+	if err != nil {
+		return nil, err
+	}
+	d.SwappableStore = NewVersionedSwappableStore(s, v)
+	for _, src := range sources {
+		src.OnSwap(func(*RangeStore, StoreVersion) {
+			d.Rebuild()
+		})
+	}
+	return d, nil
+}
+
+// Builds a store from the current stores of the sources
+func (d *DerivedStore) build() (*RangeStore, StoreVersion, error) {
+	stores := make([]*RangeStore, 0, len(d.sources))
+	v := StoreVersion{Source: "derived", BuiltAt: time.Now(), Meta: make(map[string]string)}
+	for idx, src := range d.sources {
+		st := src.state()
+		stores = append(stores, st.store)
+		v.Meta["source"+strconv.Itoa(idx)] = strconv.FormatUint(st.version.Generation, 10)
+	}
+	s, err := d.derive(stores)
+	return s, v, err
+}
+
+// Rebuilds the derived store from the current stores of the sources and swaps
+// it in. This happens by itself whenever a source is swapped. If the rebuild
+// fails, the previous store keeps being served, and the error is returned and
+// kept for Err.
+func (d *DerivedStore) Rebuild() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, v, err := d.build()
+	d.err = err
+	if err != nil {
+		return err
+	}
+	d.SwapVersioned(s, v)
+	return nil
+}
+
+// Returns the error of the latest rebuild, or nil if it succeeded, in which case
+// the derived store is up to date with its sources
+func (d *DerivedStore) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
@@ -0,0 +1,94 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * derived_test.go: Tests for derived stores
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDerivedStore(t *testing.T) {
+	a, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "A"}})
+	b, _ := NewRangeStore([]Ranged{DefaultRangedValue{20, 29, "B"}})
+	srcA, srcB := NewSwappableStore(a), NewSwappableStore(b)
+
+	merge := func(sources []*RangeStore) (*RangeStore, error) {
+		n, err := Merge(sources[0].Root(), sources[1].Root(), nil)
+		if err != nil {
+			return nil, err
+		}
+		return WrapNode(n), nil
+	}
+	d, err := NewDerivedStore(merge, srcA, srcB)
+	if err != nil {
+		t.Fatalf("Error while deriving store: %s", err.Error())
+	}
+	if v, err := d.RangeSearch(25); err != nil || v != "B" {
+		t.Fatalf("Wrong value from the derived store")
+	}
+
+	// Swapping a source rebuilds the derived store
+	a2, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 14, "A2"}})
+	srcA.Swap(a2)
+	if v, err := d.RangeSearch(12); err != nil || v != "A2" {
+		t.Fatalf("Expected the derived store to be rebuilt, got %v", v)
+	}
+	if v := d.Version(); v.Generation != 1 || !reflect.DeepEqual(v.Meta, map[string]string{"source0": "1", "source1": "0"}) {
+		t.Fatalf("Wrong version of the derived store: %+v", v)
+	}
+
+	// Stores derived from the derived store are rebuilt as well
+	count := func(sources []*RangeStore) (*RangeStore, error) {
+		return NewRangeStore([]Ranged{DefaultRangedValue{0, 0, sources[0].Len()}})
+	}
+	c, err := NewDerivedStore(count, d.SwappableStore)
+	if err != nil {
+		t.Fatalf("Error while deriving store: %s", err.Error())
+	}
+	b2, _ := NewRangeStore([]Ranged{DefaultRangedValue{20, 29, "B"}, DefaultRangedValue{30, 39, "C"}})
+	srcB.Swap(b2)
+	if v, _ := c.RangeSearch(0); v != 3 {
+		t.Fatalf("Expected the chained store to count 3 ranges, got %v", v)
+	}
+
+	// A failed rebuild keeps serving the previous store
+	overlapping, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 25, "X"}})
+	srcA.Swap(overlapping)
+	if err := d.Err(); err == nil || reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected ErrOverlap from the rebuild, got %v", err)
+	}
+	if v, err := d.RangeSearch(12); err != nil || v != "A2" {
+		t.Fatalf("Expected the previous derived store to be served")
+	}
+	srcA.Swap(a)
+	if d.Err() != nil {
+		t.Fatalf("Expected the error to clear after a successful rebuild")
+	}
+
+	if _, err := NewDerivedStore(merge, NewSwappableStore(overlapping), srcB); err == nil {
+		t.Fatalf("Expected an error when the first build fails")
+	}
+}
@@ -0,0 +1,136 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * diff.go: Differences between two stores
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Describes how an interval of keys changed between two stores
+type ChangeKind int
+
+const (
+	// The keys weren't covered by the old store
+	Added ChangeKind = iota
+	// The keys aren't covered by the new store
+	Removed
+	// The keys are covered by both stores, but map to different values
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// An interval of keys whose value differs between two stores. Old is nil for
+// added intervals, and New is nil for removed ones.
+type ChangedRange struct {
+	Min, Max uint64
+	Kind     ChangeKind
+	Old, New interface{}
+}
+
+// Lists the intervals of keys whose mapped value differs between the old and
+// the new store, in ascending key order. Values are compared using
+// reflect.DeepEqual.
+func Diff(old, newer *Node) []ChangedRange {
+	return DiffFunc(old, newer, reflect.DeepEqual)
+}
+
+// Same as Diff, but compares values using valueEq
+func DiffFunc(old, newer *Node, valueEq func(a, b interface{}) bool) []ChangedRange {
+	ret := make([]ChangedRange, 0)
+	overlay(old.Ranges(), newer.Ranges(), func(min, max uint64, x, y *RangeEntry) {
+		switch {
+		case x == nil:
+			ret = append(ret, ChangedRange{min, max, Added, nil, y.Value})
+		case y == nil:
+			ret = append(ret, ChangedRange{min, max, Removed, x.Value, nil})
+		case !valueEq(x.Value, y.Value):
+			ret = append(ret, ChangedRange{min, max, Modified, x.Value, y.Value})
+		}
+	})
+	return ret
+}
+
+// Writes the changes as a report resembling a unified diff, for reload logs and
+// change reviews. Each interval gets a header with its bounds, its kind and the
+// number of keys it spans, followed by its old value prefixed with "-" and its
+// new value prefixed with "+":
+//
+//	@@ [10, 19] modified, 10 keys @@
+//	- 10.0.0.1
+//	+ 10.0.0.2
+//	@@ [20, 29] added, 10 keys @@
+//	+ 10.0.0.3
+//
+// Values are formatted by valueFmt, or with %v if it's nil; values spanning
+// several lines get the prefix on each of them. Nothing is written if there are
+// no changes.
+func FormatDiff(changes []ChangedRange, w io.Writer, valueFmt func(interface{}) string) error {
+	if valueFmt == nil {
+		valueFmt = func(v interface{}) string {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	buf := new(bytes.Buffer)
+	for _, c := range changes {
+		keys := "all 2^64 keys"
+		if width := c.Max - c.Min + 1; width == 1 {
+			keys = "1 key"
+		} else if width != 0 {
+			keys = fmt.Sprintf("%d keys", width)
+		}
+		fmt.Fprintf(buf, "@@ [%d, %d] %s, %s @@\n", c.Min, c.Max, c.Kind, keys)
+		if c.Kind != Added {
+			writePrefixed(buf, "- ", valueFmt(c.Old))
+		}
+		if c.Kind != Removed {
+			writePrefixed(buf, "+ ", valueFmt(c.New))
+		}
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writePrefixed(buf *bytes.Buffer, prefix, s string) {
+	for _, line := range strings.Split(s, "\n") {
+		buf.WriteString(prefix)
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+}
@@ -0,0 +1,98 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * diff_test.go: Tests on differences between stores
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 9, "A"},
+		DefaultRangedValue{10, 19, "B"},
+		DefaultRangedValue{20, 29, "C"},
+	})
+	newer, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 14, "A"},
+		DefaultRangedValue{15, 19, "B"},
+		DefaultRangedValue{30, 39, "D"},
+	})
+
+	expected := []ChangedRange{
+		{10, 14, Modified, "B", "A"},
+		{20, 29, Removed, "C", nil},
+		{30, 39, Added, nil, "D"},
+	}
+	if got := Diff(old, newer); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong changes: %v", got)
+	}
+	if got := Diff(old, old); len(got) != 0 {
+		t.Fatalf("Expected no changes between a store and itself, got %v", got)
+	}
+}
+
+func TestDiffFunc(t *testing.T) {
+	old, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "a"}})
+	newer, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "A"}})
+
+	if got := DiffFunc(old, newer, func(a, b interface{}) bool {
+		return strings.EqualFold(a.(string), b.(string))
+	}); len(got) != 0 {
+		t.Fatalf("Expected the value comparison function to be used, got %v", got)
+	}
+	if got := Diff(old, newer); len(got) != 1 || got[0].Kind.String() != "modified" {
+		t.Fatalf("Expected a single modification, got %v", got)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	changes := []ChangedRange{
+		{10, 14, Modified, "B", "A"},
+		{20, 20, Removed, "C", nil},
+		{30, 39, Added, nil, "D\nE"},
+	}
+	buf := new(bytes.Buffer)
+	if err := FormatDiff(changes, buf, nil); err != nil {
+		t.Fatalf("Error while formatting: %s", err.Error())
+	}
+	expected := "@@ [10, 14] modified, 5 keys @@\n- B\n+ A\n" +
+		"@@ [20, 20] removed, 1 key @@\n- C\n" +
+		"@@ [30, 39] added, 10 keys @@\n+ D\n+ E\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Wrong report:\n%s", got)
+	}
+
+	buf.Reset()
+	FormatDiff(changes[:1], buf, func(v interface{}) string {
+		return strings.ToLower(v.(string))
+	})
+	if got := buf.String(); got != "@@ [10, 14] modified, 5 keys @@\n- b\n+ a\n" {
+		t.Fatalf("Expected the value formatter to be used, got:\n%s", got)
+	}
+}
@@ -0,0 +1,135 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * differential.go: Checking stores against a reference model
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type ErrDivergence struct {
+	reason string
+}
+
+func (ex ErrDivergence) Error() string {
+	return fmt.Sprintf("Divergence from the reference: %s", ex.reason)
+}
+
+// Returns how the stores diverged
+func (ex ErrDivergence) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrDivergence) Details() ErrorDetails {
+	return ErrorDetails{"ErrDivergence", map[string]interface{}{"reason": ex.reason}}
+}
+
+// The ways of building a store which DifferentialCheck compares
+var differentialVariants = []struct {
+	name string
+	opts []Option
+}{
+	{"tree", nil},
+	{"flat", []Option{WithBackend(FlatBackend)}},
+	{"lookup table", []Option{WithBackend(LookupTableBackend)}},
+	{"directory", []Option{WithDirectoryBuckets(4)}},
+	{"arena", []Option{WithArena()}},
+	{"miss filter", []Option{WithMissFilter()}},
+	{"lookup cache", []Option{WithLookupCache(16)}},
+}
+
+// Builds stores from the items in every supported way, with gaps allowed, and
+// compares them against a trivially correct reference which scans the sorted
+// items. The reference decides whether the items are valid; the stores must
+// reject exactly the items it rejects. For valid items, every store must pass
+// CheckInvariants and agree with the reference on the value of each of the keys,
+// as compared with reflect.DeepEqual, and on which keys are out of range. The
+// bounds of every item, and the keys just outside of them, are checked as well.
+//
+// Returns an ErrDivergence describing the first disagreement, or nil. Meant to
+// be called from fuzz targets, and to vet changes to the backends.
+func DifferentialCheck(items []Ranged, keys []uint64) error {
+	ref := append([]Ranged(nil), items...)
+	sort.SliceStable(ref, func(i, j int) bool {
+		if ref[i].GetMin() != ref[j].GetMin() {
+			return ref[i].GetMin() < ref[j].GetMin()
+		}
+		return ref[i].GetMax() < ref[j].GetMax()
+	})
+	valid := len(ref) > 0
+	for idx, item := range ref {
+		if item.GetMin() > item.GetMax() || (idx > 0 && item.GetMin() <= ref[idx-1].GetMax()) {
+			valid = false
+		}
+	}
+
+	keys = append([]uint64(nil), keys...)
+	for _, item := range ref {
+		keys = append(keys, item.GetMin()-1, item.GetMin(), item.GetMax(), item.GetMax()+1)
+	}
+
+	for _, variant := range differentialVariants {
+		s, err := NewRangeStore(items, append([]Option{AllowGaps()}, variant.opts...)...)
+		if !valid {
+			if err == nil {
+				return ErrDivergence{fmt.Sprintf("the %s store accepted items the reference rejects", variant.name)}
+			}
+			continue
+		}
+		if err != nil {
+			return ErrDivergence{fmt.Sprintf("the %s store rejected items the reference accepts: %s", variant.name, err.Error())}
+		}
+		if err := s.CheckInvariants(); err != nil {
+			return ErrDivergence{fmt.Sprintf("the %s store is invalid: %s", variant.name, err.Error())}
+		}
+		for _, key := range keys {
+			expected, found := referenceSearch(ref, key)
+			v, err := s.RangeSearch(key)
+			if !found {
+				if _, miss := err.(ErrOutOfRange); !miss {
+					return ErrDivergence{fmt.Sprintf("the %s store gave %v, %v for key %d, which no item covers", variant.name, v, err, key)}
+				}
+				continue
+			}
+			if err != nil || !reflect.DeepEqual(v, expected.GetValue()) {
+				return ErrDivergence{fmt.Sprintf("the %s store gave %v, %v for key %d, rather than %v from [%d, %d]",
+					variant.name, v, err, key, expected.GetValue(), expected.GetMin(), expected.GetMax())}
+			}
+		}
+	}
+	return nil
+}
+
+// Finds the item covering the key by scanning all of them
+func referenceSearch(items []Ranged, key uint64) (Ranged, bool) {
+	for _, item := range items {
+		if item.GetMin() <= key && key <= item.GetMax() {
+			return item, true
+		}
+	}
+	return nil, false
+}
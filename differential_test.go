@@ -0,0 +1,62 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * differential_test.go: Tests for the differential checker
+ */
+
+package rangestore
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDifferentialCheck(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i += 1 {
+		// Random ranges, some of which overlap, in random order
+		items := make([]Ranged, 0)
+		for j := r.Intn(20); j > 0; j -= 1 {
+			min := uint64(r.Intn(1000))
+			items = append(items, DefaultRangedValue{min, min + uint64(r.Intn(30)), j})
+		}
+		keys := make([]uint64, 0)
+		for j := 0; j < 50; j += 1 {
+			keys = append(keys, uint64(r.Intn(1100)))
+		}
+		if err := DifferentialCheck(items, keys); err != nil {
+			t.Fatalf("Unexpected divergence for %v: %s", items, err.Error())
+		}
+	}
+
+	edges := []Ranged{DefaultRangedValue{0, 0, "A"}, DefaultRangedValue{math.MaxUint64, math.MaxUint64, "B"}}
+	if err := DifferentialCheck(edges, []uint64{1, math.MaxUint64 - 1}); err != nil {
+		t.Fatalf("Unexpected divergence at the edges of the key space: %s", err.Error())
+	}
+	if err := DifferentialCheck([]Ranged{DefaultRangedValue{0, math.MaxUint64, "A"}}, nil); err != nil {
+		t.Fatalf("Unexpected divergence for the entire key space: %s", err.Error())
+	}
+	if err := DifferentialCheck(nil, []uint64{0}); err != nil {
+		t.Fatalf("Unexpected divergence for no items: %s", err.Error())
+	}
+}
@@ -0,0 +1,86 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * directory.go: Two level lookups for very large stores
+ */
+
+package rangestore
+
+import (
+	"sort"
+)
+
+const (
+	// Stores with at least this many ranges are given a directory automatically,
+	// unless a backend other than TreeBackend was chosen
+	DirectoryThreshold = 10000000
+	// The number of buckets given to stores which get a directory automatically.
+	// The bucket boundaries take 32KiB, which stays in L1 or L2.
+	DefaultDirectoryBuckets = 4096
+)
+
+// Splits the ranges of a store into buckets holding about the same number of
+// ranges each, with a separate tree per bucket. A lookup first finds its bucket
+// by binary searching the bucket boundaries, which are small enough to stay in
+// cache, and then only descends the much shallower tree of that bucket.
+type directory struct {
+	starts  []uint64
+	buckets []*Node
+}
+
+// Puts a directory of n buckets in front of the tree. See DirectoryThreshold
+// for when this happens without asking; WithDirectoryBuckets(1) prevents it.
+func WithDirectoryBuckets(n int) Option {
+	return func(o *options) {
+		o.dirBuckets = n
+	}
+}
+
+func newDirectory(n *Node, count int) *directory {
+	items := nodeRanged(n)
+	if count > len(items) {
+		count = len(items)
+	}
+	d := &directory{
+		starts:  make([]uint64, 0, count),
+		buckets: make([]*Node, 0, count),
+	}
+	for b := 0; b < count; b += 1 {
+		lo, hi := b*len(items)/count, (b+1)*len(items)/count
+		// The ranges came from a valid store, so there's no need to check them again
+		bucket, _ := rangeStoreFromSortedChecked(items[lo:hi], false, true)
+		d.starts = append(d.starts, items[lo].GetMin())
+		d.buckets = append(d.buckets, bucket)
+	}
+	return d
+}
+
+// Searches the bucket whose ranges could contain the key
+func (d *directory) RangeSearch(val uint64) (interface{}, error) {
+	idx := sort.Search(len(d.starts), func(i int) bool {
+		return d.starts[i] > val
+	})
+	if idx == 0 {
+		return nil, ErrOutOfRange{val}
+	}
+	return d.buckets[idx-1].RangeSearch(val)
+}
@@ -0,0 +1,96 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * directory_test.go: Tests on directories
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeStore_Directory(t *testing.T) {
+	items := make([]Ranged, 0)
+	for i := uint64(0); i < 1000; i += 1 {
+		// Leave a gap after every third range
+		if i%3 == 2 {
+			items = append(items, DefaultRangedValue{i * 10, i*10 + 4, i})
+		} else {
+			items = append(items, DefaultRangedValue{i * 10, i*10 + 9, i})
+		}
+	}
+	s, err := NewRangeStore(items, AllowGaps(), WithDirectoryBuckets(16))
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	d, ok := s.search.(*directory)
+	if !ok || len(d.buckets) != 16 {
+		t.Fatalf("Expected a directory of 16 buckets")
+	}
+	n := s.Root()
+	for key := uint64(0); key < 10020; key += 1 {
+		want, wantErr := n.RangeSearch(key)
+		got, err := s.RangeSearch(key)
+		if got != want || reflect.TypeOf(err) != reflect.TypeOf(wantErr) {
+			t.Fatalf("Wrong result for %d: %v, %v", key, got, err)
+		}
+	}
+}
+
+func TestRangeStore_DirectoryEdges(t *testing.T) {
+	// More buckets than ranges
+	s, err := NewBuilder(WithDirectoryBuckets(8)).AllowGaps().Add(10, 19, "A").Add(30, 39, "B").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	if d := s.search.(*directory); len(d.buckets) != 2 {
+		t.Fatalf("Expected one bucket per range, got %d", len(d.buckets))
+	}
+	for key, expected := range map[uint64]interface{}{15: "A", 35: "B"} {
+		if v, err := s.RangeSearch(key); err != nil || v != expected {
+			t.Fatalf("Wrong value for %d: %v", key, v)
+		}
+	}
+	for _, key := range []uint64{5, 25, 45} {
+		if _, err := s.RangeSearch(key); err == nil {
+			t.Fatalf("Expected %d to be out of range", key)
+		}
+	}
+	if err := s.Append(DefaultRangedValue{40, 49, "C"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if v, err := s.RangeSearch(45); err != nil || v != "C" {
+		t.Fatalf("Wrong value after appending: %v", v)
+	}
+
+	// Small stores don't get one unless asked
+	s, _ = NewBuilder().Add(0, 9, "A").Build()
+	if _, ok := s.search.(*directory); ok {
+		t.Fatalf("Didn't expect a directory")
+	}
+}
+
+func Benchmark_RangeSearch_LargeDirectory(b *testing.B) {
+	benchmarkSearch(b, WithDirectoryBuckets(DefaultDirectoryBuckets))
+}
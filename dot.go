@@ -0,0 +1,84 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * dot.go: Graphviz DOT export of the tree
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Writes a Graphviz DOT representation of the tree to w. Each node is labelled
+// with its value and the range it covers. Edges to left children are drawn solid
+// and edges to right children are drawn dashed, so that the shape of large trees
+// can be rendered and inspected with e.g. `dot -Tsvg`.
+func (n *Node) DOT(w io.Writer) error {
+	if _, err := io.WriteString(w, "digraph rangestore {\n\tnode [shape=box];\n"); err != nil {
+		return err
+	}
+	id := 0
+	if n != nil {
+		if _, err := n.writeDOT(w, &id); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+// Escapes a string for a quoted DOT label. Graphviz doesn't understand Go's
+// escapes, so only quotes and backslashes are escaped, and newlines become line
+// breaks; everything else, including non-ASCII text, is written as it is.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`)
+
+// Writes the node and its subtrees, returning the identifier assigned to the node
+func (n *Node) writeDOT(w io.Writer, id *int) (int, error) {
+	self := *id
+	*id += 1
+	label := `"` + dotEscaper.Replace(fmt.Sprintf("%v\n[%d, %d]", n.value, n.min, n.max)) + `"`
+	if _, err := fmt.Fprintf(w, "\tn%d [label=%s];\n", self, label); err != nil {
+		return self, err
+	}
+	if n.left != nil {
+		child, err := n.left.writeDOT(w, id)
+		if err != nil {
+			return self, err
+		}
+		if _, err := fmt.Fprintf(w, "\tn%d -> n%d [label=\"L\"];\n", self, child); err != nil {
+			return self, err
+		}
+	}
+	if n.right != nil {
+		child, err := n.right.writeDOT(w, id)
+		if err != nil {
+			return self, err
+		}
+		if _, err := fmt.Fprintf(w, "\tn%d -> n%d [label=\"R\", style=dashed];\n", self, child); err != nil {
+			return self, err
+		}
+	}
+	return self, nil
+}
@@ -0,0 +1,98 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * dot_test.go: Tests on the DOT export
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNode_DOT(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	R := `digraph rangestore {
+	node [shape=box];
+	n0 [label="B\n[10, 19]"];
+	n1 [label="A\n[0, 9]"];
+	n0 -> n1 [label="L"];
+	n2 [label="C\n[20, 29]"];
+	n0 -> n2 [label="R", style=dashed];
+}
+`
+	buf := &bytes.Buffer{}
+	if err := n.DOT(buf); err != nil {
+		t.Fatalf("Error while writing DOT output: %s", err.Error())
+	}
+	if buf.String() != R {
+		t.Fatalf("Wrong DOT output form:\n%s\n%s", buf.String(), R)
+	}
+}
+
+func TestNode_DOTEscaping(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "Zürich \"HQ\"\tC:\\ranges\r\nline 2"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	buf := &bytes.Buffer{}
+	if err := n.DOT(buf); err != nil {
+		t.Fatalf("Error while writing DOT output: %s", err.Error())
+	}
+	expected := "\tn0 [label=\"Zürich \\\"HQ\\\"\tC:\\\\ranges\\nline 2\\n[0, 9]\"];\n"
+	if !bytes.Contains(buf.Bytes(), []byte(expected)) {
+		t.Fatalf("Wrong DOT label:\n%s\n%s", buf.String(), expected)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestNode_DOTWriteError(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	if err := n.DOT(failingWriter{}); err == nil {
+		t.Fatalf("Expected the write error to be returned")
+	}
+}
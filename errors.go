@@ -0,0 +1,73 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * errors.go: Structured access to errors, for rendering them differently
+ */
+
+package rangestore
+
+// The content of an error from this package, so that it can be rendered without
+// parsing its message, e.g. to localize it or to report it as JSON
+type ErrorDetails struct {
+	// The name of the error type, e.g. "ErrOutOfRange"
+	Kind string
+	// The values the message is made of, by name, e.g. "key" for ErrOutOfRange.
+	// Errors collecting several others, such as ErrMalformedCSV, hold their
+	// details as an []ErrorDetails under "errors".
+	Fields map[string]interface{}
+}
+
+// Implemented by every error type of this package
+type DetailedError interface {
+	error
+	Details() ErrorDetails
+}
+
+// Renders the details of an error from this package. Returning an empty string
+// falls back to the message of the error, so a formatter only needs to handle
+// the kinds it knows about.
+type ErrorFormatter func(d ErrorDetails) string
+
+// Renders err with f if it's one of the errors of this package, or returns its
+// message otherwise. Returns an empty string if err is nil.
+func FormatError(err error, f ErrorFormatter) string {
+	if err == nil {
+		return ""
+	}
+	if de, ok := err.(DetailedError); ok && f != nil {
+		if s := f(de.Details()); s != "" {
+			return s
+		}
+	}
+	return err.Error()
+}
+
+// Returns the details of each of the errors. Errors from elsewhere are given no
+// Kind, and their message under "message".
+func detailsOf(errs []error) []ErrorDetails {
+	ret := make([]ErrorDetails, 0, len(errs))
+	for _, err := range errs {
+		if de, ok := err.(DetailedError); ok {
+			ret = append(ret, de.Details())
+		} else {
+			ret = append(ret, ErrorDetails{Fields: map[string]interface{}{"message": err.Error()}})
+		}
+	}
+	return ret
+}
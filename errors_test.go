@@ -0,0 +1,87 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * errors_test.go: Tests on the structured errors
+ */
+
+package rangestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatError(t *testing.T) {
+	// Every error type of the package provides its details, under its own name
+	for _, err := range []DetailedError{
+		ErrAmbiguousValue{}, ErrBigOutOfRange{}, ErrCorruptStore{}, ErrDiscontinuity{}, ErrDivergence{}, ErrDuplicateRange{}, ErrEmptyInput{}, ErrEmptyStore{}, ErrInvalidBigRange{}, ErrInvalidByteSize{}, ErrInvalidIP{}, ErrInvalidInput{}, ErrInvalidKeyRange{}, ErrInvalidProportion{}, ErrInvalidScale{}, ErrInvalidYAML{}, ErrInvariant{}, ErrInvertedRange{}, ErrKeyOutOfRange{}, ErrKeyTooLarge{}, ErrLengthMismatch{}, ErrMalformedCSV{}, ErrMalformedRecord{}, ErrMemoryBudgetExceeded{}, ErrNoFreeBlock{}, ErrNoTransition{}, ErrNonNumericValue{}, ErrNotAllocated{}, ErrNothingToPick{}, ErrOutOfRange{}, ErrOverlap{}, ErrScaleOverflow{}, ErrShiftOverflow{}, ErrSpanTooLarge{}, ErrStoreRegistered{}, ErrSumOverflow{}, ErrUncovered{}, ErrUnhashableValue{}, ErrUnknownStore{}, ErrUnserializableValue{}, ErrUnsignedIntegerOverflow{}, ErrUnsupportedVersion{}, ErrWeightSpaceExhausted{}, ErrYAMLLine{}, ErrZeroSize{}, ErrZeroWeight{},
+	} {
+		if kind := err.Details().Kind; kind != reflect.TypeOf(err).Name() {
+			t.Fatalf("Wrong kind for %T: %s", err, kind)
+		}
+	}
+
+	n, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{10, 19, "A"}})
+	_, err := n.RangeSearch(25)
+	if key := err.(ErrOutOfRange).Key(); key != 25 {
+		t.Fatalf("Wrong key: %d", key)
+	}
+	french := func(d ErrorDetails) string {
+		if d.Kind == "ErrOutOfRange" {
+			return fmt.Sprintf("La clé %d est hors limites", d.Fields["key"])
+		}
+		return ""
+	}
+	if msg := FormatError(err, french); msg != "La clé 25 est hors limites" {
+		t.Fatalf("Wrong formatted message: %s", msg)
+	}
+	// Unknown kinds, foreign errors and missing formatters fall back to the message
+	if msg := FormatError(ErrEmptyInput{}, french); msg != "Input list is empty" {
+		t.Fatalf("Wrong fallback message: %s", msg)
+	}
+	if msg := FormatError(fmt.Errorf("elsewhere"), french); msg != "elsewhere" {
+		t.Fatalf("Wrong message for a foreign error: %s", msg)
+	}
+	if FormatError(err, nil) != err.Error() || FormatError(nil, french) != "" {
+		t.Fatalf("Wrong message without a formatter or an error")
+	}
+
+	// Collected errors carry the details of each of them
+	_, err = LoadCSV(strings.NewReader("1,x,A\ny,2,B\n"), CSVOptions{})
+	asJSON := func(d ErrorDetails) string {
+		b, _ := json.Marshal(d)
+		return string(b)
+	}
+	expected := `{"Kind":"ErrMalformedCSV","Fields":{"errors":[` +
+		`{"Kind":"ErrMalformedRecord","Fields":{"reason":"bad maximum \"x\"","record":1}},` +
+		`{"Kind":"ErrMalformedRecord","Fields":{"reason":"bad minimum \"y\"","record":2}}]}}`
+	if msg := FormatError(err, asJSON); msg != expected {
+		t.Fatalf("Wrong JSON:\n%s", msg)
+	}
+	foreign := detailsOf([]error{fmt.Errorf("elsewhere")})
+	if len(foreign) != 1 || foreign[0].Kind != "" || foreign[0].Fields["message"] != "elsewhere" {
+		t.Fatalf("Wrong details for a foreign error: %v", foreign)
+	}
+}
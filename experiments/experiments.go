@@ -0,0 +1,110 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * experiments/experiments.go: Deterministic A/B experiment assignment
+ */
+
+// Package experiments assigns users to the variants of A/B experiments. Each user
+// is hashed into one of Buckets buckets, and the buckets are split between the
+// variants in proportion to their weights using a weighted range store, so
+// assignment is deterministic per user and experiment, and proportional overall.
+package experiments
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/big"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// The number of buckets users are hashed into. Weights are resolved to whole
+// buckets, so allocations are accurate to 0.01%.
+const Buckets = 10000
+
+// A variant of an experiment, such as "control", and its relative weight
+type Variant struct {
+	Name   string
+	Weight uint64
+}
+
+// Returned when none of the variants receive any buckets
+var ErrNoWeight = errors.New("Variants have no weight")
+
+// An experiment with fixed variants
+type Experiment struct {
+	name  string
+	store *rangestore.WeightedStore
+}
+
+// Creates an experiment. The name salts the hash, so that the same user lands
+// in unrelated buckets in different experiments. The buckets are handed out to
+// the variants in order, with variant i ending just before bucket
+// floor(W_i * Buckets / W), where W_i is the total weight up to and including
+// it, and W is the total weight.
+func NewExperiment(name string, variants []Variant) (*Experiment, error) {
+	total := new(big.Int)
+	for _, v := range variants {
+		total.Add(total, new(big.Int).SetUint64(v.Weight))
+	}
+	if total.Sign() == 0 {
+		return nil, ErrNoWeight
+	}
+	items := make([]rangestore.Weighted, 0, len(variants))
+	cum, start := new(big.Int), uint64(0)
+	for _, v := range variants {
+		cum.Add(cum, new(big.Int).SetUint64(v.Weight))
+		end := new(big.Int).Mul(cum, big.NewInt(Buckets))
+		end.Quo(end, total)
+		if end.Uint64() > start {
+			items = append(items, &rangestore.DefaultWeightedValue{Weight: end.Uint64() - start, Value: v})
+		}
+		start = end.Uint64()
+	}
+	if len(items) == 0 {
+		return nil, ErrNoWeight
+	}
+	store, err := rangestore.NewWeightedStore(items)
+	if err != nil {
+		return nil, err
+	}
+	return &Experiment{name: name, store: store}, nil
+}
+
+// Returns the variant the user is assigned to, which is always the same for the
+// same user in the same experiment
+func (e *Experiment) Assign(userID string) Variant {
+	// The weighted store covers the keys 1 through Buckets
+	v, _ := e.store.RangeSearch(e.Bucket(userID) + 1)
+	return v.(Variant)
+}
+
+// Returns the bucket the user is hashed into, from 0 to Buckets-1
+func (e *Experiment) Bucket(userID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(e.name))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	x := h.Sum64()
+	// Mix the high bits down, since FNV leaves them poorly distributed
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return x % Buckets
+}
@@ -0,0 +1,94 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * experiments/experiments_test.go: Tests on experiment assignment
+ */
+
+package experiments
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestExperiment_Assign(t *testing.T) {
+	e, err := NewExperiment("checkout", []Variant{{"control", 90}, {"A", 5}, {"B", 5}})
+	if err != nil {
+		t.Fatalf("Error while creating experiment: %s", err.Error())
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 100000; i += 1 {
+		user := "user-" + strconv.Itoa(i)
+		v := e.Assign(user)
+		if again := e.Assign(user); again != v {
+			t.Fatalf("Assignment isn't stable for %s: %v, %v", user, v, again)
+		}
+		counts[v.Name] += 1
+	}
+	for name, expected := range map[string]int{"control": 90000, "A": 5000, "B": 5000} {
+		if counts[name] < expected*9/10 || counts[name] > expected*11/10 {
+			t.Fatalf("Uneven allocation for %s: %d [%d]", name, counts[name], expected)
+		}
+	}
+}
+
+func TestExperiment_Buckets(t *testing.T) {
+	e, err := NewExperiment("x", []Variant{{"a", 1}, {"b", 0}, {"c", 2}})
+	if err != nil {
+		t.Fatalf("Error while creating experiment: %s", err.Error())
+	}
+	// a gets buckets 0 through 3332, c gets the rest
+	for bucket, expected := range map[uint64]string{1: "a", 3333: "a", 3334: "c", Buckets: "c"} {
+		v, err := e.store.RangeSearch(bucket)
+		if err != nil || v.(Variant).Name != expected {
+			t.Fatalf("Wrong variant for bucket %d: %v [%s]", bucket-1, v, expected)
+		}
+	}
+
+	// The same user lands in different buckets in different experiments
+	other, _ := NewExperiment("y", []Variant{{"a", 1}})
+	same := 0
+	for i := 0; i < 100; i += 1 {
+		user := strconv.Itoa(i)
+		if e.Bucket(user) == other.Bucket(user) {
+			same += 1
+		}
+	}
+	if same > 5 {
+		t.Fatalf("Experiments aren't independent: %d shared buckets", same)
+	}
+
+	if _, err := NewExperiment("z", []Variant{{"a", 0}}); err != ErrNoWeight {
+		t.Fatalf("Expected ErrNoWeight, got %v", err)
+	}
+	if _, err := NewExperiment("z", nil); err != ErrNoWeight {
+		t.Fatalf("Expected ErrNoWeight, got %v", err)
+	}
+}
+
+func TestExperiment_LargeWeights(t *testing.T) {
+	e, err := NewExperiment("x", []Variant{{"a", 1 << 63}, {"b", 1 << 63}})
+	if err != nil {
+		t.Fatalf("Error while creating experiment: %s", err.Error())
+	}
+	if v, _ := e.store.RangeSearch(Buckets/2 + 1); v.(Variant).Name != "b" {
+		t.Fatalf("Wrong split with large weights: %v", v)
+	}
+}
@@ -0,0 +1,123 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * explain.go: Tracing lookups
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// The way a lookup went at a node
+type Direction int
+
+const (
+	// The key is below the range of the node
+	GoLeft Direction = iota
+	// The key is above the range of the node
+	GoRight
+	// The key is within the range of the node
+	Stop
+)
+
+func (d Direction) String() string {
+	switch d {
+	case GoLeft:
+		return "left"
+	case GoRight:
+		return "right"
+	default:
+		return "stop"
+	}
+}
+
+// A node visited by a lookup, and the way the lookup went from there. A step
+// going left or right to a missing child ends the lookup with ErrOutOfRange.
+type TraceStep struct {
+	Min, Max  uint64
+	Value     interface{}
+	Direction Direction
+}
+
+// The nodes visited while looking up a key, in order, along with the outcome
+type LookupTrace struct {
+	Key   uint64
+	Steps []TraceStep
+	Found bool
+	Value interface{}
+}
+
+// Formats the trace as one line per visited node, followed by the outcome
+func (t LookupTrace) String() string {
+	buf := new(bytes.Buffer)
+	for _, step := range t.Steps {
+		fmt.Fprintf(buf, "[%d, %d] %v: %s\n", step.Min, step.Max, step.Value, step.Direction)
+	}
+	if t.Found {
+		fmt.Fprintf(buf, "%d -> %v\n", t.Key, t.Value)
+	} else {
+		fmt.Fprintf(buf, "%d is out of range\n", t.Key)
+	}
+	return buf.String()
+}
+
+// Looks up the key as RangeSearch does, recording every node visited on the way.
+// The error is the one RangeSearch would have returned; the trace is complete
+// either way.
+func (n *Node) Explain(val uint64) (LookupTrace, error) {
+	ret := LookupTrace{Key: val, Steps: make([]TraceStep, 0)}
+	v, err := n.RangeSearchTraced(val, func(step TraceStep) {
+		ret.Steps = append(ret.Steps, step)
+	})
+	if err == nil {
+		ret.Found, ret.Value = true, v
+	}
+	return ret, err
+}
+
+// Same as RangeSearch, but calls visit for every node visited on the way
+func (n *Node) RangeSearchTraced(val uint64, visit func(step TraceStep)) (interface{}, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	for c := n; c != nil; {
+		step := TraceStep{c.min, c.max, c.value, Stop}
+		if c.max < val {
+			step.Direction = GoRight
+		} else if c.min > val {
+			step.Direction = GoLeft
+		}
+		visit(step)
+		switch step.Direction {
+		case GoRight:
+			c = c.right
+		case GoLeft:
+			c = c.left
+		default:
+			return c.value, nil
+		}
+	}
+	return nil, ErrOutOfRange{val}
+}
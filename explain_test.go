@@ -0,0 +1,81 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * explain_test.go: Tests on lookup tracing
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_Explain(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	trace, err := n.Explain(25)
+	if err != nil {
+		t.Fatalf("Got an error while explaining: %s", err.Error())
+	}
+	expected := LookupTrace{
+		Key: 25,
+		Steps: []TraceStep{
+			{10, 19, "B", GoRight},
+			{20, 29, "C", Stop},
+		},
+		Found: true,
+		Value: "C",
+	}
+	if !reflect.DeepEqual(trace, expected) {
+		t.Fatalf("Wrong trace: %+v", trace)
+	}
+	if trace.String() != "[10, 19] B: right\n[20, 29] C: stop\n25 -> C\n" {
+		t.Fatalf("Wrong trace string:\n%s", trace.String())
+	}
+}
+
+func TestNode_ExplainMiss(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+	n, _ := NewRangeStoreFromSorted(items)
+
+	trace, err := n.Explain(35)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+	if trace.Found || len(trace.Steps) != 2 || trace.Steps[1].Direction != GoRight {
+		t.Fatalf("Wrong trace for a miss: %+v", trace)
+	}
+	if trace.String() != "[10, 19] B: right\n[20, 29] C: right\n35 is out of range\n" {
+		t.Fatalf("Wrong trace string:\n%s", trace.String())
+	}
+}
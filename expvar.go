@@ -0,0 +1,92 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * expvar.go: Publishing store state through expvar
+ */
+
+package rangestore
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// A swappable store whose state and lookup counters are published through
+// expvar, for services without a Prometheus setup. Lookups must go through the
+// ExpvarStore to be counted.
+//
+// ExpvarStore is safe for concurrent use.
+type ExpvarStore struct {
+	lookups uint64
+	misses  uint64
+	*SwappableStore
+}
+
+// Publishes the state of the store as expvar variables named after prefix:
+//
+//	<prefix>.min, <prefix>.max   the bounds of the current store
+//	<prefix>.ranges              the number of ranges in the current store
+//	<prefix>.depth               the depth of the current tree
+//	<prefix>.generation          the number of times the store was swapped
+//	<prefix>.lookups             lookups made through the returned store
+//	<prefix>.misses              those of them for keys no range covered
+//
+// The variables are read whenever expvar is served, so they follow swaps. Like
+// expvar.Publish, this panics if any of the names is already taken, so each
+// store needs its own prefix.
+func PublishExpvar(prefix string, s *SwappableStore) *ExpvarStore {
+	e := &ExpvarStore{SwappableStore: s}
+	expvar.Publish(prefix+".min", expvar.Func(func() interface{} {
+		min, _ := e.Bounds()
+		return min
+	}))
+	expvar.Publish(prefix+".max", expvar.Func(func() interface{} {
+		_, max := e.Bounds()
+		return max
+	}))
+	expvar.Publish(prefix+".ranges", expvar.Func(func() interface{} {
+		return e.Len()
+	}))
+	expvar.Publish(prefix+".depth", expvar.Func(func() interface{} {
+		return e.Load().Root().Depth()
+	}))
+	expvar.Publish(prefix+".generation", expvar.Func(func() interface{} {
+		return e.Generation()
+	}))
+	expvar.Publish(prefix+".lookups", expvar.Func(func() interface{} {
+		return atomic.LoadUint64(&e.lookups)
+	}))
+	expvar.Publish(prefix+".misses", expvar.Func(func() interface{} {
+		return atomic.LoadUint64(&e.misses)
+	}))
+	return e
+}
+
+// Searches the current store, counting the lookup
+func (e *ExpvarStore) RangeSearch(val uint64) (interface{}, error) {
+	v, err := e.SwappableStore.RangeSearch(val)
+	atomic.AddUint64(&e.lookups, 1)
+	if err != nil {
+		atomic.AddUint64(&e.misses, 1)
+	}
+	return v, err
+}
@@ -0,0 +1,62 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * expvar_test.go: Tests on expvar publishing
+ */
+
+package rangestore
+
+import (
+	"expvar"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	first, _ := NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Build()
+	e := PublishExpvar("rangestore_test", NewSwappableStore(first))
+	e.RangeSearch(5)
+	e.RangeSearch(25)
+
+	expected := map[string]string{
+		"min":        "0",
+		"max":        "19",
+		"ranges":     "2",
+		"depth":      "2",
+		"generation": "0",
+		"lookups":    "2",
+		"misses":     "1",
+	}
+	for name, value := range expected {
+		if got := expvar.Get("rangestore_test." + name).String(); got != value {
+			t.Fatalf("Wrong value for %s: %s", name, got)
+		}
+	}
+
+	second, _ := NewBuilder().Add(0, 29, "C").Build()
+	e.Swap(second)
+	if got := expvar.Get("rangestore_test.generation").String(); got != "1" {
+		t.Fatalf("Wrong generation after swapping: %s", got)
+	}
+	if got := expvar.Get("rangestore_test.max").String(); got != "29" {
+		t.Fatalf("Wrong maximum after swapping: %s", got)
+	}
+}
@@ -0,0 +1,82 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * flat.go: Struct of arrays tree layout
+ */
+
+package rangestore
+
+// The same tree as a *Node, laid out as parallel slices indexed by node id.
+// The bounds sit next to each other in memory and the children are 32 bit
+// indices rather than pointers, which shrinks the nodes and keeps more of the
+// tree in cache. A child index of -1 means there's no child.
+type flatTree struct {
+	mins, maxs  []uint64
+	left, right []int32
+	values      []interface{}
+}
+
+// Lays out the tree in pre-order, so the root is node 0
+func newFlatTree(n *Node) *flatTree {
+	count := n.Len()
+	t := &flatTree{
+		mins:   make([]uint64, 0, count),
+		maxs:   make([]uint64, 0, count),
+		left:   make([]int32, 0, count),
+		right:  make([]int32, 0, count),
+		values: make([]interface{}, 0, count),
+	}
+	t.add(n)
+	return t
+}
+
+// Appends the subtree rooted at n, returning the id of n
+func (t *flatTree) add(n *Node) int32 {
+	id := int32(len(t.mins))
+	t.mins = append(t.mins, n.min)
+	t.maxs = append(t.maxs, n.max)
+	t.left = append(t.left, -1)
+	t.right = append(t.right, -1)
+	t.values = append(t.values, n.value)
+	if n.left != nil {
+		t.left[id] = t.add(n.left)
+	}
+	if n.right != nil {
+		t.right[id] = t.add(n.right)
+	}
+	return id
+}
+
+// Searches the same way as (*Node).RangeSearch
+func (t *flatTree) RangeSearch(val uint64) (interface{}, error) {
+	idx := int32(0)
+	for idx >= 0 {
+		if val > t.maxs[idx] {
+			idx = t.right[idx]
+		} else if val < t.mins[idx] {
+			idx = t.left[idx]
+		} else {
+			return t.values[idx], nil
+		}
+	}
+	return nil, ErrOutOfRange{val}
+}
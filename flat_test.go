@@ -0,0 +1,81 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * flat_test.go: Tests and benchmarks on the struct of arrays layout
+ */
+
+package rangestore
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestFlatTree_MatchesTree(t *testing.T) {
+	s, err := NewBuilder(WithBackend(FlatBackend)).Add(0, 9, "A").Add(20, 29, "B").Add(30, 99, "C").Add(150, 159, "D").AllowGaps().Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	if _, ok := s.search.(*flatTree); !ok {
+		t.Fatalf("Expected the store to be backed by a flat tree")
+	}
+	for key := uint64(0); key < 200; key += 1 {
+		expected, expectedErr := s.Root().RangeSearch(key)
+		v, err := s.RangeSearch(key)
+		if v != expected || reflect.TypeOf(err) != reflect.TypeOf(expectedErr) {
+			t.Fatalf("Flat tree disagrees with the tree for %d: %v, %v", key, v, err)
+		}
+	}
+
+	if err := s.Append(DefaultRangedValue{160, 169, "E"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if v, err := s.RangeSearch(165); err != nil || v != "E" {
+		t.Fatalf("Wrong value after appending to a flat store")
+	}
+}
+
+func benchmarkSearch(b *testing.B, opts ...Option) {
+	s, err := NewRangeStore(arenaItems(1000000), opts...)
+	if err != nil {
+		b.Fatalf("Got an error while building: %s", err.Error())
+	}
+	keys := make([]uint64, 1024)
+	for idx := range keys {
+		keys[idx] = uint64(rand.Int63n(10000000))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		if _, err := s.RangeSearch(keys[i%len(keys)]); err != nil {
+			b.Fatalf("Got an error while searching: %s", err.Error())
+		}
+	}
+}
+
+func Benchmark_RangeSearch_LargeTree(b *testing.B) {
+	benchmarkSearch(b)
+}
+
+func Benchmark_RangeSearch_LargeFlat(b *testing.B) {
+	benchmarkSearch(b, WithBackend(FlatBackend))
+}
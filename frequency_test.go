@@ -0,0 +1,108 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * frequency_test.go: Tests on frequency guided construction
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeStoreFromSortedWithFrequencies_HotRange(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 99, "A"})
+	items = append(items, DefaultRangedValue{100, 100, "B"})
+	items = append(items, DefaultRangedValue{101, 199, "C"})
+	items = append(items, DefaultRangedValue{200, 299, "D"})
+
+	n, err := NewRangeStoreFromSortedWithFrequencies(items, []uint64{1, 1000, 1, 1})
+
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	if n.value != "B" {
+		t.Fatalf("Expected the hot range B at the root, got %v", n.value)
+	}
+	if n.left.value != "A" {
+		t.Fatalf("Expected A as the left child")
+	}
+	for key, expected := range map[uint64]string{0: "A", 100: "B", 150: "C", 299: "D"} {
+		v, err := n.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching: %s", err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back %s [%s]", v, expected)
+		}
+	}
+}
+
+func TestRangeStoreFromSortedWithFrequencies_ZeroFrequencies(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+
+	n, err := NewRangeStoreFromSortedWithFrequencies(items, []uint64{0, 0, 0})
+
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	// With no information we should still get a balanced tree
+	if n.value != "B" || n.left.value != "A" || n.right.value != "C" {
+		t.Fatalf("Expected a balanced tree, got:\n%s", n.String())
+	}
+}
+
+func TestRangeStoreFromSortedWithFrequencies_Mismatch(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+
+	_, err := NewRangeStoreFromSortedWithFrequencies(items, []uint64{1})
+
+	if err == nil {
+		t.Fatalf("Expecting a length mismatch error and got none")
+	}
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrLengthMismatch{}).Name() {
+		t.Fatalf("Expecting an ErrLengthMismatch, but got something else")
+	}
+	msg := err.Error()
+	if msg != "Length mismatch: expected 2 entries, got 1" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+}
+
+func TestRangeStoreFromSortedWithFrequencies_Invalid(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{11, 19, "B"})
+
+	_, err := NewRangeStoreFromSortedWithFrequencies(items, []uint64{1, 1})
+
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDiscontinuity{}).Name() {
+		t.Fatalf("Expecting an ErrDiscontinuity, but got something else")
+	}
+}
@@ -0,0 +1,244 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * geoip.go: Importing MaxMind GeoIP CSV databases
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Configures LoadGeoIPCSV. The zero value reads the geoname_id column of a
+// GeoLite2 blocks file as a uint64.
+type GeoIPOptions struct {
+	// The column holding the value, "geoname_id" if empty
+	ValueColumn string
+	// Columns tried in turn when the value column is empty, such as
+	// "registered_country_geoname_id". Networks with no value in any of them
+	// are left out, leaving a gap.
+	FallbackColumns []string
+	// Converts the value field, which is parsed as a uint64 if this is nil
+	ParseValue func(field string) (interface{}, error)
+}
+
+type ErrInvalidIP struct {
+	ip net.IP
+}
+
+func (ex ErrInvalidIP) Error() string {
+	return fmt.Sprintf("Invalid IP address %v", ex.ip)
+}
+
+// Returns the address which was rejected
+func (ex ErrInvalidIP) IP() net.IP {
+	return ex.ip
+}
+
+func (ex ErrInvalidIP) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidIP", map[string]interface{}{"ip": ex.ip}}
+}
+
+// A pair of stores covering IPv4 and IPv6 addresses, as read from a GeoIP
+// database or RIR delegation files. IPv4 addresses are keyed by their 32 bit value, and IPv6 addresses
+// by their 128 bit value.
+type GeoIPStore struct {
+	v4 *Node
+	v6 *BigNode
+}
+
+// Builds a store from MaxMind GeoLite2 or GeoIP2 blocks CSV files, such as
+// GeoLite2-City-Blocks-IPv4.csv and GeoLite2-City-Blocks-IPv6.csv. Each file
+// must start with the header row, and its columns are found by name: network,
+// and the value column set in opts. Either file may hold networks of either
+// family; IPv4 mapped IPv6 networks are stored as IPv4. Adjacent networks with
+// the same value are merged, and the space between networks is left as gaps.
+// Malformed rows are reported together in an ErrMalformedCSV.
+func LoadGeoIPCSV(opts GeoIPOptions, files ...io.Reader) (*GeoIPStore, error) {
+	if opts.ValueColumn == "" {
+		opts.ValueColumn = "geoname_id"
+	}
+	if opts.ParseValue == nil {
+		opts.ParseValue = func(field string) (interface{}, error) {
+			return strconv.ParseUint(field, 10, 64)
+		}
+	}
+
+	v4 := make([]Ranged, 0)
+	v6 := make([]BigRangeEntry, 0)
+	errs := make([]error, 0)
+	required := append([]string{"network", opts.ValueColumn}, opts.FallbackColumns...)
+	for _, f := range files {
+		fileErrs, err := readNamedCSV(f, required, func(field func(string) string) error {
+			value := field(opts.ValueColumn)
+			for _, name := range opts.FallbackColumns {
+				if value != "" {
+					break
+				}
+				value = field(name)
+			}
+			if value == "" {
+				return nil
+			}
+			v, err := opts.ParseValue(value)
+			if err != nil {
+				return err
+			}
+			return addGeoIPNetwork(field("network"), v, &v4, &v6)
+		})
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, fileErrs...)
+	}
+	if len(errs) > 0 {
+		return nil, ErrMalformedCSV{errs}
+	}
+	return newGeoIPStore(v4, v6)
+}
+
+// Sorts and merges the ranges of each family, and builds a store from them
+func newGeoIPStore(v4 []Ranged, v6 []BigRangeEntry) (*GeoIPStore, error) {
+	if len(v4) == 0 && len(v6) == 0 {
+		return nil, ErrEmptyInput{}
+	}
+	s := &GeoIPStore{}
+	var err error
+	if len(v4) > 0 {
+		sort.SliceStable(v4, func(i, j int) bool {
+			return v4[i].GetMin() < v4[j].GetMin()
+		})
+		if s.v4, err = rangeStoreFromSortedChecked(mergeGeoIPv4(v4), true, true); err != nil {
+			return nil, err
+		}
+	}
+	if len(v6) > 0 {
+		sort.SliceStable(v6, func(i, j int) bool {
+			return v6[i].Min.Cmp(v6[j].Min) < 0
+		})
+		if s.v6, err = NewSparseBigRangeStoreFromSorted(mergeGeoIPv6(v6)); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Parses the network and adds it to the ranges of its family
+func addGeoIPNetwork(s string, value interface{}, v4 *[]Ranged, v6 *[]BigRangeEntry) error {
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return fmt.Errorf("bad network %q", s)
+	}
+	ones, bits := network.Mask.Size()
+	if ip := network.IP.To4(); ip != nil && (bits == 32 || ones >= 96) {
+		if bits == 128 {
+			ones -= 96
+		}
+		min := uint64(ip[0])<<24 | uint64(ip[1])<<16 | uint64(ip[2])<<8 | uint64(ip[3])
+		*v4 = append(*v4, RangeEntry{min, min + (1 << uint(32-ones)) - 1, value})
+		return nil
+	}
+	min := new(big.Int).SetBytes(network.IP.To16())
+	max := new(big.Int).Lsh(big.NewInt(1), uint(128-ones))
+	max.Add(max, min).Sub(max, big.NewInt(1))
+	*v6 = append(*v6, BigRangeEntry{min, max, value})
+	return nil
+}
+
+// Whether a and b can be merged into one range. Values which can't be compared
+// with == are never merged.
+func sameGeoIPValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.TypeOf(a).Comparable() && reflect.TypeOf(b).Comparable() && a == b
+}
+
+func mergeGeoIPv4(items []Ranged) []Ranged {
+	merged := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		if last := len(merged) - 1; last >= 0 {
+			prev := merged[last].(RangeEntry)
+			if prev.Max+1 == item.GetMin() && sameGeoIPValue(prev.Value, item.GetValue()) {
+				prev.Max = item.GetMax()
+				merged[last] = prev
+				continue
+			}
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func mergeGeoIPv6(items []BigRangeEntry) []BigRanged {
+	merged := make([]BigRanged, 0, len(items))
+	one := big.NewInt(1)
+	next := new(big.Int)
+	for _, item := range items {
+		if last := len(merged) - 1; last >= 0 {
+			prev := merged[last].(BigRangeEntry)
+			if next.Add(prev.Max, one).Cmp(item.Min) == 0 && sameGeoIPValue(prev.Value, item.Value) {
+				prev.Max = item.Max
+				merged[last] = prev
+				continue
+			}
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// Returns the value of the network containing the address, or an
+// ErrOutOfRange or ErrBigOutOfRange, for IPv4 and IPv6 addresses respectively,
+// if there is none. Returns an ErrInvalidIP if ip isn't 4 or 16 bytes long.
+func (s *GeoIPStore) Lookup(ip net.IP) (interface{}, error) {
+	if ip4 := ip.To4(); ip4 != nil {
+		key := uint64(ip4[0])<<24 | uint64(ip4[1])<<16 | uint64(ip4[2])<<8 | uint64(ip4[3])
+		if s.v4 == nil {
+			return nil, ErrOutOfRange{key}
+		}
+		return s.v4.RangeSearch(key)
+	}
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return nil, ErrInvalidIP{ip}
+	}
+	// A nil BigNode has no ranges, and reports every key as out of range
+	return s.v6.RangeSearchBytes(ip16)
+}
+
+// Returns the IPv4 store, or nil if there were no IPv4 networks
+func (s *GeoIPStore) V4() *Node {
+	return s.v4
+}
+
+// Returns the IPv6 store, or nil if there were no IPv6 networks
+func (s *GeoIPStore) V6() *BigNode {
+	return s.v6
+}
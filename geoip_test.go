@@ -0,0 +1,106 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * geoip_test.go: Tests on the GeoIP importer
+ */
+
+package rangestore
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const geoIPv4Blocks = `network,geoname_id,registered_country_geoname_id,represented_country_geoname_id,is_anonymous_proxy,is_satellite_provider
+1.0.0.0/24,2077456,2077456,,0,0
+1.0.1.0/24,1814991,1814991,,0,0
+1.0.2.0/23,1814991,1814991,,0,0
+1.0.8.0/21,,1814991,,0,0
+2.0.0.0/8,,,,1,0
+`
+
+const geoIPv6Blocks = `network,geoname_id,registered_country_geoname_id,represented_country_geoname_id,is_anonymous_proxy,is_satellite_provider
+2001:200::/32,1861060,1861060,,0,0
+2001:201::/32,1861060,1861060,,0,0
+2001:208::/32,1880251,1880251,,0,0
+::ffff:3.0.0.0/104,6252001,6252001,,0,0
+`
+
+func TestLoadGeoIPCSV(t *testing.T) {
+	opts := GeoIPOptions{FallbackColumns: []string{"registered_country_geoname_id"}}
+	s, err := LoadGeoIPCSV(opts, strings.NewReader(geoIPv4Blocks), strings.NewReader(geoIPv6Blocks))
+	if err != nil {
+		t.Fatalf("Error while loading database: %s", err.Error())
+	}
+	for addr, expected := range map[string]uint64{
+		"1.0.0.1":         2077456,
+		"1.0.1.0":         1814991,
+		"1.0.3.255":       1814991,
+		"1.0.15.255":      1814991,
+		"3.1.2.3":         6252001,
+		"2001:200::1":     1861060,
+		"2001:201:ffff::": 1861060,
+		"2001:208:1::":    1880251,
+	} {
+		v, err := s.Lookup(net.ParseIP(addr))
+		if err != nil || v != expected {
+			t.Fatalf("Wrong value for %s: %v, %v", addr, v, err)
+		}
+	}
+	// The gap before 1.0.8.0, and the proxy network without a value
+	for _, addr := range []string{"1.0.4.0", "2.1.1.1", "0.0.0.0"} {
+		if _, err := s.Lookup(net.ParseIP(addr)); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+			t.Fatalf("Expected an ErrOutOfRange for %s, got %v", addr, err)
+		}
+	}
+	if _, err := s.Lookup(net.ParseIP("2001:209::")); reflect.TypeOf(err).Name() != "ErrBigOutOfRange" {
+		t.Fatalf("Expected an ErrBigOutOfRange, got %v", err)
+	}
+	if _, err := s.Lookup(net.IP{1, 2}); reflect.TypeOf(err).Name() != "ErrInvalidIP" {
+		t.Fatalf("Expected an ErrInvalidIP, got %v", err)
+	}
+	// Adjacent networks with the same value are merged
+	if s.V4().Len() != 4 || len(s.V6().Ranges()) != 2 {
+		t.Fatalf("Ranges weren't merged: %v, %v", s.V4().Ranges(), s.V6().Ranges())
+	}
+}
+
+func TestLoadGeoIPCSV_Malformed(t *testing.T) {
+	_, err := LoadGeoIPCSV(GeoIPOptions{}, strings.NewReader("network,geoname_id\n1.0.0.0/33,1\n1.0.1.0/24,x\n1.0.2.0/24,3\n"))
+	if e, ok := err.(ErrMalformedCSV); !ok || len(e.Errors()) != 2 {
+		t.Fatalf("Expected an ErrMalformedCSV with 2 errors, got %v", err)
+	}
+	_, err = LoadGeoIPCSV(GeoIPOptions{}, strings.NewReader("network,country\n1.0.0.0/24,1\n"))
+	if reflect.TypeOf(err).Name() != "ErrMalformedRecord" {
+		t.Fatalf("Expected an ErrMalformedRecord, got %v", err)
+	}
+	_, err = LoadGeoIPCSV(GeoIPOptions{}, strings.NewReader("network,geoname_id\n1.0.0.0/16,1\n1.0.1.0/24,2\n"))
+	if reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected an ErrOverlap, got %v", err)
+	}
+	_, err = LoadGeoIPCSV(GeoIPOptions{}, strings.NewReader("network,geoname_id\n"))
+	if reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected an ErrEmptyInput, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * hashring/hrw.go: Weighted rendezvous hashing
+ */
+
+package hashring
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+type hrwNode struct {
+	id     uint64
+	weight float64
+	value  interface{}
+}
+
+// Picks a value for each key by weighted rendezvous, or highest random weight,
+// hashing: every value scores the key, and the highest score wins. Removing a
+// value only moves the keys it held, and adding one only takes keys from the
+// others, with no ring to rebuild; the cost is a hash per value on each pick.
+// Values receive keys in proportion to their weights.
+//
+// HRW is immutable, and so safe for concurrent use.
+type HRW struct {
+	nodes []hrwNode
+}
+
+// Creates a selector over the values of the items. Each value is identified by
+// its fmt.Sprint form, which should be unique, and is what keeps keys with the
+// same value as the items change. Items with a weight of zero receive no keys.
+func NewHRW(items []rangestore.Weighted) (*HRW, error) {
+	h := &HRW{nodes: make([]hrwNode, 0, len(items))}
+	for _, item := range items {
+		if item.GetWeight() == 0 {
+			continue
+		}
+		h.nodes = append(h.nodes, hrwNode{
+			id:     hash([]byte(fmt.Sprint(item.GetValue()))),
+			weight: float64(item.GetWeight()),
+			value:  item.GetValue(),
+		})
+	}
+	if len(h.nodes) < 1 {
+		return nil, ErrNoWeight
+	}
+	return h, nil
+}
+
+// Returns the value with the highest score for the key
+func (h *HRW) Pick(key []byte) interface{} {
+	kh := hash(key)
+	best, bestScore := 0, math.Inf(-1)
+	for i, n := range h.nodes {
+		if score := n.score(kh); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return h.nodes[best].value
+}
+
+// Scores the key as -weight / ln(u), with u uniform in (0, 1) for each key and
+// node, which makes the chance of the node scoring highest proportional to its
+// weight
+func (n hrwNode) score(key uint64) float64 {
+	// Combines the hashes with the MurmurHash3 finalizer, as in hash
+	h := key ^ n.id*0x9e3779b97f4a7c15
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	u := (float64(h>>11) + 0.5) / (1 << 53)
+	return -n.weight / math.Log(u)
+}
@@ -0,0 +1,93 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * hashring/hrw_test.go: Tests on weighted rendezvous hashing
+ */
+
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+func hrwAssignments(h *HRW, count int) map[string]string {
+	ret := make(map[string]string)
+	for i := 0; i < count; i += 1 {
+		key := "key-" + strconv.Itoa(i)
+		ret[key] = h.Pick([]byte(key)).(string)
+	}
+	return ret
+}
+
+func hrwItems(nodes ...WeightedNode) []rangestore.Weighted {
+	items := make([]rangestore.Weighted, 0)
+	for _, n := range nodes {
+		items = append(items, rangestore.DefaultWeightedValue{Weight: n.Weight, Value: n.Name})
+	}
+	return items
+}
+
+func TestHRW_Distribution(t *testing.T) {
+	h, err := NewHRW(hrwItems(WeightedNode{"a", 1}, WeightedNode{"b", 1}, WeightedNode{"c", 2}, WeightedNode{"z", 0}))
+	if err != nil {
+		t.Fatalf("Error while building selector: %s", err.Error())
+	}
+	counts := make(map[string]int)
+	for _, node := range hrwAssignments(h, 40000) {
+		counts[node] += 1
+	}
+	// Expect roughly 10000, 10000 and 20000, and none for z
+	for node, expected := range map[string]int{"a": 10000, "b": 10000, "c": 20000} {
+		if counts[node] < expected*9/10 || counts[node] > expected*11/10 {
+			t.Fatalf("Uneven distribution for %s: %d [%d]", node, counts[node], expected)
+		}
+	}
+	if counts["z"] != 0 {
+		t.Fatalf("Node without weight got %d keys", counts["z"])
+	}
+}
+
+func TestHRW_Membership(t *testing.T) {
+	nodes := []WeightedNode{{"a", 1}, {"b", 2}, {"c", 1}}
+	h, _ := NewHRW(hrwItems(nodes...))
+	before := hrwAssignments(h, 10000)
+
+	h, _ = NewHRW(hrwItems(append(nodes, WeightedNode{"d", 1})...))
+	for key, node := range hrwAssignments(h, 10000) {
+		// Keys only ever move to the new node
+		if node != before[key] && node != "d" {
+			t.Fatalf("Key %s moved from %s to %s", key, before[key], node)
+		}
+	}
+
+	h, _ = NewHRW(hrwItems(nodes[1:]...))
+	for key, node := range hrwAssignments(h, 10000) {
+		// Only the keys of the removed node move
+		if node != before[key] && before[key] != "a" {
+			t.Fatalf("Key %s moved from %s to %s", key, before[key], node)
+		}
+	}
+
+	if _, err := NewHRW(hrwItems(WeightedNode{"a", 0})); err != ErrNoWeight {
+		t.Fatalf("Expected ErrNoWeight, got %v", err)
+	}
+}
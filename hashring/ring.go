@@ -0,0 +1,170 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * hashring/ring.go: Consistent hashing ring
+ */
+
+// Package hashring maps keys to nodes by hashing them onto range stores covering
+// the entire 64 bit hash space, so finding the node for a key is one hash and
+// one search.
+//
+// A Ring places each node at a number of points proportional to its weight, with
+// each point owning the hash space since the previous point: consistent hashing.
+// A ShardRouter gives each shard a single contiguous range instead, sized in
+// proportion to its weight. An HRW needs no store at all, scoring every node for
+// each key instead.
+package hashring
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// A node of the ring. A node with weight 2 gets twice as many points on the ring
+// as one with weight 1, and so roughly twice the share of the keys.
+type WeightedNode struct {
+	Name   string
+	Weight uint64
+}
+
+type point struct {
+	hash uint64
+	node string
+}
+
+// A consistent hashing ring. Adding or removing a node only moves the keys
+// between that node and its neighbours on the ring; everything else stays put.
+//
+// Ring is safe for concurrent use.
+type Ring struct {
+	mu       sync.RWMutex
+	replicas int
+	points   []point
+	store    *rangestore.Node
+}
+
+// Creates a ring holding the nodes, each placed at replicas points per unit of
+// weight. More replicas spread the keys more evenly, at the cost of a larger
+// store.
+func NewRing(nodes []WeightedNode, replicas int) *Ring {
+	r := &Ring{replicas: replicas, points: make([]point, 0)}
+	for _, node := range nodes {
+		r.points = append(r.points, r.pointsFor(node)...)
+	}
+	r.rebuild()
+	return r
+}
+
+// Returns the name of the node owning the key, or an empty string if the ring
+// has no nodes
+func (r *Ring) GetNode(key []byte) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.store == nil {
+		return ""
+	}
+	// The store covers the entire hash space, so this can't fail
+	v, _ := r.store.RangeSearch(hash(key))
+	return v.(string)
+}
+
+// Adds a node to the ring, replacing any node with the same name
+func (r *Ring) AddNode(node WeightedNode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(node.Name)
+	r.points = append(r.points, r.pointsFor(node)...)
+	r.rebuild()
+}
+
+// Removes the node with the given name from the ring
+func (r *Ring) RemoveNode(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(name)
+	r.rebuild()
+}
+
+func (r *Ring) remove(name string) {
+	kept := r.points[:0]
+	for _, p := range r.points {
+		if p.node != name {
+			kept = append(kept, p)
+		}
+	}
+	r.points = kept
+}
+
+func (r *Ring) pointsFor(node WeightedNode) []point {
+	count := uint64(r.replicas) * node.Weight
+	ret := make([]point, 0, count)
+	for i := uint64(0); i < count; i += 1 {
+		ret = append(ret, point{hash([]byte(node.Name + "#" + strconv.FormatUint(i, 10))), node.Name})
+	}
+	return ret
+}
+
+// Rebuilds the store from the points. Each point owns the hash space after the
+// previous point, up to and including itself, and the first point also owns
+// everything after the last one, closing the ring.
+func (r *Ring) rebuild() {
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		return r.points[i].node < r.points[j].node
+	})
+	items := make([]rangestore.Ranged, 0, len(r.points)+1)
+	min := uint64(0)
+	for idx, p := range r.points {
+		// On the rare collision, the first node in name order keeps the point
+		if idx > 0 && p.hash == r.points[idx-1].hash {
+			continue
+		}
+		items = append(items, rangestore.RangeEntry{Min: min, Max: p.hash, Value: p.node})
+		min = p.hash + 1
+	}
+	if len(items) == 0 {
+		r.store = nil
+		return
+	}
+	last := items[len(items)-1].GetMax()
+	if last != ^uint64(0) {
+		items = append(items, rangestore.RangeEntry{Min: last + 1, Max: ^uint64(0), Value: r.points[0].node})
+	}
+	r.store, _ = rangestore.NewRangeStoreFromSorted(items)
+}
+
+// Hashes the key with FNV-1a, followed by the MurmurHash3 finalizer, since FNV
+// on its own spreads short, similar keys poorly across the high bits
+func hash(key []byte) uint64 {
+	f := fnv.New64a()
+	f.Write(key)
+	h := f.Sum64()
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,83 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * hashring/ring_test.go: Tests on the consistent hashing ring
+ */
+
+package hashring
+
+import (
+	"strconv"
+	"testing"
+)
+
+func assignments(r *Ring, count int) map[string]string {
+	ret := make(map[string]string)
+	for i := 0; i < count; i += 1 {
+		key := "key-" + strconv.Itoa(i)
+		ret[key] = r.GetNode([]byte(key))
+	}
+	return ret
+}
+
+func TestRing_Distribution(t *testing.T) {
+	r := NewRing([]WeightedNode{{"a", 1}, {"b", 1}, {"c", 2}}, 100)
+	counts := make(map[string]int)
+	for _, node := range assignments(r, 40000) {
+		counts[node] += 1
+	}
+	// Expect roughly 10000, 10000 and 20000
+	for node, expected := range map[string]int{"a": 10000, "b": 10000, "c": 20000} {
+		if counts[node] < expected*8/10 || counts[node] > expected*12/10 {
+			t.Fatalf("Uneven distribution for %s: %d [%d]", node, counts[node], expected)
+		}
+	}
+}
+
+func TestRing_AddRemove(t *testing.T) {
+	r := NewRing([]WeightedNode{{"a", 1}, {"b", 1}, {"c", 1}}, 100)
+	before := assignments(r, 10000)
+
+	r.AddNode(WeightedNode{"d", 1})
+	after := assignments(r, 10000)
+	for key, node := range after {
+		// Keys only ever move to the new node
+		if node != before[key] && node != "d" {
+			t.Fatalf("Key %s moved from %s to %s", key, before[key], node)
+		}
+	}
+
+	r.RemoveNode("d")
+	for key, node := range assignments(r, 10000) {
+		if node != before[key] {
+			t.Fatalf("Key %s didn't move back after removing the node", key)
+		}
+	}
+
+	r.RemoveNode("a")
+	r.RemoveNode("b")
+	r.RemoveNode("c")
+	if node := r.GetNode([]byte("key")); node != "" {
+		t.Fatalf("Expected no node from an empty ring, got %s", node)
+	}
+	r.AddNode(WeightedNode{"e", 1})
+	if node := r.GetNode([]byte("key")); node != "e" {
+		t.Fatalf("Expected the only node, got %s", node)
+	}
+}
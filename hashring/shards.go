@@ -0,0 +1,156 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * hashring/shards.go: Proportional shard routing with migration plans
+ */
+
+package hashring
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// A shard and its share of the hash space
+type ShardSpec struct {
+	Name   string
+	Weight uint64
+}
+
+// A range of hashes which moves from one shard to another
+type Move struct {
+	Min, Max uint64
+	From, To string
+}
+
+// Returned when the shards have no weight between them
+var ErrNoWeight = errors.New("Shards have no weight")
+
+// Routes keys to shards by splitting the 64 bit hash space into one contiguous
+// range per shard, sized in proportion to its weight. Unlike a Ring, changing
+// the weights moves whole ranges around, which makes it easy to plan the data
+// migration; see Plan.
+//
+// ShardRouter is safe for concurrent use.
+type ShardRouter struct {
+	mu    sync.RWMutex
+	store *rangestore.Node
+}
+
+// Creates a router over the shards, in the order given. Shards with a weight of
+// zero receive no keys.
+func NewShardRouter(shards []ShardSpec) (*ShardRouter, error) {
+	store, err := layoutShards(shards)
+	if err != nil {
+		return nil, err
+	}
+	return &ShardRouter{store: store}, nil
+}
+
+// Returns the name of the shard owning the key
+func (s *ShardRouter) Route(key []byte) string {
+	return s.RouteHash(hash(key))
+}
+
+// Returns the name of the shard owning the hash
+func (s *ShardRouter) RouteHash(h uint64) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// The store covers the entire hash space, so this can't fail
+	v, _ := s.store.RangeSearch(h)
+	return v.(string)
+}
+
+// Lists the ranges of hashes which would change shards if the router was
+// re-weighted to the given shards, in ascending order, without changing it
+func (s *ShardRouter) Plan(shards []ShardSpec) ([]Move, error) {
+	next, err := layoutShards(shards)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return moves(s.store, next), nil
+}
+
+// Switches the router to the given shards, returning the ranges of hashes which
+// changed shards
+func (s *ShardRouter) Reweight(shards []ShardSpec) ([]Move, error) {
+	next, err := layoutShards(shards)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := moves(s.store, next)
+	s.store = next
+	return ret, nil
+}
+
+// Lists the changes between two layouts, merging adjacent ones between the
+// same pair of shards
+func moves(old, next *rangestore.Node) []Move {
+	ret := make([]Move, 0)
+	for _, c := range rangestore.Diff(old, next) {
+		from, to := c.Old.(string), c.New.(string)
+		if last := len(ret) - 1; last >= 0 && ret[last].Max+1 == c.Min && ret[last].From == from && ret[last].To == to {
+			ret[last].Max = c.Max
+			continue
+		}
+		ret = append(ret, Move{c.Min, c.Max, from, to})
+	}
+	return ret
+}
+
+// Splits the hash space into contiguous ranges, one per shard with any weight.
+// Shard i ends just before floor(W_i * 2^64 / W), where W_i is the total weight
+// of the shards up to and including it, and W is the total of all of them.
+func layoutShards(shards []ShardSpec) (*rangestore.Node, error) {
+	total := new(big.Int)
+	for _, shard := range shards {
+		total.Add(total, new(big.Int).SetUint64(shard.Weight))
+	}
+	if total.Sign() == 0 {
+		return nil, ErrNoWeight
+	}
+
+	space := new(big.Int).Lsh(big.NewInt(1), 64)
+	cum := new(big.Int)
+	items := make([]rangestore.Ranged, 0, len(shards))
+	min := new(big.Int)
+	for _, shard := range shards {
+		if shard.Weight == 0 {
+			continue
+		}
+		cum.Add(cum, new(big.Int).SetUint64(shard.Weight))
+		end := new(big.Int).Mul(cum, space)
+		end.Quo(end, total)
+		if end.Cmp(min) <= 0 {
+			// Too light to get even a single hash
+			continue
+		}
+		max := new(big.Int).Sub(end, big.NewInt(1))
+		items = append(items, rangestore.RangeEntry{Min: min.Uint64(), Max: max.Uint64(), Value: shard.Name})
+		min = end
+	}
+	return rangestore.NewRangeStoreFromSorted(items)
+}
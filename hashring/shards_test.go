@@ -0,0 +1,83 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * hashring/shards_test.go: Tests on the shard router
+ */
+
+package hashring
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestShardRouter_Route(t *testing.T) {
+	s, err := NewShardRouter([]ShardSpec{{"a", 1}, {"b", 0}, {"c", 3}})
+	if err != nil {
+		t.Fatalf("Error while creating shard router: %s", err.Error())
+	}
+	for h, expected := range map[uint64]string{0: "a", 1<<62 - 1: "a", 1 << 62: "c", math.MaxUint64: "c"} {
+		if got := s.RouteHash(h); got != expected {
+			t.Fatalf("Wrong shard for %x: %s [%s]", h, got, expected)
+		}
+	}
+	if got := s.Route([]byte("key")); got != "a" && got != "c" {
+		t.Fatalf("Routed to an unexpected shard: %s", got)
+	}
+
+	if _, err := NewShardRouter([]ShardSpec{{"a", 0}}); err != ErrNoWeight {
+		t.Fatalf("Expected ErrNoWeight, got %v", err)
+	}
+}
+
+func TestShardRouter_Reweight(t *testing.T) {
+	s, _ := NewShardRouter([]ShardSpec{{"a", 1}, {"b", 1}})
+
+	plan, err := s.Plan([]ShardSpec{{"a", 1}, {"b", 1}, {"c", 2}})
+	if err != nil {
+		t.Fatalf("Error while planning: %s", err.Error())
+	}
+	expected := []Move{
+		{1 << 62, 1<<63 - 1, "a", "b"},
+		{1 << 63, math.MaxUint64, "b", "c"},
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Fatalf("Wrong plan: %v", plan)
+	}
+	if got := s.RouteHash(1 << 63); got != "b" {
+		t.Fatalf("Planning changed the router")
+	}
+
+	moves, err := s.Reweight([]ShardSpec{{"a", 1}, {"b", 1}, {"c", 2}})
+	if err != nil {
+		t.Fatalf("Error while re-weighting: %s", err.Error())
+	}
+	if !reflect.DeepEqual(moves, expected) {
+		t.Fatalf("Wrong moves: %v", moves)
+	}
+	if got := s.RouteHash(1 << 63); got != "c" {
+		t.Fatalf("Re-weighting didn't change the router")
+	}
+
+	moves, _ = s.Reweight([]ShardSpec{{"a", 1}, {"b", 1}, {"c", 2}})
+	if len(moves) != 0 {
+		t.Fatalf("Expected no moves without changes, got %v", moves)
+	}
+}
@@ -0,0 +1,112 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * histogram.go: Concurrent bucket counting
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// Counts observed values in buckets, e.g. to bucket latencies into "fast", "ok"
+// and "slow". Each bucket is a range of the underlying store, so an observation
+// costs a single search and an atomic increment.
+//
+// Histogram is safe for concurrent use.
+type Histogram struct {
+	counts  []uint64
+	buckets []HistogramBucket
+	store   *Node
+}
+
+// A bucket of a histogram, covering the values from Min to Max inclusive
+type HistogramBucket struct {
+	Min, Max uint64
+	Label    string
+	Count    uint64
+}
+
+// Creates a histogram with buckets ending at each of the upper bounds, which must
+// be strictly increasing, plus an overflow bucket for everything above the last
+// bound, unless it's already math.MaxUint64. The buckets are labelled by labels,
+// which must have one entry per bucket, or if none are given, by their upper
+// bounds, e.g. "<= 100", with "+Inf" for the overflow bucket.
+func NewHistogram(upper []uint64, labels ...string) (*Histogram, error) {
+	bounds := append([]uint64(nil), upper...)
+	if len(bounds) == 0 || bounds[len(bounds)-1] != math.MaxUint64 {
+		bounds = append(bounds, math.MaxUint64)
+	}
+	if len(labels) > 0 && len(labels) != len(bounds) {
+		return nil, ErrLengthMismatch{len(bounds), len(labels)}
+	}
+
+	h := &Histogram{
+		counts:  make([]uint64, len(bounds)),
+		buckets: make([]HistogramBucket, 0, len(bounds)),
+	}
+	items, err := bucketItems(bounds, func(idx int) interface{} {
+		return idx
+	})
+	if err != nil {
+		return nil, err
+	}
+	for idx, item := range items {
+		label := fmt.Sprintf("<= %d", item.GetMax())
+		if len(labels) > 0 {
+			label = labels[idx]
+		} else if idx == len(upper) {
+			label = "+Inf"
+		}
+		h.buckets = append(h.buckets, HistogramBucket{Min: item.GetMin(), Max: item.GetMax(), Label: label})
+	}
+	h.store, _ = rangeStoreFromSortedChecked(items, false, false)
+	return h, nil
+}
+
+// Counts the value in the bucket which covers it
+func (h *Histogram) Observe(value uint64) {
+	// The buckets cover every value, so this can't fail
+	idx, _ := h.store.RangeSearch(value)
+	atomic.AddUint64(&h.counts[idx.(int)], 1)
+}
+
+// Returns the buckets along with their current counts. The counts are read one
+// at a time, so observations made while taking the snapshot may only be partly
+// reflected.
+func (h *Histogram) Snapshot() []HistogramBucket {
+	ret := append([]HistogramBucket(nil), h.buckets...)
+	for idx := range ret {
+		ret[idx].Count = atomic.LoadUint64(&h.counts[idx])
+	}
+	return ret
+}
+
+// Sets every count back to zero
+func (h *Histogram) Reset() {
+	for idx := range h.counts {
+		atomic.StoreUint64(&h.counts[idx], 0)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * histogram_test.go: Tests on the histogram
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestHistogram_Basic(t *testing.T) {
+	h, err := NewHistogram([]uint64{10, 100}, "fast", "ok", "slow")
+	if err != nil {
+		t.Fatalf("Error while creating histogram: %s", err.Error())
+	}
+	for _, v := range []uint64{0, 5, 10, 11, 100, 101, math.MaxUint64} {
+		h.Observe(v)
+	}
+	expected := []HistogramBucket{
+		{0, 10, "fast", 3},
+		{11, 100, "ok", 2},
+		{101, math.MaxUint64, "slow", 2},
+	}
+	if got := h.Snapshot(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong snapshot: %v", got)
+	}
+
+	h.Reset()
+	for _, b := range h.Snapshot() {
+		if b.Count != 0 {
+			t.Fatalf("Count wasn't reset: %v", b)
+		}
+	}
+}
+
+func TestHistogram_Labels(t *testing.T) {
+	h, err := NewHistogram([]uint64{10, 100})
+	if err != nil {
+		t.Fatalf("Error while creating histogram: %s", err.Error())
+	}
+	labels := make([]string, 0)
+	for _, b := range h.Snapshot() {
+		labels = append(labels, b.Label)
+	}
+	if !reflect.DeepEqual(labels, []string{"<= 10", "<= 100", "+Inf"}) {
+		t.Fatalf("Wrong default labels: %v", labels)
+	}
+
+	h, err = NewHistogram([]uint64{10, math.MaxUint64})
+	if err != nil {
+		t.Fatalf("Error while creating histogram: %s", err.Error())
+	}
+	if len(h.Snapshot()) != 2 {
+		t.Fatalf("Expected no overflow bucket when the last bound is the maximum")
+	}
+
+	_, err = NewHistogram([]uint64{10, 100}, "fast", "slow")
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrLengthMismatch{}).Name() {
+		t.Fatalf("Expecting an ErrLengthMismatch, but got something else")
+	}
+	_, err = NewHistogram([]uint64{100, 100})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+}
+
+func TestHistogram_Concurrent(t *testing.T) {
+	h, _ := NewHistogram([]uint64{9})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := uint64(0); j < 1000; j += 1 {
+				h.Observe(j % 20)
+			}
+		}()
+	}
+	wg.Wait()
+	snap := h.Snapshot()
+	if snap[0].Count != 4000 || snap[1].Count != 4000 {
+		t.Fatalf("Wrong counts: %v", snap)
+	}
+}
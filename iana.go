@@ -0,0 +1,126 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * iana.go: Importing IANA port and AS number registries
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Builds a sparse store mapping port numbers to service names from the IANA
+// service name and port number registry, service-names-port-numbers.csv.
+// Only registrations for the protocol, such as "tcp" or "udp", are kept, and
+// rows without a service name or port, i.e. unassigned or reserved ports, are
+// skipped. Where registrations overlap, such as aliases for the same port, the
+// one starting first, or the first in the file, is kept. Malformed rows are
+// reported together in an ErrMalformedCSV.
+func LoadIANAPorts(r io.Reader, protocol string) (*Node, error) {
+	items := make([]Ranged, 0)
+	errs, err := readNamedCSV(r, []string{"Service Name", "Port Number", "Transport Protocol"}, func(field func(string) string) error {
+		name, port := field("Service Name"), field("Port Number")
+		if name == "" || port == "" || !strings.EqualFold(field("Transport Protocol"), protocol) {
+			return nil
+		}
+		min, max, err := parseIANARange(port, 65535)
+		if err != nil {
+			return err
+		}
+		items = append(items, RangeEntry{min, max, name})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, ErrMalformedCSV{errs}
+	}
+	return ianaStore(items, true)
+}
+
+// Builds a sparse store mapping AS numbers to the description of their
+// allocation, e.g. "Assigned by RIPE NCC", from the IANA 16 and 32 bit AS number
+// registries, as-numbers-1.csv and as-numbers-2.csv. Pass both files to cover
+// the whole number space. Malformed rows are reported together in an
+// ErrMalformedCSV, and overlapping blocks give an ErrOverlap.
+func LoadIANAASNs(files ...io.Reader) (*Node, error) {
+	items := make([]Ranged, 0)
+	errs := make([]error, 0)
+	for _, f := range files {
+		fileErrs, err := readNamedCSV(f, []string{"Number", "Description"}, func(field func(string) string) error {
+			min, max, err := parseIANARange(field("Number"), 1<<32-1)
+			if err != nil {
+				return err
+			}
+			items = append(items, RangeEntry{min, max, field("Description")})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, fileErrs...)
+	}
+	if len(errs) > 0 {
+		return nil, ErrMalformedCSV{errs}
+	}
+	return ianaStore(items, false)
+}
+
+// Parses a number, or an inclusive range of the form "min-max", up to limit
+func parseIANARange(s string, limit uint64) (min, max uint64, err error) {
+	lo, hi := s, s
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		lo, hi = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	}
+	if min, err = strconv.ParseUint(lo, 10, 64); err != nil || min > limit {
+		return 0, 0, fmt.Errorf("bad number %q", s)
+	}
+	if max, err = strconv.ParseUint(hi, 10, 64); err != nil || max > limit {
+		return 0, 0, fmt.Errorf("bad number %q", s)
+	}
+	if min > max {
+		return 0, 0, ErrInvertedRange{min, max}
+	}
+	return min, max, nil
+}
+
+// Sorts the items and builds a sparse store from them, first dropping any which
+// overlap an earlier one if dropOverlaps is set
+func ianaStore(items []Ranged, dropOverlaps bool) (*Node, error) {
+	SortRanged(items)
+	if dropOverlaps {
+		kept := items[:0]
+		for _, item := range items {
+			if len(kept) > 0 && item.GetMin() <= kept[len(kept)-1].GetMax() {
+				continue
+			}
+			kept = append(kept, item)
+		}
+		items = kept
+	}
+	return rangeStoreFromSortedChecked(items, true, true)
+}
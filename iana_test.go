@@ -0,0 +1,96 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * iana_test.go: Tests on the IANA registry importers
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ianaPorts = `Service Name,Port Number,Transport Protocol,Description,Assignee,Contact,Registration Date,Modification Date,Reference,Service Code,Unauthorized Use Reported,Assignment Notes
+,0,tcp,Reserved,[Jon_Postel],[Jon_Postel],,,,,,
+tcpmux,1,tcp,TCP Port Service Multiplexer,[Mark_Lottor],[Mark_Lottor],,,,,,
+tcpmux,1,udp,TCP Port Service Multiplexer,[Mark_Lottor],[Mark_Lottor],,,,,,
+http,80,tcp,World Wide Web HTTP,,,,,,,,"Defined TXT keys: u=<username> p=<password> path=<path to document>"
+www,80,tcp,World Wide Web HTTP,,,,,,,,"This is a duplicate of the ""http"" service"
+x11,6000-6063,tcp,X Window System,[Stephen_Sherman],[Stephen_Sherman],,,,,,
+,6064,tcp,Unassigned,,,,,,,,
+`
+
+const ianaASNs = `Number,Description,WHOIS,Reference,RDAP,Registration Date
+0,Reserved,,[RFC7607],,
+1-1876,Assigned by ARIN,whois.arin.net,,https://rdap.arin.net/registry/,
+1877-1901,Assigned by RIPE NCC,whois.ripe.net,,https://rdap.db.ripe.net/,
+`
+
+const ianaASNs32 = `Number,Description,WHOIS,Reference,RDAP,Registration Date
+65536-65551,Reserved for use in documentation and sample code,,[RFC5398],,
+131072-132095,Assigned by APNIC,whois.apnic.net,,https://rdap.apnic.net/,
+`
+
+func TestLoadIANAPorts(t *testing.T) {
+	n, err := LoadIANAPorts(strings.NewReader(ianaPorts), "tcp")
+	if err != nil {
+		t.Fatalf("Error while loading registry: %s", err.Error())
+	}
+	expected := []RangeEntry{{1, 1, "tcpmux"}, {80, 80, "http"}, {6000, 6063, "x11"}}
+	if !reflect.DeepEqual(n.Ranges(), expected) {
+		t.Fatalf("Wrong ranges: %v", n.Ranges())
+	}
+	if v, err := n.RangeSearch(6010); err != nil || v != "x11" {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+
+	_, err = LoadIANAPorts(strings.NewReader("Service Name,Port Number,Transport Protocol\nfoo,70000,tcp\nbar,9-8,tcp\n"), "tcp")
+	if e, ok := err.(ErrMalformedCSV); !ok || len(e.Errors()) != 2 {
+		t.Fatalf("Expected an ErrMalformedCSV with 2 errors, got %v", err)
+	}
+}
+
+func TestLoadIANAASNs(t *testing.T) {
+	n, err := LoadIANAASNs(strings.NewReader(ianaASNs32), strings.NewReader(ianaASNs))
+	if err != nil {
+		t.Fatalf("Error while loading registry: %s", err.Error())
+	}
+	for asn, expected := range map[uint64]string{
+		0:      "Reserved",
+		1880:   "Assigned by RIPE NCC",
+		65540:  "Reserved for use in documentation and sample code",
+		132000: "Assigned by APNIC",
+	} {
+		if v, err := n.RangeSearch(asn); err != nil || v != expected {
+			t.Fatalf("Wrong value for %d: %v, %v", asn, v, err)
+		}
+	}
+	if _, err := n.RangeSearch(1902); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected an ErrOutOfRange, got %v", err)
+	}
+	_, err = LoadIANAASNs(strings.NewReader("Number,Description\n1-10,A\n5-20,B\n"))
+	if reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected an ErrOverlap, got %v", err)
+	}
+}
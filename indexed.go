@@ -0,0 +1,91 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * indexed.go: Stores returning indices instead of values
+ */
+
+package rangestore
+
+import (
+	"sort"
+)
+
+// A store which maps keys to the index of the range containing them, leaving
+// the values to the caller, e.g. in a []T indexed the same way. Without the
+// interface{} values, each range takes just its 8 byte starting key, and lookups
+// don't box or type assert anything.
+type IndexedStore struct {
+	starts []uint64
+}
+
+// Creates an indexed store from the starting keys of consecutive ranges, in
+// ascending order. Range i covers the keys from boundaries[i] up to
+// boundaries[i+1]-1, and the last range runs to the largest uint64. Keys below
+// boundaries[0] aren't covered. The boundaries must be strictly increasing,
+// otherwise ErrOverlap is returned, and there must be fewer than 2^32 of them.
+func NewIndexedRangeStore(boundaries []uint64) (*IndexedStore, error) {
+	if len(boundaries) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	for idx := 1; idx < len(boundaries); idx += 1 {
+		if boundaries[idx] <= boundaries[idx-1] {
+			return nil, ErrOverlap{boundaries[idx-1], boundaries[idx]}
+		}
+	}
+	return &IndexedStore{starts: append([]uint64(nil), boundaries...)}, nil
+}
+
+// Returns the index of the range containing the key, or ErrOutOfRange if the
+// key is below the first boundary
+func (s *IndexedStore) SearchIndex(val uint64) (uint32, error) {
+	idx := sort.Search(len(s.starts), func(i int) bool {
+		return s.starts[i] > val
+	})
+	if idx == 0 {
+		return 0, ErrOutOfRange{val}
+	}
+	return uint32(idx - 1), nil
+}
+
+// Same as SearchIndex, returning the index as an interface{}, so that the store
+// can be used as a RangeSearcher
+func (s *IndexedStore) RangeSearch(val uint64) (interface{}, error) {
+	idx, err := s.SearchIndex(val)
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Returns the number of ranges
+func (s *IndexedStore) Len() int {
+	return len(s.starts)
+}
+
+// Returns the bounds of range i
+func (s *IndexedStore) Range(i uint32) (min, max uint64) {
+	min, max = s.starts[i], ^uint64(0)
+	if int(i)+1 < len(s.starts) {
+		max = s.starts[i+1] - 1
+	}
+	return min, max
+}
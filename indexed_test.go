@@ -0,0 +1,86 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * indexed_test.go: Tests on indexed stores
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestIndexedStore_SearchIndex(t *testing.T) {
+	s, err := NewIndexedRangeStore([]uint64{10, 20, 30})
+	if err != nil {
+		t.Fatalf("Error while constructing indexed store: %s", err.Error())
+	}
+	values := []string{"A", "B", "C"}
+	tests := map[uint64]string{10: "A", 19: "A", 20: "B", 29: "B", 30: "C", math.MaxUint64: "C"}
+	for key, expected := range tests {
+		idx, err := s.SearchIndex(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching: %s", err.Error())
+		}
+		if values[idx] != expected {
+			t.Fatalf("Wrong value for %d: %s", key, values[idx])
+		}
+	}
+	_, err = s.SearchIndex(9)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+	if v, err := s.RangeSearch(25); err != nil || v != uint32(1) {
+		t.Fatalf("Wrong index: %v", v)
+	}
+	if min, max := s.Range(1); min != 20 || max != 29 {
+		t.Fatalf("Wrong bounds: %d, %d", min, max)
+	}
+	if min, max := s.Range(2); min != 30 || max != math.MaxUint64 {
+		t.Fatalf("Wrong bounds: %d, %d", min, max)
+	}
+}
+
+func TestIndexedStore_Invalid(t *testing.T) {
+	_, err := NewIndexedRangeStore([]uint64{10, 20, 20})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+	_, err = NewIndexedRangeStore(nil)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+}
+
+func BenchmarkIndexedStore_SearchIndex(b *testing.B) {
+	boundaries := make([]uint64, 0, 1<<16)
+	for i := uint64(0); i < 1<<16; i += 1 {
+		boundaries = append(boundaries, i*10)
+	}
+	s, _ := NewIndexedRangeStore(boundaries)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.SearchIndex(uint64(i*7919) % (10 << 16))
+	}
+}
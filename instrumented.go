@@ -0,0 +1,95 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * instrumented.go: Lookup statistics and instrumentation
+ */
+
+package rangestore
+
+import (
+	"sync/atomic"
+)
+
+// Called after every lookup on an InstrumentedStore with the key searched for,
+// the depth of the node which terminated the search (the root is at depth 1),
+// and whether the key was found
+type Observer func(key uint64, depth int, hit bool)
+
+// Point in time lookup statistics of an InstrumentedStore
+type StoreStats struct {
+	Lookups  uint64
+	Misses   uint64
+	AvgDepth float64
+}
+
+// Wraps a range store, counting lookups, misses and search depths, and optionally
+// reporting every lookup to an Observer. Useful for exporting metrics and for
+// verifying that the tree is well balanced for real traffic.
+//
+// InstrumentedStore is safe for concurrent use, provided the observer is too.
+type InstrumentedStore struct {
+	lookups  uint64
+	misses   uint64
+	depth    uint64
+	root     *Node
+	observer Observer
+}
+
+// Creates an instrumented wrapper around root. The observer may be nil, in which
+// case only the built in counters are maintained.
+func NewInstrumentedStore(root *Node, observer Observer) *InstrumentedStore {
+	return &InstrumentedStore{root: root, observer: observer}
+}
+
+// Searches for the range which contains the specified key, recording statistics
+// about the lookup
+func (s *InstrumentedStore) RangeSearch(val uint64) (interface{}, error) {
+	v, depth, err := s.root.searchDepth(val, 1)
+	atomic.AddUint64(&s.lookups, 1)
+	atomic.AddUint64(&s.depth, uint64(depth))
+	if err != nil {
+		atomic.AddUint64(&s.misses, 1)
+	}
+	if s.observer != nil {
+		s.observer(val, depth, err == nil)
+	}
+	return v, err
+}
+
+// Returns the statistics gathered since construction or the last reset
+func (s *InstrumentedStore) Stats() StoreStats {
+	ret := StoreStats{
+		Lookups: atomic.LoadUint64(&s.lookups),
+		Misses:  atomic.LoadUint64(&s.misses),
+	}
+	if ret.Lookups > 0 {
+		ret.AvgDepth = float64(atomic.LoadUint64(&s.depth)) / float64(ret.Lookups)
+	}
+	return ret
+}
+
+// Zeroes all of the counters
+func (s *InstrumentedStore) ResetStats() {
+	atomic.StoreUint64(&s.lookups, 0)
+	atomic.StoreUint64(&s.misses, 0)
+	atomic.StoreUint64(&s.depth, 0)
+}
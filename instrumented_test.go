@@ -0,0 +1,113 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * instrumented_test.go: Tests on the instrumented wrapper
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstrumentedStore_Stats(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	observed := make([]int, 0)
+	s := NewInstrumentedStore(n, func(key uint64, depth int, hit bool) {
+		if key == 30 && hit {
+			t.Fatalf("Expected a miss to be reported for 30")
+		}
+		observed = append(observed, depth)
+	})
+
+	v, err := s.RangeSearch(15)
+	if err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	if v != "B" {
+		t.Fatalf("Got invalid value back %s [%s]", v, "B")
+	}
+	if _, err := s.RangeSearch(5); err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	if _, err := s.RangeSearch(30); err == nil {
+		t.Fatalf("Expected an error while performing an out of range search, got nothing")
+	}
+
+	// B is at the root, A and C are its children
+	if len(observed) != 3 || observed[0] != 1 || observed[1] != 2 || observed[2] != 2 {
+		t.Fatalf("Wrong depths observed: %v", observed)
+	}
+
+	stats := s.Stats()
+	if stats.Lookups != 3 {
+		t.Fatalf("Expected 3 lookups, got %d", stats.Lookups)
+	}
+	if stats.Misses != 1 {
+		t.Fatalf("Expected 1 miss, got %d", stats.Misses)
+	}
+	if stats.AvgDepth != 5.0/3.0 {
+		t.Fatalf("Wrong average depth: %f", stats.AvgDepth)
+	}
+
+	s.ResetStats()
+	if s.Stats() != (StoreStats{}) {
+		t.Fatalf("Expected empty stats after reset, got %v", s.Stats())
+	}
+}
+
+func TestInstrumentedStore_NilObserver(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	s := NewInstrumentedStore(n, nil)
+	if _, err := s.RangeSearch(9); err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	if s.Stats().Lookups != 1 {
+		t.Fatalf("Expected a single lookup to be counted")
+	}
+}
+
+func TestInstrumentedStore_NilRoot(t *testing.T) {
+	s := NewInstrumentedStore(nil, nil)
+	if _, err := s.RangeSearch(5); reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+		t.Fatalf("Expected ErrEmptyStore, got %v", err)
+	}
+	if stats := s.Stats(); stats.Lookups != 1 || stats.Misses != 1 {
+		t.Fatalf("Wrong stats after searching an empty store: %+v", stats)
+	}
+}
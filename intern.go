@@ -0,0 +1,124 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * intern.go: Sharing repeated values
+ */
+
+package rangestore
+
+import (
+	"reflect"
+)
+
+// Replaces the values of the ranges added to the builder with a single shared
+// instance of each distinct value, so that stores where many ranges hold equal
+// values, such as GeoIP data, keep one copy of each rather than one per range.
+// See InternValues.
+func WithValueInterning(hash func(v interface{}) uint64, eq func(a, b interface{}) bool) Option {
+	return func(o *options) {
+		o.intern = true
+		o.internHash = hash
+		o.internEq = eq
+	}
+}
+
+// Returns a copy of the items whose values are replaced by the first of the
+// values equal to them, so that equal values which were boxed separately share
+// a single copy. Values are bucketed by hash and compared with eq. A nil hash
+// puts every value in the same bucket, which is only practical for a handful of
+// distinct values, and a nil eq compares with reflect.DeepEqual. If both are nil
+// and every value is made of strings, numbers and the like, which == compares
+// by content, a map of the values themselves is used instead.
+func InternValues(items []Ranged, hash func(v interface{}) uint64, eq func(a, b interface{}) bool) []Ranged {
+	if hash == nil && eq == nil && allComparable(items) {
+		return internComparable(items)
+	}
+	if eq == nil {
+		eq = reflect.DeepEqual
+	}
+	buckets := make(map[uint64][]interface{})
+	ret := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		v := item.GetValue()
+		h := uint64(0)
+		if hash != nil {
+			h = hash(v)
+		}
+		canonical := v
+		found := false
+		for _, c := range buckets[h] {
+			if eq(c, v) {
+				canonical, found = c, true
+				break
+			}
+		}
+		if !found {
+			buckets[h] = append(buckets[h], v)
+		}
+		ret = append(ret, RangeEntry{item.GetMin(), item.GetMax(), canonical})
+	}
+	return ret
+}
+
+func allComparable(items []Ranged) bool {
+	for _, item := range items {
+		if v := item.GetValue(); v != nil && !safelyComparable(reflect.TypeOf(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether values of the type can be used as map keys without panicking,
+// and are compared by content rather than identity. That rules out interfaces,
+// as they may hold something which can't be, and pointers.
+func safelyComparable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return false
+	case reflect.Array:
+		return safelyComparable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i += 1 {
+			if !safelyComparable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return t.Comparable()
+}
+
+func internComparable(items []Ranged) []Ranged {
+	seen := make(map[interface{}]interface{})
+	ret := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		v := item.GetValue()
+		if c, ok := seen[v]; ok {
+			v = c
+		} else {
+			seen[v] = v
+		}
+		ret = append(ret, RangeEntry{item.GetMin(), item.GetMax(), v})
+	}
+	return ret
+}
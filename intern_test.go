@@ -0,0 +1,99 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * intern_test.go: Tests on value interning
+ */
+
+package rangestore
+
+import (
+	"hash/fnv"
+	"testing"
+)
+
+type location struct {
+	Country, City string
+}
+
+func TestInternValues(t *testing.T) {
+	items := make([]Ranged, 0)
+	for i := uint64(0); i < 100; i += 1 {
+		items = append(items, DefaultRangedValue{i * 10, i*10 + 9, &location{"US", []string{"NYC", "SFO"}[i%2]}})
+	}
+	hash := func(v interface{}) uint64 {
+		h := fnv.New64a()
+		l := v.(*location)
+		h.Write([]byte(l.Country + "/" + l.City))
+		return h.Sum64()
+	}
+	interned := InternValues(items, hash, nil)
+	if len(interned) != len(items) {
+		t.Fatalf("Wrong number of items: %d", len(interned))
+	}
+	distinct := make(map[*location]bool)
+	for idx, item := range interned {
+		l := item.GetValue().(*location)
+		if *l != *items[idx].GetValue().(*location) || item.GetMin() != items[idx].GetMin() {
+			t.Fatalf("Item %d changed: %v", idx, item)
+		}
+		distinct[l] = true
+	}
+	if len(distinct) != 2 {
+		t.Fatalf("Expected 2 distinct values, got %d", len(distinct))
+	}
+}
+
+func TestInternValues_Comparable(t *testing.T) {
+	items := make([]Ranged, 0)
+	for i := uint64(0); i < 10; i += 1 {
+		items = append(items, DefaultRangedValue{i * 10, i*10 + 9, location{"US", "NYC"}})
+	}
+	interned := InternValues(items, nil, nil)
+	for _, item := range interned {
+		if item.GetValue() != (location{"US", "NYC"}) {
+			t.Fatalf("Wrong value: %v", item.GetValue())
+		}
+	}
+
+	// Values which can't be map keys fall back to comparing them
+	items = append(items, DefaultRangedValue{100, 109, []string{"a"}}, DefaultRangedValue{110, 119, []string{"a"}})
+	interned = InternValues(items, nil, nil)
+	a, b := interned[10].GetValue().([]string), interned[11].GetValue().([]string)
+	if &a[0] != &b[0] {
+		t.Fatalf("Expected the slices to be shared")
+	}
+}
+
+func TestBuilder_WithValueInterning(t *testing.T) {
+	s, err := NewBuilder(WithValueInterning(nil, nil)).
+		Add(0, 9, &location{"US", "NYC"}).
+		Add(10, 19, &location{"US", "NYC"}).
+		Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	a, _ := s.RangeSearch(5)
+	b, _ := s.RangeSearch(15)
+	if a.(*location) != b.(*location) {
+		t.Fatalf("Expected the values to be shared")
+	}
+}
@@ -0,0 +1,201 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * interval.go: Interval tree for overlapping ranges
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A range carrying a priority, used to order (or resolve) overlapping ranges.
+// Higher priorities win.
+type Prioritized interface {
+	Ranged
+	GetPriority() int
+}
+type DefaultPrioritizedValue struct {
+	Min, Max uint64
+	Priority int
+	Value    interface{}
+}
+
+func (p DefaultPrioritizedValue) GetMin() uint64 {
+	return p.Min
+}
+func (p DefaultPrioritizedValue) GetMax() uint64 {
+	return p.Max
+}
+func (p DefaultPrioritizedValue) GetValue() interface{} {
+	return p.Value
+}
+func (p DefaultPrioritizedValue) GetPriority() int {
+	return p.Priority
+}
+
+type ErrInvertedRange struct {
+	min, max uint64
+}
+
+func (ex ErrInvertedRange) Error() string {
+	return fmt.Sprintf("Range minimum %d is greater than its maximum %d", ex.min, ex.max)
+}
+
+// Returns the bounds which were given
+func (ex ErrInvertedRange) Range() (min, max uint64) {
+	return ex.min, ex.max
+}
+
+func (ex ErrInvertedRange) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvertedRange", map[string]interface{}{"min": ex.min, "max": ex.max}}
+}
+
+// An interval tree which, unlike the strict range store, accepts overlapping
+// ranges and can return every range containing a key. The items don't need to
+// be sorted.
+type IntervalTree struct {
+	root  *intervalNode
+	count int
+}
+
+type intervalNode struct {
+	entry       Ranged
+	index       int
+	priority    int
+	maxEnd      uint64
+	left, right *intervalNode
+}
+
+// Builds an interval tree from the items, which may overlap and may be given
+// in any order. Items implementing Prioritized are ordered by their priority
+// in search results; all others have a priority of 0. Ranges of equal priority
+// are ordered as they come in items, so the first one wins, as it does in
+// NewRangeStoreFromPrioritized.
+func NewIntervalTree(items []Ranged) (*IntervalTree, error) {
+	if len(items) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	nodes := make([]*intervalNode, 0, len(items))
+	for idx, item := range items {
+		if item.GetMin() > item.GetMax() {
+			return nil, ErrInvertedRange{item.GetMin(), item.GetMax()}
+		}
+		n := &intervalNode{entry: item, index: idx}
+		if p, ok := item.(Prioritized); ok {
+			n.priority = p.GetPriority()
+		}
+		nodes = append(nodes, n)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].entry.GetMin() < nodes[j].entry.GetMin()
+	})
+	return &IntervalTree{root: buildInterval(nodes), count: len(items)}, nil
+}
+
+// Builds a balanced tree from nodes sorted by their minimum, annotating each
+// node with the largest maximum in its subtree
+func buildInterval(nodes []*intervalNode) *intervalNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	n := nodes[mid]
+	n.left = buildInterval(nodes[:mid])
+	n.right = buildInterval(nodes[mid+1:])
+	n.maxEnd = n.entry.GetMax()
+	if n.left != nil && n.left.maxEnd > n.maxEnd {
+		n.maxEnd = n.left.maxEnd
+	}
+	if n.right != nil && n.right.maxEnd > n.maxEnd {
+		n.maxEnd = n.right.maxEnd
+	}
+	return n
+}
+
+// Returns the number of ranges in the tree
+func (t *IntervalTree) Len() int {
+	return t.count
+}
+
+// Returns the values of every range containing val, ordered by descending
+// priority. Ranges with equal priority are returned in the order they were
+// given to NewIntervalTree. Returns an empty slice if nothing matches.
+func (t *IntervalTree) StabSearch(val uint64) []interface{} {
+	matches := t.stab(val)
+	ret := make([]interface{}, 0, len(matches))
+	for _, m := range matches {
+		ret = append(ret, m.entry.GetValue())
+	}
+	return ret
+}
+
+// Returns every range containing val, in the same order as StabSearch
+func (t *IntervalTree) StabSearchRanges(val uint64) []Ranged {
+	matches := t.stab(val)
+	ret := make([]Ranged, 0, len(matches))
+	for _, m := range matches {
+		ret = append(ret, m.entry)
+	}
+	return ret
+}
+
+// Returns the value of the highest priority range containing val, or
+// ErrOutOfRange if there is none
+func (t *IntervalTree) RangeSearch(val uint64) (interface{}, error) {
+	matches := t.stab(val)
+	if len(matches) == 0 {
+		return nil, ErrOutOfRange{val}
+	}
+	return matches[0].entry.GetValue(), nil
+}
+
+func (t *IntervalTree) stab(val uint64) []*intervalNode {
+	matches := make([]*intervalNode, 0)
+	t.root.stab(val, &matches)
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].priority != matches[j].priority {
+			return matches[i].priority > matches[j].priority
+		}
+		return matches[i].index < matches[j].index
+	})
+	return matches
+}
+
+func (n *intervalNode) stab(val uint64, matches *[]*intervalNode) {
+	// Nothing in this subtree reaches as far as val
+	if n == nil || n.maxEnd < val {
+		return
+	}
+	n.left.stab(val, matches)
+	// Everything to the right starts after this node, so if this one starts
+	// after val, so does everything to the right
+	if n.entry.GetMin() > val {
+		return
+	}
+	if val <= n.entry.GetMax() {
+		*matches = append(*matches, n)
+	}
+	n.right.stab(val, matches)
+}
@@ -0,0 +1,105 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * interval_test.go: Tests on the interval tree
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntervalTree_StabSearch(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 100, "default"})
+	items = append(items, DefaultRangedValue{50, 59, "office"})
+	items = append(items, DefaultRangedValue{10, 60, "campus"})
+	items = append(items, DefaultRangedValue{200, 299, "remote"})
+
+	tree, err := NewIntervalTree(items)
+	if err != nil {
+		t.Fatalf("Error while constructing interval tree: %s", err.Error())
+	}
+	if tree.Len() != 4 {
+		t.Fatalf("Expected 4 ranges, got %d", tree.Len())
+	}
+
+	expected := map[uint64][]interface{}{
+		5:   {"default"},
+		55:  {"default", "office", "campus"},
+		60:  {"default", "campus"},
+		250: {"remote"},
+		150: {},
+	}
+	for key, values := range expected {
+		if got := tree.StabSearch(key); !reflect.DeepEqual(got, values) {
+			t.Fatalf("Wrong values for %d: %v", key, got)
+		}
+	}
+	if got := tree.StabSearchRanges(60); !reflect.DeepEqual(got, []Ranged{items[0], items[2]}) {
+		t.Fatalf("Wrong ranges for 60: %v", got)
+	}
+
+	_, err = tree.RangeSearch(150)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+}
+
+func TestIntervalTree_Priority(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultPrioritizedValue{0, 100, 0, "default"})
+	items = append(items, DefaultPrioritizedValue{50, 59, 10, "office"})
+	items = append(items, DefaultPrioritizedValue{10, 60, 5, "campus"})
+
+	tree, err := NewIntervalTree(items)
+	if err != nil {
+		t.Fatalf("Error while constructing interval tree: %s", err.Error())
+	}
+	if got := tree.StabSearch(55); !reflect.DeepEqual(got, []interface{}{"office", "campus", "default"}) {
+		t.Fatalf("Wrong values ordering: %v", got)
+	}
+	v, err := tree.RangeSearch(20)
+	if err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	if v != "campus" {
+		t.Fatalf("Got invalid value back %s [%s]", v, "campus")
+	}
+}
+
+func TestIntervalTree_Invalid(t *testing.T) {
+	_, err := NewIntervalTree(make([]Ranged, 0))
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+
+	_, err = NewIntervalTree([]Ranged{DefaultRangedValue{10, 5, "A"}})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvertedRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvertedRange, but got something else")
+	}
+	if msg := err.Error(); msg != "Range minimum 10 is greater than its maximum 5" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+}
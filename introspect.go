@@ -0,0 +1,159 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * introspect.go: Tree shape introspection
+ */
+
+package rangestore
+
+import (
+	"unsafe"
+)
+
+// Reports whether the store holds no ranges, which is only the case for a nil
+// *Node. Every method can be called on a nil *Node: those which look up keys or
+// derive new stores return ErrEmptyStore, and the rest describe an empty store.
+func (n *Node) IsEmpty() bool {
+	return n == nil
+}
+
+// Returns the depth of the deepest node in the tree, counting the root as 1, or
+// 0 for an empty store
+func (n *Node) Depth() int {
+	if n == nil {
+		return 0
+	}
+	l, r := 0, 0
+	if n.left != nil {
+		l = n.left.Depth()
+	}
+	if n.right != nil {
+		r = n.right.Depth()
+	}
+	if l > r {
+		return l + 1
+	}
+	return r + 1
+}
+
+// Returns the number of ranges held in the store
+func (n *Node) Len() int {
+	count := 0
+	n.walk(func(*Node) {
+		count += 1
+	})
+	return count
+}
+
+// Returns the smallest and largest keys covered by the store, or zeroes for an
+// empty store
+func (n *Node) Bounds() (min, max uint64) {
+	if n == nil {
+		return 0, 0
+	}
+	lft := n
+	for lft.left != nil {
+		lft = lft.left
+	}
+	rht := n
+	for rht.right != nil {
+		rht = rht.right
+	}
+	return lft.min, rht.max
+}
+
+// Returns the smallest key of the range held by this node alone. Bounds returns
+// the smallest key of the whole subtree instead.
+func (n *Node) Min() uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.min
+}
+
+// Returns the largest key of the range held by this node alone
+func (n *Node) Max() uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.max
+}
+
+// Returns the value of the range held by this node
+func (n *Node) Value() interface{} {
+	if n == nil {
+		return nil
+	}
+	return n.value
+}
+
+// Returns the subtree holding the ranges below this node's, or nil if there are
+// none. The tree must not be modified through it.
+func (n *Node) Left() *Node {
+	if n == nil {
+		return nil
+	}
+	return n.left
+}
+
+// Returns the subtree holding the ranges above this node's, or nil if there are
+// none. The tree must not be modified through it.
+func (n *Node) Right() *Node {
+	if n == nil {
+		return nil
+	}
+	return n.right
+}
+
+// Implemented by values which can report how much memory they reference, so that
+// it's included in MemoryFootprint
+type Sizer interface {
+	// Returns the approximate number of bytes referenced by the value, not
+	// counting the interface holding it
+	Size() uintptr
+}
+
+// Returns the approximate number of bytes used by the nodes of the tree, plus
+// those reported by values implementing Sizer. Other values are opaque, so the
+// memory they reference isn't included; see MemoryFootprintFunc.
+func (n *Node) MemoryFootprint() uintptr {
+	return n.MemoryFootprintFunc(sizerSize)
+}
+
+// Same as MemoryFootprint, but adds the number of bytes size returns for each
+// value. Values held by several ranges are counted once per range, so values
+// shared between ranges are overestimated.
+func (n *Node) MemoryFootprintFunc(size func(v interface{}) uintptr) uintptr {
+	total := uintptr(0)
+	n.walk(func(c *Node) {
+		total += unsafe.Sizeof(Node{}) + size(c.value)
+	})
+	return total
+}
+
+// Returns the size reported by values implementing Sizer, and 0 for others
+func sizerSize(v interface{}) uintptr {
+	if s, ok := v.(Sizer); ok {
+		return s.Size()
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * introspect_test.go: Tests on tree shape introspection
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestNode_Introspection(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{5, 7, "A"})
+	items = append(items, DefaultRangedValue{8, 9, "B"})
+	items = append(items, DefaultRangedValue{10, 29, "C"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	//-C [max: 29]
+	// |-A [max: 7]
+	// | !-B [max: 9]
+	if d := n.Depth(); d != 3 {
+		t.Fatalf("Expected a depth of 3, got %d", d)
+	}
+	if l := n.Len(); l != 3 {
+		t.Fatalf("Expected a length of 3, got %d", l)
+	}
+	min, max := n.Bounds()
+	if min != 5 || max != 29 {
+		t.Fatalf("Expected bounds of [5, 29], got [%d, %d]", min, max)
+	}
+	if m := n.MemoryFootprint(); m != 3*unsafe.Sizeof(Node{}) {
+		t.Fatalf("Wrong memory footprint: %d", m)
+	}
+}
+
+func TestNode_IntrospectionSingle(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	if n.Depth() != 1 || n.Len() != 1 {
+		t.Fatalf("Expected a single node tree")
+	}
+	min, max := n.Bounds()
+	if min != 0 || max != 9 {
+		t.Fatalf("Expected bounds of [0, 9], got [%d, %d]", min, max)
+	}
+}
+
+func TestNode_Nil(t *testing.T) {
+	var n *Node
+	if !n.IsEmpty() || n.Len() != 0 || n.Depth() != 0 || n.String() != "" || len(n.Ranges()) != 0 {
+		t.Fatalf("Expected a nil store to describe itself as empty")
+	}
+	if min, max := n.Bounds(); min != 0 || max != 0 {
+		t.Fatalf("Wrong bounds for a nil store: [%d, %d]", min, max)
+	}
+	if n.CoveredCount() != 0 || n.Clone() != nil || n.CheckInvariants() != nil || n.Stats().Nodes != 0 {
+		t.Fatalf("Expected a nil store to have no contents")
+	}
+
+	expectEmpty := func(name string, err error) {
+		if err == nil || reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+			t.Fatalf("Expected ErrEmptyStore from %s, got %v", name, err)
+		}
+	}
+	_, err := n.RangeSearch(5)
+	expectEmpty("RangeSearch", err)
+	_, err = n.Explain(5)
+	expectEmpty("Explain", err)
+	_, _, err = n.SearchNearest(5)
+	expectEmpty("SearchNearest", err)
+	_, err = n.NextRange(5)
+	expectEmpty("NextRange", err)
+	_, err = n.SelectKey(0)
+	expectEmpty("SelectKey", err)
+	_, err = n.Slice(0, 10)
+	expectEmpty("Slice", err)
+	_, _, err = n.Split(5)
+	expectEmpty("Split", err)
+	_, err = n.Shift(1)
+	expectEmpty("Shift", err)
+	expectEmpty("Append", n.Append(DefaultRangedValue{0, 1, "A"}))
+
+	var buf bytes.Buffer
+	if err := n.DOT(&buf); err != nil {
+		t.Fatalf("Error while writing DOT: %s", err.Error())
+	}
+	if text, err := n.MarshalText(); err != nil || len(text) != 0 {
+		t.Fatalf("Expected no text for a nil store")
+	}
+
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{5, 7, "A"})
+	n, _ = NewRangeStoreFromSorted(items)
+	if n.IsEmpty() {
+		t.Fatalf("Expected a store with a range not to be empty")
+	}
+}
+
+func TestNode_Accessors(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+	n, _ := NewRangeStoreFromSorted(items)
+
+	if n.Min() != 10 || n.Max() != 19 || n.Value() != "B" {
+		t.Fatalf("Wrong root: [%d, %d] %v", n.Min(), n.Max(), n.Value())
+	}
+	if l := n.Left(); l.Min() != 0 || l.Max() != 9 || l.Value() != "A" || l.Left() != nil || l.Right() != nil {
+		t.Fatalf("Wrong left child")
+	}
+	if r := n.Right(); r.Min() != 20 || r.Max() != 29 || r.Value() != "C" {
+		t.Fatalf("Wrong right child")
+	}
+
+	var empty *Node
+	if empty.Min() != 0 || empty.Max() != 0 || empty.Value() != nil || empty.Left() != nil || empty.Right() != nil {
+		t.Fatalf("Expected zero values from a nil node")
+	}
+}
+
+type sizedValue []byte
+
+func (v sizedValue) Size() uintptr {
+	return uintptr(cap(v))
+}
+
+func TestNode_MemoryFootprintSizer(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, sizedValue(make([]byte, 1000))})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	n, _ := NewRangeStoreFromSorted(items)
+	if m := n.MemoryFootprint(); m != 2*unsafe.Sizeof(Node{})+1000 {
+		t.Fatalf("Wrong memory footprint: %d", m)
+	}
+	strlen := func(v interface{}) uintptr {
+		if s, ok := v.(string); ok {
+			return uintptr(len(s))
+		}
+		return 0
+	}
+	if m := n.MemoryFootprintFunc(strlen); m != 2*unsafe.Sizeof(Node{})+1 {
+		t.Fatalf("Wrong memory footprint from the size function: %d", m)
+	}
+}
@@ -0,0 +1,164 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * invariants.go: Verifying the structure of built trees
+ */
+
+package rangestore
+
+import (
+	"fmt"
+)
+
+type ErrInvariant struct {
+	reason string
+}
+
+func (ex ErrInvariant) Error() string {
+	return fmt.Sprintf("Invariant violated: %s", ex.reason)
+}
+
+// Returns the invariant which doesn't hold
+func (ex ErrInvariant) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrInvariant) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvariant", map[string]interface{}{"reason": ex.reason}}
+}
+
+// Verifies the structural invariants of the tree, returning the first violation
+// found, or nil. Checks that
+//
+//   - every range has its minimum no greater than its maximum (ErrInvertedRange)
+//   - the ranges are strictly ascending along the in order walk (ErrOverlap)
+//   - no node holds a nil value (ErrInvariant)
+//   - every node lies within the key interval its ancestors route to it, so
+//     that searching for either bound of a range finds it (ErrInvariant)
+//
+// Gaps between ranges are allowed, as the tree doesn't record whether it was
+// built as a sparse store; RangeStore.CheckInvariants also checks continuity
+// for stores which weren't built with AllowGaps. Meant for fuzzers and tests
+// validating trees built or modified through any code path.
+func (n *Node) CheckInvariants() error {
+	if n == nil {
+		return nil
+	}
+	if err := n.checkRouting(0, ^uint64(0)); err != nil {
+		return err
+	}
+	var prev *Node
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		if prev != nil && c.min <= prev.max {
+			err = ErrOverlap{prev.max, c.min}
+			return
+		}
+		prev = c
+		for _, key := range []uint64{c.min, c.max} {
+			if found := n.find(key); found != c {
+				err = ErrInvariant{fmt.Sprintf("key %d doesn't resolve to its range [%d, %d]", key, c.min, c.max)}
+				return
+			}
+		}
+	})
+	return err
+}
+
+// Checks that every node of the subtree is well formed and lies within [lo, hi]
+func (n *Node) checkRouting(lo, hi uint64) error {
+	if n.min > n.max {
+		return ErrInvertedRange{n.min, n.max}
+	}
+	if n.value == nil {
+		return ErrInvariant{fmt.Sprintf("range [%d, %d] has a nil value", n.min, n.max)}
+	}
+	if n.min < lo || n.max > hi {
+		return ErrInvariant{fmt.Sprintf("range [%d, %d] is outside [%d, %d] where its ancestors place it", n.min, n.max, lo, hi)}
+	}
+	if n.left != nil {
+		if n.min == 0 {
+			return ErrInvariant{fmt.Sprintf("range [%d, %d] has a left child but no keys below it", n.min, n.max)}
+		}
+		if err := n.left.checkRouting(lo, n.min-1); err != nil {
+			return err
+		}
+	}
+	if n.right != nil {
+		if n.max == ^uint64(0) {
+			return ErrInvariant{fmt.Sprintf("range [%d, %d] has a right child but no keys above it", n.min, n.max)}
+		}
+		if err := n.right.checkRouting(n.max+1, hi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the node a search for the key ends at, or nil if it isn't covered
+func (n *Node) find(key uint64) *Node {
+	for n != nil {
+		if n.max < key {
+			n = n.right
+		} else if n.min > key {
+			n = n.left
+		} else {
+			return n
+		}
+	}
+	return nil
+}
+
+// Verifies the invariants of the tree as (*Node).CheckInvariants does, along with
+// the continuity of stores built without AllowGaps, and the store metadata
+func (s *RangeStore) CheckInvariants() error {
+	if err := s.root.CheckInvariants(); err != nil {
+		return err
+	}
+	count, span := 0, uint64(0)
+	var prev *Node
+	var err error
+	s.root.walk(func(c *Node) {
+		count += 1
+		span += (c.max - c.min) + 1
+		if err == nil && prev != nil && !s.opts.gaps && c.min != prev.max+1 {
+			err = ErrDiscontinuity{prev.max, c.min}
+		}
+		prev = c
+	})
+	if err != nil {
+		return err
+	}
+	if count != s.count {
+		return ErrInvariant{fmt.Sprintf("store records %d ranges, but the tree holds %d", s.count, count)}
+	}
+	if span != s.span {
+		return ErrInvariant{fmt.Sprintf("store records a span of %d, but the tree spans %d", s.span, span)}
+	}
+	if min, max := s.root.Bounds(); min != s.min || max != s.max {
+		return ErrInvariant{fmt.Sprintf("store records bounds [%d, %d], but the tree covers [%d, %d]", s.min, s.max, min, max)}
+	}
+	return nil
+}
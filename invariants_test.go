@@ -0,0 +1,103 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * invariants_test.go: Tests on invariant checking
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_CheckInvariants(t *testing.T) {
+	items := make([]Ranged, 0)
+	for i := uint64(0); i < 100; i += 1 {
+		items = append(items, DefaultRangedValue{i * 10, i*10 + 9, i})
+	}
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	if err := n.CheckInvariants(); err != nil {
+		t.Fatalf("Valid tree failed the check: %s", err.Error())
+	}
+	sparse, _ := NewSparseRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{20, ^uint64(0), "B"}})
+	if err := sparse.CheckInvariants(); err != nil {
+		t.Fatalf("Valid sparse tree failed the check: %s", err.Error())
+	}
+}
+
+func TestNode_CheckInvariantsBroken(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     *Node
+		expected error
+	}{
+		{"inverted", &Node{min: 9, max: 0, value: "A"}, ErrInvertedRange{}},
+		{"nil value", &Node{min: 0, max: 9}, ErrInvariant{}},
+		{"misplaced", &Node{min: 10, max: 19, value: "B", left: &Node{min: 20, max: 29, value: "C"}}, ErrInvariant{}},
+		{"misplaced deep", &Node{min: 10, max: 19, value: "B",
+			left: &Node{min: 0, max: 9, value: "A", right: &Node{min: 25, max: 29, value: "C"}}}, ErrInvariant{}},
+		{"nothing below", &Node{min: 0, max: 9, value: "A", left: &Node{min: 0, max: 0, value: "B"}}, ErrInvariant{}},
+	}
+	for _, test := range tests {
+		err := test.root.CheckInvariants()
+		if err == nil {
+			t.Fatalf("%s: expected the check to fail", test.name)
+		}
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(test.expected).Name() {
+			t.Fatalf("%s: expecting an %s, but got %s", test.name, reflect.TypeOf(test.expected).Name(), err.Error())
+		}
+	}
+}
+
+func TestRangeStore_CheckInvariants(t *testing.T) {
+	s, err := NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Add(20, 29, "C").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	if err := s.CheckInvariants(); err != nil {
+		t.Fatalf("Valid store failed the check: %s", err.Error())
+	}
+	if err := s.Append(DefaultRangedValue{30, 39, "D"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if err := s.CheckInvariants(); err != nil {
+		t.Fatalf("Store failed the check after appending: %s", err.Error())
+	}
+
+	// A gap the store wasn't built to allow
+	s.root.find(39).max = 35
+	s.root.find(30).right = &Node{min: 37, max: 39, value: "E"}
+	err = s.CheckInvariants()
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDiscontinuity{}).Name() {
+		t.Fatalf("Expecting an ErrDiscontinuity, but got something else")
+	}
+
+	s.count += 1
+	s.opts.gaps = true
+	if err := s.CheckInvariants(); err == nil {
+		t.Fatalf("Expected mismatching metadata to fail the check")
+	}
+}
@@ -0,0 +1,163 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * keyspace.go: Rendering the key space as SVG or HTML
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+)
+
+const (
+	defaultKeyspaceWidth  = 1000
+	defaultKeyspaceHeight = 40
+)
+
+// Controls how KeyspaceSVG and KeyspaceHTML render a store. The zero value
+// renders a 1000 by 40 band with colors derived from the values.
+type KeyspaceOptions struct {
+	// The size of the band in pixels. Hits, if any, are drawn in a second band
+	// of the same size below it.
+	Width, Height int
+	// Returns the CSS color of a value. By default the hue is picked from a hash
+	// of the value printed with %v, so equal values get the same color.
+	Color func(v interface{}) string
+	// Formats values for the tooltips, %v if nil
+	Format func(v interface{}) string
+	// Hit counts to overlay, e.g. from AdaptiveStore.HitCounts. Each count is
+	// drawn under the range with the same bounds, scaled to the largest one.
+	Hits []RangeHit
+	// The title of the HTML page
+	Title string
+}
+
+// Writes an SVG image of the key space of the store, from its smallest to its
+// largest key, as a band with one rectangle per range. Widths are proportional
+// to the number of keys, and gaps are left blank, so skewed ranges and missing
+// keys stand out. Each rectangle has a tooltip with its bounds and value.
+func (n *Node) KeyspaceSVG(w io.Writer, opts KeyspaceOptions) error {
+	if n == nil {
+		return ErrEmptyStore{}
+	}
+	if opts.Width <= 0 {
+		opts.Width = defaultKeyspaceWidth
+	}
+	if opts.Height <= 0 {
+		opts.Height = defaultKeyspaceHeight
+	}
+	if opts.Color == nil {
+		opts.Color = valueColor
+	}
+	if opts.Format == nil {
+		opts.Format = func(v interface{}) string { return fmt.Sprint(v) }
+	}
+	hits := make(map[[2]uint64]uint64, len(opts.Hits))
+	peak := uint64(0)
+	for _, h := range opts.Hits {
+		hits[[2]uint64{h.Entry.Min, h.Entry.Max}] += h.Count
+	}
+	for _, count := range hits {
+		if count > peak {
+			peak = count
+		}
+	}
+
+	lo, hi := n.Bounds()
+	span := float64(hi-lo) + 1
+	x := func(k uint64) float64 {
+		return float64(k-lo) / span * float64(opts.Width)
+	}
+	height := opts.Height
+	if len(opts.Hits) > 0 {
+		height *= 2
+	}
+	ew := &errWriter{w: w}
+	fmt.Fprintf(ew, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", opts.Width, height, opts.Width, height)
+	n.walk(func(c *Node) {
+		x0, x1 := x(c.min), x(c.max)+float64(opts.Width)/span
+		title := fmt.Sprintf("[%d, %d] %s", c.min, c.max, opts.Format(c.value))
+		count, hit := hits[[2]uint64{c.min, c.max}]
+		if hit {
+			title += fmt.Sprintf(" (%d hits)", count)
+		}
+		fmt.Fprintf(ew, "<rect x=\"%g\" y=\"0\" width=\"%g\" height=\"%d\" fill=\"%s\"><title>%s</title></rect>\n",
+			x0, x1-x0, opts.Height, html.EscapeString(opts.Color(c.value)), html.EscapeString(title))
+		if hit && peak > 0 {
+			bar := float64(count) / float64(peak) * float64(opts.Height)
+			fmt.Fprintf(ew, "<rect x=\"%g\" y=\"%g\" width=\"%g\" height=\"%g\" fill=\"#444\"><title>%d hits</title></rect>\n",
+				x0, float64(height)-bar, x1-x0, bar, count)
+		}
+	})
+	fmt.Fprint(ew, "</svg>\n")
+	return ew.err
+}
+
+// Writes a standalone HTML page showing the SVG from KeyspaceSVG, along with
+// the bounds of the store and its number of ranges
+func (n *Node) KeyspaceHTML(w io.Writer, opts KeyspaceOptions) error {
+	if n == nil {
+		return ErrEmptyStore{}
+	}
+	title := opts.Title
+	if title == "" {
+		title = "Range store key space"
+	}
+	lo, hi := n.Bounds()
+	ew := &errWriter{w: w}
+	fmt.Fprintf(ew, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", html.EscapeString(title))
+	fmt.Fprintf(ew, "<h1>%s</h1>\n<p>%d ranges covering [%d, %d]</p>\n", html.EscapeString(title), n.Len(), lo, hi)
+	if ew.err != nil {
+		return ew.err
+	}
+	if err := n.KeyspaceSVG(w, opts); err != nil {
+		return err
+	}
+	fmt.Fprint(ew, "</body>\n</html>\n")
+	return ew.err
+}
+
+// Picks a hue from a hash of the printed value
+func valueColor(v interface{}) string {
+	h := fnv.New32a()
+	fmt.Fprint(h, v)
+	return fmt.Sprintf("hsl(%d, 65%%, 55%%)", h.Sum32()%360)
+}
+
+// Remembers the first error, so that a sequence of writes can be checked once
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	var n int
+	n, e.err = e.w.Write(p)
+	return n, e.err
+}
@@ -0,0 +1,86 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * keyspace_test.go: Tests on the key space rendering
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNode_KeyspaceSVG(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 49, "A"})
+	items = append(items, DefaultRangedValue{50, 74, "<B>"})
+	items = append(items, DefaultRangedValue{90, 99, "A"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+
+	var buf bytes.Buffer
+	opts := KeyspaceOptions{Width: 100, Height: 10, Hits: []RangeHit{{RangeEntry{0, 49, "A"}, 2}, {RangeEntry{90, 99, "A"}, 8}}}
+	if err := n.KeyspaceSVG(&buf, opts); err != nil {
+		t.Fatalf("Error while rendering: %s", err.Error())
+	}
+	svg := buf.String()
+	if err := xml.Unmarshal(buf.Bytes(), new(interface{})); err != nil {
+		t.Fatalf("Invalid SVG: %s\n%s", err.Error(), svg)
+	}
+	for _, expected := range []string{
+		`height="20"`,
+		`<rect x="0" y="0" width="50" height="10"`,
+		`<rect x="50" y="0" width="25" height="10"`,
+		`<title>[50, 74] &lt;B&gt;</title>`,
+		`<title>[90, 99] A (8 hits)</title>`,
+		// The largest count fills the hit band, the others are scaled to it
+		`<rect x="90" y="10" width="10" height="10" fill="#444">`,
+		`<rect x="0" y="17.5" width="50" height="2.5" fill="#444">`,
+	} {
+		if !strings.Contains(svg, expected) {
+			t.Fatalf("Expected %s in:\n%s", expected, svg)
+		}
+	}
+	if strings.Contains(svg, "x=\"75\"") {
+		t.Fatalf("Expected the gap to be left blank:\n%s", svg)
+	}
+	if valueColor("A") != valueColor("A") || valueColor("A") == valueColor("<B>") {
+		t.Fatalf("Expected colors to follow the values")
+	}
+
+	buf.Reset()
+	if err := n.KeyspaceHTML(&buf, KeyspaceOptions{Title: "Zips"}); err != nil {
+		t.Fatalf("Error while rendering: %s", err.Error())
+	}
+	page := buf.String()
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") || !strings.Contains(page, "<h1>Zips</h1>\n<p>3 ranges covering [0, 99]</p>") || !strings.Contains(page, `width="1000" height="40"`) {
+		t.Fatalf("Wrong page:\n%s", page)
+	}
+
+	var empty *Node
+	if err := empty.KeyspaceSVG(&buf, KeyspaceOptions{}); reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+		t.Fatalf("Expected ErrEmptyStore, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * layered.go: Layered stores with a fallback chain
+ */
+
+package rangestore
+
+import (
+	"sync/atomic"
+)
+
+// A stack of stores consulted in order until one of them covers the key. Put
+// overrides first and defaults last; a layer can be replaced without rebuilding
+// or merging the others, which makes this cheaper than Merge when overlays
+// change frequently.
+//
+// LayeredStore is safe for concurrent use, including replacing layers.
+type LayeredStore struct {
+	layers atomic.Value
+}
+
+// Creates a layered store, with the first layer taking precedence
+func NewLayeredStore(layers ...RangeSearcher) *LayeredStore {
+	s := &LayeredStore{}
+	s.layers.Store(append([]RangeSearcher(nil), layers...))
+	return s
+}
+
+// Searches each layer in turn, returning the value from the first layer which
+// covers the key. A layer returning an error other than ErrOutOfRange stops the
+// search and the error is returned. If no layer covers the key, ErrOutOfRange
+// is returned.
+func (s *LayeredStore) RangeSearch(val uint64) (interface{}, error) {
+	for _, layer := range s.layers.Load().([]RangeSearcher) {
+		v, err := layer.RangeSearch(val)
+		if err == nil {
+			return v, nil
+		}
+		if _, ok := err.(ErrOutOfRange); !ok {
+			return nil, err
+		}
+	}
+	return nil, ErrOutOfRange{val}
+}
+
+// Returns the number of layers
+func (s *LayeredStore) Len() int {
+	return len(s.layers.Load().([]RangeSearcher))
+}
+
+// Replaces the layer at index i. Lookups already in progress finish against the
+// previous set of layers.
+func (s *LayeredStore) ReplaceLayer(i int, layer RangeSearcher) {
+	layers := append([]RangeSearcher(nil), s.layers.Load().([]RangeSearcher)...)
+	layers[i] = layer
+	s.layers.Store(layers)
+}
@@ -0,0 +1,81 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * layered_test.go: Tests on layered stores
+ */
+
+package rangestore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLayeredStore(t *testing.T) {
+	base, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 99, "default"}})
+	overlay, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{10, 19, "tenant"},
+		DefaultRangedValue{150, 159, "extra"},
+	})
+
+	s := NewLayeredStore(overlay, base)
+	if s.Len() != 2 {
+		t.Fatalf("Expected 2 layers, got %d", s.Len())
+	}
+	for key, expected := range map[uint64]string{5: "default", 15: "tenant", 155: "extra"} {
+		v, err := s.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back %s [%s]", v, expected)
+		}
+	}
+	_, err := s.RangeSearch(120)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+
+	replacement, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "new"}})
+	s.ReplaceLayer(0, replacement)
+	if v, _ := s.RangeSearch(5); v != "new" {
+		t.Fatalf("Expected the replaced layer to be consulted, got %v", v)
+	}
+	if v, _ := s.RangeSearch(15); v != "default" {
+		t.Fatalf("Expected the old overlay to be gone, got %v", v)
+	}
+}
+
+type failingSearcher struct{}
+
+func (failingSearcher) RangeSearch(val uint64) (interface{}, error) {
+	return nil, errors.New("backend unavailable")
+}
+
+func TestLayeredStore_Error(t *testing.T) {
+	base, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 99, "default"}})
+	s := NewLayeredStore(failingSearcher{}, base)
+	if _, err := s.RangeSearch(5); err == nil || err.Error() != "backend unavailable" {
+		t.Fatalf("Expected the layer error to be returned, got %v", err)
+	}
+}
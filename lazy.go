@@ -0,0 +1,177 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * lazy.go: Stores loaded a region at a time from a backend
+ */
+
+package rangestore
+
+import (
+	"math"
+	"sync"
+)
+
+// Returns the ranges which overlap the keys from lo to hi inclusive, in any
+// order, e.g. by querying a database
+type FetchRanges func(lo, hi uint64) ([]Ranged, error)
+
+// A read through store for datasets too large to hold in memory. The key space
+// is split into regions of equal size, and the ranges of a region are fetched
+// and built into a tree the first time a key within it is looked up. Only the
+// most recently used regions are kept, which bounds the memory used.
+//
+// LazyStore is safe for concurrent use. Concurrent lookups in a region which
+// isn't loaded share a single fetch.
+type LazyStore struct {
+	fetch      FetchRanges
+	regionSize uint64
+	regions    *lookupCache
+	mu         sync.Mutex
+	loading    map[uint64]*lazyLoad
+}
+
+// A fetch in progress, which lookups in the same region wait for
+type lazyLoad struct {
+	done chan struct{}
+	root *Node
+	err  error
+}
+
+// Creates a store which fetches regions of regionSize keys, keeping at most
+// maxRegions of them. A regionSize of 0 makes the whole key space a single
+// region. Fetch errors aren't cached, so the next lookup in the region tries
+// again.
+func NewLazyStore(fetch FetchRanges, regionSize uint64, maxRegions int) *LazyStore {
+	if maxRegions < 1 {
+		maxRegions = 1
+	}
+	return &LazyStore{
+		fetch:      fetch,
+		regionSize: regionSize,
+		regions:    newLookupCache(maxRegions),
+		loading:    make(map[uint64]*lazyLoad),
+	}
+}
+
+// Returns the first and last keys of the region
+func (s *LazyStore) bounds(region uint64) (lo, hi uint64) {
+	if s.regionSize == 0 {
+		return 0, math.MaxUint64
+	}
+	lo = region * s.regionSize
+	if s.regionSize-1 > math.MaxUint64-lo {
+		return lo, math.MaxUint64
+	}
+	return lo, lo + s.regionSize - 1
+}
+
+// Returns the value of the range containing the key, fetching its region if it
+// isn't loaded. Returns ErrOutOfRange if no range contains it, or the error
+// from the fetch, or from building the region if its ranges overlap.
+func (s *LazyStore) RangeSearch(val uint64) (interface{}, error) {
+	region := uint64(0)
+	if s.regionSize != 0 {
+		region = val / s.regionSize
+	}
+	var root *Node
+	if e, ok := s.regions.get(region); ok {
+		root = e.value.(*Node)
+	} else {
+		var err error
+		if root, err = s.load(region); err != nil {
+			return nil, err
+		}
+	}
+	// Regions without any ranges have no tree
+	if root == nil {
+		return nil, ErrOutOfRange{val}
+	}
+	return root.RangeSearch(val)
+}
+
+// Fetches the region and builds its tree, or waits for a fetch already running
+func (s *LazyStore) load(region uint64) (*Node, error) {
+	s.mu.Lock()
+	if l, ok := s.loading[region]; ok {
+		s.mu.Unlock()
+		<-l.done
+		return l.root, l.err
+	}
+	// Another fetch of the region may have finished since the caller missed
+	if e, ok := s.regions.peek(region); ok {
+		s.mu.Unlock()
+		return e.value.(*Node), nil
+	}
+	l := &lazyLoad{done: make(chan struct{})}
+	s.loading[region] = l
+	s.mu.Unlock()
+
+	l.root, l.err = s.build(region)
+	if l.err == nil {
+		s.regions.add(region, l.root, nil)
+	}
+	s.mu.Lock()
+	delete(s.loading, region)
+	s.mu.Unlock()
+	close(l.done)
+	return l.root, l.err
+}
+
+func (s *LazyStore) build(region uint64) (*Node, error) {
+	lo, hi := s.bounds(region)
+	fetched, err := s.fetch(lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	// Ranges reaching into the neighbouring regions are cut at the boundaries
+	items := make([]Ranged, 0, len(fetched))
+	for _, item := range fetched {
+		min, max := item.GetMin(), item.GetMax()
+		if max < lo || min > hi {
+			continue
+		}
+		if min < lo {
+			min = lo
+		}
+		if max > hi {
+			max = hi
+		}
+		items = append(items, RangeEntry{min, max, item.GetValue()})
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	SortRanged(items)
+	return rangeStoreFromSortedChecked(items, true, true)
+}
+
+// Drops every loaded region, e.g. after the backend has changed, so that they
+// are fetched again
+func (s *LazyStore) Purge() {
+	s.regions.purge()
+}
+
+// Returns how often lookups found their region loaded, and how many regions
+// are loaded
+func (s *LazyStore) CacheStats() CacheStats {
+	return s.regions.stats()
+}
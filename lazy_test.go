@@ -0,0 +1,132 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * lazy_test.go: Tests on the lazily loaded store
+ */
+
+package rangestore
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Serves arenaItems(1000), counting the fetches
+type lazyBackend struct {
+	// Accessed atomically, so kept first for 64-bit alignment on 32-bit platforms
+	fetches uint64
+	items   []Ranged
+	fail    bool
+}
+
+func (b *lazyBackend) fetch(lo, hi uint64) ([]Ranged, error) {
+	atomic.AddUint64(&b.fetches, 1)
+	if b.fail {
+		return nil, errors.New("backend down")
+	}
+	ret := make([]Ranged, 0)
+	for _, item := range b.items {
+		if item.GetMax() >= lo && item.GetMin() <= hi {
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
+}
+
+func TestLazyStore_RangeSearch(t *testing.T) {
+	backend := &lazyBackend{items: arenaItems(1000)}
+	// Regions of 64 keys, so ranges straddle the boundaries
+	s := NewLazyStore(backend.fetch, 64, 4)
+	for _, key := range []uint64{0, 63, 64, 65, 127, 5000} {
+		v, err := s.RangeSearch(key)
+		if err != nil || v != int(key/10) {
+			t.Fatalf("Wrong value for %d: %v, %v", key, v, err)
+		}
+	}
+	if backend.fetches != 3 {
+		t.Fatalf("Expected 3 fetches, got %d", backend.fetches)
+	}
+	if _, err := s.RangeSearch(20000); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+		t.Fatalf("Expected an ErrOutOfRange, got %v", err)
+	}
+	// The empty region is cached too
+	s.RangeSearch(20001)
+	if backend.fetches != 4 {
+		t.Fatalf("Expected 4 fetches, got %d", backend.fetches)
+	}
+
+	// Only 4 regions are kept, so the first one has been evicted
+	s.RangeSearch(70000)
+	if stats := s.CacheStats(); stats.Entries != 4 {
+		t.Fatalf("Wrong number of regions: %d", stats.Entries)
+	}
+	s.RangeSearch(0)
+	if backend.fetches != 6 {
+		t.Fatalf("Expected 6 fetches, got %d", backend.fetches)
+	}
+
+	s.Purge()
+	backend.fail = true
+	if _, err := s.RangeSearch(0); err == nil || err.Error() != "backend down" {
+		t.Fatalf("Expected the fetch error, got %v", err)
+	}
+	backend.fail = false
+	if v, err := s.RangeSearch(0); err != nil || v != 0 {
+		t.Fatalf("Failed fetch was cached: %v, %v", v, err)
+	}
+}
+
+func TestLazyStore_Concurrent(t *testing.T) {
+	backend := &lazyBackend{items: arenaItems(1000)}
+	s := NewLazyStore(backend.fetch, 0, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i += 1 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if v, err := s.RangeSearch(uint64(i * 100)); err != nil || v != i*10 {
+				t.Errorf("Wrong value for %d: %v, %v", i*100, v, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if backend.fetches != 1 {
+		t.Fatalf("Expected a single fetch, got %d", backend.fetches)
+	}
+	if lo, hi := s.bounds(0); lo != 0 || hi != math.MaxUint64 {
+		t.Fatalf("Wrong region bounds: %d, %d", lo, hi)
+	}
+}
+
+func TestLazyStore_Overlap(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{5, 14, "B"})
+	s := NewLazyStore((&lazyBackend{items: items}).fetch, 100, 1)
+	if _, err := s.RangeSearch(3); reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected an ErrOverlap, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * lint.go: Warnings about suspicious input data
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Identifies the kind of problem a Warning is about
+type WarningKind int
+
+const (
+	// A range of a single key next to a range with an equal value, which was
+	// likely meant to be part of it
+	StrayKey WarningKind = iota
+	// A range covering no keys, either inverted or, for items which are also
+	// Weighted, with a zero weight
+	EmptyRange
+	// A range far wider than the typical range of the input
+	SkewedRange
+	// Values which print the same but aren't equal, such as 1 and "1"
+	LookalikeValues
+)
+
+func (k WarningKind) String() string {
+	switch k {
+	case StrayKey:
+		return "stray key"
+	case EmptyRange:
+		return "empty range"
+	case SkewedRange:
+		return "skewed range"
+	default:
+		return "lookalike values"
+	}
+}
+
+// A likely problem with an item of the input, found by Lint. Index is the
+// position of the item in the input.
+type Warning struct {
+	Kind    WarningKind
+	Index   int
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("item %d: %s: %s", w.Index, w.Kind, w.Message)
+}
+
+// How many times wider than the median range a range must be to count as skewed
+const lintSkewFactor = 1 << 20
+
+// Looks for patterns in the items which are usually data bugs, but which the
+// constructors accept or only reject in passing, e.g. to log them from an import
+// pipeline. The items may be in any order. Values are compared with
+// reflect.DeepEqual and printed with %v. Returns the warnings ordered by the
+// index of the item they're about.
+func Lint(items []Ranged) []Warning {
+	ret := make([]Warning, 0)
+	order := make([]int, 0, len(items))
+	widths := make([]float64, 0, len(items))
+	for idx, item := range items {
+		min, max := item.GetMin(), item.GetMax()
+		if min > max {
+			ret = append(ret, Warning{EmptyRange, idx, fmt.Sprintf("[%d, %d] is inverted", min, max)})
+			continue
+		}
+		if w, ok := item.(Weighted); ok && w.GetWeight() == 0 {
+			ret = append(ret, Warning{EmptyRange, idx, "weight is zero"})
+		}
+		order = append(order, idx)
+		widths = append(widths, float64(max-min)+1)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return items[order[i]].GetMin() < items[order[j]].GetMin()
+	})
+	for i, idx := range order {
+		item := items[idx]
+		if item.GetMin() != item.GetMax() {
+			continue
+		}
+		for _, j := range []int{i - 1, i + 1} {
+			if j < 0 || j >= len(order) {
+				continue
+			}
+			other := items[order[j]]
+			adjacent := (j < i && other.GetMax()+1 == item.GetMin()) || (j > i && item.GetMax()+1 == other.GetMin())
+			if adjacent && other.GetMin() != other.GetMax() && reflect.DeepEqual(other.GetValue(), item.GetValue()) {
+				ret = append(ret, Warning{StrayKey, idx, fmt.Sprintf("key %d is next to item %d, which has the same value", item.GetMin(), order[j])})
+				break
+			}
+		}
+	}
+
+	if len(widths) > 0 {
+		sorted := append([]float64(nil), widths...)
+		sort.Float64s(sorted)
+		median := sorted[len(sorted)/2]
+		for _, idx := range order {
+			item := items[idx]
+			if w := float64(item.GetMax()-item.GetMin()) + 1; w > median*lintSkewFactor {
+				ret = append(ret, Warning{SkewedRange, idx, fmt.Sprintf("spans %.0f keys, against a median of %.0f", w, median)})
+			}
+		}
+	}
+
+	// The first item seen with each distinct value, by how it prints
+	printed := make(map[string][]int)
+	for idx, item := range items {
+		s := fmt.Sprintf("%v", item.GetValue())
+		seen := printed[s]
+		distinct := true
+		for _, prev := range seen {
+			if reflect.DeepEqual(items[prev].GetValue(), item.GetValue()) {
+				distinct = false
+				break
+			}
+		}
+		if !distinct {
+			continue
+		}
+		if len(seen) > 0 {
+			ret = append(ret, Warning{LookalikeValues, idx, fmt.Sprintf("value %v (%T) prints the same as that of item %d (%T)", item.GetValue(), item.GetValue(), seen[0], items[seen[0]].GetValue())})
+		}
+		printed[s] = append(seen, idx)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Index < ret[j].Index
+	})
+	return ret
+}
@@ -0,0 +1,78 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * lint_test.go: Tests for linting input data
+ */
+
+package rangestore
+
+import (
+	"testing"
+)
+
+// A weighted item whose range was already laid out
+type weightedRange struct {
+	DefaultRangedValue
+	weight uint64
+}
+
+func (w weightedRange) GetWeight() uint64 {
+	return w.weight
+}
+
+func TestLint(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{20, 20, "A"})
+	items = append(items, DefaultRangedValue{0, 9, "1"})
+	items = append(items, DefaultRangedValue{30, 39, 1})
+	items = append(items, DefaultRangedValue{50, 40, "B"})
+	items = append(items, DefaultRangedValue{100, 1 << 40, "C"})
+	items = append(items, weightedRange{DefaultRangedValue{60, 69, "D"}, 0})
+
+	expected := []struct {
+		kind  WarningKind
+		index int
+	}{
+		{StrayKey, 1},
+		{LookalikeValues, 3},
+		{EmptyRange, 4},
+		{SkewedRange, 5},
+		{EmptyRange, 6},
+	}
+	got := Lint(items)
+	if len(got) != len(expected) {
+		t.Fatalf("Wrong number of warnings: %v", got)
+	}
+	for i, w := range got {
+		if w.Kind != expected[i].kind || w.Index != expected[i].index {
+			t.Fatalf("Wrong warning %d: %s", i, w)
+		}
+	}
+	if s := got[1].String(); s != `item 3: lookalike values: value 1 (int) prints the same as that of item 2 (string)` {
+		t.Fatalf("Wrong message: %s", s)
+	}
+
+	if got := Lint(items[:1]); len(got) != 0 {
+		t.Fatalf("Expected no warnings for clean input, got %v", got)
+	}
+}
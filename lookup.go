@@ -0,0 +1,116 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * lookup.go: Dense lookup table backend
+ */
+
+package rangestore
+
+import (
+	"fmt"
+)
+
+// The largest number of keys NewLookupTable will materialize
+const DefaultLookupTableLimit = 1 << 16
+
+type ErrSpanTooLarge struct {
+	min, max, limit uint64
+}
+
+func (ex ErrSpanTooLarge) Error() string {
+	return fmt.Sprintf("Span from %d to %d exceeds the lookup table limit of %d keys", ex.min, ex.max, ex.limit)
+}
+
+// Returns the bounds of the store
+func (ex ErrSpanTooLarge) Range() (min, max uint64) {
+	return ex.min, ex.max
+}
+
+// Returns the largest number of keys allowed
+func (ex ErrSpanTooLarge) Limit() uint64 {
+	return ex.limit
+}
+
+func (ex ErrSpanTooLarge) Details() ErrorDetails {
+	return ErrorDetails{"ErrSpanTooLarge", map[string]interface{}{"min": ex.min, "max": ex.max, "limit": ex.limit}}
+}
+
+// A dense table holding one slot for every key between the bounds of the store,
+// giving constant time lookups for small key spans. Each slot refers to the
+// range which covers it, so the values are only stored once.
+type LookupTable struct {
+	min    uint64
+	slots  []int32
+	values []interface{}
+}
+
+// Builds a lookup table from the items, which must be sorted and not overlap,
+// but may leave gaps. Returns an ErrSpanTooLarge if the items cover more than
+// DefaultLookupTableLimit keys from the smallest to the largest.
+func NewLookupTable(items []Ranged) (*LookupTable, error) {
+	return NewLookupTableWithLimit(items, DefaultLookupTableLimit)
+}
+
+// Same as NewLookupTable, with a custom limit on the number of keys to materialize
+func NewLookupTableWithLimit(items []Ranged, limit uint64) (*LookupTable, error) {
+	if _, err := rangeStoreFromSortedChecked(items, true, true); err != nil {
+		return nil, err
+	}
+	min, max := items[0].GetMin(), items[len(items)-1].GetMax()
+	if max-min >= limit {
+		return nil, ErrSpanTooLarge{min, max, limit}
+	}
+
+	t := &LookupTable{
+		min:    min,
+		slots:  make([]int32, max-min+1),
+		values: make([]interface{}, 0, len(items)),
+	}
+	for idx := range t.slots {
+		t.slots[idx] = -1
+	}
+	for _, item := range items {
+		slot := int32(len(t.values))
+		t.values = append(t.values, item.GetValue())
+		for key := item.GetMin() - min; key <= item.GetMax()-min; key += 1 {
+			t.slots[key] = slot
+		}
+	}
+	return t, nil
+}
+
+// Returns the value associated with the key, or ErrOutOfRange if no range covers it
+func (t *LookupTable) RangeSearch(val uint64) (interface{}, error) {
+	if val < t.min || val-t.min >= uint64(len(t.slots)) {
+		return nil, ErrOutOfRange{val}
+	}
+	slot := t.slots[val-t.min]
+	if slot < 0 {
+		return nil, ErrOutOfRange{val}
+	}
+	return t.values[slot], nil
+}
+
+// Returns the number of keys materialized by the table
+func (t *LookupTable) Len() int {
+	return len(t.slots)
+}
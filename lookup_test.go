@@ -0,0 +1,131 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * lookup_test.go: Tests on the lookup table backend
+ */
+
+package rangestore
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLookupTable_Basic(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{100, 109, "A"})
+	items = append(items, DefaultRangedValue{110, 119, "B"})
+	items = append(items, DefaultRangedValue{130, 139, "C"})
+
+	table, err := NewLookupTable(items)
+	if err != nil {
+		t.Fatalf("Error while building lookup table: %s", err.Error())
+	}
+	if table.Len() != 40 {
+		t.Fatalf("Wrong table size: %d", table.Len())
+	}
+	for key, expected := range map[uint64]string{100: "A", 109: "A", 110: "B", 119: "B", 130: "C", 139: "C"} {
+		v, err := table.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back for %d: %s [%s]", key, v, expected)
+		}
+	}
+	for _, key := range []uint64{0, 99, 120, 129, 140, 1 << 63} {
+		_, err := table.RangeSearch(key)
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+			t.Fatalf("Expecting an ErrOutOfRange for %d, but got something else", key)
+		}
+	}
+}
+
+func TestLookupTable_Limit(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 99, "A"})
+
+	if _, err := NewLookupTableWithLimit(items, 100); err != nil {
+		t.Fatalf("Error while building lookup table: %s", err.Error())
+	}
+	_, err := NewLookupTableWithLimit(items, 99)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrSpanTooLarge{}).Name() {
+		t.Fatalf("Expecting an ErrSpanTooLarge, but got something else")
+	}
+	if msg := err.Error(); msg != "Span from 0 to 99 exceeds the lookup table limit of 99 keys" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+
+	_, err = NewLookupTable([]Ranged{DefaultRangedValue{10, 19, "A"}, DefaultRangedValue{15, 29, "B"}})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+}
+
+func TestLookupTable_Backend(t *testing.T) {
+	s, err := NewBuilder(WithBackend(LookupTableBackend)).Add(0, 9, "A").Add(20, 29, "B").AllowGaps().Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	if _, ok := s.search.(*LookupTable); !ok {
+		t.Fatalf("Expected the store to be backed by a lookup table")
+	}
+	if err := s.Append(DefaultRangedValue{30, 39, "C"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	for key, expected := range map[uint64]string{5: "A", 25: "B", 35: "C"} {
+		v, err := s.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back for %d: %s [%s]", key, v, expected)
+		}
+	}
+
+	// Too wide for the table, so it falls back to the tree
+	s, err = NewBuilder(WithBackend(LookupTableBackend), WithLookupTableLimit(10)).Add(0, 99, "A").Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	if _, ok := s.search.(*Node); !ok {
+		t.Fatalf("Expected the store to fall back to the tree")
+	}
+}
+
+func Benchmark_RangeSearch_LookupTable(b *testing.B) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 19999, "A"})
+	items = append(items, DefaultRangedValue{20000, 39999, "B"})
+	items = append(items, DefaultRangedValue{40000, 60000, "C"})
+	table, err := NewLookupTable(items)
+	if err != nil {
+		b.Fatalf("Got an error while building: %s", err.Error())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		if _, err := table.RangeSearch(uint64(rand.Int() % 60000)); err != nil {
+			b.Fatalf("Got an error while searching: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,454 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * mapped.go: Memory mapped read only store format
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// The on disk format is little endian and made of three page aligned sections:
+//
+//	header   magic, version, checksum, node count, the offsets of the other
+//	         sections, and the bounds of the store
+//	nodes    the tree in pre-order, mappedNodeSize bytes per node
+//	blob     the values, back to back
+//
+// The checksum is the CRC32 (IEEE) of the rest of the header after it, followed
+// by the nodes and the blob. Version 1 had neither the checksum nor the bounds;
+// it can still be read, but is no longer written.
+//
+// Each node holds its min and max, the offset and length of its value in the
+// blob, and the indices of its children, or -1 if there's no child. Since the
+// nodes are in pre-order, children always have a higher index than their parent.
+const (
+	mappedMagic        = "RNGSTORE"
+	mappedVersion      = 2
+	mappedPageSize     = 4096
+	mappedHeaderSizeV1 = 48
+	mappedHeaderSize   = 64
+	mappedNodeSize     = 40
+)
+
+type ErrCorruptStore struct {
+	reason string
+}
+
+func (ex ErrCorruptStore) Error() string {
+	return fmt.Sprintf("Corrupt store: %s", ex.reason)
+}
+
+// Returns what doesn't add up
+func (ex ErrCorruptStore) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrCorruptStore) Details() ErrorDetails {
+	return ErrorDetails{"ErrCorruptStore", map[string]interface{}{"reason": ex.reason}}
+}
+
+type ErrUnsupportedVersion struct {
+	version uint32
+}
+
+func (ex ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("Unsupported store format version %d", ex.version)
+}
+
+// Returns the version found in the data
+func (ex ErrUnsupportedVersion) Version() uint32 {
+	return ex.version
+}
+
+func (ex ErrUnsupportedVersion) Details() ErrorDetails {
+	return ErrorDetails{"ErrUnsupportedVersion", map[string]interface{}{"version": ex.version}}
+}
+
+type ErrUnserializableValue struct {
+	v interface{}
+}
+
+func (ex ErrUnserializableValue) Error() string {
+	return fmt.Sprintf("Value of type %T can't be serialized", ex.v)
+}
+
+// Returns the value which couldn't be serialized
+func (ex ErrUnserializableValue) Value() interface{} {
+	return ex.v
+}
+
+func (ex ErrUnserializableValue) Details() ErrorDetails {
+	return ErrorDetails{"ErrUnserializableValue", map[string]interface{}{"value": ex.v}}
+}
+
+// Writes the store in the format read by OpenMapped. The values must be strings
+// or byte slices. Identical values are only written once.
+func WriteMapped(w io.Writer, n *Node) error {
+	return WriteMappedCodec(w, n, StringCodec{})
+}
+
+// Same as WriteMapped, but encodes the values with codec. The store must then be
+// read with the same codec, see MappedStore.SetCodec.
+func WriteMappedCodec(w io.Writer, n *Node, codec ValueCodec) error {
+	var blob bytes.Buffer
+	offsets := make(map[string]uint64)
+	nodes := make([]byte, 0, n.Len()*mappedNodeSize)
+
+	var add func(c *Node) (int32, error)
+	add = func(c *Node) (int32, error) {
+		encoded, err := codec.Encode(c.value)
+		if err != nil {
+			return 0, err
+		}
+		value := string(encoded)
+		off, ok := offsets[value]
+		if !ok {
+			off = uint64(blob.Len())
+			offsets[value] = off
+			blob.WriteString(value)
+		}
+
+		id := int32(len(nodes) / mappedNodeSize)
+		var rec [mappedNodeSize]byte
+		binary.LittleEndian.PutUint64(rec[0:], c.min)
+		binary.LittleEndian.PutUint64(rec[8:], c.max)
+		binary.LittleEndian.PutUint64(rec[16:], off)
+		binary.LittleEndian.PutUint32(rec[24:], uint32(len(value)))
+		nodes = append(nodes, rec[:]...)
+
+		// The children are filled in once they've been assigned their ids
+		left, right := int32(-1), int32(-1)
+		if c.left != nil {
+			if left, err = add(c.left); err != nil {
+				return 0, err
+			}
+		}
+		if c.right != nil {
+			if right, err = add(c.right); err != nil {
+				return 0, err
+			}
+		}
+		base := int(id) * mappedNodeSize
+		binary.LittleEndian.PutUint32(nodes[base+28:], uint32(left))
+		binary.LittleEndian.PutUint32(nodes[base+32:], uint32(right))
+		return id, nil
+	}
+	if _, err := add(n); err != nil {
+		return err
+	}
+
+	nodesOff := uint64(mappedPageSize)
+	blobOff := alignPage(nodesOff + uint64(len(nodes)))
+	header := make([]byte, mappedPageSize)
+	copy(header, mappedMagic)
+	binary.LittleEndian.PutUint32(header[8:], mappedVersion)
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(nodes)/mappedNodeSize))
+	binary.LittleEndian.PutUint64(header[24:], nodesOff)
+	binary.LittleEndian.PutUint64(header[32:], blobOff)
+	binary.LittleEndian.PutUint64(header[40:], uint64(blob.Len()))
+	min, max := n.Bounds()
+	binary.LittleEndian.PutUint64(header[48:], min)
+	binary.LittleEndian.PutUint64(header[56:], max)
+	sum := crc32.ChecksumIEEE(header[16:mappedHeaderSize])
+	sum = crc32.Update(sum, crc32.IEEETable, nodes)
+	sum = crc32.Update(sum, crc32.IEEETable, blob.Bytes())
+	binary.LittleEndian.PutUint32(header[12:], sum)
+
+	padding := make([]byte, blobOff-nodesOff-uint64(len(nodes)))
+	for _, section := range [][]byte{header, nodes, padding, blob.Bytes()} {
+		if _, err := w.Write(section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func alignPage(off uint64) uint64 {
+	return (off + mappedPageSize - 1) &^ (mappedPageSize - 1)
+}
+
+// Reads a store written by WriteMapped in any supported format version, and
+// writes it back in the current one. The input is fully verified first, and the
+// tree keeps its shape. The values are copied as they are, so this works whatever
+// codec they were encoded with. Returns an ErrUnsupportedVersion for unknown
+// versions, and an ErrCorruptStore if the input can't be read.
+func Migrate(r io.Reader, w io.Writer) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m, err := NewMappedStoreFromBytes(data)
+	if err != nil {
+		return err
+	}
+	// StringCodec hands the bytes back unchanged on both sides
+	n, err := m.Tree()
+	if err != nil {
+		return err
+	}
+	return WriteMapped(w, n)
+}
+
+// A read only store which searches directly against the bytes of a file written
+// by WriteMapped. The file is memory mapped where the platform allows it, so
+// processes opening the same file share a single copy of it.
+type MappedStore struct {
+	data     []byte
+	count    uint64
+	min, max uint64
+	nodes    []byte
+	blob     []byte
+	unmap    func([]byte) error
+	codec    ValueCodec
+}
+
+// Memory maps the file at path and verifies it, see NewMappedStoreFromBytes.
+// The store must be closed once it's no longer needed.
+func OpenMapped(path string) (*MappedStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < mappedHeaderSizeV1 {
+		return nil, ErrCorruptStore{"file is too short for the header"}
+	}
+	data, err := mapFile(f, int(fi.Size()))
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewMappedStoreFromBytes(data)
+	if err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	m.unmap = unmapFile
+	return m, nil
+}
+
+// Reads a store from bytes written by WriteMapped, without copying them. The
+// header is checked against the rest of the data, and the checksum verified,
+// which reads through all of it once. Returns an ErrUnsupportedVersion for
+// unknown format versions, and an ErrCorruptStore if anything doesn't add up.
+func NewMappedStoreFromBytes(data []byte) (*MappedStore, error) {
+	if len(data) < mappedHeaderSizeV1 || string(data[:8]) != mappedMagic {
+		return nil, ErrCorruptStore{"bad magic"}
+	}
+	version := binary.LittleEndian.Uint32(data[8:])
+	if version != 1 && version != mappedVersion {
+		return nil, ErrUnsupportedVersion{version}
+	}
+	if version == mappedVersion && len(data) < mappedHeaderSize {
+		return nil, ErrCorruptStore{"file is too short for the header"}
+	}
+	count := binary.LittleEndian.Uint64(data[16:])
+	nodesOff := binary.LittleEndian.Uint64(data[24:])
+	blobOff := binary.LittleEndian.Uint64(data[32:])
+	blobLen := binary.LittleEndian.Uint64(data[40:])
+	size := uint64(len(data))
+	if count == 0 || nodesOff > size || count > (size-nodesOff)/mappedNodeSize {
+		return nil, ErrCorruptStore{"node section is out of bounds"}
+	}
+	if blobOff > size || blobLen > size-blobOff {
+		return nil, ErrCorruptStore{"blob section is out of bounds"}
+	}
+	m := &MappedStore{
+		data:  data,
+		count: count,
+		nodes: data[nodesOff : nodesOff+count*mappedNodeSize],
+		blob:  data[blobOff : blobOff+blobLen],
+	}
+	var err error
+	if m.min, m.max, err = m.extremes(); err != nil {
+		return nil, err
+	}
+	if version == 1 {
+		return m, nil
+	}
+
+	sum := crc32.ChecksumIEEE(data[16:mappedHeaderSize])
+	sum = crc32.Update(sum, crc32.IEEETable, m.nodes)
+	sum = crc32.Update(sum, crc32.IEEETable, m.blob)
+	if sum != binary.LittleEndian.Uint32(data[12:]) {
+		return nil, ErrCorruptStore{"checksum mismatch"}
+	}
+	if m.min != binary.LittleEndian.Uint64(data[48:]) || m.max != binary.LittleEndian.Uint64(data[56:]) {
+		return nil, ErrCorruptStore{"bounds don't match the header"}
+	}
+	return m, nil
+}
+
+// Finds the bounds of the store by following the leftmost and rightmost paths
+func (m *MappedStore) extremes() (min, max uint64, err error) {
+	first, err := m.edge(28)
+	if err != nil {
+		return 0, 0, err
+	}
+	last, err := m.edge(32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return binary.LittleEndian.Uint64(first[0:]), binary.LittleEndian.Uint64(last[8:]), nil
+}
+
+// Returns the last node reached from the root by following the child index at
+// the given offset within each node
+func (m *MappedStore) edge(child int) ([]byte, error) {
+	idx := uint64(0)
+	for {
+		rec := m.nodes[idx*mappedNodeSize : (idx+1)*mappedNodeSize]
+		next := int32(binary.LittleEndian.Uint32(rec[child:]))
+		if next < 0 {
+			return rec, nil
+		}
+		if uint64(next) <= idx || uint64(next) >= m.count {
+			return nil, ErrCorruptStore{"child index is out of bounds"}
+		}
+		idx = uint64(next)
+	}
+}
+
+// Returns the value associated with the key as a slice of the underlying bytes,
+// which must not be modified, and is only valid until the store is closed
+func (m *MappedStore) RangeSearchBytes(val uint64) ([]byte, error) {
+	idx := uint64(0)
+	for {
+		rec := m.nodes[idx*mappedNodeSize : (idx+1)*mappedNodeSize]
+		var next int32
+		if val > binary.LittleEndian.Uint64(rec[8:]) {
+			next = int32(binary.LittleEndian.Uint32(rec[32:]))
+		} else if val < binary.LittleEndian.Uint64(rec[0:]) {
+			next = int32(binary.LittleEndian.Uint32(rec[28:]))
+		} else {
+			off := binary.LittleEndian.Uint64(rec[16:])
+			length := uint64(binary.LittleEndian.Uint32(rec[24:]))
+			if off > uint64(len(m.blob)) || length > uint64(len(m.blob))-off {
+				return nil, ErrCorruptStore{"value is out of bounds"}
+			}
+			return m.blob[off : off+length], nil
+		}
+		if next < 0 {
+			return nil, ErrOutOfRange{val}
+		}
+		// Children always follow their parent, which also rules out cycles
+		if uint64(next) <= idx || uint64(next) >= m.count {
+			return nil, ErrCorruptStore{"child index is out of bounds"}
+		}
+		idx = uint64(next)
+	}
+}
+
+// Sets the codec used to decode values, which must match the one the store was
+// written with. The default is StringCodec.
+func (m *MappedStore) SetCodec(codec ValueCodec) {
+	m.codec = codec
+}
+
+func (m *MappedStore) decode(b []byte) (interface{}, error) {
+	if m.codec == nil {
+		return string(b), nil
+	}
+	return m.codec.Decode(b)
+}
+
+// Returns the value associated with the key, decoded by the codec of the store,
+// which by default gives a string
+func (m *MappedStore) RangeSearch(val uint64) (interface{}, error) {
+	b, err := m.RangeSearchBytes(val)
+	if err != nil {
+		return nil, err
+	}
+	return m.decode(b)
+}
+
+// Copies the store into a regular tree of the same shape, with the values
+// decoded by the codec of the store
+func (m *MappedStore) Tree() (*Node, error) {
+	return m.tree(0)
+}
+
+func (m *MappedStore) tree(idx uint64) (*Node, error) {
+	rec := m.nodes[idx*mappedNodeSize : (idx+1)*mappedNodeSize]
+	n := &Node{min: binary.LittleEndian.Uint64(rec[0:]), max: binary.LittleEndian.Uint64(rec[8:])}
+	off := binary.LittleEndian.Uint64(rec[16:])
+	length := uint64(binary.LittleEndian.Uint32(rec[24:]))
+	if off > uint64(len(m.blob)) || length > uint64(len(m.blob))-off {
+		return nil, ErrCorruptStore{"value is out of bounds"}
+	}
+	v, err := m.decode(m.blob[off : off+length])
+	if err != nil {
+		return nil, err
+	}
+	n.value = v
+	for _, child := range []struct {
+		at  int
+		ptr **Node
+	}{{28, &n.left}, {32, &n.right}} {
+		next := int32(binary.LittleEndian.Uint32(rec[child.at:]))
+		if next < 0 {
+			continue
+		}
+		if uint64(next) <= idx || uint64(next) >= m.count {
+			return nil, ErrCorruptStore{"child index is out of bounds"}
+		}
+		c, err := m.tree(uint64(next))
+		if err != nil {
+			return nil, err
+		}
+		*child.ptr = c
+	}
+	return n, nil
+}
+
+// Returns the number of ranges in the store
+func (m *MappedStore) Len() int {
+	return int(m.count)
+}
+
+// Returns the smallest and largest keys covered by the store
+func (m *MappedStore) Bounds() (min, max uint64) {
+	return m.min, m.max
+}
+
+// Releases the mapping. The store, and any slices returned by it, must not be
+// used afterwards.
+func (m *MappedStore) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	data, unmap := m.data, m.unmap
+	m.data, m.nodes, m.blob, m.unmap = nil, nil, nil, nil
+	return unmap(data)
+}
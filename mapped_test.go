@@ -0,0 +1,270 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * mapped_test.go: Tests on the memory mapped store
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func mappedTestStore(t *testing.T) *Node {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 99, []byte("B")})
+	items = append(items, DefaultRangedValue{150, 199, "C"})
+	items = append(items, DefaultRangedValue{200, 299, "A"})
+	n, err := NewSparseRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	return n
+}
+
+func TestMappedStore_RoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.bin")
+
+	var buf bytes.Buffer
+	if err := WriteMapped(&buf, mappedTestStore(t)); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	if buf.Len() != 2*mappedPageSize+3 {
+		// Two pages of header and nodes, then the deduplicated blob "ABC"
+		t.Fatalf("Unexpected file size %d", buf.Len())
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Error while writing file: %s", err.Error())
+	}
+
+	m, err := OpenMapped(path)
+	if err != nil {
+		t.Fatalf("Error while opening store: %s", err.Error())
+	}
+	defer m.Close()
+	if m.Len() != 4 {
+		t.Fatalf("Wrong number of ranges: %d", m.Len())
+	}
+	if min, max := m.Bounds(); min != 0 || max != 299 {
+		t.Fatalf("Wrong bounds: %d, %d", min, max)
+	}
+	for key, expected := range map[uint64]string{0: "A", 9: "A", 10: "B", 99: "B", 150: "C", 250: "A", 299: "A"} {
+		v, err := m.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back for %d: %s [%s]", key, v, expected)
+		}
+	}
+	tree, err := m.Tree()
+	if err != nil {
+		t.Fatalf("Error while copying the tree: %s", err.Error())
+	}
+	if tree.String() != "-C [max: 199]\n |-B [max: 99]\n | |-A [max: 9]\n !-A [max: 299]\n" {
+		t.Fatalf("Wrong tree shape:\n%s", tree.String())
+	}
+	for _, key := range []uint64{100, 149, 300} {
+		_, err := m.RangeSearch(key)
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+			t.Fatalf("Expecting an ErrOutOfRange for %d, but got something else", key)
+		}
+	}
+}
+
+func TestMappedStore_Unserializable(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, 42}})
+	err := WriteMapped(ioutil.Discard, n)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrUnserializableValue{}).Name() {
+		t.Fatalf("Expecting an ErrUnserializableValue, but got something else")
+	}
+	if msg := err.Error(); msg != "Value of type int can't be serialized" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+}
+
+func TestMappedStore_Corrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMapped(&buf, mappedTestStore(t)); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	good := buf.Bytes()
+	corrupt := func(f func(b []byte) []byte) error {
+		b := f(append([]byte(nil), good...))
+		m, err := NewMappedStoreFromBytes(b)
+		if err != nil {
+			return err
+		}
+		for key := uint64(0); key < 300; key += 1 {
+			if _, err := m.RangeSearch(key); err != nil {
+				if _, ok := err.(ErrOutOfRange); !ok {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for name, f := range map[string]func(b []byte) []byte{
+		"magic":     func(b []byte) []byte { b[0] = 'X'; return b },
+		"truncated": func(b []byte) []byte { return b[:mappedPageSize+10] },
+		"checksum":  func(b []byte) []byte { b[len(b)-1] = 'X'; return b },
+		"bounds": func(b []byte) []byte {
+			binary.LittleEndian.PutUint64(b[56:], 1000)
+			return b
+		},
+		"count": func(b []byte) []byte {
+			binary.LittleEndian.PutUint64(b[16:], 1<<40)
+			return b
+		},
+		"child": func(b []byte) []byte {
+			// Point the root's right child back at the root
+			binary.LittleEndian.PutUint32(b[mappedPageSize+32:], 0)
+			return resum(b)
+		},
+		"value": func(b []byte) []byte {
+			binary.LittleEndian.PutUint64(b[mappedPageSize+16:], 1<<20)
+			return resum(b)
+		},
+	} {
+		err := corrupt(f)
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrCorruptStore{}).Name() {
+			t.Fatalf("Expecting an ErrCorruptStore for a bad %s, but got %v", name, err)
+		}
+	}
+}
+
+// Recomputes the checksum after tampering with the nodes, so that the damage is
+// caught by the structural checks instead
+func resum(b []byte) []byte {
+	count := binary.LittleEndian.Uint64(b[16:])
+	nodesOff := binary.LittleEndian.Uint64(b[24:])
+	blobOff := binary.LittleEndian.Uint64(b[32:])
+	blobLen := binary.LittleEndian.Uint64(b[40:])
+	sum := crc32.ChecksumIEEE(b[16:mappedHeaderSize])
+	sum = crc32.Update(sum, crc32.IEEETable, b[nodesOff:nodesOff+count*mappedNodeSize])
+	sum = crc32.Update(sum, crc32.IEEETable, b[blobOff:blobOff+blobLen])
+	binary.LittleEndian.PutUint32(b[12:], sum)
+	return b
+}
+
+func TestMappedStore_Versions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMapped(&buf, mappedTestStore(t)); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	b := buf.Bytes()
+
+	binary.LittleEndian.PutUint32(b[8:], 3)
+	_, err := NewMappedStoreFromBytes(b)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrUnsupportedVersion{}).Name() {
+		t.Fatalf("Expecting an ErrUnsupportedVersion, but got something else")
+	}
+	if msg := err.Error(); msg != "Unsupported store format version 3" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+
+	// Version 1 files have no checksum or bounds, so they're read unverified
+	m, err := NewMappedStoreFromBytes(downgradeV1(b))
+	if err != nil {
+		t.Fatalf("Error while reading a version 1 store: %s", err.Error())
+	}
+	if min, max := m.Bounds(); min != 0 || max != 299 {
+		t.Fatalf("Wrong bounds: %d, %d", min, max)
+	}
+	if v, err := m.RangeSearch(150); err != nil || v != "C" {
+		t.Fatalf("Wrong value from a version 1 store")
+	}
+}
+
+// Turns a store written by WriteMapped into a version 1 one, in place
+func downgradeV1(b []byte) []byte {
+	binary.LittleEndian.PutUint32(b[8:], 1)
+	for idx := 12; idx < 16; idx += 1 {
+		b[idx] = 0
+	}
+	for idx := mappedHeaderSizeV1; idx < mappedHeaderSize; idx += 1 {
+		b[idx] = 0
+	}
+	return b
+}
+
+func TestMigrate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMapped(&buf, mappedTestStore(t)); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	current := append([]byte(nil), buf.Bytes()...)
+
+	var out bytes.Buffer
+	if err := Migrate(bytes.NewReader(downgradeV1(buf.Bytes())), &out); err != nil {
+		t.Fatalf("Error while migrating a version 1 store: %s", err.Error())
+	}
+	if !bytes.Equal(out.Bytes(), current) {
+		t.Fatalf("Expected the migrated store to match one written in the current version")
+	}
+	out.Reset()
+	if err := Migrate(bytes.NewReader(current), &out); err != nil || !bytes.Equal(out.Bytes(), current) {
+		t.Fatalf("Expected a current store to be migrated as it is")
+	}
+
+	// Values encoded with another codec are carried over untouched
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, 7})
+	items = append(items, DefaultRangedValue{10, 19, -300})
+	n, _ := NewRangeStoreFromSorted(items)
+	buf.Reset()
+	if err := WriteMappedCodec(&buf, n, IntCodec{}); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	out.Reset()
+	if err := Migrate(bytes.NewReader(downgradeV1(buf.Bytes())), &out); err != nil {
+		t.Fatalf("Error while migrating a version 1 store: %s", err.Error())
+	}
+	m, err := NewMappedStoreFromBytes(out.Bytes())
+	if err != nil {
+		t.Fatalf("Error while reading the migrated store: %s", err.Error())
+	}
+	m.SetCodec(IntCodec{})
+	if v, err := m.RangeSearch(15); err != nil || v != int64(-300) {
+		t.Fatalf("Wrong value from the migrated store: %v", v)
+	}
+
+	binary.LittleEndian.PutUint32(current[8:], 3)
+	err = Migrate(bytes.NewReader(current), &out)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrUnsupportedVersion{}).Name() {
+		t.Fatalf("Expecting an ErrUnsupportedVersion, but got %v", err)
+	}
+}
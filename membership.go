@@ -0,0 +1,83 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * membership.go: Compact store for boolean membership
+ */
+
+package rangestore
+
+import (
+	"sort"
+)
+
+// A store answering only whether a key is covered, e.g. for block lists. It
+// keeps nothing but the bounds of the member ranges, with adjacent ranges merged,
+// making it far smaller than a tree holding a value per range.
+type MembershipStore struct {
+	// Pairs of inclusive bounds, [min0, max0, min1, max1, ...]
+	bounds []uint64
+}
+
+// Builds a membership store from the items, which must be sorted and not overlap,
+// but may leave gaps. Ranges whose value is the boolean false are left out, every
+// other range is a member.
+func NewMembershipStore(items []Ranged) (*MembershipStore, error) {
+	if _, err := rangeStoreFromSortedChecked(items, true, true); err != nil {
+		return nil, err
+	}
+	m := &MembershipStore{bounds: make([]uint64, 0)}
+	for _, item := range items {
+		if v, ok := item.GetValue().(bool); ok && !v {
+			continue
+		}
+		last := len(m.bounds) - 1
+		if last > 0 && m.bounds[last]+1 == item.GetMin() {
+			m.bounds[last] = item.GetMax()
+			continue
+		}
+		m.bounds = append(m.bounds, item.GetMin(), item.GetMax())
+	}
+	return m, nil
+}
+
+// Reports whether the key is covered by a member range
+func (m *MembershipStore) Contains(val uint64) bool {
+	count := len(m.bounds) / 2
+	idx := sort.Search(count, func(i int) bool {
+		return m.bounds[2*i+1] >= val
+	})
+	return idx < count && m.bounds[2*idx] <= val
+}
+
+// Returns true for keys covered by a member range, and ErrOutOfRange otherwise,
+// so that the store can be used wherever a RangeSearcher is expected
+func (m *MembershipStore) RangeSearch(val uint64) (interface{}, error) {
+	if !m.Contains(val) {
+		return nil, ErrOutOfRange{val}
+	}
+	return true, nil
+}
+
+// Returns the number of member ranges, after merging adjacent ones
+func (m *MembershipStore) Len() int {
+	return len(m.bounds) / 2
+}
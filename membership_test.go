@@ -0,0 +1,86 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * membership_test.go: Tests on the membership store
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMembershipStore_Basic(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, true})
+	items = append(items, DefaultRangedValue{20, 29, true})
+	items = append(items, DefaultRangedValue{30, 39, false})
+	items = append(items, DefaultRangedValue{50, 59, "blocked"})
+
+	m, err := NewMembershipStore(items)
+	if err != nil {
+		t.Fatalf("Error while building membership store: %s", err.Error())
+	}
+	if m.Len() != 2 {
+		t.Fatalf("Adjacent ranges weren't merged: %d", m.Len())
+	}
+	for key, expected := range map[uint64]bool{0: false, 9: false, 10: true, 25: true, 29: true, 30: false, 45: false, 50: true, 59: true, 60: false} {
+		if got := m.Contains(key); got != expected {
+			t.Fatalf("Wrong membership for %d: %t [%t]", key, got, expected)
+		}
+	}
+
+	if v, err := m.RangeSearch(15); err != nil || v != true {
+		t.Fatalf("Wrong search result for a member key")
+	}
+	_, err = m.RangeSearch(35)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+}
+
+func TestMembershipStore_Edges(t *testing.T) {
+	m, err := NewMembershipStore([]Ranged{AtMost(5, true), AtLeast(math.MaxUint64-5, true)})
+	if err != nil {
+		t.Fatalf("Error while building membership store: %s", err.Error())
+	}
+	for key, expected := range map[uint64]bool{0: true, 5: true, 6: false, math.MaxUint64 - 6: false, math.MaxUint64: true} {
+		if got := m.Contains(key); got != expected {
+			t.Fatalf("Wrong membership for %d: %t [%t]", key, got, expected)
+		}
+	}
+
+	m, err = NewMembershipStore([]Ranged{DefaultRangedValue{0, 9, false}})
+	if err != nil {
+		t.Fatalf("Error while building membership store: %s", err.Error())
+	}
+	if m.Len() != 0 || m.Contains(0) {
+		t.Fatalf("Expected an empty membership store")
+	}
+
+	_, err = NewMembershipStore([]Ranged{DefaultRangedValue{0, 9, true}, DefaultRangedValue{5, 19, true}})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+}
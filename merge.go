@@ -0,0 +1,134 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * merge.go: Merging range stores
+ */
+
+package rangestore
+
+import (
+	"math"
+)
+
+// Merges the ranges of both stores into a new store. Where the stores overlap,
+// the overlapping keys are split out into their own range whose value is chosen
+// by calling conflict with the value from a and the value from b. If conflict is
+// nil, overlaps are reported as an ErrOverlap instead. Gaps between the ranges
+// are permitted, so the result may be a sparse store. Either store may be nil,
+// e.g. the Root of NewEmptyRangeStore, in which case the ranges of the other are
+// returned. Merging two empty stores returns ErrEmptyInput.
+func Merge(a, b *Node, conflict func(x, y interface{}) interface{}) (*Node, error) {
+	items := make([]Ranged, 0)
+	var err error
+	overlay(a.Ranges(), b.Ranges(), func(min, max uint64, x, y *RangeEntry) {
+		if err != nil {
+			return
+		}
+		switch {
+		case x != nil && y != nil:
+			if conflict == nil {
+				err = ErrOverlap{x.Max, y.Min}
+				if y.Min < x.Min {
+					err = ErrOverlap{y.Max, x.Min}
+				}
+				return
+			}
+			items = append(items, RangeEntry{min, max, conflict(x.Value, y.Value)})
+		case x != nil:
+			items = append(items, RangeEntry{min, max, x.Value})
+		default:
+			items = append(items, RangeEntry{min, max, y.Value})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewSparseRangeStoreFromSorted(items)
+}
+
+// Walks two sorted lists of non-overlapping ranges together, calling fn for every
+// maximal interval of keys which is covered by the same range of a (x) and the
+// same range of b (y). Either x or y is nil if that list doesn't cover the interval.
+// Keys which neither list covers are skipped.
+func overlay(a, b []RangeEntry, fn func(min, max uint64, x, y *RangeEntry)) {
+	i, j := 0, 0
+	pos := uint64(0)
+	for i < len(a) || j < len(b) {
+		var x, y *RangeEntry
+		var xmin, ymin uint64
+		start := uint64(math.MaxUint64)
+		if i < len(a) {
+			x = &a[i]
+			xmin = x.Min
+			if xmin < pos {
+				xmin = pos
+			}
+			start = xmin
+		}
+		if j < len(b) {
+			y = &b[j]
+			ymin = y.Min
+			if ymin < pos {
+				ymin = pos
+			}
+			if ymin < start {
+				start = ymin
+			}
+		}
+
+		// Work out which ranges cover the start, and where the first of them ends,
+		// or where the next range not yet covering the start begins
+		end := uint64(math.MaxUint64)
+		coverX := x != nil && xmin == start
+		coverY := y != nil && ymin == start
+		if coverX && x.Max < end {
+			end = x.Max
+		} else if x != nil && !coverX && xmin-1 < end {
+			end = xmin - 1
+		}
+		if coverY && y.Max < end {
+			end = y.Max
+		} else if y != nil && !coverY && ymin-1 < end {
+			end = ymin - 1
+		}
+
+		cx, cy := x, y
+		if !coverX {
+			cx = nil
+		}
+		if !coverY {
+			cy = nil
+		}
+		fn(start, end, cx, cy)
+
+		if coverX && end == x.Max {
+			i += 1
+		}
+		if coverY && end == y.Max {
+			j += 1
+		}
+		if end == math.MaxUint64 {
+			return
+		}
+		pos = end + 1
+	}
+}
@@ -0,0 +1,141 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * merge_test.go: Tests on merging range stores
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRangeStoreFromSorted_Sparse(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{30, 39, "B"})
+	items = append(items, DefaultRangedValue{40, 49, "C"})
+
+	n, err := NewSparseRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	for key, expected := range map[uint64]string{10: "A", 19: "A", 30: "B", 45: "C"} {
+		v, err := n.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back %s [%s]", v, expected)
+		}
+	}
+	for _, key := range []uint64{0, 9, 20, 29, 50} {
+		_, err := n.RangeSearch(key)
+		if err == nil {
+			t.Fatalf("Expected an error while searching %d, got nothing", key)
+		}
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+			t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+		}
+	}
+
+	items = append(items, DefaultRangedValue{45, 59, "D"})
+	_, err = NewSparseRangeStoreFromSorted(items)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+}
+
+func TestRangeStoreFromSorted_OverlapAtMaximum(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, math.MaxUint64, "A"})
+	items = append(items, DefaultRangedValue{5, 10, "B"})
+
+	_, err := NewSparseRangeStoreFromSorted(items)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+}
+
+func TestMerge_Conflict(t *testing.T) {
+	a, _ := NewRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 99, "A"},
+		DefaultRangedValue{100, 199, "B"},
+	})
+	b, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{50, 59, "X"},
+		DefaultRangedValue{300, math.MaxUint64, "Y"},
+	})
+
+	m, err := Merge(a, b, func(x, y interface{}) interface{} {
+		return x.(string) + y.(string)
+	})
+	if err != nil {
+		t.Fatalf("Error while merging: %s", err.Error())
+	}
+
+	expected := []RangeEntry{
+		{0, 49, "A"},
+		{50, 59, "AX"},
+		{60, 99, "A"},
+		{100, 199, "B"},
+		{300, math.MaxUint64, "Y"},
+	}
+	if got := m.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong merged ranges: %v", got)
+	}
+	if _, err := m.RangeSearch(250); err == nil {
+		t.Fatalf("Expected the gap between the stores to be preserved")
+	}
+}
+
+func TestMerge_Disjoint(t *testing.T) {
+	a, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "A"}})
+	b, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{10, 19, "B"}})
+
+	m, err := Merge(b, a, nil)
+	if err != nil {
+		t.Fatalf("Error while merging: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 9, "A"}, {10, 19, "B"}}
+	if got := m.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong merged ranges: %v", got)
+	}
+}
+
+func TestMerge_OverlapWithoutConflict(t *testing.T) {
+	a, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "A"}})
+	b, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{5, 19, "B"}})
+
+	_, err := Merge(a, b, nil)
+	if err == nil {
+		t.Fatalf("Expecting overlap error and got none")
+	}
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap, but got something else")
+	}
+	if msg := err.Error(); msg != "Overlap detected between 9 -> 5" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+}
@@ -0,0 +1,82 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * missfilter.go: Fast rejection of lookups missing sparse stores
+ */
+
+package rangestore
+
+const (
+	// The number of bits a miss filter spends on each range, so that the gaps
+	// between ranges mostly get buckets of their own
+	missFilterBitsPerRange = 16
+	// The most bits a miss filter uses, which take 1MiB
+	missFilterMaxBits = 1 << 23
+)
+
+// Summarizes which parts of the key space a store covers, as a bitmap with one
+// bit per bucket of equally sized key intervals between the bounds of the store.
+// A bucket's bit is set if any range intersects it, so a clear bit means any
+// key in the bucket misses without having to descend the tree. Keys in a set
+// bucket may still miss, when the bucket is only partly covered.
+type missFilter struct {
+	min, max uint64
+	shift    uint
+	bits     []uint64
+}
+
+// Checks a summary of the covered keys before the tree, rejecting most of the
+// keys which aren't covered without descending it. Worth it for very sparse
+// stores where most lookups miss; it costs a few bits per range, up to 1MiB.
+func WithMissFilter() Option {
+	return func(o *options) {
+		o.missFilter = true
+	}
+}
+
+func newMissFilter(n *Node, count int) *missFilter {
+	f := &missFilter{}
+	f.min, f.max = n.Bounds()
+	buckets := uint64(missFilterBitsPerRange) * uint64(count)
+	if buckets > missFilterMaxBits {
+		buckets = missFilterMaxBits
+	}
+	for (f.max-f.min)>>f.shift >= buckets {
+		f.shift += 1
+	}
+	f.bits = make([]uint64, ((f.max-f.min)>>f.shift)/64+1)
+	n.walk(func(c *Node) {
+		for b := (c.min - f.min) >> f.shift; b <= (c.max-f.min)>>f.shift; b += 1 {
+			f.bits[b/64] |= 1 << (b % 64)
+		}
+	})
+	return f
+}
+
+// Returns false if the key certainly isn't covered by the store
+func (f *missFilter) mayContain(val uint64) bool {
+	if val < f.min || val > f.max {
+		return false
+	}
+	b := (val - f.min) >> f.shift
+	return f.bits[b/64]&(1<<(b%64)) != 0
+}
@@ -0,0 +1,81 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * missfilter_test.go: Tests for the miss filter
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRangeStore_MissFilter(t *testing.T) {
+	// A few narrow ranges spread far apart
+	items := make([]Ranged, 0)
+	for i := uint64(0); i < 100; i += 1 {
+		items = append(items, DefaultRangedValue{i * 1000000, i*1000000 + i, i})
+	}
+	s, err := NewRangeStore(items, AllowGaps(), WithMissFilter())
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	if s.filter == nil {
+		t.Fatalf("Expected a miss filter")
+	}
+	n := s.Root()
+	rejected := 0
+	for key := uint64(0); key < 100000000; key += 997 {
+		want, wantErr := n.RangeSearch(key)
+		got, err := s.RangeSearch(key)
+		if got != want || reflect.TypeOf(err) != reflect.TypeOf(wantErr) {
+			t.Fatalf("Wrong result for %d: %v, %v", key, got, err)
+		}
+		if !s.filter.mayContain(key) {
+			rejected += 1
+		}
+	}
+	for _, item := range items {
+		for key := item.GetMin(); key <= item.GetMax(); key += 1 {
+			if v, err := s.RangeSearch(key); err != nil || v != item.GetValue() {
+				t.Fatalf("Wrong result for covered key %d: %v", key, v)
+			}
+		}
+	}
+	if rejected < 90000 {
+		t.Fatalf("Expected most misses to be rejected by the filter, only %d were", rejected)
+	}
+
+	// Misses still fall back to the default
+	s, _ = NewRangeStore(items, AllowGaps(), WithMissFilter(), WithDefault("X"))
+	if v, err := s.RangeSearch(500); err != nil || v != "X" {
+		t.Fatalf("Expected the default for a miss, got %v", v)
+	}
+	if err := s.Append(DefaultRangedValue{math.MaxUint64 - 1, math.MaxUint64, "Z"}); err != nil {
+		t.Fatalf("Error while appending: %s", err.Error())
+	}
+	if v, err := s.RangeSearch(math.MaxUint64); err != nil || v != "Z" {
+		t.Fatalf("Wrong value for an appended range: %v", v)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !rangestore_core && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !rangestore_core,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * mmap_other.go: Fallback for platforms without memory mapping
+ */
+
+package rangestore
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// Reads the whole file instead, so each process has its own copy
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return ioutil.ReadAll(f)
+}
+
+func unmapFile(data []byte) error {
+	return nil
+}
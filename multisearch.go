@@ -0,0 +1,117 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * multisearch.go: Lookups across several independent stores
+ */
+
+package rangestore
+
+// Selects which of the stores covering a key MultiSearch reports
+type MatchMode int
+
+const (
+	// Only the first store, in the order given, which covers the key
+	FirstMatch MatchMode = iota
+	// Every store which covers the key
+	AllMatches
+)
+
+// A value found by MultiSearch, along with the index of the store it came from
+type Match struct {
+	Source int
+	Value  interface{}
+}
+
+// The outcome of looking up the key in a single store
+type storeResult struct {
+	source int
+	value  interface{}
+	err    error
+}
+
+// Looks up the key in each of the stores, e.g. in several independent blocklist
+// feeds, returning the matches in the order of the stores. With FirstMatch only
+// the first store covering the key is reported and the rest aren't consulted.
+// Returns ErrOutOfRange if none of the stores covers the key. A store returning
+// any other error fails the search, and the error from the first such store is
+// returned.
+func MultiSearch(stores []RangeSearcher, key uint64, mode MatchMode) ([]Match, error) {
+	ret := make([]Match, 0)
+	for idx, s := range stores {
+		v, err := s.RangeSearch(key)
+		if done, err := collectMatch(&ret, storeResult{idx, v, err}, mode); done {
+			return ret, err
+		}
+	}
+	return finishMatches(ret, key)
+}
+
+// Same as MultiSearch, but consults the stores concurrently, one goroutine
+// each, for stores whose lookups are slow, such as remote ones. With FirstMatch
+// it returns as soon as the first store covering the key, and every store before
+// it, have answered; the remaining lookups finish in the background.
+func MultiSearchConcurrent(stores []RangeSearcher, key uint64, mode MatchMode) ([]Match, error) {
+	// Buffered, so lookups finishing after the search returns don't block
+	results := make(chan storeResult, len(stores))
+	for idx, s := range stores {
+		go func(idx int, s RangeSearcher) {
+			v, err := s.RangeSearch(key)
+			results <- storeResult{idx, v, err}
+		}(idx, s)
+	}
+
+	// Results arrive in any order, but are collected in the order of the stores
+	arrived := make([]*storeResult, len(stores))
+	ret := make([]Match, 0)
+	next := 0
+	for range stores {
+		r := <-results
+		arrived[r.source] = &r
+		for ; next < len(stores) && arrived[next] != nil; next += 1 {
+			if done, err := collectMatch(&ret, *arrived[next], mode); done {
+				return ret, err
+			}
+		}
+	}
+	return finishMatches(ret, key)
+}
+
+// Adds the result to the matches, reporting whether the search is done, or the
+// error which failed it
+func collectMatch(matches *[]Match, r storeResult, mode MatchMode) (bool, error) {
+	if r.err != nil {
+		if _, ok := r.err.(ErrOutOfRange); ok {
+			return false, nil
+		}
+		*matches = nil
+		return true, r.err
+	}
+	*matches = append(*matches, Match{r.source, r.value})
+	return mode == FirstMatch, nil
+}
+
+func finishMatches(matches []Match, key uint64) ([]Match, error) {
+	if len(matches) == 0 {
+		return nil, ErrOutOfRange{key}
+	}
+	return matches, nil
+}
@@ -0,0 +1,73 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * multisearch_test.go: Tests for lookups across several stores
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Blocks lookups until release is closed
+type blockingSearcher struct {
+	release chan struct{}
+}
+
+func (s blockingSearcher) RangeSearch(val uint64) (interface{}, error) {
+	<-s.release
+	return "late", nil
+}
+
+func TestMultiSearch(t *testing.T) {
+	a, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "a"}})
+	b, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{5, 19, "b"}})
+	c, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{8, 8, "c"}})
+	stores := []RangeSearcher{a, b, c}
+
+	for name, search := range map[string]func([]RangeSearcher, uint64, MatchMode) ([]Match, error){
+		"sequential": MultiSearch,
+		"concurrent": MultiSearchConcurrent,
+	} {
+		if m, err := search(stores, 8, AllMatches); err != nil || !reflect.DeepEqual(m, []Match{{0, "a"}, {1, "b"}, {2, "c"}}) {
+			t.Fatalf("Wrong %s matches: %v, %v", name, m, err)
+		}
+		if m, err := search(stores, 15, FirstMatch); err != nil || !reflect.DeepEqual(m, []Match{{1, "b"}}) {
+			t.Fatalf("Wrong %s first match: %v, %v", name, m, err)
+		}
+		if _, err := search(stores, 25, AllMatches); err == nil || reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+			t.Fatalf("Expected ErrOutOfRange from the %s search, got %v", name, err)
+		}
+		if _, err := search([]RangeSearcher{a, failingSearcher{}}, 5, AllMatches); err == nil || err.Error() != "backend unavailable" {
+			t.Fatalf("Expected the %s search to fail, got %v", name, err)
+		}
+	}
+
+	// The first match doesn't wait for the stores after it
+	slow := blockingSearcher{make(chan struct{})}
+	defer close(slow.release)
+	if m, err := MultiSearchConcurrent([]RangeSearcher{a, slow}, 5, FirstMatch); err != nil || !reflect.DeepEqual(m, []Match{{0, "a"}}) {
+		t.Fatalf("Wrong concurrent first match: %v, %v", m, err)
+	}
+}
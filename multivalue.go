@@ -0,0 +1,100 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * multivalue.go: Ranges carrying several values
+ */
+
+package rangestore
+
+import (
+	"fmt"
+)
+
+type ErrAmbiguousValue struct {
+	key   uint64
+	count int
+}
+
+func (ex ErrAmbiguousValue) Error() string {
+	return fmt.Sprintf("Value %d maps to %d values", ex.key, ex.count)
+}
+
+// Returns the key which was looked up
+func (ex ErrAmbiguousValue) Key() uint64 {
+	return ex.key
+}
+
+// Returns the number of values the key maps to
+func (ex ErrAmbiguousValue) Count() int {
+	return ex.count
+}
+
+func (ex ErrAmbiguousValue) Details() ErrorDetails {
+	return ErrorDetails{"ErrAmbiguousValue", map[string]interface{}{"key": ex.key, "count": ex.count}}
+}
+
+// Accepts several ranges with exactly the same bounds, collecting their values
+// in the order they were added. Use RangeStore.RangeSearchAll to retrieve all of
+// them; RangeStore.RangeSearch still returns a single value, and fails with
+// ErrAmbiguousValue for keys which map to more than one. Ranges which overlap
+// without having identical bounds are still rejected, unless ResolveOverlaps
+// is given as well.
+func MultiValue() Option {
+	return func(o *options) {
+		o.multi = true
+	}
+}
+
+// Collapses the items with identical bounds into a single range whose value is
+// the []interface{} of their values, in the order they were given
+func groupIdentical(items []Ranged) []Ranged {
+	SortRanged(items)
+	ret := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		last := len(ret) - 1
+		if last >= 0 && ret[last].GetMin() == item.GetMin() && ret[last].GetMax() == item.GetMax() {
+			e := ret[last].(RangeEntry)
+			e.Value = append(e.Value.([]interface{}), item.GetValue())
+			ret[last] = e
+			continue
+		}
+		ret = append(ret, RangeEntry{item.GetMin(), item.GetMax(), []interface{}{item.GetValue()}})
+	}
+	return ret
+}
+
+// Returns all of the values mapped to the key, or nil if it isn't covered. For
+// stores built without MultiValue, this is the single value RangeSearch returns.
+// A default value counts as the only value of the keys which aren't covered.
+func (s *RangeStore) RangeSearchAll(val uint64) []interface{} {
+	v, err := s.search.RangeSearch(val)
+	if err != nil {
+		if s.opts.hasDefault {
+			return []interface{}{s.opts.def}
+		}
+		return nil
+	}
+	if s.opts.multi {
+		return append([]interface{}(nil), v.([]interface{})...)
+	}
+	return []interface{}{v}
+}
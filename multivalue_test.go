@@ -0,0 +1,75 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * multivalue_test.go: Tests on ranges carrying several values
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeStore_RangeSearchAll(t *testing.T) {
+	s, err := NewBuilder(MultiValue()).Add(0, 9, "A").Add(10, 19, "B").Add(0, 9, "C").Add(20, 29, "D").Build()
+	if err != nil {
+		t.Fatalf("Error while building multi value store: %s", err.Error())
+	}
+	if s.Len() != 3 {
+		t.Fatalf("Expected identical ranges to collapse, got %d ranges", s.Len())
+	}
+	if got := s.RangeSearchAll(5); !reflect.DeepEqual(got, []interface{}{"A", "C"}) {
+		t.Fatalf("Wrong values: %v", got)
+	}
+	if got := s.RangeSearchAll(15); !reflect.DeepEqual(got, []interface{}{"B"}) {
+		t.Fatalf("Wrong values: %v", got)
+	}
+	if got := s.RangeSearchAll(30); got != nil {
+		t.Fatalf("Expected no values: %v", got)
+	}
+	if v, err := s.RangeSearch(25); err != nil || v != "D" {
+		t.Fatalf("Wrong single value: %v", v)
+	}
+	_, err = s.RangeSearch(5)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrAmbiguousValue{}).Name() {
+		t.Fatalf("Expecting an ErrAmbiguousValue, but got something else")
+	}
+}
+
+func TestRangeStore_RangeSearchAllSingle(t *testing.T) {
+	s, err := NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	if got := s.RangeSearchAll(12); !reflect.DeepEqual(got, []interface{}{"B"}) {
+		t.Fatalf("Wrong values: %v", got)
+	}
+	_, err = NewBuilder().Add(0, 9, "A").Add(0, 9, "C").Build()
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDuplicateRange{}).Name() {
+		t.Fatalf("Expecting an ErrDuplicateRange without MultiValue, but got something else")
+	}
+	_, err = NewBuilder(MultiValue()).Add(0, 9, "A").Add(5, 14, "C").Build()
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOverlap{}).Name() {
+		t.Fatalf("Expecting an ErrOverlap for partial overlaps, but got something else")
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * navigate.go: Navigation between adjacent ranges
+ */
+
+package rangestore
+
+// Returns the first range which starts after afterMax. Passing the Max of a
+// range returns the range following it, so the store can be walked range by
+// range. Returns ErrOutOfRange if there is no such range.
+func (n *Node) NextRange(afterMax uint64) (RangeEntry, error) {
+	if n == nil {
+		return RangeEntry{}, ErrEmptyStore{}
+	}
+	var best *Node
+	for c := n; c != nil; {
+		if c.min > afterMax {
+			best = c
+			c = c.left
+		} else {
+			c = c.right
+		}
+	}
+	if best == nil {
+		return RangeEntry{}, ErrOutOfRange{afterMax}
+	}
+	return RangeEntry{best.min, best.max, best.value}, nil
+}
+
+// Returns the last range which ends before beforeMin. Passing the Min of a range
+// returns the range preceding it. Returns ErrOutOfRange if there is no such range.
+func (n *Node) PrevRange(beforeMin uint64) (RangeEntry, error) {
+	if n == nil {
+		return RangeEntry{}, ErrEmptyStore{}
+	}
+	var best *Node
+	for c := n; c != nil; {
+		if c.max < beforeMin {
+			best = c
+			c = c.right
+		} else {
+			c = c.left
+		}
+	}
+	if best == nil {
+		return RangeEntry{}, ErrOutOfRange{beforeMin}
+	}
+	return RangeEntry{best.min, best.max, best.value}, nil
+}
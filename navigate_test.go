@@ -0,0 +1,84 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * navigate_test.go: Tests on navigation between ranges
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_NextRange(t *testing.T) {
+	n, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{10, 19, "A"},
+		DefaultRangedValue{20, 29, "B"},
+		DefaultRangedValue{50, 59, "C"},
+		DefaultRangedValue{60, 69, "D"},
+		DefaultRangedValue{90, 99, "E"},
+	})
+
+	// Walk the whole store forwards
+	visited := make([]interface{}, 0)
+	e, err := n.NextRange(0)
+	for err == nil {
+		visited = append(visited, e.Value)
+		e, err = n.NextRange(e.Max)
+	}
+	if !reflect.DeepEqual(visited, []interface{}{"A", "B", "C", "D", "E"}) {
+		t.Fatalf("Wrong ranges visited: %v", visited)
+	}
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+
+	if e, _ := n.NextRange(35); e.Value != "C" {
+		t.Fatalf("Expected C after a gap, got %v", e)
+	}
+	if e, _ := n.NextRange(55); e.Value != "D" {
+		t.Fatalf("Expected D after a key inside C, got %v", e)
+	}
+}
+
+func TestNode_PrevRange(t *testing.T) {
+	n, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{10, 19, "A"},
+		DefaultRangedValue{20, 29, "B"},
+		DefaultRangedValue{50, 59, "C"},
+	})
+
+	// Walk the whole store backwards
+	visited := make([]interface{}, 0)
+	e, err := n.PrevRange(1000)
+	for err == nil {
+		visited = append(visited, e.Value)
+		e, err = n.PrevRange(e.Min)
+	}
+	if !reflect.DeepEqual(visited, []interface{}{"C", "B", "A"}) {
+		t.Fatalf("Wrong ranges visited: %v", visited)
+	}
+	if e, _ := n.PrevRange(40); e.Value != "B" {
+		t.Fatalf("Expected B before a gap, got %v", e)
+	}
+}
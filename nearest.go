@@ -0,0 +1,93 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * nearest.go: Floor, ceiling and nearest lookups
+ */
+
+package rangestore
+
+// Returns the range containing val or, if val falls outside of the store, the
+// closest range entirely below it, along with the distance from the end of that
+// range to val (0 when val is covered). Returns ErrOutOfRange if there is no
+// such range.
+func (n *Node) SearchFloor(val uint64) (RangeEntry, uint64, error) {
+	if n == nil {
+		return RangeEntry{}, 0, ErrEmptyStore{}
+	}
+	var best *Node
+	for c := n; c != nil; {
+		if val < c.min {
+			c = c.left
+		} else if val > c.max {
+			best = c
+			c = c.right
+		} else {
+			return RangeEntry{c.min, c.max, c.value}, 0, nil
+		}
+	}
+	if best == nil {
+		return RangeEntry{}, 0, ErrOutOfRange{val}
+	}
+	return RangeEntry{best.min, best.max, best.value}, val - best.max, nil
+}
+
+// Returns the range containing val or, if val falls outside of the store, the
+// closest range entirely above it, along with the distance from val to the start
+// of that range (0 when val is covered). Returns ErrOutOfRange if there is no
+// such range.
+func (n *Node) SearchCeiling(val uint64) (RangeEntry, uint64, error) {
+	if n == nil {
+		return RangeEntry{}, 0, ErrEmptyStore{}
+	}
+	var best *Node
+	for c := n; c != nil; {
+		if val < c.min {
+			best = c
+			c = c.left
+		} else if val > c.max {
+			c = c.right
+		} else {
+			return RangeEntry{c.min, c.max, c.value}, 0, nil
+		}
+	}
+	if best == nil {
+		return RangeEntry{}, 0, ErrOutOfRange{val}
+	}
+	return RangeEntry{best.min, best.max, best.value}, best.min - val, nil
+}
+
+// Returns the range closest to val, along with the distance to it (0 when val is
+// covered). When val sits exactly halfway between two ranges, the lower one wins.
+func (n *Node) SearchNearest(val uint64) (RangeEntry, uint64, error) {
+	floor, fd, ferr := n.SearchFloor(val)
+	if ferr == nil && fd == 0 {
+		return floor, 0, nil
+	}
+	ceil, cd, cerr := n.SearchCeiling(val)
+	if ferr != nil {
+		return ceil, cd, cerr
+	}
+	if cerr != nil || fd <= cd {
+		return floor, fd, nil
+	}
+	return ceil, cd, nil
+}
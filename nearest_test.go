@@ -0,0 +1,111 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * nearest_test.go: Tests on floor, ceiling and nearest lookups
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func nearestStore() *Node {
+	n, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{10, 19, "A"},
+		DefaultRangedValue{30, 39, "B"},
+		DefaultRangedValue{50, 59, "C"},
+		DefaultRangedValue{80, 89, "D"},
+	})
+	return n
+}
+
+func TestNode_SearchFloor(t *testing.T) {
+	n := nearestStore()
+	for key, expected := range map[uint64]struct {
+		value interface{}
+		dist  uint64
+	}{15: {"A", 0}, 25: {"A", 6}, 45: {"B", 6}, 60: {"C", 1}, 100: {"D", 11}} {
+		e, d, err := n.SearchFloor(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if e.Value != expected.value || d != expected.dist {
+			t.Fatalf("Wrong floor for %d: %v (%d)", key, e, d)
+		}
+	}
+	_, _, err := n.SearchFloor(5)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+}
+
+func TestNode_SearchCeiling(t *testing.T) {
+	n := nearestStore()
+	for key, expected := range map[uint64]struct {
+		value interface{}
+		dist  uint64
+	}{0: {"A", 10}, 15: {"A", 0}, 25: {"B", 5}, 60: {"D", 20}} {
+		e, d, err := n.SearchCeiling(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if e.Value != expected.value || d != expected.dist {
+			t.Fatalf("Wrong ceiling for %d: %v (%d)", key, e, d)
+		}
+	}
+	_, _, err := n.SearchCeiling(90)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+}
+
+func TestNode_SearchNearest(t *testing.T) {
+	n := nearestStore()
+	for key, expected := range map[uint64]struct {
+		value interface{}
+		dist  uint64
+	}{0: {"A", 10}, 35: {"B", 0}, 24: {"A", 5}, 25: {"B", 5}, 26: {"B", 4}, 69: {"C", 10}, 70: {"D", 10}, 95: {"D", 6}} {
+		e, d, err := n.SearchNearest(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if e.Value != expected.value || d != expected.dist {
+			t.Fatalf("Wrong nearest for %d: %v (%d)", key, e, d)
+		}
+	}
+}
+
+func TestNode_SearchNearestTie(t *testing.T) {
+	n, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 0, "A"},
+		DefaultRangedValue{4, 4, "B"},
+	})
+	e, d, err := n.SearchNearest(2)
+	if err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	if e.Value != "A" || d != 2 {
+		t.Fatalf("Expected the lower range to win a tie, got %v (%d)", e, d)
+	}
+}
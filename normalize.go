@@ -0,0 +1,108 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * normalize.go: Rescaling the weights of weighted items
+ */
+
+package rangestore
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Returns the greatest common divisor of the weights of the items, ignoring zero
+// weights, or 0 if every weight is zero
+func WeightsGCD(items []Weighted) uint64 {
+	g := uint64(0)
+	for _, item := range items {
+		for w := item.GetWeight(); w != 0; {
+			g, w = w, g%w
+		}
+	}
+	return g
+}
+
+// Returns the items with their weights divided by WeightsGCD, which picks each
+// of them with the same probability from a smaller total
+func ReduceWeights(items []Weighted) []Weighted {
+	g := WeightsGCD(items)
+	ret := make([]Weighted, 0, len(items))
+	for _, item := range items {
+		w := item.GetWeight()
+		if g > 1 {
+			w /= g
+		}
+		ret = append(ret, DefaultWeightedValue{w, item.GetValue()})
+	}
+	return ret
+}
+
+// Returns the items with their weights scaled to add up to targetTotal, e.g. to
+// turn bytes served per upstream, whose total could overflow, into shares of
+// 10000. The totals are worked out exactly, however large. Each weight is
+// rounded down, and the units left over go to the items which lost the most
+// to rounding, ties going to the earlier item, so the result doesn't depend
+// on anything but the order of the items. Heavier items never end up lighter
+// than lighter ones, but items with equal weights can end up one apart.
+//
+// Weights small enough to round down to zero stay zero, which NewWeightedStore
+// rejects unless WithDropZeroWeights is given. If every weight is zero the
+// items are returned unchanged.
+func NormalizeWeights(items []Weighted, targetTotal uint64) []Weighted {
+	total := new(big.Int)
+	for _, item := range items {
+		total.Add(total, new(big.Int).SetUint64(item.GetWeight()))
+	}
+	ret := make([]Weighted, 0, len(items))
+	if total.Sign() == 0 {
+		for _, item := range items {
+			ret = append(ret, DefaultWeightedValue{item.GetWeight(), item.GetValue()})
+		}
+		return ret
+	}
+
+	target := new(big.Int).SetUint64(targetTotal)
+	rems := make([]*big.Int, len(items))
+	assigned := uint64(0)
+	for idx, item := range items {
+		q, r := new(big.Int), new(big.Int)
+		q.QuoRem(new(big.Int).Mul(new(big.Int).SetUint64(item.GetWeight()), target), total, r)
+		rems[idx] = r
+		ret = append(ret, DefaultWeightedValue{q.Uint64(), item.GetValue()})
+		assigned += q.Uint64()
+	}
+	// The shares rounded down fall short by less than one unit per item
+	order := make([]int, len(items))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return rems[order[i]].Cmp(rems[order[j]]) > 0
+	})
+	for _, idx := range order[:targetTotal-assigned] {
+		d := ret[idx].(DefaultWeightedValue)
+		d.Weight += 1
+		ret[idx] = d
+	}
+	return ret
+}
@@ -0,0 +1,88 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * normalize_test.go: Tests for rescaling weights
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func weights(items []Weighted) []uint64 {
+	ret := make([]uint64, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.GetWeight())
+	}
+	return ret
+}
+
+func TestReduceWeights(t *testing.T) {
+	items := []Weighted{DefaultWeightedValue{300, "A"}, DefaultWeightedValue{0, "B"}, DefaultWeightedValue{450, "C"}}
+	if g := WeightsGCD(items); g != 150 {
+		t.Fatalf("Wrong GCD: %d", g)
+	}
+	reduced := ReduceWeights(items)
+	if !reflect.DeepEqual(reduced, []Weighted{DefaultWeightedValue{2, "A"}, DefaultWeightedValue{0, "B"}, DefaultWeightedValue{3, "C"}}) {
+		t.Fatalf("Wrong reduced items: %v", reduced)
+	}
+	if g := WeightsGCD([]Weighted{DefaultWeightedValue{0, "A"}}); g != 0 {
+		t.Fatalf("Expected a GCD of 0 for zero weights, got %d", g)
+	}
+}
+
+func TestNormalizeWeights(t *testing.T) {
+	// The total of these would overflow
+	items := []Weighted{
+		DefaultWeightedValue{math.MaxUint64, "A"},
+		DefaultWeightedValue{math.MaxUint64 / 2, "B"},
+		DefaultWeightedValue{math.MaxUint64 / 2, "C"},
+	}
+	if got := weights(NormalizeWeights(items, 1000)); !reflect.DeepEqual(got, []uint64{500, 250, 250}) {
+		t.Fatalf("Wrong normalized weights: %v", got)
+	}
+
+	// 1000 split three ways leaves a unit over, which goes to the first item
+	items = []Weighted{DefaultWeightedValue{1, "A"}, DefaultWeightedValue{1, "B"}, DefaultWeightedValue{1, "C"}}
+	if got := weights(NormalizeWeights(items, 1000)); !reflect.DeepEqual(got, []uint64{334, 333, 333}) {
+		t.Fatalf("Wrong normalized weights: %v", got)
+	}
+
+	// The units left over go to the largest remainders, keeping the order
+	items = []Weighted{DefaultWeightedValue{1, "A"}, DefaultWeightedValue{7, "B"}, DefaultWeightedValue{2, "C"}}
+	got := weights(NormalizeWeights(items, 6))
+	if !reflect.DeepEqual(got, []uint64{1, 4, 1}) {
+		t.Fatalf("Wrong normalized weights: %v", got)
+	}
+	normalized := NormalizeWeights(items, 6)
+	if normalized[1].GetValue() != "B" {
+		t.Fatalf("Expected the values to be kept")
+	}
+
+	zero := []Weighted{DefaultWeightedValue{0, "A"}}
+	if got := weights(NormalizeWeights(zero, 10)); !reflect.DeepEqual(got, []uint64{0}) {
+		t.Fatalf("Expected zero weights to be left alone, got %v", got)
+	}
+}
@@ -0,0 +1,139 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * observer.go: Hooks reporting on the progress of builds
+ */
+
+package rangestore
+
+import (
+	"time"
+)
+
+const (
+	defaultProgressInterval = 1 << 16
+	defaultPivotDepth       = 3
+)
+
+// Callbacks reporting on the progress of Builder.Build, e.g. to log how far a
+// large build got before failing. Any of them may be nil. They're called
+// synchronously from the goroutine running Build.
+type BuildObserver struct {
+	// Called with the number of ranges validated so far, every ProgressInterval
+	// ranges and once validation is done
+	OnProgress func(validated, total int)
+	// Called for each pivot chosen in the top PivotDepth levels of the tree,
+	// from the root down
+	OnPivot func(p BuildPivot)
+	// Called once Build returns, whether it succeeded or not
+	OnComplete func(stats BuildStats)
+
+	// How often OnProgress is called, 65536 ranges if zero
+	ProgressInterval int
+	// How many levels of pivots are reported, 3 if zero
+	PivotDepth int
+}
+
+// A range chosen as the root of a subtree
+type BuildPivot struct {
+	// The depth of the subtree, 0 for the root of the tree
+	Depth int
+	Entry RangeEntry
+	// The number of ranges on either side of the pivot
+	Left, Right int
+}
+
+// Summarizes a build. Only Duration and Err are set if the build failed.
+type BuildStats struct {
+	Ranges   int
+	Depth    int
+	Duration time.Duration
+	Err      error
+}
+
+// Reports on the progress of builds to o
+func WithBuildObserver(o BuildObserver) Option {
+	return func(opts *options) {
+		opts.observer = &o
+	}
+}
+
+// Returns the function passed to cumulativeWidthsProgress, or nil if no one is
+// listening
+func (o *BuildObserver) progress(total int) func(validated int) {
+	if o == nil || o.OnProgress == nil {
+		return nil
+	}
+	interval := o.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	return func(validated int) {
+		if validated%interval == 0 || validated == total {
+			o.OnProgress(validated, total)
+		}
+	}
+}
+
+// Reports the pivots in the top levels of the tree
+func (o *BuildObserver) pivots(n *Node) {
+	if o == nil || o.OnPivot == nil {
+		return
+	}
+	limit := o.PivotDepth
+	if limit <= 0 {
+		limit = defaultPivotDepth
+	}
+	found := make([]BuildPivot, 0)
+	var visit func(c *Node, depth int) int
+	visit = func(c *Node, depth int) int {
+		if c == nil {
+			return 0
+		}
+		if depth >= limit {
+			return c.Len()
+		}
+		// Reserve the slot first, so that the pivots come out in pre-order
+		idx := len(found)
+		found = append(found, BuildPivot{Depth: depth, Entry: RangeEntry{c.min, c.max, c.value}})
+		left := visit(c.left, depth+1)
+		right := visit(c.right, depth+1)
+		found[idx].Left, found[idx].Right = left, right
+		return left + right + 1
+	}
+	visit(n, 0)
+	for _, p := range found {
+		o.OnPivot(p)
+	}
+}
+
+func (o *BuildObserver) complete(s *RangeStore, err error, elapsed time.Duration) {
+	if o == nil || o.OnComplete == nil {
+		return
+	}
+	stats := BuildStats{Duration: elapsed, Err: err}
+	if s != nil {
+		stats.Ranges = s.Len()
+		stats.Depth = s.root.Depth()
+	}
+	o.OnComplete(stats)
+}
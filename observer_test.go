@@ -0,0 +1,85 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * observer_test.go: Tests on the build hooks
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_Observer(t *testing.T) {
+	progress := make([][2]int, 0)
+	pivots := make([]BuildPivot, 0)
+	var stats BuildStats
+	observer := BuildObserver{
+		OnProgress: func(validated, total int) { progress = append(progress, [2]int{validated, total}) },
+		OnPivot:    func(p BuildPivot) { pivots = append(pivots, p) },
+		OnComplete: func(s BuildStats) { stats = s },
+
+		ProgressInterval: 4,
+		PivotDepth:       2,
+	}
+	b := NewBuilder(WithBuildObserver(observer))
+	for idx := uint64(0); idx < 10; idx += 1 {
+		b.Add(idx*10, idx*10+9, idx)
+	}
+	s, err := b.Build()
+	if err != nil {
+		t.Fatalf("Error while building: %s", err.Error())
+	}
+	if !reflect.DeepEqual(progress, [][2]int{{4, 10}, {8, 10}, {10, 10}}) {
+		t.Fatalf("Wrong progress: %v", progress)
+	}
+	root := s.Root()
+	expected := []BuildPivot{
+		{0, RangeEntry{root.min, root.max, root.value}, root.left.Len(), root.right.Len()},
+		{1, RangeEntry{root.left.min, root.left.max, root.left.value}, root.left.left.Len(), root.left.right.Len()},
+		{1, RangeEntry{root.right.min, root.right.max, root.right.value}, root.right.left.Len(), root.right.right.Len()},
+	}
+	if !reflect.DeepEqual(pivots, expected) {
+		t.Fatalf("Wrong pivots: %v [%v]", pivots, expected)
+	}
+	if stats.Ranges != 10 || stats.Depth != root.Depth() || stats.Err != nil {
+		t.Fatalf("Wrong stats: %+v", stats)
+	}
+
+	// Failures are reported too, after the ranges validated before them
+	progress = progress[:0]
+	b = NewBuilder(WithBuildObserver(observer))
+	for idx := uint64(0); idx < 10; idx += 1 {
+		b.Add(idx*10, idx*10+9, idx)
+	}
+	b.Add(75, 75, "overlap")
+	if _, err := b.Build(); err == nil {
+		t.Fatalf("Expected the overlap to fail the build")
+	}
+	if reflect.TypeOf(stats.Err).Name() != "ErrOverlap" || stats.Ranges != 0 {
+		t.Fatalf("Wrong stats for a failed build: %+v", stats)
+	}
+	if !reflect.DeepEqual(progress, [][2]int{{4, 11}, {8, 11}}) {
+		t.Fatalf("Wrong progress for a failed build: %v", progress)
+	}
+}
@@ -0,0 +1,245 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * packed.go: Zero copy store format searched in place
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// The packed format is a flat, little endian layout meant to be simple to read
+// from any language, and to be searched where it lies, with nothing decoded up
+// front. Unlike the mapped format there's no tree and no checksum, so opening a
+// store only reads its header:
+//
+//	header   packedHeaderSize bytes: the magic, the version as a uint32, 4
+//	         reserved bytes, the number of ranges and the length of the blob,
+//	         both as uint64
+//	ranges   packedRecordSize bytes per range, sorted by min: the min, the max
+//	         and the offset of the value in the blob as uint64s, the length of
+//	         the value as a uint32, and 4 reserved bytes
+//	blob     the values, back to back
+//
+// Every record is 8 byte aligned, and a lookup is a binary search over them.
+const (
+	packedMagic      = "RNGPACK\x00"
+	packedVersion    = 1
+	packedHeaderSize = 32
+	packedRecordSize = 32
+)
+
+// Writes the store in the packed format read by NewPackedStore, with the values
+// encoded by codec, or StringCodec if it's nil. Identical values are only
+// written once.
+func WritePacked(w io.Writer, n *Node, codec ValueCodec) error {
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	var blob bytes.Buffer
+	offsets := make(map[string]uint64)
+	records := make([]byte, 0, n.Len()*packedRecordSize)
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		var encoded []byte
+		if encoded, err = codec.Encode(c.value); err != nil {
+			return
+		}
+		value := string(encoded)
+		off, ok := offsets[value]
+		if !ok {
+			off = uint64(blob.Len())
+			offsets[value] = off
+			blob.WriteString(value)
+		}
+		var rec [packedRecordSize]byte
+		binary.LittleEndian.PutUint64(rec[0:], c.min)
+		binary.LittleEndian.PutUint64(rec[8:], c.max)
+		binary.LittleEndian.PutUint64(rec[16:], off)
+		binary.LittleEndian.PutUint32(rec[24:], uint32(len(value)))
+		records = append(records, rec[:]...)
+	})
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, packedHeaderSize)
+	copy(header, packedMagic)
+	binary.LittleEndian.PutUint32(header[8:], packedVersion)
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(records)/packedRecordSize))
+	binary.LittleEndian.PutUint64(header[24:], uint64(blob.Len()))
+	for _, section := range [][]byte{header, records, blob.Bytes()} {
+		if _, err := w.Write(section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A read only store which searches directly against bytes written by
+// WritePacked. Nothing is copied or decoded until a value is asked for.
+type PackedStore struct {
+	data    []byte
+	count   uint64
+	records []byte
+	blob    []byte
+	unmap   func([]byte) error
+	codec   ValueCodec
+}
+
+// Memory maps the file at path, see NewPackedStore. The store must be closed
+// once it's no longer needed.
+func OpenPacked(path string) (*PackedStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < packedHeaderSize {
+		return nil, ErrCorruptStore{"file is too short for the header"}
+	}
+	data, err := mapFile(f, int(fi.Size()))
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewPackedStore(data)
+	if err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	p.unmap = unmapFile
+	return p, nil
+}
+
+// Reads a store from bytes written by WritePacked, without copying them. Only
+// the header is checked, against the size of the data, so this takes the same
+// time however large the store is. Records pointing outside the blob give an
+// ErrCorruptStore when they're looked up; ranges which are out of order give
+// wrong results rather than errors.
+func NewPackedStore(data []byte) (*PackedStore, error) {
+	if len(data) < packedHeaderSize || string(data[:8]) != packedMagic {
+		return nil, ErrCorruptStore{"bad magic"}
+	}
+	if version := binary.LittleEndian.Uint32(data[8:]); version != packedVersion {
+		return nil, ErrUnsupportedVersion{version}
+	}
+	count := binary.LittleEndian.Uint64(data[16:])
+	blobLen := binary.LittleEndian.Uint64(data[24:])
+	size := uint64(len(data)) - packedHeaderSize
+	if count == 0 || count > size/packedRecordSize {
+		return nil, ErrCorruptStore{"range section is out of bounds"}
+	}
+	blobOff := packedHeaderSize + count*packedRecordSize
+	if blobLen > uint64(len(data))-blobOff {
+		return nil, ErrCorruptStore{"blob section is out of bounds"}
+	}
+	return &PackedStore{
+		data:    data,
+		count:   count,
+		records: data[packedHeaderSize:blobOff],
+		blob:    data[blobOff : blobOff+blobLen],
+	}, nil
+}
+
+func (p *PackedStore) record(i uint64) []byte {
+	return p.records[i*packedRecordSize : (i+1)*packedRecordSize]
+}
+
+// Returns the value associated with the key as a slice of the underlying bytes,
+// which must not be modified, and is only valid until the store is closed
+func (p *PackedStore) RangeSearchBytes(val uint64) ([]byte, error) {
+	// Finds the first range whose max is at least the key
+	lo, hi := uint64(0), p.count
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if binary.LittleEndian.Uint64(p.record(mid)[8:]) < val {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == p.count {
+		return nil, ErrOutOfRange{val}
+	}
+	rec := p.record(lo)
+	if val < binary.LittleEndian.Uint64(rec[0:]) {
+		return nil, ErrOutOfRange{val}
+	}
+	off := binary.LittleEndian.Uint64(rec[16:])
+	length := uint64(binary.LittleEndian.Uint32(rec[24:]))
+	if off > uint64(len(p.blob)) || length > uint64(len(p.blob))-off {
+		return nil, ErrCorruptStore{"value is out of bounds"}
+	}
+	return p.blob[off : off+length], nil
+}
+
+// Sets the codec used to decode values, which must match the one the store was
+// written with. The default is StringCodec.
+func (p *PackedStore) SetCodec(codec ValueCodec) {
+	p.codec = codec
+}
+
+// Returns the value associated with the key, decoded by the codec of the store,
+// which by default gives a string
+func (p *PackedStore) RangeSearch(val uint64) (interface{}, error) {
+	b, err := p.RangeSearchBytes(val)
+	if err != nil {
+		return nil, err
+	}
+	if p.codec == nil {
+		return string(b), nil
+	}
+	return p.codec.Decode(b)
+}
+
+// Returns the number of ranges in the store
+func (p *PackedStore) Len() int {
+	return int(p.count)
+}
+
+// Returns the smallest and largest keys covered by the store
+func (p *PackedStore) Bounds() (min, max uint64) {
+	return binary.LittleEndian.Uint64(p.record(0)[0:]), binary.LittleEndian.Uint64(p.record(p.count - 1)[8:])
+}
+
+// Releases the mapping, if the store was opened with OpenPacked. The store, and
+// any slices returned by it, must not be used afterwards.
+func (p *PackedStore) Close() error {
+	if p.unmap == nil {
+		return nil
+	}
+	data, unmap := p.data, p.unmap
+	p.data, p.records, p.blob, p.unmap = nil, nil, nil, nil
+	return unmap(data)
+}
@@ -0,0 +1,154 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * packed_test.go: Tests on the packed store
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPackedStore_RoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.pack")
+
+	var buf bytes.Buffer
+	if err := WritePacked(&buf, mappedTestStore(t), nil); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	if buf.Len() != packedHeaderSize+4*packedRecordSize+3 {
+		// The header, four records, then the deduplicated blob "ABC"
+		t.Fatalf("Unexpected file size %d", buf.Len())
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Error while writing file: %s", err.Error())
+	}
+
+	p, err := OpenPacked(path)
+	if err != nil {
+		t.Fatalf("Error while opening store: %s", err.Error())
+	}
+	defer p.Close()
+	if p.Len() != 4 {
+		t.Fatalf("Wrong number of ranges: %d", p.Len())
+	}
+	if min, max := p.Bounds(); min != 0 || max != 299 {
+		t.Fatalf("Wrong bounds: %d, %d", min, max)
+	}
+	for key, expected := range map[uint64]string{0: "A", 9: "A", 10: "B", 99: "B", 150: "C", 250: "A", 299: "A"} {
+		if v, err := p.RangeSearch(key); err != nil || v != expected {
+			t.Fatalf("Wrong value for %d: %v, %v", key, v, err)
+		}
+	}
+	for _, key := range []uint64{100, 149, 300} {
+		if _, err := p.RangeSearch(key); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+			t.Fatalf("Expected an ErrOutOfRange for %d, got %v", key, err)
+		}
+	}
+}
+
+func TestPackedStore_Codec(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, 100})
+	items = append(items, DefaultRangedValue{10, 19, -3})
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	var buf bytes.Buffer
+	if err := WritePacked(&buf, n, IntCodec{}); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	p, err := NewPackedStore(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Error while reading store: %s", err.Error())
+	}
+	p.SetCodec(IntCodec{})
+	if v, err := p.RangeSearch(12); err != nil || v != int64(-3) {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+}
+
+func TestNewPackedStore_Corrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePacked(&buf, mappedTestStore(t), nil); err != nil {
+		t.Fatalf("Error while writing store: %s", err.Error())
+	}
+	good := buf.Bytes()
+
+	if _, err := NewPackedStore(good[:packedHeaderSize+packedRecordSize]); reflect.TypeOf(err).Name() != "ErrCorruptStore" {
+		t.Fatalf("Expected an ErrCorruptStore for truncated data, got %v", err)
+	}
+	version := append([]byte(nil), good...)
+	version[8] = 9
+	if _, err := NewPackedStore(version); reflect.TypeOf(err).Name() != "ErrUnsupportedVersion" {
+		t.Fatalf("Expected an ErrUnsupportedVersion, got %v", err)
+	}
+	// A value pointing past the blob is caught when it's looked up
+	bad := append([]byte(nil), good...)
+	bad[packedHeaderSize+16] = 200
+	p, err := NewPackedStore(bad)
+	if err != nil {
+		t.Fatalf("Error while reading store: %s", err.Error())
+	}
+	if _, err := p.RangeSearch(5); reflect.TypeOf(err).Name() != "ErrCorruptStore" {
+		t.Fatalf("Expected an ErrCorruptStore, got %v", err)
+	}
+	if v, err := p.RangeSearch(50); err != nil || v != "B" {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+}
+
+func BenchmarkPackedStore_RangeSearch(b *testing.B) {
+	items := arenaItems(100000)
+	values := make([]Ranged, len(items))
+	for i, item := range items {
+		values[i] = RangeEntry{item.GetMin(), item.GetMax(), "v"}
+	}
+	n, err := NewRangeStoreFromSorted(values)
+	if err != nil {
+		b.Fatalf("Error while building range store: %s", err.Error())
+	}
+	var buf bytes.Buffer
+	if err := WritePacked(&buf, n, nil); err != nil {
+		b.Fatalf("Error while writing store: %s", err.Error())
+	}
+	p, err := NewPackedStore(buf.Bytes())
+	if err != nil {
+		b.Fatalf("Error while reading store: %s", err.Error())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.RangeSearchBytes(uint64(i*7919) % 1000000)
+	}
+}
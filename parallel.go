@@ -0,0 +1,109 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * parallel.go: Parallel construction of large trees
+ */
+
+package rangestore
+
+import (
+	"sync"
+)
+
+// Subtrees with fewer items than this are always built by the goroutine which
+// reaches them, since handing them off costs more than building them
+const parallelThreshold = 1 << 14
+
+// Builds the tree for already validated items using up to workers goroutines.
+// Since the left and right subtrees of a node cover disjoint items, large left
+// subtrees are handed off to other goroutines while the right subtree is built
+// in place. The result has exactly the same shape as buildFromCumulative.
+type parallelBuild struct {
+	items  []Ranged
+	cum    []uint64
+	tokens chan struct{}
+	arena  bool
+}
+
+func buildParallel(items []Ranged, cum []uint64, workers int, arena bool) *Node {
+	p := &parallelBuild{items: items, cum: cum, arena: arena}
+	if workers > 1 {
+		p.tokens = make(chan struct{}, workers-1)
+	}
+	var slots []Node
+	if arena {
+		slots = make([]Node, len(items))
+	}
+	return p.build(0, len(items), slots)
+}
+
+// Builds the subtree for items[lo:hi]. When building into an arena, slots holds
+// exactly hi-lo nodes, which are filled in pre-order just like buildArena does.
+func (p *parallelBuild) build(lo, hi int, slots []Node) *Node {
+	var n *Node
+	if p.arena {
+		n = &slots[0]
+	} else {
+		n = &Node{}
+	}
+	ridx := lo
+	if hi-lo > 1 {
+		ridx = pivotIndex(p.cum, lo, hi)
+	}
+	n.min = p.items[ridx].GetMin()
+	n.max = p.items[ridx].GetMax()
+	n.value = p.items[ridx].GetValue()
+
+	var leftSlots, rightSlots []Node
+	if p.arena {
+		leftSlots = slots[1 : 1+ridx-lo]
+		rightSlots = slots[1+ridx-lo:]
+	}
+	var wg sync.WaitGroup
+	if ridx != lo {
+		if ridx-lo >= parallelThreshold && p.acquire() {
+			wg.Add(1)
+			go func() {
+				n.left = p.build(lo, ridx, leftSlots)
+				<-p.tokens
+				wg.Done()
+			}()
+		} else {
+			n.left = p.build(lo, ridx, leftSlots)
+		}
+	}
+	if ridx != hi-1 {
+		n.right = p.build(ridx+1, hi, rightSlots)
+	}
+	wg.Wait()
+	return n
+}
+
+// Claims a worker if one is free
+func (p *parallelBuild) acquire() bool {
+	select {
+	case p.tokens <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,93 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * parallel_test.go: Tests and benchmarks on parallel construction
+ */
+
+package rangestore
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// Reports whether both trees have the same shape, bounds and values
+func sameTree(a, b *Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.min == b.min && a.max == b.max && a.value == b.value &&
+		sameTree(a.left, b.left) && sameTree(a.right, b.right)
+}
+
+func TestBuilder_WithParallelism(t *testing.T) {
+	items := arenaItems(200000)
+	// Skew the weights so the pivots aren't all in the middle
+	items[1000] = DefaultRangedValue{10000, 5000009, 1000}
+	for idx := 1001; idx < len(items); idx += 1 {
+		items[idx] = DefaultRangedValue{uint64(idx*10 + 4990000), uint64(idx*10 + 4990009), idx}
+	}
+
+	sequential, err := NewRangeStore(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	for _, opts := range [][]Option{
+		{WithParallelism(4)},
+		{WithParallelism(4), WithArena()},
+		{WithParallelism(1)},
+	} {
+		parallel, err := NewRangeStore(items, opts...)
+		if err != nil {
+			t.Fatalf("Error while building range store: %s", err.Error())
+		}
+		if !sameTree(sequential.Root(), parallel.Root()) {
+			t.Fatalf("Parallel build produced a different tree")
+		}
+	}
+
+	arena, _ := NewRangeStore(items, WithParallelism(4), WithArena())
+	root := arena.Root()
+	if addr(root.left) != addr(root)+unsafe.Sizeof(Node{}) {
+		t.Fatalf("Parallel arena build isn't laid out in pre-order")
+	}
+}
+
+func Benchmark_Build_Sequential(b *testing.B) {
+	items := arenaItems(1000000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		if _, err := NewRangeStore(items); err != nil {
+			b.Fatalf("Got an error while building: %s", err.Error())
+		}
+	}
+}
+
+func Benchmark_Build_Parallel(b *testing.B) {
+	items := arenaItems(1000000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		if _, err := NewRangeStore(items, WithParallelism(4)); err != nil {
+			b.Fatalf("Got an error while building: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * pipeline.go: Looking up streams of keys
+ */
+
+package rangestore
+
+import (
+	"context"
+	"sync"
+)
+
+// The outcome of looking up a single key with SearchAll
+type Result struct {
+	Key   uint64
+	Value interface{}
+	Err   error
+}
+
+// Looks up every key received from keys in s, using up to workers goroutines,
+// and sends a Result for each to results, e.g. to enrich a stream of flow logs
+// with the AS of each address. Results arrive in no particular order. Stops once
+// keys is closed and drained, returning nil, or once the context is cancelled,
+// returning its error; either way, results is closed before returning, and no
+// goroutines are left behind. A workers count below 1 is treated as 1.
+func SearchAll(ctx context.Context, s RangeSearcher, keys <-chan uint64, results chan<- Result, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case key, ok := <-keys:
+					if !ok {
+						return
+					}
+					v, err := s.RangeSearch(key)
+					select {
+					case results <- Result{key, v, err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+	return ctx.Err()
+}
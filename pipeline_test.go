@@ -0,0 +1,90 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * pipeline_test.go: Tests on streaming lookups
+ */
+
+package rangestore
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSearchAll(t *testing.T) {
+	s, err := NewBuilder().AllowGaps().Add(0, 9, "A").Add(20, 29, "B").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	keys := make(chan uint64)
+	results := make(chan Result)
+	done := make(chan error, 1)
+	go func() {
+		done <- SearchAll(context.Background(), s, keys, results, 4)
+	}()
+	go func() {
+		for key := uint64(0); key < 40; key += 1 {
+			keys <- key
+		}
+		close(keys)
+	}()
+
+	hits, misses := 0, 0
+	for r := range results {
+		want, wantErr := s.RangeSearch(r.Key)
+		if r.Value != want || reflect.TypeOf(r.Err) != reflect.TypeOf(wantErr) {
+			t.Fatalf("Wrong result for %d: %v", r.Key, r)
+		}
+		if r.Err != nil {
+			misses += 1
+		} else {
+			hits += 1
+		}
+	}
+	if hits != 20 || misses != 20 {
+		t.Fatalf("Wrong totals: %d hits, %d misses", hits, misses)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestSearchAll_Cancel(t *testing.T) {
+	s, _ := NewBuilder().Add(0, 9, "A").Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	keys := make(chan uint64, 1)
+	keys <- 5
+	// Nobody reads the results, so the workers can only stop by cancellation
+	results := make(chan Result)
+	done := make(chan error, 1)
+	go func() {
+		done <- SearchAll(ctx, s, keys, results, 2)
+	}()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Expected the context error, got %v", err)
+	}
+	if _, ok := <-results; ok {
+		t.Fatalf("Expected results to be closed")
+	}
+}
@@ -0,0 +1,131 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * priority.go: Build time priority resolution of overlapping ranges
+ */
+
+package rangestore
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Builds a range store from ranges which may overlap, resolving every overlap
+// at build time so that each key maps to the value of the highest priority
+// range containing it. When ranges of equal priority overlap, the one which
+// comes first in items wins, as it does in IntervalTree, so more specific
+// overrides can simply be listed before broad defaults. The items don't need to
+// be sorted, and keys which no range covers are left as gaps.
+func NewRangeStoreFromPrioritized(items []Prioritized) (*Node, error) {
+	if len(items) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	order := make([]int, 0, len(items))
+	for idx, item := range items {
+		if item.GetMin() > item.GetMax() {
+			return nil, ErrInvertedRange{item.GetMin(), item.GetMax()}
+		}
+		order = append(order, idx)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return items[order[i]].GetMin() < items[order[j]].GetMin()
+	})
+
+	resolved := make([]Ranged, 0)
+	last := -1
+	emit := func(min, max uint64, idx int) {
+		// Coalesce consecutive pieces of the same winning range
+		if idx == last && resolved[len(resolved)-1].GetMax()+1 == min {
+			resolved[len(resolved)-1] = RangeEntry{resolved[len(resolved)-1].GetMin(), max, items[idx].GetValue()}
+			return
+		}
+		resolved = append(resolved, RangeEntry{min, max, items[idx].GetValue()})
+		last = idx
+	}
+
+	active := &priorityHeap{items: items}
+	pos := uint64(0)
+	next := 0
+	for next < len(order) || active.Len() > 0 {
+		if active.Len() == 0 {
+			pos = items[order[next]].GetMin()
+		}
+		for next < len(order) && items[order[next]].GetMin() <= pos {
+			heap.Push(active, order[next])
+			next += 1
+		}
+		// Lazily drop ranges which ended before the current position
+		for active.Len() > 0 && items[active.idx[0]].GetMax() < pos {
+			heap.Pop(active)
+		}
+		if active.Len() == 0 {
+			continue
+		}
+
+		// The winner holds until it ends, or until a new range starts which
+		// might beat it
+		top := active.idx[0]
+		end := items[top].GetMax()
+		if next < len(order) && items[order[next]].GetMin()-1 < end {
+			end = items[order[next]].GetMin() - 1
+		}
+		emit(pos, end, top)
+		if end == math.MaxUint64 {
+			break
+		}
+		pos = end + 1
+	}
+
+	// Resolution produces sorted, non-overlapping ranges, so skip the checks
+	return rangeStoreFromSortedChecked(resolved, false, true)
+}
+
+// Max heap of indexes into items, ordered by priority, with ties going to the
+// lower index
+type priorityHeap struct {
+	items []Prioritized
+	idx   []int
+}
+
+func (h *priorityHeap) Len() int {
+	return len(h.idx)
+}
+func (h *priorityHeap) Less(i, j int) bool {
+	pi, pj := h.items[h.idx[i]].GetPriority(), h.items[h.idx[j]].GetPriority()
+	if pi != pj {
+		return pi > pj
+	}
+	return h.idx[i] < h.idx[j]
+}
+func (h *priorityHeap) Swap(i, j int) {
+	h.idx[i], h.idx[j] = h.idx[j], h.idx[i]
+}
+func (h *priorityHeap) Push(x interface{}) {
+	h.idx = append(h.idx, x.(int))
+}
+func (h *priorityHeap) Pop() interface{} {
+	x := h.idx[len(h.idx)-1]
+	h.idx = h.idx[:len(h.idx)-1]
+	return x
+}
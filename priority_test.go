@@ -0,0 +1,104 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * priority_test.go: Tests on priority resolution
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRangeStoreFromPrioritized(t *testing.T) {
+	items := make([]Prioritized, 0)
+	items = append(items, DefaultPrioritizedValue{0, 99, 0, "default"})
+	items = append(items, DefaultPrioritizedValue{40, 59, 5, "campus"})
+	items = append(items, DefaultPrioritizedValue{50, 54, 10, "office"})
+	items = append(items, DefaultPrioritizedValue{200, 299, 0, "remote"})
+
+	n, err := NewRangeStoreFromPrioritized(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	expected := []RangeEntry{
+		{0, 39, "default"},
+		{40, 49, "campus"},
+		{50, 54, "office"},
+		{55, 59, "campus"},
+		{60, 99, "default"},
+		{200, 299, "remote"},
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong resolved ranges: %v", got)
+	}
+	if _, err := n.RangeSearch(150); err == nil {
+		t.Fatalf("Expected keys covered by no range to remain a gap")
+	}
+}
+
+func TestRangeStoreFromPrioritized_Ties(t *testing.T) {
+	items := make([]Prioritized, 0)
+	items = append(items, DefaultPrioritizedValue{10, 19, 1, "override"})
+	items = append(items, DefaultPrioritizedValue{0, math.MaxUint64, 1, "broad"})
+	items = append(items, DefaultPrioritizedValue{15, 24, 1, "specific"})
+
+	n, err := NewRangeStoreFromPrioritized(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	// Earlier items win ties
+	expected := []RangeEntry{
+		{0, 9, "broad"},
+		{10, 19, "override"},
+		{20, math.MaxUint64, "broad"},
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong resolved ranges: %v", got)
+	}
+
+	// IntervalTree settles ties the same way
+	ranged := make([]Ranged, 0)
+	for _, item := range items {
+		ranged = append(ranged, item)
+	}
+	tree, _ := NewIntervalTree(ranged)
+	for key := range map[uint64]bool{5: true, 12: true, 17: true, 22: true, 100: true} {
+		expected, _ := n.RangeSearch(key)
+		if v, err := tree.RangeSearch(key); err != nil || v != expected {
+			t.Fatalf("Interval tree disagrees for %d: %v [%v]", key, v, expected)
+		}
+	}
+}
+
+func TestRangeStoreFromPrioritized_Invalid(t *testing.T) {
+	_, err := NewRangeStoreFromPrioritized(make([]Prioritized, 0))
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+	_, err = NewRangeStoreFromPrioritized([]Prioritized{DefaultPrioritizedValue{10, 5, 0, "A"}})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvertedRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvertedRange, but got something else")
+	}
+}
@@ -0,0 +1,138 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * proportions.go: Weights given as proportions
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// A value with a fractional weight, such as a percentage of traffic
+type FloatWeighted interface {
+	GetProportion() float64
+	GetValue() interface{}
+}
+
+type DefaultFloatWeightedValue struct {
+	Proportion float64
+	Value      interface{}
+}
+
+func (w DefaultFloatWeightedValue) GetProportion() float64 {
+	return w.Proportion
+}
+func (w DefaultFloatWeightedValue) GetValue() interface{} {
+	return w.Value
+}
+
+type ErrInvalidProportion struct {
+	index      int
+	proportion float64
+}
+
+func (ex ErrInvalidProportion) Error() string {
+	return fmt.Sprintf("Item %d has an invalid proportion %v", ex.index, ex.proportion)
+}
+
+// Returns the index of the offending item
+func (ex ErrInvalidProportion) Index() int {
+	return ex.index
+}
+
+// Returns the proportion which was rejected
+func (ex ErrInvalidProportion) Proportion() float64 {
+	return ex.proportion
+}
+
+func (ex ErrInvalidProportion) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidProportion", map[string]interface{}{"index": ex.index, "proportion": ex.proportion}}
+}
+
+// Builds a store as NewRangeStoreFromWeighted does, converting the proportions to
+// integer weights which add up to exactly resolution. The proportions are taken
+// relative to their sum, so they needn't add up to 1. Each item gets the floor of
+// its exact share of the resolution, and the keys left over go one at a time to
+// the items with the largest remainders, the earlier item winning ties.
+//
+// Items whose share rounds down to nothing are left out. Proportions must be
+// finite and not negative, otherwise ErrInvalidProportion is returned. If there
+// are no items, the proportions are all zero or the resolution is 0,
+// ErrEmptyInput is returned.
+func NewRangeStoreFromProportions(items []FloatWeighted, resolution uint64) (*Node, error) {
+	weights, err := apportion(items, resolution)
+	if err != nil {
+		return nil, err
+	}
+	weighted := make([]Weighted, 0, len(items))
+	for idx, item := range items {
+		weighted = append(weighted, DefaultWeightedValue{weights[idx], item.GetValue()})
+	}
+	return NewRangeStoreFromWeighted(weighted, WithDropZeroWeights())
+}
+
+// Splits resolution between the items in proportion to their weights, using
+// exact rational arithmetic and the largest remainder method
+func apportion(items []FloatWeighted, resolution uint64) ([]uint64, error) {
+	sum := new(big.Rat)
+	for idx, item := range items {
+		p := item.GetProportion()
+		if p < 0 || math.IsNaN(p) || math.IsInf(p, 0) {
+			return nil, ErrInvalidProportion{idx, p}
+		}
+		sum.Add(sum, new(big.Rat).SetFloat64(p))
+	}
+	if sum.Sign() == 0 || resolution == 0 {
+		return nil, ErrEmptyInput{}
+	}
+
+	res := new(big.Rat).SetInt(new(big.Int).SetUint64(resolution))
+	weights := make([]uint64, len(items))
+	remainders := make([]*big.Rat, len(items))
+	assigned := uint64(0)
+	for idx, item := range items {
+		share := new(big.Rat).SetFloat64(item.GetProportion())
+		share.Mul(share, res).Quo(share, sum)
+		floor := new(big.Int).Quo(share.Num(), share.Denom())
+		weights[idx] = floor.Uint64()
+		assigned += weights[idx]
+		remainders[idx] = share.Sub(share, new(big.Rat).SetInt(floor))
+	}
+
+	// Fewer keys than items are left over, as each remainder is below one
+	order := make([]int, len(items))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]].Cmp(remainders[order[j]]) > 0
+	})
+	for _, idx := range order[:resolution-assigned] {
+		weights[idx] += 1
+	}
+	return weights, nil
+}
@@ -0,0 +1,93 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * proportions_test.go: Tests on proportional weights
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeStoreFromProportions(t *testing.T) {
+	items := make([]FloatWeighted, 0)
+	items = append(items, DefaultFloatWeightedValue{0.25, "A"})
+	items = append(items, DefaultFloatWeightedValue{0.70, "B"})
+	items = append(items, DefaultFloatWeightedValue{0.05, "C"})
+
+	n, err := NewRangeStoreFromProportions(items, 10000)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	expected := []RangeEntry{{1, 2500, "A"}, {2501, 9500, "B"}, {9501, 10000, "C"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
+
+func TestRangeStoreFromProportions_Rounding(t *testing.T) {
+	items := make([]FloatWeighted, 0)
+	items = append(items, DefaultFloatWeightedValue{1, "A"})
+	items = append(items, DefaultFloatWeightedValue{1, "B"})
+	items = append(items, DefaultFloatWeightedValue{1, "C"})
+
+	// 3⅓ each, with the spare key going to the first
+	n, err := NewRangeStoreFromProportions(items, 10)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	expected := []RangeEntry{{1, 4, "A"}, {5, 7, "B"}, {8, 10, "C"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	// Shares which round down to nothing are left out
+	items = append(items, DefaultFloatWeightedValue{0.001, "D"})
+	n, err = NewRangeStoreFromProportions(items, 10)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	if _, max := n.Bounds(); max != 10 || n.Len() != 3 {
+		t.Fatalf("Wrong ranges: %v", n.Ranges())
+	}
+}
+
+func TestRangeStoreFromProportions_Invalid(t *testing.T) {
+	items := make([]FloatWeighted, 0)
+	items = append(items, DefaultFloatWeightedValue{0.5, "A"})
+	items = append(items, DefaultFloatWeightedValue{-0.5, "B"})
+	_, err := NewRangeStoreFromProportions(items, 100)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvalidProportion{}).Name() {
+		t.Fatalf("Expecting an ErrInvalidProportion, but got something else")
+	}
+	if msg := err.Error(); msg != "Item 1 has an invalid proportion -0.5" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+
+	zero := []FloatWeighted{DefaultFloatWeightedValue{0, "A"}}
+	_, err = NewRangeStoreFromProportions(zero, 100)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+}
@@ -0,0 +1,175 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * proto.go: Converting stores to and from protocol buffers
+ */
+
+package rangestore
+
+import (
+	"encoding/binary"
+)
+
+// Field numbers and wire types from rangestore.proto
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+
+	protoStoreRanges = 1
+	protoRangeMin    = 1
+	protoRangeMax    = 2
+	protoRangeValue  = 3
+)
+
+// Encodes the store as a RangeStore message from rangestore.proto, with the
+// values encoded by codec, or StringCodec if it's nil. Only the ranges are kept,
+// not the shape of the tree.
+func ToProto(n *Node, codec ValueCodec) ([]byte, error) {
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	var out, rec []byte
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		var value []byte
+		if value, err = codec.Encode(c.value); err != nil {
+			return
+		}
+		rec = rec[:0]
+		// Fields holding their default value are left out, as proto3 does
+		if c.min != 0 {
+			rec = appendProtoVarint(rec, protoRangeMin<<3|protoVarint)
+			rec = appendProtoVarint(rec, c.min)
+		}
+		if c.max != 0 {
+			rec = appendProtoVarint(rec, protoRangeMax<<3|protoVarint)
+			rec = appendProtoVarint(rec, c.max)
+		}
+		if len(value) > 0 {
+			rec = appendProtoVarint(rec, protoRangeValue<<3|protoBytes)
+			rec = appendProtoVarint(rec, uint64(len(value)))
+			rec = append(rec, value...)
+		}
+		out = appendProtoVarint(out, protoStoreRanges<<3|protoBytes)
+		out = appendProtoVarint(out, uint64(len(rec)))
+		out = append(out, rec...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Decodes a RangeStore message from rangestore.proto and builds a sparse store
+// from its ranges, decoding the values with codec, or StringCodec if it's nil.
+// Unknown fields are skipped. Returns an ErrCorruptStore if the message can't be
+// parsed, and the usual errors if the ranges aren't sorted or overlap.
+func FromProto(b []byte, codec ValueCodec) (*Node, error) {
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	items := make([]Ranged, 0)
+	err := readProtoFields(b, func(field, wire uint64, v uint64, data []byte) error {
+		if field != protoStoreRanges || wire != protoBytes {
+			return nil
+		}
+		var e RangeEntry
+		var value []byte
+		err := readProtoFields(data, func(field, wire uint64, v uint64, data []byte) error {
+			switch {
+			case field == protoRangeMin && wire == protoVarint:
+				e.Min = v
+			case field == protoRangeMax && wire == protoVarint:
+				e.Max = v
+			case field == protoRangeValue && wire == protoBytes:
+				value = data
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if e.Value, err = codec.Decode(value); err != nil {
+			return err
+		}
+		items = append(items, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rangeStoreFromSortedChecked(items, true, true)
+}
+
+func appendProtoVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// Calls fn for each field of the message, with the value for varint and fixed
+// width fields, or the contents for length delimited ones
+func readProtoFields(b []byte, fn func(field, wire uint64, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrCorruptStore{"invalid field key"}
+		}
+		b = b[n:]
+		field, wire := key>>3, key&7
+		var v uint64
+		var data []byte
+		switch wire {
+		case protoVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return ErrCorruptStore{"invalid varint"}
+			}
+			b = b[n:]
+		case protoFixed64:
+			if len(b) < 8 {
+				return ErrCorruptStore{"truncated field"}
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case protoFixed32:
+			if len(b) < 4 {
+				return ErrCorruptStore{"truncated field"}
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case protoBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return ErrCorruptStore{"truncated field"}
+			}
+			data, b = b[n:n+int(length)], b[n+int(length):]
+		default:
+			return ErrCorruptStore{"unsupported wire type"}
+		}
+		if err := fn(field, wire, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
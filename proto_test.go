@@ -0,0 +1,88 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * proto_test.go: Tests on the protocol buffer converters
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestToProto(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{20, 300, ""})
+	n, err := NewSparseRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	b, err := ToProto(n, nil)
+	if err != nil {
+		t.Fatalf("Error while encoding store: %s", err.Error())
+	}
+	// As protoc would encode it: zero fields and empty values are left out
+	expected := []byte{
+		0x0a, 0x05, 0x10, 0x09, 0x1a, 0x01, 'A',
+		0x0a, 0x05, 0x08, 0x14, 0x10, 0xac, 0x02,
+	}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("Wrong encoding: % x", b)
+	}
+
+	back, err := FromProto(b, nil)
+	if err != nil {
+		t.Fatalf("Error while decoding store: %s", err.Error())
+	}
+	if !reflect.DeepEqual(back.Ranges(), n.Ranges()) {
+		t.Fatalf("Ranges changed: %v", back.Ranges())
+	}
+}
+
+func TestFromProto(t *testing.T) {
+	// An unknown fixed32 field and an unknown length delimited one are skipped
+	b := []byte{
+		0x0a, 0x0c, 0x08, 0x05, 0x10, 0x07, 0x1a, 0x01, 0x02, 0x25, 1, 2, 3, 4,
+		0x12, 0x02, 'x', 'y',
+	}
+	n, err := FromProto(b, IntCodec{})
+	if err != nil {
+		t.Fatalf("Error while decoding store: %s", err.Error())
+	}
+	if v, err := n.RangeSearch(6); err != nil || v != int64(1) {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+
+	if _, err := FromProto([]byte{0x0a, 0x05, 0x08}, nil); reflect.TypeOf(err).Name() != "ErrCorruptStore" {
+		t.Fatalf("Expected an ErrCorruptStore, got %v", err)
+	}
+	if _, err := FromProto(nil, nil); reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected an ErrEmptyInput, got %v", err)
+	}
+	unsorted := []byte{0x0a, 0x04, 0x08, 0x05, 0x10, 0x07, 0x0a, 0x02, 0x10, 0x01}
+	if _, err := FromProto(unsorted, nil); err == nil {
+		t.Fatalf("Expected an error for unsorted ranges")
+	}
+}
@@ -0,0 +1,128 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * random.go: Sources of randomness for weighted picks
+ */
+
+package rangestore
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// A source of uniformly distributed random numbers. *math/rand.Rand, the
+// generators of math/rand/v2 such as rand.PCG, and CryptoSource all satisfy it,
+// so picks can be reproducible in tests or unpredictable where it matters.
+type RandSource interface {
+	Uint64() uint64
+}
+
+// A RandSource reading from crypto/rand, for security sensitive selection
+type CryptoSource struct{}
+
+// Returns a random number from crypto/rand. Panics if the system's secure random
+// number generator fails, since there's no sensible way to carry on.
+func (CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Hashes keys to uniformly distributed numbers, for picks which must always give
+// the same result for the same key
+type KeyHasher func(key []byte) uint64
+
+// Hashes the keys of WeightedStore.PickSticky with h rather than FNV-1a, e.g. to
+// use xxHash or to match the assignment made by another system
+func WithKeyHasher(h KeyHasher) Option {
+	return func(o *options) {
+		o.keyHasher = h
+	}
+}
+
+// Hashes the key with FNV-1a, followed by the MurmurHash3 finalizer, since FNV
+// on its own spreads short, similar keys poorly across the high bits
+func fnvKeyHasher(key []byte) uint64 {
+	f := fnv.New64a()
+	f.Write(key)
+	h := f.Sum64()
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// A SplitMix64 generator seeded with a hash, so that picks made with it are
+// deterministic but can still reject draws as uniform does
+type hashSource struct {
+	state uint64
+}
+
+func (s *hashSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Anything which covers keys between a smallest and a largest one, such as *Node,
+// *RangeStore and *PackedStore
+type Bounded interface {
+	Bounds() (min, max uint64)
+}
+
+// Returns a key drawn uniformly at random from the bounds of the store. Unlike
+// min + r.Uint64()%(max-min+1), which favors the low keys whenever the width
+// doesn't divide 2^64, every key is equally likely. The key may fall in a gap of
+// a sparse store; SampleKeys only returns covered keys.
+func UniformKey(r RandSource, s Bounded) uint64 {
+	min, max := s.Bounds()
+	return uniformBetween(r, min, max)
+}
+
+// Returns a uniformly distributed number in [min, max]
+func uniformBetween(r RandSource, min, max uint64) uint64 {
+	// A width of 0 is the entire key space
+	if n := (max - min) + 1; n != 0 {
+		return min + uniform(r, n)
+	}
+	return r.Uint64()
+}
+
+// Returns a uniformly distributed number in [0, n), rejecting the few values
+// which would otherwise bias the result towards small numbers. n must not be 0.
+func uniform(r RandSource, n uint64) uint64 {
+	// 2^64 mod n, the number of values which don't fit evenly
+	threshold := -n % n
+	for {
+		if x := r.Uint64(); x >= threshold {
+			return x % n
+		}
+	}
+}
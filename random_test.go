@@ -0,0 +1,240 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * random_test.go: Tests on random sources and weighted picks
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Returns the values in order, over and over
+type sequenceSource struct {
+	values []uint64
+	next   int
+}
+
+func (s *sequenceSource) Uint64() uint64 {
+	v := s.values[s.next%len(s.values)]
+	s.next += 1
+	return v
+}
+
+func TestUniform_Rejection(t *testing.T) {
+	// With n = 3, 2^64 mod 3 = 1, so only 0 is rejected
+	s := &sequenceSource{values: []uint64{0, 0, 5}}
+	if got := uniform(s, 3); got != 2 || s.next != 3 {
+		t.Fatalf("Expected 0 to be rejected, got %d after %d draws", got, s.next)
+	}
+	s = &sequenceSource{values: []uint64{0}}
+	if got := uniform(s, 1<<10); got != 0 || s.next != 1 {
+		t.Fatalf("Expected no rejection for powers of 2")
+	}
+}
+
+func TestWeightedStore_Pick(t *testing.T) {
+	w := testWeightedStore(t)
+
+	// Keys 1-25 are A, 26-95 are B and 96-100 are C. Draws below 2^64 mod 100,
+	// which is 16, are rejected, so stay clear of them.
+	for draw, expected := range map[uint64]string{100: "A", 124: "A", 125: "B", 194: "B", 195: "C", 199: "C", 200: "A"} {
+		if v := w.Pick(&sequenceSource{values: []uint64{draw}}); v != expected {
+			t.Fatalf("Wrong pick for %d: %s [%s]", draw, v, expected)
+		}
+	}
+
+	counts := make(map[interface{}]int)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i += 1 {
+		counts[w.Pick(r)] += 1
+	}
+	for value, expected := range map[string]int{"A": 25000, "B": 70000, "C": 5000} {
+		if counts[value] < expected*9/10 || counts[value] > expected*11/10 {
+			t.Fatalf("Uneven picks for %s: %d [%d]", value, counts[value], expected)
+		}
+	}
+
+	if v := w.Pick(CryptoSource{}); v != "A" && v != "B" && v != "C" {
+		t.Fatalf("Unexpected pick from the crypto source: %v", v)
+	}
+}
+
+func TestWeightedStore_Disable(t *testing.T) {
+	w := testWeightedStore(t)
+	w.Disable("B")
+	w.Disable("B")
+
+	// With B disabled, A covers 25 of the remaining 30 and C the rest
+	for draw, expected := range map[uint64]string{120: "A", 144: "A", 145: "C", 149: "C", 150: "A"} {
+		if v := w.Pick(&sequenceSource{values: []uint64{draw}}); v != expected {
+			t.Fatalf("Wrong pick for %d: %s [%s]", draw, v, expected)
+		}
+	}
+	if v, err := w.RangeSearch(26); err != nil || v != "B" {
+		t.Fatalf("Disabling a value shouldn't affect lookups by key")
+	}
+
+	w.Disable("A")
+	w.Disable("C")
+	if v := w.Pick(rand.New(rand.NewSource(1))); v != nil {
+		t.Fatalf("Expected no pick with every value disabled, got %v", v)
+	}
+
+	w.Enable("A")
+	w.Enable("B")
+	w.Enable("C")
+	w.Enable("D")
+	if v := w.Pick(&sequenceSource{values: []uint64{125}}); v != "B" {
+		t.Fatalf("Wrong pick after enabling every value: %v", v)
+	}
+}
+
+func TestWeightedStore_PickExcluding(t *testing.T) {
+	w := testWeightedStore(t)
+	r := rand.New(rand.NewSource(1))
+
+	// B holds most of the weight, so this mostly falls back to the remaining items
+	counts := make(map[interface{}]int)
+	for i := 0; i < 30000; i += 1 {
+		v, err := w.PickExcluding(r, func(v interface{}) bool { return v == "B" })
+		if err != nil {
+			t.Fatalf("Error while picking: %s", err.Error())
+		}
+		counts[v] += 1
+	}
+	for value, expected := range map[string]int{"A": 25000, "B": 0, "C": 5000} {
+		if counts[value] < expected*9/10 || counts[value] > expected*11/10 {
+			t.Fatalf("Uneven picks for %s: %d [%d]", value, counts[value], expected)
+		}
+	}
+
+	w.Disable("A")
+	if v, err := w.PickExcluding(r, func(v interface{}) bool { return v == "B" }); err != nil || v != "C" {
+		t.Fatalf("Wrong pick with A disabled and B excluded: %v", v)
+	}
+	if _, err := w.PickExcluding(r, func(v interface{}) bool { return v != "A" }); err == nil || reflect.TypeOf(err).Name() != "ErrNothingToPick" {
+		t.Fatalf("Expected ErrNothingToPick, got %v", err)
+	}
+}
+
+func TestWeightedStore_PickWithOverrides(t *testing.T) {
+	w := testWeightedStore(t)
+
+	// Draws are offset by the adjusted total, since uniform rejects the few
+	// smallest numbers
+	draws := func(draw, total uint64) RandSource {
+		return &sequenceSource{values: []uint64{total + draw}}
+	}
+
+	// C weighs 35 instead of 5, out of 130
+	canary := map[interface{}]uint64{"C": 35}
+	for draw, expected := range map[uint64]string{0: "A", 24: "A", 25: "B", 94: "B", 95: "C", 129: "C"} {
+		if v := w.PickWithOverrides(draws(draw, 130), canary); v != expected {
+			t.Fatalf("Wrong pick for %d: %v [%s]", draw, v, expected)
+		}
+	}
+	cached := w.adjusted.Load()
+	w.PickWithOverrides(draws(0, 130), map[interface{}]uint64{"C": 35})
+	if w.adjusted.Load() != cached {
+		t.Fatalf("Expected the adjustment to be reused for the same overrides")
+	}
+
+	// Picks from the rest of the store skip over the overridden items
+	for draw, expected := range map[uint64]string{0: "B", 69: "B", 70: "C", 74: "C"} {
+		if v := w.PickWithOverrides(draws(draw, 75), map[interface{}]uint64{"A": 0, "X": 10}); v != expected {
+			t.Fatalf("Wrong pick for %d without A: %v [%s]", draw, v, expected)
+		}
+	}
+
+	// Disabled values stay disabled, even when overridden
+	w.Disable("B")
+	for draw, expected := range map[uint64]string{0: "A", 24: "A", 25: "C", 29: "C"} {
+		if v := w.PickWithOverrides(draws(draw, 30), map[interface{}]uint64{"B": 10}); v != expected {
+			t.Fatalf("Wrong pick for %d with B disabled: %v [%s]", draw, v, expected)
+		}
+	}
+	if v := w.PickWithOverrides(rand.New(rand.NewSource(1)), map[interface{}]uint64{"A": 0, "C": 0}); v != nil {
+		t.Fatalf("Expected no pick with every value overridden or disabled, got %v", v)
+	}
+	w.Enable("B")
+	if v := w.PickWithOverrides(draws(30, 40), map[interface{}]uint64{"B": 10}); v != "B" {
+		t.Fatalf("Expected B to be picked once enabled, got %v", v)
+	}
+	if w.Total() != 100 {
+		t.Fatalf("Overrides shouldn't change the store")
+	}
+}
+
+func TestWeightedStore_PickSticky(t *testing.T) {
+	w := testWeightedStore(t)
+
+	counts := make(map[interface{}]int)
+	for i := 0; i < 100000; i += 1 {
+		key := []byte(fmt.Sprintf("session-%d", i))
+		v := w.PickSticky(key)
+		if again := w.PickSticky(key); again != v {
+			t.Fatalf("Different picks for the same key: %v and %v", v, again)
+		}
+		counts[v] += 1
+	}
+	for value, expected := range map[string]int{"A": 25000, "B": 70000, "C": 5000} {
+		if counts[value] < expected*9/10 || counts[value] > expected*11/10 {
+			t.Fatalf("Uneven picks for %s: %d [%d]", value, counts[value], expected)
+		}
+	}
+
+	// Keys hashing to the same value are assigned to the same value
+	w = testWeightedStore(t, WithKeyHasher(func(key []byte) uint64 { return 42 }))
+	if a, b := w.PickSticky([]byte("a")), w.PickSticky([]byte("b")); a != b {
+		t.Fatalf("Expected the custom hasher to be used: %v and %v", a, b)
+	}
+}
+
+func TestUniformKey(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 11, "A"})
+	items = append(items, DefaultRangedValue{12, 12, "B"})
+	n, _ := NewRangeStoreFromSorted(items)
+
+	// 2^64 mod 3 is 1, so a draw of 0 is rejected
+	if key := UniformKey(&sequenceSource{values: []uint64{0, 5}}, n); key != 12 {
+		t.Fatalf("Wrong key: %d", key)
+	}
+	s := WrapNode(n)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i += 1 {
+		if key := UniformKey(r, s); key < 10 || key > 12 {
+			t.Fatalf("Key outside of the bounds: %d", key)
+		}
+	}
+
+	full, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, math.MaxUint64, "A"}})
+	if key := UniformKey(&sequenceSource{values: []uint64{math.MaxUint64}}, full); key != math.MaxUint64 {
+		t.Fatalf("Wrong key from the entire key space: %d", key)
+	}
+}
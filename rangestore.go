@@ -24,14 +24,23 @@ package rangestore
 
 import (
 	"fmt"
+	"math"
+	"sort"
 )
 
 type Node struct {
-	max         uint64
+	min, max    uint64
 	value       interface{}
 	left, right *Node
 }
 
+// Anything which can look up the value for a key. Implemented by *Node and by
+// all of the wrappers and alternate representations in this package, so that
+// they can be used interchangeably.
+type RangeSearcher interface {
+	RangeSearch(val uint64) (interface{}, error)
+}
+
 type Weighted interface {
 	GetWeight() uint64
 	GetValue() interface{}
@@ -68,6 +77,41 @@ func (r DefaultRangedValue) GetValue() interface{} {
 	return r.value
 }
 
+// Markers for open ended ranges. Since keys are unsigned 64 bit integers, a range
+// starting at UnboundedMin covers every key below its maximum, and a range ending
+// at UnboundedMax covers every key above its minimum.
+const (
+	UnboundedMin uint64 = 0
+	UnboundedMax uint64 = math.MaxUint64
+)
+
+// Returns a range covering every key from min upwards
+func AtLeast(min uint64, v interface{}) RangeEntry {
+	return RangeEntry{min, UnboundedMax, v}
+}
+
+// Returns a range covering every key from max downwards
+func AtMost(max uint64, v interface{}) RangeEntry {
+	return RangeEntry{UnboundedMin, max, v}
+}
+
+// A single range of a store along with its value. Returned when enumerating
+// the contents of a store, and usable as input anywhere a Ranged is accepted.
+type RangeEntry struct {
+	Min, Max uint64
+	Value    interface{}
+}
+
+func (r RangeEntry) GetMin() uint64 {
+	return r.Min
+}
+func (r RangeEntry) GetMax() uint64 {
+	return r.Max
+}
+func (r RangeEntry) GetValue() interface{} {
+	return r.Value
+}
+
 type ErrUnsignedIntegerOverflow struct {
 	a, b uint64
 }
@@ -76,6 +120,15 @@ func (ex ErrUnsignedIntegerOverflow) Error() string {
 	return fmt.Sprintf("Overflow adding %d + %d", ex.a, ex.b)
 }
 
+// Returns the numbers whose sum overflowed
+func (ex ErrUnsignedIntegerOverflow) Operands() (a, b uint64) {
+	return ex.a, ex.b
+}
+
+func (ex ErrUnsignedIntegerOverflow) Details() ErrorDetails {
+	return ErrorDetails{"ErrUnsignedIntegerOverflow", map[string]interface{}{"a": ex.a, "b": ex.b}}
+}
+
 type ErrDiscontinuity struct {
 	x, y uint64
 }
@@ -84,6 +137,15 @@ func (ex ErrDiscontinuity) Error() string {
 	return fmt.Sprintf("Discontinuity detected from %d -> %d", ex.x, ex.y)
 }
 
+// Returns the end of the range before the gap, and the start of the one after it
+func (ex ErrDiscontinuity) Keys() (prevMax, nextMin uint64) {
+	return ex.x, ex.y
+}
+
+func (ex ErrDiscontinuity) Details() ErrorDetails {
+	return ErrorDetails{"ErrDiscontinuity", map[string]interface{}{"prevMax": ex.x, "nextMin": ex.y}}
+}
+
 type ErrOutOfRange struct {
 	s uint64
 }
@@ -92,6 +154,15 @@ func (ex ErrOutOfRange) Error() string {
 	return fmt.Sprintf("Value %d is out of range", ex.s)
 }
 
+// Returns the key which was looked up
+func (ex ErrOutOfRange) Key() uint64 {
+	return ex.s
+}
+
+func (ex ErrOutOfRange) Details() ErrorDetails {
+	return ErrorDetails{"ErrOutOfRange", map[string]interface{}{"key": ex.s}}
+}
+
 type ErrOverlap struct {
 	a, b uint64
 }
@@ -100,26 +171,187 @@ func (ex ErrOverlap) Error() string {
 	return fmt.Sprintf("Overlap detected between %d -> %d", ex.a, ex.b)
 }
 
+// Returns the end of the earlier range, and the start of the one overlapping it
+func (ex ErrOverlap) Keys() (prevMax, nextMin uint64) {
+	return ex.a, ex.b
+}
+
+func (ex ErrOverlap) Details() ErrorDetails {
+	return ErrorDetails{"ErrOverlap", map[string]interface{}{"prevMax": ex.a, "nextMin": ex.b}}
+}
+
+type ErrLengthMismatch struct {
+	expected, actual int
+}
+
+func (ex ErrLengthMismatch) Error() string {
+	return fmt.Sprintf("Length mismatch: expected %d entries, got %d", ex.expected, ex.actual)
+}
+
+// Returns the number of entries which was expected
+func (ex ErrLengthMismatch) Expected() int {
+	return ex.expected
+}
+
+// Returns the number of entries which was given
+func (ex ErrLengthMismatch) Actual() int {
+	return ex.actual
+}
+
+func (ex ErrLengthMismatch) Details() ErrorDetails {
+	return ErrorDetails{"ErrLengthMismatch", map[string]interface{}{"expected": ex.expected, "actual": ex.actual}}
+}
+
+type ErrDuplicateRange struct {
+	index1, index2 int
+	min, max       uint64
+}
+
+func (ex ErrDuplicateRange) Error() string {
+	return fmt.Sprintf("Items %d and %d are both the range [%d, %d]", ex.index1, ex.index2, ex.min, ex.max)
+}
+
+// Returns the indices of the two items with identical bounds
+func (ex ErrDuplicateRange) Indices() (int, int) {
+	return ex.index1, ex.index2
+}
+
+// Returns the bounds given twice
+func (ex ErrDuplicateRange) Range() (min, max uint64) {
+	return ex.min, ex.max
+}
+
+func (ex ErrDuplicateRange) Details() ErrorDetails {
+	return ErrorDetails{"ErrDuplicateRange", map[string]interface{}{"index1": ex.index1, "index2": ex.index2, "min": ex.min, "max": ex.max}}
+}
+
 type ErrEmptyInput struct{}
 
 func (ex ErrEmptyInput) Error() string {
 	return "Input list is empty"
 }
 
-func NewRangeStoreFromWeighted(items []Weighted) (*Node, error) {
+func (ex ErrEmptyInput) Details() ErrorDetails {
+	return ErrorDetails{"ErrEmptyInput", map[string]interface{}{}}
+}
+
+// Returned by the methods of a nil *Node, which is how a store holding no ranges
+// is represented, e.g. after a failed construction
+type ErrEmptyStore struct{}
+
+func (ex ErrEmptyStore) Error() string {
+	return "Store is empty"
+}
+
+func (ex ErrEmptyStore) Details() ErrorDetails {
+	return ErrorDetails{"ErrEmptyStore", map[string]interface{}{}}
+}
+
+type ErrZeroWeight struct {
+	index int
+}
+
+func (ex ErrZeroWeight) Error() string {
+	return fmt.Sprintf("Item %d has a zero weight", ex.index)
+}
+
+// Returns the index of the item with a zero weight
+func (ex ErrZeroWeight) Index() int {
+	return ex.index
+}
+
+func (ex ErrZeroWeight) Details() ErrorDetails {
+	return ErrorDetails{"ErrZeroWeight", map[string]interface{}{"index": ex.index}}
+}
+
+type ErrWeightSpaceExhausted struct {
+	index     int
+	weight    uint64
+	remaining uint64
+}
+
+func (ex ErrWeightSpaceExhausted) Error() string {
+	return fmt.Sprintf("Item %d has a weight of %d, but only %d remains before the total exceeds the key space", ex.index, ex.weight, ex.remaining)
+}
+
+// Returns the index of the item which didn't fit
+func (ex ErrWeightSpaceExhausted) Index() int {
+	return ex.index
+}
+
+// Returns the weight of the item
+func (ex ErrWeightSpaceExhausted) Weight() uint64 {
+	return ex.weight
+}
+
+// Returns the weight which remained for it
+func (ex ErrWeightSpaceExhausted) Remaining() uint64 {
+	return ex.remaining
+}
+
+func (ex ErrWeightSpaceExhausted) Details() ErrorDetails {
+	return ErrorDetails{"ErrWeightSpaceExhausted", map[string]interface{}{"index": ex.index, "weight": ex.weight, "remaining": ex.remaining}}
+}
+
+// Returns the largest total weight of the items NewRangeStoreFromWeighted and
+// NewWeightedStore accept, so that weight sets can be checked before building
+func MaxTotalWeight() uint64 {
+	return math.MaxUint64
+}
+
+// Skips weighted items with a zero weight, rather than failing with
+// ErrZeroWeight. Only affects NewRangeStoreFromWeighted and NewWeightedStore.
+func WithDropZeroWeights() Option {
+	return func(o *options) {
+		o.dropZeroWeights = true
+	}
+}
+
+// Starts the ranges of weighted items at 0 rather than 1, so that keys generated
+// as rand.Uint64() % total are all covered. Only affects NewRangeStoreFromWeighted
+// and NewWeightedStore.
+func WithZeroBasedWeights() Option {
+	return func(o *options) {
+		o.zeroBased = true
+	}
+}
+
+// Builds a store mapping consecutive keys, starting from 1, to each of the items
+// in proportion to their weights, so that the keys from 1 to the total weight
+// are covered. WithZeroBasedWeights starts them from 0 instead, covering the keys
+// below the total weight. Items with a zero weight would cover no keys, and are
+// rejected with ErrZeroWeight unless WithDropZeroWeights is given. If the total
+// weight exceeds MaxTotalWeight, ErrWeightSpaceExhausted is returned.
+func NewRangeStoreFromWeighted(items []Weighted, opts ...Option) (*Node, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
 	if len(items) < 1 {
 		return nil, ErrEmptyInput{}
 	}
+	base := uint64(1)
+	if o.zeroBased {
+		base = 0
+	}
 	totalWeight := uint64(0)
 	ranges := make([]Ranged, 0)
-	for _, item := range items {
+	for idx, item := range items {
 		w := item.GetWeight()
-		ranges = append(ranges, DefaultRangedValue{totalWeight + 1, totalWeight + w, item.GetValue()})
-		newSum := totalWeight + w
-		if newSum < totalWeight || newSum < w {
-			return nil, ErrUnsignedIntegerOverflow{totalWeight, w}
+		if w == 0 {
+			if o.dropZeroWeights {
+				continue
+			}
+			return nil, ErrZeroWeight{idx}
+		}
+		if remaining := MaxTotalWeight() - totalWeight; w > remaining {
+			return nil, ErrWeightSpaceExhausted{idx, w, remaining}
 		}
-		totalWeight = newSum
+		ranges = append(ranges, DefaultRangedValue{base + totalWeight, base + totalWeight + w - 1, item.GetValue()})
+		totalWeight += w
+	}
+	if len(ranges) < 1 {
+		return nil, ErrEmptyInput{}
 	}
 
 	return NewRangeStoreFromSorted(ranges)
@@ -176,116 +408,272 @@ func NewRangeStoreFromWeighted(items []Weighted) (*Node, error) {
 // the produced data structure approaches, but may not always be
 // exactly, optimal.
 func NewRangeStoreFromSorted(items []Ranged) (*Node, error) {
-	return rangeStoreFromSortedChecked(items, true)
+	return rangeStoreFromSortedChecked(items, true, false)
+}
+
+// Builds a tree the same way as NewRangeStoreFromSorted, but allows for gaps
+// between consecutive ranges. The items must still be sorted and must not
+// overlap. Searching for a key which falls into a gap returns ErrOutOfRange.
+func NewSparseRangeStoreFromSorted(items []Ranged) (*Node, error) {
+	return rangeStoreFromSortedChecked(items, true, true)
+}
+
+// Builds a tree the same way as NewSparseRangeStoreFromSorted, but skips
+// checking the items for overlaps, which saves a pass over them when rebuilding
+// a store from data which was validated when it was first built, such as a
+// snapshot written by this package.
+//
+// _Warning_: Nothing stops unsorted or overlapping items from producing a tree
+// which silently returns wrong values, or no value at all, for some keys. Only
+// use this with trusted input, and consider spot checking the result with
+// CheckInvariants. Only ErrEmptyInput and ErrUnsignedIntegerOverflow are
+// still reported.
+func NewRangeStoreFromSortedUnchecked(items []Ranged) (*Node, error) {
+	return rangeStoreFromSortedChecked(items, false, true)
+}
+
+// Builds a tree the same way as NewRangeStoreFromSorted, but instead of
+// assuming that every key is equally likely to be looked up, uses the supplied
+// access frequencies as the weights when choosing pivots. The frequency at
+// freq[i] is the relative number of lookups expected to land in items[i], so
+// hot ranges end up near the root even when they're narrow.
+//
+// _Note_: The items must satisfy the same ordering and continuity constraints
+// as NewRangeStoreFromSorted, and freq must have exactly one entry per item.
+func NewRangeStoreFromSortedWithFrequencies(items []Ranged, freq []uint64) (*Node, error) {
+	if len(items) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	if len(freq) != len(items) {
+		return nil, ErrLengthMismatch{len(items), len(freq)}
+	}
+	// Validate the ranges themselves, discarding the width based tree
+	if _, err := rangeStoreFromSortedChecked(items, true, false); err != nil {
+		return nil, err
+	}
+	cum := make([]uint64, len(items)+1)
+	for idx, f := range freq {
+		newSum := cum[idx] + f
+		if newSum < cum[idx] || newSum < f {
+			return nil, ErrUnsignedIntegerOverflow{cum[idx], f}
+		}
+		cum[idx+1] = newSum
+	}
+	return buildFromCumulative(items, cum, 0, len(items)), nil
 }
 
 // Helper function which takes a bool whether the ranges have already been checked
-// Breaking this into two functions is a small optimization on big sets, but not
-// having to do all of the overlap and discontinuity checking on every
-// recursive call. We know that if we're calling recursively that we have
-// only part of a range that's previously been through this function, so
-// we can skip the checks for monotonicity.
-func rangeStoreFromSortedChecked(items []Ranged, check bool) (*Node, error) {
+// When check is false, we skip all of the overlap and discontinuity checking
+// and trust that the caller has handed us a monotonically increasing and
+// continuous sequence. When gaps is true, discontinuities are permitted. Either
+// way, we compute the running total of the range widths, which is used as the
+// weighting when choosing pivots.
+func rangeStoreFromSortedChecked(items []Ranged, check, gaps bool) (*Node, error) {
+	cum, err := cumulativeWidths(items, check, gaps)
+	if err != nil {
+		return nil, err
+	}
+	return buildFromCumulative(items, cum, 0, len(items)), nil
+}
+
+// Validates the items as described for rangeStoreFromSortedChecked, and returns
+// the running total of their widths
+func cumulativeWidths(items []Ranged, check, gaps bool) ([]uint64, error) {
+	return cumulativeWidthsProgress(items, check, gaps, nil)
+}
+
+// Same as cumulativeWidths, calling progress, if it isn't nil, with the number
+// of items validated so far after each one
+func cumulativeWidthsProgress(items []Ranged, check, gaps bool, progress func(int)) ([]uint64, error) {
 	if len(items) < 1 {
 		return nil, ErrEmptyInput{}
 	}
-	n := &Node{}
-	// Easy base case: We've got one item. Just set it and forget it
-	if len(items) == 1 {
-		n.max = items[0].GetMax()
-		n.value = items[0].GetValue()
-	} else {
-		// Compute the total weight in this slice
-		// Also, check for discontinuities
-		start := uint64(0)
-		total := uint64(0)
-		for idx, item := range items {
-			if idx == 0 {
-				start = item.GetMin()
-			} else if check {
-				// Check for discontinuity
-				prev := items[idx-1].GetMax()
-				curr := item.GetMin()
-				if curr > prev+1 {
-					return nil, ErrDiscontinuity{prev, curr}
-				}
-				// Check for overlap
-				if curr < prev+1 {
-					return nil, ErrOverlap{prev, curr}
-				}
+	// Compute the running total weight of the items
+	// Also, check for discontinuities
+	cum := make([]uint64, len(items)+1)
+	wrapped := false
+	for idx, item := range items {
+		if idx > 0 && check {
+			prev := items[idx-1].GetMax()
+			curr := item.GetMin()
+			// Check for the same range given twice, which is a common
+			// mistake deserving a clearer error than an overlap
+			if curr == items[idx-1].GetMin() && item.GetMax() == prev {
+				return nil, ErrDuplicateRange{idx - 1, idx, curr, prev}
 			}
-			a := (item.GetMax() - item.GetMin()) + 1
-			newSum := total + a
-			if newSum < total || newSum < a {
-				return nil, ErrUnsignedIntegerOverflow{total, a}
+			// Check for overlap
+			if curr <= prev {
+				return nil, ErrOverlap{prev, curr}
 			}
-			total = newSum
+			// Check for discontinuity
+			if curr > prev+1 && !gaps {
+				return nil, ErrDiscontinuity{prev, curr}
+			}
+		}
+		// A total of exactly 2^64 wraps around to 0, which is fine as long
+		// as nothing comes after it: the store covers the whole key space.
+		// An item covering everything on its own has a width of 0.
+		a := (item.GetMax() - item.GetMin()) + 1
+		newSum := cum[idx] + a
+		if wrapped || (a == 0 && idx > 0) || ((newSum < cum[idx] || newSum < a) && newSum != 0) {
+			return nil, ErrUnsignedIntegerOverflow{cum[idx], a}
 		}
+		wrapped = newSum == 0
+		cum[idx+1] = newSum
+		if progress != nil {
+			progress(idx + 1)
+		}
+	}
+	return cum, nil
+}
 
-		// Compute the pivot
-		pivot := total / 2
+// Recursively builds the tree for items[lo:hi], where cum[i] holds the total
+// weight of all of the items before index i. Since the items have already been
+// validated, this can't fail.
+func buildFromCumulative(items []Ranged, cum []uint64, lo, hi int) *Node {
+	return buildWithAlloc(items, cum, lo, hi, func() *Node {
+		return &Node{}
+	})
+}
 
-		// Walk the list backwards and find the index of the item which has
-		// a min less than the pivot
-		var ridx int
-		for ridx = len(items) - 1; ridx >= 0; ridx -= 1 {
-			if items[ridx].GetMin() < pivot+start {
-				break
-			}
-		}
+// Builds the tree the same way as buildFromCumulative, taking the nodes from alloc
+func buildWithAlloc(items []Ranged, cum []uint64, lo, hi int, alloc func() *Node) *Node {
+	n := alloc()
+	// Easy base case: We've got one item. Just set it and forget it
+	ridx := lo
+	if hi-lo > 1 {
+		ridx = pivotIndex(cum, lo, hi)
+	}
 
-		// Fill the node based on the current item
-		n.max = items[ridx].GetMax()
-		n.value = items[ridx].GetValue()
+	// Fill the node based on the current item
+	n.min = items[ridx].GetMin()
+	n.max = items[ridx].GetMax()
+	n.value = items[ridx].GetValue()
 
-		// If we didn't pick the first item for the pivot, build the left subtree
-		if ridx != 0 {
-			// Explicitly ignore the error, since we've indicated we've already checked
-			lft, _ := rangeStoreFromSortedChecked(items[:ridx], false)
-			n.left = lft
-		}
-		// If we didn't pick the last item for the pivot, build the right subtree
-		if ridx != len(items)-1 {
-			// Explicitly ignore the error, since we've indicated we've already checked
-			rht, _ := rangeStoreFromSortedChecked(items[ridx+1:], false)
-			n.right = rht
+	// If we didn't pick the first item for the pivot, build the left subtree
+	if ridx != lo {
+		n.left = buildWithAlloc(items, cum, lo, ridx, alloc)
+	}
+	// If we didn't pick the last item for the pivot, build the right subtree
+	if ridx != hi-1 {
+		n.right = buildWithAlloc(items, cum, ridx+1, hi, alloc)
+	}
+	return n
+}
+
+// Chooses the pivot for items[lo:hi]: the last item whose preceding weight
+// is less than half of the total weight of the slice. If the slice carries no
+// weight at all, we fall back to picking the middle item so that we don't
+// produce a degenerate tree.
+func pivotIndex(cum []uint64, lo, hi int) int {
+	total := cum[hi] - cum[lo]
+	pivot := total / 2
+	if total == 0 {
+		if cum[hi] >= cum[hi-1] {
+			return lo + (hi-lo)/2
 		}
+		// The running total wrapped around, so the slice covers the whole
+		// key space, and the pivot is at 2^63
+		pivot = 1 << 63
 	}
-	return n, nil
+	ridx := lo + sort.Search(hi-lo, func(i int) bool {
+		return cum[lo+i]-cum[lo] >= pivot
+	}) - 1
+	if ridx < lo {
+		ridx = lo
+	}
+	return ridx
 }
 
 // Searches for the range which contains the specified key
 // and returns the associated value, or an error if the
-// value is out of range
+// value is out of range. Returns ErrEmptyStore if the
+// store is nil.
 func (n *Node) RangeSearch(val uint64) (interface{}, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
 	if n.max < val {
 		if n.right == nil {
 			return nil, ErrOutOfRange{val}
 		}
 		return n.right.RangeSearch(val)
-	} else {
-		if n.left != nil {
-			val, err := n.left.RangeSearch(val)
-			if err == nil {
-				return val, nil
-			}
+	} else if n.min > val {
+		if n.left == nil {
+			return nil, ErrOutOfRange{val}
+		}
+		return n.left.RangeSearch(val)
+	}
+	return n.value, nil
+}
+
+// Performs the same search as RangeSearch, additionally returning the depth
+// of the node which terminated the search
+func (n *Node) searchDepth(val uint64, depth int) (interface{}, int, error) {
+	if n == nil {
+		return nil, 0, ErrEmptyStore{}
+	}
+	if n.max < val {
+		if n.right == nil {
+			return nil, depth, ErrOutOfRange{val}
 		}
-		return n.value, nil
+		return n.right.searchDepth(val, depth+1)
+	} else if n.min > val {
+		if n.left == nil {
+			return nil, depth, ErrOutOfRange{val}
+		}
+		return n.left.searchDepth(val, depth+1)
 	}
+	return n.value, depth, nil
+}
+
+// Visits every node of the tree in ascending key order, if there are any
+func (n *Node) walk(fn func(*Node)) {
+	if n == nil {
+		return
+	}
+	if n.left != nil {
+		n.left.walk(fn)
+	}
+	fn(n)
+	if n.right != nil {
+		n.right.walk(fn)
+	}
+}
+
+// Returns all of the ranges in the store in ascending key order
+func (n *Node) Ranges() []RangeEntry {
+	ret := make([]RangeEntry, 0)
+	n.walk(func(c *Node) {
+		ret = append(ret, RangeEntry{c.min, c.max, c.value})
+	})
+	return ret
 }
 
 // Creates a nicely formatter string representation of the Range Store. Useful for understanding how the data is
-// internally stored and represented.
+// internally stored and represented. Values are formatted using %v. A nil store is an
+// empty string.
 func (n *Node) String() string {
-	return n.formattedString("")
+	return n.StringFunc(func(v interface{}) string {
+		return fmt.Sprintf("%v", v)
+	})
+}
+
+// Same as String, but uses the supplied function to format each of the values, which is
+// handy when the values are structs without a useful default representation.
+func (n *Node) StringFunc(format func(v interface{}) string) string {
+	if n == nil {
+		return ""
+	}
+	return n.formattedString("", format)
 }
-func (n *Node) formattedString(prefix string) string {
-	ret := fmt.Sprintf("%s-%s [max: %d]\n", prefix, n.value, n.max)
+func (n *Node) formattedString(prefix string, format func(v interface{}) string) string {
+	ret := fmt.Sprintf("%s-%s [max: %d]\n", prefix, format(n.value), n.max)
 	if n.left != nil {
-		ret += n.left.formattedString(prefix + " |")
+		ret += n.left.formattedString(prefix+" |", format)
 	}
 	if n.right != nil {
-		ret += n.right.formattedString(prefix + " !")
+		ret += n.right.formattedString(prefix+" !", format)
 	}
 	return ret
 }
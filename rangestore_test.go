@@ -1,3 +1,6 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
 /**
  * Go Range Store
  *
@@ -23,6 +26,7 @@
 package rangestore
 
 import (
+	"bytes"
 	"math/rand"
 	"reflect"
 	"testing"
@@ -465,3 +469,145 @@ func TestNode_String(t *testing.T) {
 		t.Fatalf("Wrong string output form:\n%s\n%s", str, R)
 	}
 }
+
+func TestNode_StringFunc(t *testing.T) {
+	type city struct {
+		Name string
+		Zip  int
+	}
+	items := make([]Ranged, 0)
+
+	items = append(items, DefaultRangedValue{0, 9, city{"Austin", 78701}})
+	items = append(items, DefaultRangedValue{10, 19, city{"Boston", 2108}})
+
+	n, err := NewRangeStoreFromSorted(items)
+
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	R := `-{Austin 78701} [max: 9]
+ !-{Boston 2108} [max: 19]
+`
+	if str := n.String(); str != R {
+		t.Fatalf("Wrong string output form:\n%s\n%s", str, R)
+	}
+
+	R = `-Austin [max: 9]
+ !-Boston [max: 19]
+`
+	str := n.StringFunc(func(v interface{}) string {
+		return v.(city).Name
+	})
+	if str != R {
+		t.Fatalf("Wrong string output form:\n%s\n%s", str, R)
+	}
+}
+
+func TestRangeStoreFromSorted_Duplicate(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+
+	_, err := NewRangeStoreFromSorted(items)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDuplicateRange{}).Name() {
+		t.Fatalf("Expecting an ErrDuplicateRange, but got something else")
+	}
+	if msg := err.Error(); msg != "Items 1 and 2 are both the range [10, 19]" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+}
+
+func TestRangeStoreFromSortedUnchecked(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{20, 29, "B"})
+	n, err := NewRangeStoreFromSortedUnchecked(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	if err := n.CheckInvariants(); err != nil {
+		t.Fatalf("Valid input failed the check: %s", err.Error())
+	}
+
+	// Overlapping input isn't caught during construction, only by the check
+	items = append(items, DefaultRangedValue{25, 39, "C"})
+	n, err = NewRangeStoreFromSortedUnchecked(items)
+	if err != nil {
+		t.Fatalf("Unexpected error from unchecked construction: %s", err.Error())
+	}
+	if err := n.CheckInvariants(); err == nil {
+		t.Fatalf("Expected the check to catch the overlap")
+	}
+
+	_, err = NewRangeStoreFromSortedUnchecked(nil)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+}
+
+func BenchmarkRangeStoreFromSorted(b *testing.B) {
+	items := arenaItems(100000)
+	for i := 0; i < b.N; i++ {
+		NewRangeStoreFromSorted(items)
+	}
+}
+
+func BenchmarkRangeStoreFromSortedUnchecked(b *testing.B) {
+	items := arenaItems(100000)
+	for i := 0; i < b.N; i++ {
+		NewRangeStoreFromSortedUnchecked(items)
+	}
+}
+
+func TestRangeSearch_BelowMin(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{100, 109, "A"})
+	items = append(items, DefaultRangedValue{110, 119, "B"})
+	items = append(items, DefaultRangedValue{120, 129, "C"})
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	b, err := NewRangeStoreBranchless(items)
+	if err != nil {
+		t.Fatalf("Error while constructing branchless store: %s", err.Error())
+	}
+	var packed, mapped bytes.Buffer
+	if err := WritePacked(&packed, n, nil); err != nil {
+		t.Fatalf("Error while packing store: %s", err.Error())
+	}
+	p, _ := NewPackedStore(packed.Bytes())
+	if err := WriteMapped(&mapped, n); err != nil {
+		t.Fatalf("Error while mapping store: %s", err.Error())
+	}
+	m, _ := NewMappedStoreFromBytes(mapped.Bytes())
+
+	searchers := map[string]RangeSearcher{"tree": n, "branchless": b, "packed": p, "mapped": m}
+	for name, opts := range map[string][]Option{
+		"flat":         {WithBackend(FlatBackend)},
+		"lookup table": {WithBackend(LookupTableBackend)},
+		"directory":    {WithDirectoryBuckets(4)},
+		"arena":        {WithArena()},
+		"miss filter":  {WithMissFilter()},
+		"cache":        {WithLookupCache(4)},
+	} {
+		s, err := NewRangeStore(items, opts...)
+		if err != nil {
+			t.Fatalf("Error while constructing %s store: %s", name, err.Error())
+		}
+		searchers[name] = s
+	}
+	// Keys below the first range must not fall through to its value
+	for name, s := range searchers {
+		for _, key := range []uint64{0, 1, 50, 99} {
+			if v, err := s.RangeSearch(key); err == nil || reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+				t.Fatalf("Expected ErrOutOfRange below the minimum of the %s store, got %v, %v", name, v, err)
+			}
+		}
+		if v, err := s.RangeSearch(100); err != nil || v != "A" {
+			t.Fatalf("Wrong value at the minimum of the %s store: %v", name, v)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestorehttp/handler.go: HTTP lookup service
+ */
+
+// Package rangestorehttp exposes a range store as a small JSON lookup service,
+// and provides middleware classifying requests through a store.
+package rangestorehttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// The store served by the handler. *rangestore.Node and *rangestore.RangeStore
+// both satisfy it.
+type Store interface {
+	rangestore.RangeSearcher
+	Ranges() []rangestore.RangeEntry
+	Len() int
+	Bounds() (min, max uint64)
+}
+
+// Serves lookups against a store:
+//
+//	GET /lookup?key=N   {"key": N, "value": ...}, or 404 if no range covers N
+//	GET /ranges         [{"min": ..., "max": ..., "value": ...}, ...]
+//	GET /stats          the size and bounds of the store, and lookup counters
+//
+// Errors are reported as {"error": "..."}. Keys may be decimal, or hexadecimal
+// with a 0x prefix.
+type Handler struct {
+	lookups, misses uint64
+	store           Store
+	mux             *http.ServeMux
+}
+
+type lookupResponse struct {
+	Key   uint64      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type rangeResponse struct {
+	Min   uint64      `json:"min"`
+	Max   uint64      `json:"max"`
+	Value interface{} `json:"value"`
+}
+
+type statsResponse struct {
+	Ranges  int    `json:"ranges"`
+	Min     uint64 `json:"min"`
+	Max     uint64 `json:"max"`
+	Lookups uint64 `json:"lookups"`
+	Misses  uint64 `json:"misses"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Creates a handler serving the store
+func NewHandler(store Store) *Handler {
+	h := &Handler{store: store, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/lookup", h.lookup)
+	h.mux.HandleFunc("/ranges", h.ranges)
+	h.mux.HandleFunc("/stats", h.stats)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"method not allowed"})
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) lookup(w http.ResponseWriter, r *http.Request) {
+	key, err := strconv.ParseUint(r.URL.Query().Get("key"), 0, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{"key must be an unsigned integer"})
+		return
+	}
+	atomic.AddUint64(&h.lookups, 1)
+	v, err := h.store.RangeSearch(key)
+	if err != nil {
+		atomic.AddUint64(&h.misses, 1)
+		status := http.StatusInternalServerError
+		if _, ok := err.(rangestore.ErrOutOfRange); ok {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, errorResponse{err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, lookupResponse{key, v})
+}
+
+func (h *Handler) ranges(w http.ResponseWriter, r *http.Request) {
+	entries := h.store.Ranges()
+	resp := make([]rangeResponse, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, rangeResponse{e.Min, e.Max, e.Value})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request) {
+	min, max := h.store.Bounds()
+	writeJSON(w, http.StatusOK, statsResponse{
+		Ranges:  h.store.Len(),
+		Min:     min,
+		Max:     max,
+		Lookups: atomic.LoadUint64(&h.lookups),
+		Misses:  atomic.LoadUint64(&h.misses),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,89 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestorehttp/handler_test.go: Tests on the HTTP lookup service
+ */
+
+package rangestorehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+func testHandler(t *testing.T) *Handler {
+	s, err := rangestore.NewBuilder().Add(0, 9, "A").Add(20, 29, "B").AllowGaps().Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	return NewHandler(s)
+}
+
+func get(h http.Handler, method, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+	return w
+}
+
+func TestHandler_Lookup(t *testing.T) {
+	h := testHandler(t)
+	for url, expected := range map[string]struct {
+		status int
+		body   string
+	}{
+		"/lookup?key=5":    {200, `{"key":5,"value":"A"}`},
+		"/lookup?key=0x14": {200, `{"key":20,"value":"B"}`},
+		"/lookup?key=15":   {404, `{"error":"Value 15 is out of range"}`},
+		"/lookup?key=-1":   {400, `{"error":"key must be an unsigned integer"}`},
+		"/lookup":          {400, `{"error":"key must be an unsigned integer"}`},
+	} {
+		w := get(h, "GET", url)
+		if w.Code != expected.status || w.Body.String() != expected.body+"\n" {
+			t.Fatalf("Wrong response for %s: %d %s", url, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("Wrong content type for %s", url)
+		}
+	}
+
+	if w := get(h, "POST", "/lookup?key=5"); w.Code != 405 {
+		t.Fatalf("Expected POST to be rejected, got %d", w.Code)
+	}
+	if w := get(h, "GET", "/nothing"); w.Code != 404 {
+		t.Fatalf("Expected an unknown path to be rejected, got %d", w.Code)
+	}
+}
+
+func TestHandler_RangesAndStats(t *testing.T) {
+	h := testHandler(t)
+	w := get(h, "GET", "/ranges")
+	if w.Code != 200 || w.Body.String() != `[{"min":0,"max":9,"value":"A"},{"min":20,"max":29,"value":"B"}]`+"\n" {
+		t.Fatalf("Wrong ranges response: %d %s", w.Code, w.Body.String())
+	}
+
+	get(h, "GET", "/lookup?key=5")
+	get(h, "GET", "/lookup?key=15")
+	w = get(h, "GET", "/stats")
+	if w.Code != 200 || w.Body.String() != `{"ranges":2,"min":0,"max":29,"lookups":2,"misses":1}`+"\n" {
+		t.Fatalf("Wrong stats response: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,83 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestorehttp/middleware.go: Classifying requests through a store
+ */
+
+package rangestorehttp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// Extracts the number a request is classified by, returning false if the
+// request doesn't have one
+type KeyFunc func(r *http.Request) (uint64, bool)
+
+// Classifies requests by their declared Content-Length, skipping those of
+// unknown length
+func ContentLength(r *http.Request) (uint64, bool) {
+	if r.ContentLength < 0 {
+		return 0, false
+	}
+	return uint64(r.ContentLength), true
+}
+
+// Classifies requests by the number in the named header, such as a user ID,
+// which may be decimal, or hexadecimal with a 0x prefix. Requests without the
+// header, or with one which isn't an unsigned integer, are skipped.
+func HeaderKey(name string) KeyFunc {
+	return func(r *http.Request) (uint64, bool) {
+		key, err := strconv.ParseUint(r.Header.Get(name), 0, 64)
+		return key, err == nil
+	}
+}
+
+type classKey struct {
+	name string
+}
+
+// Returns middleware which looks up the key of each request in the store, and
+// passes the value on to next in the request's context, where Class finds it
+// under the name. Requests without a key, or whose key isn't covered, are
+// passed on without a value. Several classifications can be stacked, as long as
+// their names differ.
+func Classify(name string, store rangestore.RangeSearcher, key KeyFunc) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if k, ok := key(r); ok {
+				if v, err := store.RangeSearch(k); err == nil {
+					r = r.WithContext(context.WithValue(r.Context(), classKey{name}, v))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Returns the value Classify found for the request under the name, and whether
+// there was one
+func Class(ctx context.Context, name string) (interface{}, bool) {
+	v := ctx.Value(classKey{name})
+	return v, v != nil
+}
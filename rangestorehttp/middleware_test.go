@@ -0,0 +1,75 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestorehttp/middleware_test.go: Tests on classifying requests
+ */
+
+package rangestorehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+func TestClassify(t *testing.T) {
+	sizes, err := rangestore.NewBucketStore([]uint64{1023, 1<<20 - 1}, []interface{}{"small", "large"})
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	users, err := rangestore.NewBuilder().Add(0, 999, "internal").Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+
+	var size, user interface{}
+	var hasSize, hasUser bool
+	h := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, hasSize = Class(r.Context(), "size")
+		user, hasUser = Class(r.Context(), "user")
+	}))
+	h = Classify("size", sizes, ContentLength)(h)
+	h = Classify("user", users, HeaderKey("X-User-Id"))(h)
+
+	r := httptest.NewRequest("POST", "/upload", strings.NewReader(strings.Repeat("x", 2000)))
+	r.Header.Set("X-User-Id", "0x10")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if size != "large" || user != "internal" {
+		t.Fatalf("Wrong classes: %v, %v", size, user)
+	}
+
+	// No header, and a body too large for any bucket
+	r = httptest.NewRequest("POST", "/upload", nil)
+	r.ContentLength = 1 << 20
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if hasSize || hasUser {
+		t.Fatalf("Expected no classes, got %v, %v", size, user)
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.ContentLength = -1
+	r.Header.Set("X-User-Id", "nobody")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if hasSize || hasUser {
+		t.Fatalf("Expected no classes for an unknown length and a malformed header")
+	}
+}
@@ -0,0 +1,247 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestoremetrics/metrics.go: Prometheus metrics for range stores
+ */
+
+// Package rangestoremetrics exports the lookup counters, lookup latencies and
+// size of a range store as Prometheus metrics. By default, metrics are written
+// in the Prometheus text exposition format by a Registry, which is served over
+// HTTP for Prometheus to scrape, so no client library is needed. Built with the
+// prometheus tag, the stores are also prometheus.Collectors, and can be
+// registered with any prometheus.Registerer by InstrumentPrometheus.
+package rangestoremetrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// The store being instrumented. *rangestore.RangeStore, *rangestore.Node and
+// *rangestore.SwappableStore all satisfy it.
+type Store interface {
+	rangestore.RangeSearcher
+	Len() int
+}
+
+// Writes metrics in the Prometheus text exposition format. Unrelated to
+// prometheus.Collector, which the prometheus tag adds.
+type Collector interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// Accepts collectors to be scraped. Registry is the implementation; for a
+// prometheus.Registerer, see InstrumentPrometheus.
+type Registerer interface {
+	Register(c Collector) error
+}
+
+// A set of collectors, served to Prometheus at whichever path it's mounted on,
+// conventionally /metrics.
+//
+// Registry is safe for concurrent use.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// Creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Adds a collector to those scraped
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// Writes the metrics of every collector, in the order they were registered
+func (r *Registry) WriteMetrics(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+	for _, c := range collectors {
+		if err := c.WriteMetrics(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serves the metrics of every collector
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	buf := new(bytes.Buffer)
+	if err := r.WriteMetrics(buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	buf.WriteTo(w)
+}
+
+// The upper bounds of the lookup latency buckets, in nanoseconds
+var LatencyBuckets = []uint64{100, 250, 500, 1000, 2500, 5000, 10000, 25000, 100000}
+
+// A store whose lookups are counted and timed. Exports
+//
+//	rangestore_lookups_total                 counter
+//	rangestore_misses_total                  counter
+//	rangestore_lookup_duration_seconds       histogram
+//	rangestore_ranges                        gauge
+//	rangestore_last_reload_timestamp_seconds gauge
+//
+// InstrumentedStore is safe for concurrent use, provided the store is too.
+type InstrumentedStore struct {
+	lookups    uint64
+	misses     uint64
+	nanos      uint64
+	lastReload int64
+	store      Store
+	latency    *rangestore.Histogram
+}
+
+// Wraps the store, registering its metrics with registerer. The reload time
+// starts out as the current time; call Reloaded whenever the data behind the
+// store is replaced, e.g. from the OnReload callback of a Watcher.
+func Instrument(store Store, registerer Registerer) (*InstrumentedStore, error) {
+	s, err := newInstrumentedStore(store)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerer.Register(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newInstrumentedStore(store Store) (*InstrumentedStore, error) {
+	latency, err := rangestore.NewHistogram(LatencyBuckets)
+	if err != nil {
+		return nil, err
+	}
+	return &InstrumentedStore{store: store, latency: latency, lastReload: time.Now().UnixNano()}, nil
+}
+
+// Searches the store, recording the lookup
+func (s *InstrumentedStore) RangeSearch(val uint64) (interface{}, error) {
+	start := time.Now()
+	v, err := s.store.RangeSearch(val)
+	elapsed := uint64(time.Since(start))
+	atomic.AddUint64(&s.lookups, 1)
+	atomic.AddUint64(&s.nanos, elapsed)
+	s.latency.Observe(elapsed)
+	if err != nil {
+		atomic.AddUint64(&s.misses, 1)
+	}
+	return v, err
+}
+
+// Records that the data behind the store was just replaced
+func (s *InstrumentedStore) Reloaded() {
+	atomic.StoreInt64(&s.lastReload, time.Now().UnixNano())
+}
+
+// Writes the metrics of the store
+func (s *InstrumentedStore) WriteMetrics(w io.Writer) error {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# HELP rangestore_lookups_total Lookups made against the store.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_lookups_total counter\n")
+	fmt.Fprintf(buf, "rangestore_lookups_total %d\n", atomic.LoadUint64(&s.lookups))
+	fmt.Fprintf(buf, "# HELP rangestore_misses_total Lookups for keys no range covers.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_misses_total counter\n")
+	fmt.Fprintf(buf, "rangestore_misses_total %d\n", atomic.LoadUint64(&s.misses))
+
+	fmt.Fprintf(buf, "# HELP rangestore_lookup_duration_seconds Time taken by lookups.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_lookup_duration_seconds histogram\n")
+	cum := uint64(0)
+	for _, b := range s.latency.Snapshot() {
+		cum += b.Count
+		le := "+Inf"
+		if b.Label != "+Inf" {
+			le = fmt.Sprintf("%g", float64(b.Max)/1e9)
+		}
+		fmt.Fprintf(buf, "rangestore_lookup_duration_seconds_bucket{le=\"%s\"} %d\n", le, cum)
+	}
+	fmt.Fprintf(buf, "rangestore_lookup_duration_seconds_sum %g\n", float64(atomic.LoadUint64(&s.nanos))/1e9)
+	fmt.Fprintf(buf, "rangestore_lookup_duration_seconds_count %d\n", cum)
+
+	fmt.Fprintf(buf, "# HELP rangestore_ranges Ranges held by the store.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_ranges gauge\n")
+	fmt.Fprintf(buf, "rangestore_ranges %d\n", s.store.Len())
+	fmt.Fprintf(buf, "# HELP rangestore_last_reload_timestamp_seconds When the store was last reloaded.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_last_reload_timestamp_seconds gauge\n")
+	fmt.Fprintf(buf, "rangestore_last_reload_timestamp_seconds %g\n", float64(atomic.LoadInt64(&s.lastReload))/1e9)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Exports the lookup counters and sizes of the stores of a registry, labelled by
+// the names they're registered under:
+//
+//	rangestore_registry_lookups_total{store="..."} counter
+//	rangestore_registry_misses_total{store="..."}  counter
+//	rangestore_registry_ranges{store="..."}        gauge, for stores with a Len method
+//
+// Only lookups made through the registry are counted. The stores are read on
+// every scrape, so the metrics follow registrations and swaps.
+func InstrumentRegistry(r *rangestore.Registry, registerer Registerer) error {
+	return registerer.Register(registryCollector{r})
+}
+
+type registryCollector struct {
+	r *rangestore.Registry
+}
+
+// Escapes a label value as the text exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (c registryCollector) WriteMetrics(w io.Writer) error {
+	entries := c.r.Entries()
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# HELP rangestore_registry_lookups_total Lookups made against each store of the registry.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_registry_lookups_total counter\n")
+	for _, e := range entries {
+		fmt.Fprintf(buf, "rangestore_registry_lookups_total{store=\"%s\"} %d\n", labelEscaper.Replace(e.Name), e.Lookups)
+	}
+	fmt.Fprintf(buf, "# HELP rangestore_registry_misses_total Lookups for keys no range of the store covers.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_registry_misses_total counter\n")
+	for _, e := range entries {
+		fmt.Fprintf(buf, "rangestore_registry_misses_total{store=\"%s\"} %d\n", labelEscaper.Replace(e.Name), e.Misses)
+	}
+	fmt.Fprintf(buf, "# HELP rangestore_registry_ranges Ranges held by each store of the registry.\n")
+	fmt.Fprintf(buf, "# TYPE rangestore_registry_ranges gauge\n")
+	for _, e := range entries {
+		if s, ok := e.Store.(Store); ok {
+			fmt.Fprintf(buf, "rangestore_registry_ranges{store=\"%s\"} %d\n", labelEscaper.Replace(e.Name), s.Len())
+		}
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
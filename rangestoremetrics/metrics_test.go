@@ -0,0 +1,96 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestoremetrics/metrics_test.go: Tests on Prometheus metrics
+ */
+
+package rangestoremetrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+func TestInstrument(t *testing.T) {
+	store, err := rangestore.NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	registry := NewRegistry()
+	s, err := Instrument(store, registry)
+	if err != nil {
+		t.Fatalf("Error while instrumenting: %s", err.Error())
+	}
+	for _, key := range []uint64{5, 15, 25} {
+		s.RangeSearch(key)
+	}
+	s.Reloaded()
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("Wrong content type: %s", ct)
+	}
+	body, _ := ioutil.ReadAll(rec.Body)
+	for _, line := range []string{
+		"rangestore_lookups_total 3\n",
+		"rangestore_misses_total 1\n",
+		"rangestore_lookup_duration_seconds_bucket{le=\"+Inf\"} 3\n",
+		"rangestore_lookup_duration_seconds_bucket{le=\"1e-07\"} ",
+		"rangestore_lookup_duration_seconds_count 3\n",
+		"rangestore_ranges 2\n",
+		"# TYPE rangestore_last_reload_timestamp_seconds gauge\n",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("Missing %q from the metrics:\n%s", line, body)
+		}
+	}
+}
+
+func TestInstrumentRegistry(t *testing.T) {
+	a, _ := rangestore.NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Build()
+	r := rangestore.NewRegistry()
+	r.Register(`tenant "a"`, a)
+	r.Register("tenant-b", a.Root())
+	registry := NewRegistry()
+	if err := InstrumentRegistry(r, registry); err != nil {
+		t.Fatalf("Error while instrumenting: %s", err.Error())
+	}
+	for _, key := range []uint64{5, 25} {
+		r.Lookup(`tenant "a"`, key)
+	}
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Body)
+	for _, line := range []string{
+		"rangestore_registry_lookups_total{store=\"tenant \\\"a\\\"\"} 2\n",
+		"rangestore_registry_misses_total{store=\"tenant \\\"a\\\"\"} 1\n",
+		"rangestore_registry_lookups_total{store=\"tenant-b\"} 0\n",
+		"rangestore_registry_ranges{store=\"tenant-b\"} 2\n",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("Missing %q from the metrics:\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+//go:build prometheus
+// +build prometheus
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestoremetrics/prometheus.go: Collectors for the Prometheus client library
+ */
+
+package rangestoremetrics
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tenta-browser/go-range-store"
+)
+
+// This file is only built with the prometheus tag, so that the package has no
+// dependencies unless the client library is wanted:
+//
+//	go get github.com/prometheus/client_golang/prometheus
+//	go build -tags prometheus
+
+var (
+	lookupsDesc    = prometheus.NewDesc("rangestore_lookups_total", "Lookups made against the store.", nil, nil)
+	missesDesc     = prometheus.NewDesc("rangestore_misses_total", "Lookups for keys no range covers.", nil, nil)
+	durationDesc   = prometheus.NewDesc("rangestore_lookup_duration_seconds", "Time taken by lookups.", nil, nil)
+	rangesDesc     = prometheus.NewDesc("rangestore_ranges", "Ranges held by the store.", nil, nil)
+	lastReloadDesc = prometheus.NewDesc("rangestore_last_reload_timestamp_seconds", "When the store was last reloaded.", nil, nil)
+
+	registryLookupsDesc = prometheus.NewDesc("rangestore_registry_lookups_total", "Lookups made against each store of the registry.", []string{"store"}, nil)
+	registryMissesDesc  = prometheus.NewDesc("rangestore_registry_misses_total", "Lookups for keys no range of the store covers.", []string{"store"}, nil)
+	registryRangesDesc  = prometheus.NewDesc("rangestore_registry_ranges", "Ranges held by each store of the registry.", []string{"store"}, nil)
+)
+
+// Wraps the store as Instrument does, registering it with a registerer of the
+// Prometheus client library, such as prometheus.DefaultRegisterer
+func InstrumentPrometheus(store Store, registerer prometheus.Registerer) (*InstrumentedStore, error) {
+	s, err := newInstrumentedStore(store)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerer.Register(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Implements prometheus.Collector
+func (s *InstrumentedStore) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lookupsDesc
+	ch <- missesDesc
+	ch <- durationDesc
+	ch <- rangesDesc
+	ch <- lastReloadDesc
+}
+
+// Implements prometheus.Collector
+func (s *InstrumentedStore) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(lookupsDesc, prometheus.CounterValue, float64(atomic.LoadUint64(&s.lookups)))
+	ch <- prometheus.MustNewConstMetric(missesDesc, prometheus.CounterValue, float64(atomic.LoadUint64(&s.misses)))
+
+	buckets := make(map[float64]uint64)
+	cum := uint64(0)
+	for _, b := range s.latency.Snapshot() {
+		cum += b.Count
+		if b.Label != "+Inf" {
+			buckets[float64(b.Max)/1e9] = cum
+		}
+	}
+	sum := float64(atomic.LoadUint64(&s.nanos)) / 1e9
+	ch <- prometheus.MustNewConstHistogram(durationDesc, cum, sum, buckets)
+
+	ch <- prometheus.MustNewConstMetric(rangesDesc, prometheus.GaugeValue, float64(s.store.Len()))
+	ch <- prometheus.MustNewConstMetric(lastReloadDesc, prometheus.GaugeValue, float64(atomic.LoadInt64(&s.lastReload))/1e9)
+}
+
+// Exports the stores of a registry as InstrumentRegistry does, registering them
+// with a registerer of the Prometheus client library
+func InstrumentRegistryPrometheus(r *rangestore.Registry, registerer prometheus.Registerer) error {
+	return registerer.Register(registryCollector{r})
+}
+
+// Implements prometheus.Collector
+func (c registryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- registryLookupsDesc
+	ch <- registryMissesDesc
+	ch <- registryRangesDesc
+}
+
+// Implements prometheus.Collector
+func (c registryCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, e := range c.r.Entries() {
+		ch <- prometheus.MustNewConstMetric(registryLookupsDesc, prometheus.CounterValue, float64(e.Lookups), e.Name)
+		ch <- prometheus.MustNewConstMetric(registryMissesDesc, prometheus.CounterValue, float64(e.Misses), e.Name)
+		if s, ok := e.Store.(Store); ok {
+			ch <- prometheus.MustNewConstMetric(registryRangesDesc, prometheus.GaugeValue, float64(s.Len()), e.Name)
+		}
+	}
+}
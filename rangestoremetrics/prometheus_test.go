@@ -0,0 +1,108 @@
+//go:build prometheus
+// +build prometheus
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestoremetrics/prometheus_test.go: Tests on the Prometheus client collectors
+ */
+
+package rangestoremetrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tenta-browser/go-range-store"
+)
+
+func TestInstrumentPrometheus(t *testing.T) {
+	store, err := rangestore.NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Build()
+	if err != nil {
+		t.Fatalf("Error while building store: %s", err.Error())
+	}
+	registry := prometheus.NewPedanticRegistry()
+	s, err := InstrumentPrometheus(store, registry)
+	if err != nil {
+		t.Fatalf("Error while instrumenting: %s", err.Error())
+	}
+	for _, key := range []uint64{5, 15, 25} {
+		s.RangeSearch(key)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Error while gathering: %s", err.Error())
+	}
+	got := make(map[string]float64)
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		switch {
+		case m.GetCounter() != nil:
+			got[f.GetName()] = m.GetCounter().GetValue()
+		case m.GetGauge() != nil:
+			got[f.GetName()] = m.GetGauge().GetValue()
+		case m.GetHistogram() != nil:
+			got[f.GetName()] = float64(m.GetHistogram().GetSampleCount())
+		}
+	}
+	for name, value := range map[string]float64{
+		"rangestore_lookups_total":           3,
+		"rangestore_misses_total":            1,
+		"rangestore_lookup_duration_seconds": 3,
+		"rangestore_ranges":                  2,
+	} {
+		if got[name] != value {
+			t.Fatalf("Wrong value for %s: %v", name, got[name])
+		}
+	}
+	if got["rangestore_last_reload_timestamp_seconds"] == 0 {
+		t.Fatalf("Missing the reload time")
+	}
+}
+
+func TestInstrumentRegistryPrometheus(t *testing.T) {
+	a, _ := rangestore.NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Build()
+	r := rangestore.NewRegistry()
+	r.Register("tenant-a", a)
+	registry := prometheus.NewPedanticRegistry()
+	if err := InstrumentRegistryPrometheus(r, registry); err != nil {
+		t.Fatalf("Error while instrumenting: %s", err.Error())
+	}
+	for _, key := range []uint64{5, 25} {
+		r.Lookup("tenant-a", key)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Error while gathering: %s", err.Error())
+	}
+	if len(families) != 3 {
+		t.Fatalf("Wrong number of metric families: %d", len(families))
+	}
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		if m.GetLabel()[0].GetValue() != "tenant-a" {
+			t.Fatalf("Wrong store label for %s", f.GetName())
+		}
+		if f.GetName() == "rangestore_registry_misses_total" && m.GetCounter().GetValue() != 1 {
+			t.Fatalf("Wrong miss count: %v", m.GetCounter().GetValue())
+		}
+	}
+}
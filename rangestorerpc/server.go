@@ -0,0 +1,145 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestorerpc/server.go: net/rpc lookup service
+ */
+
+// Package rangestorerpc implements a lookup service over net/rpc, with Lookup,
+// BatchLookup and Reload methods, serving a store which can be reloaded while
+// it's in use. Clients call the methods as "RangeStore.Lookup" and so on, with
+// any net/rpc codec, such as the default gob codec or net/rpc/jsonrpc.
+package rangestorerpc
+
+import (
+	"errors"
+	"fmt"
+	"net/rpc"
+	"time"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+type LookupRequest struct {
+	Key uint64
+}
+
+type LookupResponse struct {
+	Found bool
+	Value string
+}
+
+type BatchLookupRequest struct {
+	Keys []uint64
+}
+
+type BatchLookupResponse struct {
+	Results []LookupResponse
+}
+
+type ReloadRequest struct {
+	Source string
+}
+
+type ReloadResponse struct {
+	Ranges uint64
+}
+
+// Builds a fresh store from the source named in a ReloadRequest
+type Loader func(source string) (*rangestore.RangeStore, error)
+
+// Returned by Reload when the server has no loader
+var ErrNoLoader = errors.New("Server has no loader")
+
+// Serves lookups against a swappable store
+type Server struct {
+	store  *rangestore.SwappableStore
+	loader Loader
+	// Converts values to strings for the responses; fmt.Sprint if nil
+	Format func(v interface{}) string
+}
+
+// The name the service is registered under by Register
+const ServiceName = "RangeStore"
+
+// Creates a server for the store. The loader is used by Reload, and may be nil
+// if the store is never reloaded.
+func NewServer(store *rangestore.SwappableStore, loader Loader) *Server {
+	return &Server{store: store, loader: loader}
+}
+
+// Registers the service with an RPC server under ServiceName
+func (s *Server) Register(srv *rpc.Server) error {
+	return srv.RegisterName(ServiceName, s)
+}
+
+// Looks up the value of a single key. Keys which aren't covered by any range
+// aren't an error, they're reported with Found set to false.
+func (s *Server) Lookup(req *LookupRequest, resp *LookupResponse) error {
+	r, err := s.lookup(s.store.Load(), req.Key)
+	if err != nil {
+		return err
+	}
+	*resp = r
+	return nil
+}
+
+// Looks up the values of many keys at once, all against the same store, even if
+// it's reloaded in the meantime
+func (s *Server) BatchLookup(req *BatchLookupRequest, resp *BatchLookupResponse) error {
+	store := s.store.Load()
+	results := make([]LookupResponse, 0, len(req.Keys))
+	for _, key := range req.Keys {
+		r, err := s.lookup(store, key)
+		if err != nil {
+			return err
+		}
+		results = append(results, r)
+	}
+	resp.Results = results
+	return nil
+}
+
+// Builds a fresh store with the loader and swaps it in. If loading fails, the
+// current store is kept.
+func (s *Server) Reload(req *ReloadRequest, resp *ReloadResponse) error {
+	if s.loader == nil {
+		return ErrNoLoader
+	}
+	store, err := s.loader(req.Source)
+	if err != nil {
+		return err
+	}
+	s.store.SwapVersioned(store, rangestore.StoreVersion{Source: req.Source, BuiltAt: time.Now()})
+	resp.Ranges = uint64(store.Len())
+	return nil
+}
+
+func (s *Server) lookup(store *rangestore.RangeStore, key uint64) (LookupResponse, error) {
+	v, err := store.RangeSearch(key)
+	if err != nil {
+		if _, ok := err.(rangestore.ErrOutOfRange); ok {
+			return LookupResponse{}, nil
+		}
+		return LookupResponse{}, err
+	}
+	if s.Format != nil {
+		return LookupResponse{true, s.Format(v)}, nil
+	}
+	return LookupResponse{true, fmt.Sprint(v)}, nil
+}
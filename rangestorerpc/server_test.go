@@ -0,0 +1,119 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestorerpc/server_test.go: Tests on the lookup service server
+ */
+
+package rangestorerpc
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"reflect"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+func testServer(t *testing.T) *Server {
+	s, err := rangestore.NewBuilder().Add(0, 9, "A").Add(20, 29, "B").AllowGaps().Build()
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	return NewServer(rangestore.NewSwappableStore(s), func(source string) (*rangestore.RangeStore, error) {
+		if source == "bad" {
+			return nil, errors.New("no such source")
+		}
+		return rangestore.NewBuilder().Add(0, 99, source).Build()
+	})
+}
+
+func TestServer_Lookup(t *testing.T) {
+	s := testServer(t)
+	var resp LookupResponse
+	if err := s.Lookup(&LookupRequest{5}, &resp); err != nil {
+		t.Fatalf("Error while looking up: %s", err.Error())
+	}
+	if resp != (LookupResponse{true, "A"}) {
+		t.Fatalf("Wrong response: %v", resp)
+	}
+	if err := s.Lookup(&LookupRequest{15}, &resp); err != nil {
+		t.Fatalf("Error while looking up: %s", err.Error())
+	}
+	if resp.Found {
+		t.Fatalf("Expected a miss, got %v", resp)
+	}
+
+	var batch BatchLookupResponse
+	if err := s.BatchLookup(&BatchLookupRequest{[]uint64{25, 15, 0}}, &batch); err != nil {
+		t.Fatalf("Error while looking up: %s", err.Error())
+	}
+	expected := []LookupResponse{{true, "B"}, {false, ""}, {true, "A"}}
+	if !reflect.DeepEqual(batch.Results, expected) {
+		t.Fatalf("Wrong batch response: %v", batch.Results)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	s := testServer(t)
+	var resp ReloadResponse
+	if err := s.Reload(&ReloadRequest{"C"}, &resp); err != nil {
+		t.Fatalf("Error while reloading: %s", err.Error())
+	}
+	if resp.Ranges != 1 {
+		t.Fatalf("Wrong number of ranges after reloading: %d", resp.Ranges)
+	}
+	var lookup LookupResponse
+	s.Lookup(&LookupRequest{15}, &lookup)
+	if lookup != (LookupResponse{true, "C"}) {
+		t.Fatalf("Wrong response after reloading: %v", lookup)
+	}
+
+	if err := s.Reload(&ReloadRequest{"bad"}, &resp); err == nil {
+		t.Fatalf("Expected the reload to fail")
+	}
+	s.Lookup(&LookupRequest{15}, &lookup)
+	if lookup != (LookupResponse{true, "C"}) {
+		t.Fatalf("Failed reload replaced the store: %v", lookup)
+	}
+
+	if err := NewServer(nil, nil).Reload(&ReloadRequest{}, &resp); err != ErrNoLoader {
+		t.Fatalf("Expected ErrNoLoader, got %v", err)
+	}
+}
+
+func TestServer_NetRPC(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := testServer(t).Register(srv); err != nil {
+		t.Fatalf("Error while registering: %s", err.Error())
+	}
+	a, b := net.Pipe()
+	go srv.ServeConn(a)
+	client := rpc.NewClient(b)
+	defer client.Close()
+
+	var resp LookupResponse
+	if err := client.Call("RangeStore.Lookup", &LookupRequest{25}, &resp); err != nil {
+		t.Fatalf("Error while calling: %s", err.Error())
+	}
+	if resp != (LookupResponse{true, "B"}) {
+		t.Fatalf("Wrong response: %v", resp)
+	}
+}
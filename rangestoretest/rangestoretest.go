@@ -0,0 +1,197 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestoretest/rangestoretest.go: Generators and assertions for testing range stores
+ */
+
+// Package rangestoretest generates valid and invalid inputs for range stores,
+// and asserts the invariants the rangestore package maintains, so that custom
+// backends, value types and code built on the stores can be tested the same way
+// the package tests itself.
+package rangestoretest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// Anything holding ranges which can be searched, such as *rangestore.Node,
+// *rangestore.RangeStore and *rangestore.SwappableStore
+type Store interface {
+	rangestore.RangeSearcher
+	Ranges() []rangestore.RangeEntry
+}
+
+// Returns a number in [0, n). The slight bias of the modulo doesn't matter for
+// test data.
+func below(r rangestore.RandSource, n uint64) uint64 {
+	return r.Uint64() % n
+}
+
+// Returns count contiguous ranges starting at 0, each between 1 and maxWidth
+// keys wide, holding their index as the value. They are valid input for
+// NewRangeStoreFromSorted.
+func Contiguous(r rangestore.RandSource, count int, maxWidth uint64) []rangestore.Ranged {
+	return Sparse(r, count, maxWidth, 0)
+}
+
+// Returns count ascending ranges starting at 0, each between 1 and maxWidth keys
+// wide and followed by a gap of up to maxGap keys, holding their index as the
+// value. They are valid input for NewSparseRangeStoreFromSorted.
+func Sparse(r rangestore.RandSource, count int, maxWidth, maxGap uint64) []rangestore.Ranged {
+	items := make([]rangestore.Ranged, 0, count)
+	min := uint64(0)
+	for i := 0; i < count; i += 1 {
+		max := min + below(r, maxWidth)
+		items = append(items, rangestore.RangeEntry{Min: min, Max: max, Value: i})
+		min = max + 1 + below(r, maxGap+1)
+	}
+	return items
+}
+
+// A defect Invalid introduces into otherwise valid input
+type Defect int
+
+const (
+	// Two neighbouring ranges share a key, giving ErrOverlap
+	Overlap Defect = iota
+	// A range has its minimum above its maximum, giving ErrInvertedRange from
+	// the Builder
+	Inverted
+	// The same range appears twice, giving ErrDuplicateRange
+	Duplicate
+	// A gap between two ranges, giving ErrDiscontinuity unless gaps are allowed
+	Gap
+	// No ranges at all, giving ErrEmptyInput
+	Empty
+)
+
+// Returns count contiguous ranges, as Contiguous does, with the defect
+// introduced at a random position. Every defect but Empty needs a count of at
+// least 2.
+func Invalid(r rangestore.RandSource, count int, defect Defect) []rangestore.Ranged {
+	if defect == Empty {
+		return make([]rangestore.Ranged, 0)
+	}
+	items := Contiguous(r, count, 10)
+	// The range which gets the defect, and the one before it
+	idx := 1 + int(below(r, uint64(count-1)))
+	prev := items[idx-1].(rangestore.RangeEntry)
+	e := items[idx].(rangestore.RangeEntry)
+	switch defect {
+	case Overlap:
+		e.Min = prev.Max
+	case Inverted:
+		e.Min, e.Max = e.Max+1, e.Min
+	case Duplicate:
+		e.Min, e.Max = prev.Min, prev.Max
+	case Gap:
+		// Move everything from here on up by one key
+		for i := idx; i < len(items); i += 1 {
+			moved := items[i].(rangestore.RangeEntry)
+			moved.Min, moved.Max = moved.Min+1, moved.Max+1
+			items[i] = moved
+		}
+		return items
+	}
+	items[idx] = e
+	return items
+}
+
+// Asserts that the store covers every key from lo to hi, reporting the first key
+// which isn't, and that searching each of the bounds of the ranges in between
+// agrees with the ranges
+func AssertCovers(t testing.TB, s Store, lo, hi uint64) bool {
+	t.Helper()
+	next := lo
+	for _, e := range s.Ranges() {
+		if e.Max < next || e.Min > hi {
+			continue
+		}
+		if e.Min > next {
+			t.Errorf("Key %d isn't covered", next)
+			return false
+		}
+		for _, key := range []uint64{next, e.Max} {
+			if v, err := s.RangeSearch(key); err != nil || !reflect.DeepEqual(v, e.Value) {
+				t.Errorf("Searching for %d gave %v, %v rather than %v from [%d, %d]", key, v, err, e.Value, e.Min, e.Max)
+				return false
+			}
+		}
+		if e.Max >= hi {
+			return true
+		}
+		next = e.Max + 1
+	}
+	t.Errorf("Key %d isn't covered", next)
+	return false
+}
+
+// Asserts that both stores hold the same ranges with equal values, as compared
+// with reflect.DeepEqual, reporting the first difference. The shape of the trees
+// doesn't matter.
+func AssertEqualStores(t testing.TB, a, b Store) bool {
+	t.Helper()
+	ra, rb := a.Ranges(), b.Ranges()
+	for i := 0; i < len(ra) && i < len(rb); i += 1 {
+		if !reflect.DeepEqual(ra[i], rb[i]) {
+			t.Errorf("Range %d differs: [%d, %d] %v against [%d, %d] %v", i, ra[i].Min, ra[i].Max, ra[i].Value, rb[i].Min, rb[i].Max, rb[i].Value)
+			return false
+		}
+	}
+	if len(ra) != len(rb) {
+		t.Errorf("Different numbers of ranges: %d against %d", len(ra), len(rb))
+		return false
+	}
+	return true
+}
+
+// Asserts that searching the store for each key of the reference gives its
+// value, or ErrOutOfRange for keys whose value is nil, reporting every mismatch
+func AssertLookupConsistent(t testing.TB, s rangestore.RangeSearcher, reference map[uint64]interface{}) bool {
+	t.Helper()
+	ok := true
+	for key, expected := range reference {
+		v, err := s.RangeSearch(key)
+		if expected == nil {
+			if _, miss := err.(rangestore.ErrOutOfRange); !miss {
+				t.Errorf("Expected key %d to be out of range, got %v, %v", key, v, err)
+				ok = false
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(v, expected) {
+			t.Errorf("Searching for %d gave %v, %v rather than %v", key, v, err, expected)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Asserts that the store passes its CheckInvariants
+func AssertInvariants(t testing.TB, s interface{ CheckInvariants() error }) bool {
+	t.Helper()
+	if err := s.CheckInvariants(); err != nil {
+		t.Errorf("%s", err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rangestoretest/rangestoretest_test.go: Tests on the generators and assertions
+ */
+
+package rangestoretest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/tenta-browser/go-range-store"
+)
+
+// Records the failures of assertions which are expected to fail
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestGenerators(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i += 1 {
+		n, err := rangestore.NewRangeStoreFromSorted(Contiguous(r, 100, 20))
+		if err != nil {
+			t.Fatalf("Error while building from contiguous ranges: %s", err.Error())
+		}
+		AssertInvariants(t, n)
+		_, max := n.Bounds()
+		AssertCovers(t, n, 0, max)
+
+		if _, err := rangestore.NewSparseRangeStoreFromSorted(Sparse(r, 100, 20, 5)); err != nil {
+			t.Fatalf("Error while building from sparse ranges: %s", err.Error())
+		}
+
+		for defect, expected := range map[Defect]string{
+			Overlap: "ErrOverlap", Inverted: "ErrInvertedRange", Duplicate: "ErrDuplicateRange", Gap: "ErrDiscontinuity", Empty: "ErrEmptyInput",
+		} {
+			if _, err := rangestore.NewRangeStore(Invalid(r, 10, defect)); err == nil || reflect.TypeOf(err).Name() != expected {
+				t.Fatalf("Expected %s for defect %d, got %v", expected, defect, err)
+			}
+		}
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	a, _ := rangestore.NewBuilder().Add(0, 9, "A").Add(20, 29, "B").AllowGaps().Build()
+	b, _ := rangestore.NewBuilder().Add(0, 9, "A").Add(20, 29, "C").AllowGaps().Build()
+
+	if !AssertCovers(t, a, 2, 8) || !AssertEqualStores(t, a, a.Root()) {
+		t.Fatalf("Expected the assertions to pass")
+	}
+	if !AssertLookupConsistent(t, a, map[uint64]interface{}{5: "A", 25: "B", 15: nil}) {
+		t.Fatalf("Expected the lookups to be consistent")
+	}
+
+	rec := &recorder{}
+	if AssertCovers(rec, a, 5, 25) || rec.errors[0] != "Key 10 isn't covered" {
+		t.Fatalf("Expected the gap to be reported: %v", rec.errors)
+	}
+	rec = &recorder{}
+	if AssertEqualStores(rec, a, b) || rec.errors[0] != "Range 1 differs: [20, 29] B against [20, 29] C" {
+		t.Fatalf("Expected the difference to be reported: %v", rec.errors)
+	}
+	rec = &recorder{}
+	if AssertLookupConsistent(rec, a, map[uint64]interface{}{5: "B", 15: "A", 25: nil}) || len(rec.errors) != 3 {
+		t.Fatalf("Expected every mismatch to be reported: %v", rec.errors)
+	}
+}
@@ -0,0 +1,139 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rank.go: Rank and select over covered keys
+ */
+
+package rangestore
+
+import (
+	"sort"
+)
+
+// Returns the covered key at position idx, counting from 0 in ascending order, so
+// that the covered keys of a sparse store can be numbered densely. Returns
+// ErrOutOfRange if the store covers idx keys or fewer. The nodes don't record the
+// size of their subtrees, so this walks the ranges in order until it reaches the
+// key; build a RankIndex to select many keys.
+func (n *Node) SelectKey(idx uint64) (uint64, error) {
+	if n == nil {
+		return 0, ErrEmptyStore{}
+	}
+	var key uint64
+	found := false
+	remaining := idx
+	n.WalkInOrder(func(c *Node, depth int) bool {
+		// A width of 0 is a range covering all of the keys
+		width := (c.max - c.min) + 1
+		if width == 0 || remaining < width {
+			key, found = c.min+remaining, true
+			return false
+		}
+		remaining -= width
+		return true
+	})
+	if !found {
+		return 0, ErrOutOfRange{idx}
+	}
+	return key, nil
+}
+
+// Returns the number of covered keys which come before the key, which must
+// itself be covered, otherwise ErrOutOfRange is returned. This is the inverse of
+// SelectKey, and like it walks the ranges in order until it passes the key.
+func (n *Node) Rank(key uint64) (uint64, error) {
+	if n == nil {
+		return 0, ErrEmptyStore{}
+	}
+	rank := uint64(0)
+	found := false
+	n.WalkInOrder(func(c *Node, depth int) bool {
+		if c.min > key {
+			return false
+		}
+		if key <= c.max {
+			rank, found = rank+(key-c.min), true
+			return false
+		}
+		rank += (c.max - c.min) + 1
+		return true
+	})
+	if !found {
+		return 0, ErrOutOfRange{key}
+	}
+	return rank, nil
+}
+
+// The number of keys covered before each range of a store, so that rank and
+// select take O(log n) instead of a walk of the tree. Like Aggregates, the index
+// lives beside the tree, and is a snapshot of the tree as it was when
+// NewRankIndex was called; build a new index after changing the tree.
+type RankIndex struct {
+	mins, maxs []uint64
+	// Covered keys before each range. These always fit, since the range itself
+	// covers at least one more key.
+	before []uint64
+	// Covered keys in total, unless every key is covered, when it wraps to 0
+	total uint64
+	full  bool
+}
+
+// Builds the rank index of the store, in a single walk
+func NewRankIndex(n *Node) (*RankIndex, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	count := n.Len()
+	ri := &RankIndex{
+		mins:   make([]uint64, 0, count),
+		maxs:   make([]uint64, 0, count),
+		before: make([]uint64, 0, count),
+	}
+	n.walk(func(c *Node) {
+		width := (c.max - c.min) + 1
+		// The ranges don't overlap, so overflowing means every key is covered
+		if width == 0 || ri.total+width < ri.total {
+			ri.full = true
+		}
+		ri.mins, ri.maxs, ri.before = append(ri.mins, c.min), append(ri.maxs, c.max), append(ri.before, ri.total)
+		ri.total += width
+	})
+	return ri, nil
+}
+
+// Same as (*Node).SelectKey
+func (ri *RankIndex) SelectKey(idx uint64) (uint64, error) {
+	i := sort.Search(len(ri.before), func(i int) bool { return ri.before[i] > idx }) - 1
+	if i < 0 || idx-ri.before[i] > ri.maxs[i]-ri.mins[i] {
+		return 0, ErrOutOfRange{idx}
+	}
+	return ri.mins[i] + (idx - ri.before[i]), nil
+}
+
+// Same as (*Node).Rank
+func (ri *RankIndex) Rank(key uint64) (uint64, error) {
+	i := sort.Search(len(ri.maxs), func(i int) bool { return ri.maxs[i] >= key })
+	if i == len(ri.maxs) || ri.mins[i] > key {
+		return 0, ErrOutOfRange{key}
+	}
+	return ri.before[i] + (key - ri.mins[i]), nil
+}
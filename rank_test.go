@@ -0,0 +1,118 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rank_test.go: Tests on rank and select
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNode_SelectKeyRank(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{30, 34, "B"})
+	items = append(items, DefaultRangedValue{100, 100, "C"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+
+	for idx, expected := range map[uint64]uint64{0: 10, 9: 19, 10: 30, 14: 34, 15: 100} {
+		key, err := n.SelectKey(idx)
+		if err != nil {
+			t.Fatalf("Got an error while selecting %d: %s", idx, err.Error())
+		}
+		if key != expected {
+			t.Fatalf("Wrong key for %d: %d [%d]", idx, key, expected)
+		}
+		rank, err := n.Rank(key)
+		if err != nil {
+			t.Fatalf("Got an error while ranking %d: %s", key, err.Error())
+		}
+		if rank != idx {
+			t.Fatalf("Wrong rank for %d: %d [%d]", key, rank, idx)
+		}
+	}
+
+	_, err := n.SelectKey(16)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+	for _, key := range []uint64{0, 20, 99, 101} {
+		_, err := n.Rank(key)
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+			t.Fatalf("Expecting an ErrOutOfRange for %d, but got something else", key)
+		}
+	}
+}
+
+func TestNode_SelectKeyRank_Unbounded(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{AtLeast(0, "all")})
+	if key, err := n.SelectKey(math.MaxUint64); err != nil || key != math.MaxUint64 {
+		t.Fatalf("Wrong key selected from the whole key space")
+	}
+	if rank, err := n.Rank(math.MaxUint64); err != nil || rank != math.MaxUint64 {
+		t.Fatalf("Wrong rank in the whole key space")
+	}
+}
+
+func TestRankIndex(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{30, 34, "B"})
+	items = append(items, DefaultRangedValue{100, 100, "C"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	ri, err := NewRankIndex(n)
+	if err != nil {
+		t.Fatalf("Error while building the rank index: %s", err.Error())
+	}
+
+	for idx := uint64(0); idx < 17; idx += 1 {
+		key, err := ri.SelectKey(idx)
+		expected, expectedErr := n.SelectKey(idx)
+		if key != expected || reflect.TypeOf(err) != reflect.TypeOf(expectedErr) {
+			t.Fatalf("Wrong selection for %d: %d, %v [%d, %v]", idx, key, err, expected, expectedErr)
+		}
+	}
+	for key := uint64(0); key < 110; key += 1 {
+		rank, err := ri.Rank(key)
+		expected, expectedErr := n.Rank(key)
+		if rank != expected || reflect.TypeOf(err) != reflect.TypeOf(expectedErr) {
+			t.Fatalf("Wrong rank for %d: %d, %v [%d, %v]", key, rank, err, expected, expectedErr)
+		}
+	}
+
+	full, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "A"}, AtLeast(10, "B")})
+	ri, _ = NewRankIndex(full)
+	if key, err := ri.SelectKey(math.MaxUint64); err != nil || key != math.MaxUint64 {
+		t.Fatalf("Wrong key selected from the whole key space")
+	}
+	if rank, err := ri.Rank(math.MaxUint64); err != nil || rank != math.MaxUint64 {
+		t.Fatalf("Wrong rank in the whole key space")
+	}
+
+	if _, err := NewRankIndex(nil); reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+		t.Fatalf("Expected ErrEmptyStore, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rebuild.go: Rebuilding stores whose shape degraded
+ */
+
+package rangestore
+
+// How many appends, as a fraction of the number of ranges, go by between checks
+// of the shape of a store built with WithRebuildThreshold. Each check builds a
+// balanced tree to compare against, so checking after every 1/16th keeps the
+// amortized cost of an append constant.
+const rebuildCheckDivisor = 16
+
+// Rebuilds the tree of a store once the expected cost of a lookup, as reported
+// by Stats, exceeds that of a freshly balanced tree by more than factor, e.g.
+// 1.25 for 25%. Appending ranges balances the new part of the tree by the number
+// of ranges rather than by their widths, so when the appended ranges vary in
+// width the shape drifts away from the one the construction would choose. The
+// shape is checked after every so many appends, and rebuilt in place as part of
+// the append which found it degraded. A factor of 0 or less never rebuilds.
+func WithRebuildThreshold(factor float64) Option {
+	return func(o *options) {
+		o.rebuildFactor = factor
+	}
+}
+
+// Checks the shape of the store after an append, if it's due, and rebuilds the
+// tree if the shape has degraded beyond the threshold. Reports whether it did.
+func (s *RangeStore) maybeRebuild() bool {
+	if s.opts.rebuildFactor <= 0 {
+		return false
+	}
+	s.appended += 1
+	if s.appended < s.count/rebuildCheckDivisor {
+		return false
+	}
+	s.appended = 0
+	// The ranges came from a valid store, so there's no need to check them again
+	balanced, _ := rangeStoreFromSortedChecked(nodeRanged(s.root), false, true)
+	if s.root.Stats().ExpectedCost <= s.opts.rebuildFactor*balanced.Stats().ExpectedCost {
+		return false
+	}
+	// Existing references to the root stay valid, as they do across appends
+	*s.root = *balanced
+	return true
+}
@@ -0,0 +1,71 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rebuild_test.go: Tests for rebuilding degraded stores
+ */
+
+package rangestore
+
+import (
+	"testing"
+)
+
+func TestRangeStore_RebuildThreshold(t *testing.T) {
+	build := func(opts ...Option) *RangeStore {
+		s, err := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, 0}}, opts...)
+		if err != nil {
+			t.Fatalf("Error while building store: %s", err.Error())
+		}
+		// Every tenth range is far wider than the others, which appending
+		// doesn't take into account
+		min := uint64(10)
+		for i := 1; i < 2000; i += 1 {
+			width := uint64(10)
+			if i%10 == 0 {
+				width = 100000
+			}
+			if err := s.Append(DefaultRangedValue{min, min + width - 1, i}); err != nil {
+				t.Fatalf("Error while appending: %s", err.Error())
+			}
+			min += width
+		}
+		return s
+	}
+	plain, rebuilt := build(), build(WithRebuildThreshold(1.2))
+	balanced, _ := rangeStoreFromSortedChecked(nodeRanged(plain.Root()), false, true)
+
+	plainCost, rebuiltCost, balancedCost := plain.Root().Stats().ExpectedCost, rebuilt.Root().Stats().ExpectedCost, balanced.Stats().ExpectedCost
+	if plainCost <= 1.2*balancedCost {
+		t.Fatalf("Expected appending alone to degrade the shape: %f against %f", plainCost, balancedCost)
+	}
+	if rebuiltCost >= plainCost || rebuiltCost > 1.5*balancedCost {
+		t.Fatalf("Expected the rebuilt store to stay close to balanced: %f against %f", rebuiltCost, balancedCost)
+	}
+	if err := rebuilt.CheckInvariants(); err != nil {
+		t.Fatalf("Invalid store after rebuilding: %s", err.Error())
+	}
+	for _, e := range plain.Ranges() {
+		if v, err := rebuilt.RangeSearch(e.Min); err != nil || v != e.Value {
+			t.Fatalf("Wrong value for %d after rebuilding: %v", e.Min, v)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * registry.go: Named collections of stores
+ */
+
+package rangestore
+
+import (
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+type ErrUnknownStore struct {
+	name string
+}
+
+func (ex ErrUnknownStore) Error() string {
+	return fmt.Sprintf("No store is registered as %s", ex.name)
+}
+
+// Returns the name which was looked up
+func (ex ErrUnknownStore) Name() string {
+	return ex.name
+}
+
+func (ex ErrUnknownStore) Details() ErrorDetails {
+	return ErrorDetails{"ErrUnknownStore", map[string]interface{}{"name": ex.name}}
+}
+
+type ErrStoreRegistered struct {
+	name string
+}
+
+func (ex ErrStoreRegistered) Error() string {
+	return fmt.Sprintf("A store is already registered as %s", ex.name)
+}
+
+// Returns the name which is already taken
+func (ex ErrStoreRegistered) Name() string {
+	return ex.name
+}
+
+func (ex ErrStoreRegistered) Details() ErrorDetails {
+	return ErrorDetails{"ErrStoreRegistered", map[string]interface{}{"name": ex.name}}
+}
+
+// Holds stores by name, e.g. one per tenant or table, and counts the lookups
+// made against each of them. Each store can be swapped for another on its own,
+// with lookups seeing either the old store or the new one, as with
+// SwappableStore.
+//
+// Registry is safe for concurrent use. Lookups never block; registering and
+// unregistering copy the set of names, so are meant to be rare.
+type Registry struct {
+	mu sync.Mutex
+	// The map[string]*registryEntry of the registered stores, replaced whole
+	// whenever a name is added or removed
+	entries atomic.Value
+}
+
+type registryEntry struct {
+	lookups uint64
+	misses  uint64
+	store   atomic.Value
+}
+
+// Wraps the stores held by an entry, since atomic.Value needs every value it
+// holds to have the same type
+type registeredStore struct {
+	s RangeSearcher
+}
+
+// A registered store, along with the lookups made against it through the registry
+type RegistryEntry struct {
+	Name    string
+	Store   RangeSearcher
+	Lookups uint64
+	Misses  uint64
+}
+
+// Creates an empty registry
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.entries.Store(map[string]*registryEntry{})
+	return r
+}
+
+func (r *Registry) load() map[string]*registryEntry {
+	return r.entries.Load().(map[string]*registryEntry)
+}
+
+// Adds the store under name. Returns ErrStoreRegistered if the name is taken;
+// use Swap to replace a registered store.
+func (r *Registry) Register(name string, s RangeSearcher) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.load()
+	if _, ok := old[name]; ok {
+		return ErrStoreRegistered{name}
+	}
+	e := &registryEntry{}
+	e.store.Store(registeredStore{s})
+	entries := make(map[string]*registryEntry, len(old)+1)
+	for k, v := range old {
+		entries[k] = v
+	}
+	entries[name] = e
+	r.entries.Store(entries)
+	return nil
+}
+
+// Removes the store registered under name, returning it, or ErrUnknownStore if
+// there is none
+func (r *Registry) Unregister(name string) (RangeSearcher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.load()
+	e, ok := old[name]
+	if !ok {
+		return nil, ErrUnknownStore{name}
+	}
+	entries := make(map[string]*registryEntry, len(old))
+	for k, v := range old {
+		if k != name {
+			entries[k] = v
+		}
+	}
+	r.entries.Store(entries)
+	return e.store.Load().(registeredStore).s, nil
+}
+
+// Replaces the store registered under name, returning the previous one, or
+// ErrUnknownStore if there is none. The lookup counters carry over. Lookups
+// already in progress finish against the previous store.
+func (r *Registry) Swap(name string, s RangeSearcher) (RangeSearcher, error) {
+	e, ok := r.load()[name]
+	if !ok {
+		return nil, ErrUnknownStore{name}
+	}
+	old := e.store.Load().(registeredStore)
+	e.store.Store(registeredStore{s})
+	return old.s, nil
+}
+
+// Returns the store registered under name
+func (r *Registry) Get(name string) (RangeSearcher, bool) {
+	e, ok := r.load()[name]
+	if !ok {
+		return nil, false
+	}
+	return e.store.Load().(registeredStore).s, true
+}
+
+// Searches the store registered under name for the key, counting the lookup.
+// Returns ErrUnknownStore if no store is registered under name.
+func (r *Registry) Lookup(name string, key uint64) (interface{}, error) {
+	e, ok := r.load()[name]
+	if !ok {
+		return nil, ErrUnknownStore{name}
+	}
+	v, err := e.store.Load().(registeredStore).s.RangeSearch(key)
+	atomic.AddUint64(&e.lookups, 1)
+	if err != nil {
+		atomic.AddUint64(&e.misses, 1)
+	}
+	return v, err
+}
+
+// Returns the names of the registered stores, in ascending order
+func (r *Registry) Names() []string {
+	return sortedNames(r.load())
+}
+
+func sortedNames(entries map[string]*registryEntry) []string {
+	ret := make([]string, 0, len(entries))
+	for name := range entries {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Returns the registered stores and their lookup counters, in ascending order
+// of their names
+func (r *Registry) Entries() []RegistryEntry {
+	entries := r.load()
+	ret := make([]RegistryEntry, 0, len(entries))
+	for _, name := range sortedNames(entries) {
+		e := entries[name]
+		ret = append(ret, RegistryEntry{
+			Name:    name,
+			Store:   e.store.Load().(registeredStore).s,
+			Lookups: atomic.LoadUint64(&e.lookups),
+			Misses:  atomic.LoadUint64(&e.misses),
+		})
+	}
+	return ret
+}
+
+// Publishes the registered stores as a single expvar variable named name, an
+// object with a member per store holding its lookup counters, and the number of
+// ranges of stores which have a Len method:
+//
+//	{"tenant-a": {"lookups": 10, "misses": 1, "ranges": 4}, ...}
+//
+// The variable is read whenever expvar is served, so it follows registrations
+// and swaps. Like expvar.Publish, this panics if the name is already taken.
+func (r *Registry) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		ret := make(map[string]map[string]interface{})
+		for _, e := range r.Entries() {
+			vars := map[string]interface{}{"lookups": e.Lookups, "misses": e.Misses}
+			if l, ok := e.Store.(interface{ Len() int }); ok {
+				vars["ranges"] = l.Len()
+			}
+			ret[e.Name] = vars
+		}
+		return ret
+	}))
+}
@@ -0,0 +1,94 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * registry_test.go: Tests for store registries
+ */
+
+package rangestore
+
+import (
+	"expvar"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	a, _ := NewBuilder().Add(0, 9, "A").Add(10, 19, "B").Build()
+	b, _ := NewBuilder().Add(0, 99, "X").Build()
+	r := NewRegistry()
+	if err := r.Register("tenant-b", b); err != nil {
+		t.Fatalf("Error while registering: %s", err.Error())
+	}
+	if err := r.Register("tenant-a", a); err != nil {
+		t.Fatalf("Error while registering: %s", err.Error())
+	}
+	if err := r.Register("tenant-a", b); err == nil || reflect.TypeOf(err).Name() != "ErrStoreRegistered" {
+		t.Fatalf("Expected ErrStoreRegistered, got %v", err)
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"tenant-a", "tenant-b"}) {
+		t.Fatalf("Wrong names: %v", names)
+	}
+
+	if v, err := r.Lookup("tenant-a", 15); err != nil || v != "B" {
+		t.Fatalf("Wrong value from tenant-a: %v", v)
+	}
+	if _, err := r.Lookup("tenant-a", 25); err == nil {
+		t.Fatalf("Expected a miss from tenant-a")
+	}
+	if v, err := r.Lookup("tenant-b", 15); err != nil || v != "X" {
+		t.Fatalf("Wrong value from tenant-b: %v", v)
+	}
+	if _, err := r.Lookup("tenant-c", 15); err == nil || reflect.TypeOf(err).Name() != "ErrUnknownStore" {
+		t.Fatalf("Expected ErrUnknownStore, got %v", err)
+	}
+
+	// Swapping keeps the counters
+	old, err := r.Swap("tenant-a", b)
+	if err != nil || old != a {
+		t.Fatalf("Expected the previous store back from Swap")
+	}
+	if v, _ := r.Lookup("tenant-a", 25); v != "X" {
+		t.Fatalf("Wrong value after swapping: %v", v)
+	}
+	entries := r.Entries()
+	if len(entries) != 2 || entries[0].Name != "tenant-a" || entries[0].Store != b || entries[0].Lookups != 3 || entries[0].Misses != 1 {
+		t.Fatalf("Wrong entries: %+v", entries)
+	}
+	if _, err := r.Swap("tenant-c", b); err == nil || reflect.TypeOf(err).Name() != "ErrUnknownStore" {
+		t.Fatalf("Expected ErrUnknownStore, got %v", err)
+	}
+
+	r.PublishExpvar("rangestore_registry_test")
+	if got := expvar.Get("rangestore_registry_test").String(); got != `{"tenant-a":{"lookups":3,"misses":1,"ranges":1},"tenant-b":{"lookups":1,"misses":0,"ranges":1}}` {
+		t.Fatalf("Wrong expvar: %s", got)
+	}
+
+	if s, err := r.Unregister("tenant-b"); err != nil || s != b {
+		t.Fatalf("Expected the store back from Unregister")
+	}
+	if _, ok := r.Get("tenant-b"); ok {
+		t.Fatalf("Expected tenant-b to be gone")
+	}
+	if _, err := r.Unregister("tenant-b"); err == nil || reflect.TypeOf(err).Name() != "ErrUnknownStore" {
+		t.Fatalf("Expected ErrUnknownStore, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * replicated.go: Per shard copies of a store
+ */
+
+package rangestore
+
+// Holds several identical copies of a store, so that each pool of workers can
+// search its own copy and keep its pointer chasing local, rather than every
+// core on every socket contending for the cache lines of a single tree. Each
+// copy is allocated as one contiguous arena, see WithArena; to place a copy on
+// a particular NUMA node, pin the workers using it to that node, since memory is
+// typically placed where it's first touched.
+type ReplicatedStore struct {
+	replicas []*RangeStore
+}
+
+// Builds replicas copies of the store from the items, with the same options as
+// NewRangeStore. At least one replica is always built.
+func NewReplicatedStore(items []Ranged, replicas int, opts ...Option) (*ReplicatedStore, error) {
+	if replicas < 1 {
+		replicas = 1
+	}
+	opts = append([]Option{WithArena()}, opts...)
+	s := &ReplicatedStore{replicas: make([]*RangeStore, 0, replicas)}
+	for i := 0; i < replicas; i += 1 {
+		r, err := NewRangeStore(items, opts...)
+		if err != nil {
+			return nil, err
+		}
+		s.replicas = append(s.replicas, r)
+	}
+	return s, nil
+}
+
+// Searches the copy belonging to shard. Shards beyond the number of replicas
+// wrap around, so any stable worker identifier can be used as the shard.
+func (s *ReplicatedStore) SearchShard(shard int, val uint64) (interface{}, error) {
+	return s.Shard(shard).RangeSearch(val)
+}
+
+// Returns the copy belonging to shard, wrapping around as SearchShard does
+func (s *ReplicatedStore) Shard(shard int) *RangeStore {
+	if shard < 0 {
+		shard = -shard
+	}
+	return s.replicas[shard%len(s.replicas)]
+}
+
+// Searches the first copy, for callers without a shard of their own
+func (s *ReplicatedStore) RangeSearch(val uint64) (interface{}, error) {
+	return s.replicas[0].RangeSearch(val)
+}
+
+// Returns the number of copies
+func (s *ReplicatedStore) Replicas() int {
+	return len(s.replicas)
+}
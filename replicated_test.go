@@ -0,0 +1,81 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * replicated_test.go: Tests on replicated stores
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestReplicatedStore_SearchShard(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	s, err := NewReplicatedStore(items, 4)
+	if err != nil {
+		t.Fatalf("Error while building replicated store: %s", err.Error())
+	}
+	if s.Replicas() != 4 {
+		t.Fatalf("Wrong number of replicas: %d", s.Replicas())
+	}
+	if s.Shard(0).Root() == s.Shard(1).Root() {
+		t.Fatalf("Expected the replicas not to share a tree")
+	}
+	if s.Shard(1) != s.Shard(5) || s.Shard(-1) != s.Shard(1) {
+		t.Fatalf("Expected shards to wrap around")
+	}
+
+	var wg sync.WaitGroup
+	for shard := 0; shard < 8; shard += 1 {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			for key := uint64(0); key < 20; key += 1 {
+				v, err := s.SearchShard(shard, key)
+				if err != nil || v != []string{"A", "B"}[key/10] {
+					t.Errorf("Wrong value for %d on shard %d: %v", key, shard, v)
+				}
+			}
+		}(shard)
+	}
+	wg.Wait()
+	if v, err := s.RangeSearch(15); err != nil || v != "B" {
+		t.Fatalf("Wrong value: %v", v)
+	}
+}
+
+func TestReplicatedStore_Error(t *testing.T) {
+	items := []Ranged{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{20, 29, "B"}}
+	_, err := NewReplicatedStore(items, 2)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrDiscontinuity{}).Name() {
+		t.Fatalf("Expecting an ErrDiscontinuity, but got something else")
+	}
+	s, err := NewReplicatedStore(items, 0, AllowGaps())
+	if err != nil || s.Replicas() != 1 {
+		t.Fatalf("Expected a single replica")
+	}
+}
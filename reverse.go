@@ -0,0 +1,100 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * reverse.go: Reverse lookups from values to ranges
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type ErrUnhashableValue struct {
+	v interface{}
+}
+
+func (ex ErrUnhashableValue) Error() string {
+	return fmt.Sprintf("Value of type %T can't be used as an index key", ex.v)
+}
+
+// Returns the value which can't be used as a map key
+func (ex ErrUnhashableValue) Value() interface{} {
+	return ex.v
+}
+
+func (ex ErrUnhashableValue) Details() ErrorDetails {
+	return ErrorDetails{"ErrUnhashableValue", map[string]interface{}{"value": ex.v}}
+}
+
+// Returns all of the ranges which map to v, in ascending key order. Values are
+// compared using eq, or reflect.DeepEqual if it is nil. This walks the whole
+// store, so for repeated reverse queries build a ReverseIndex instead.
+func (n *Node) ReverseSearch(v interface{}, eq func(a, b interface{}) bool) []RangeEntry {
+	if eq == nil {
+		eq = reflect.DeepEqual
+	}
+	ret := make([]RangeEntry, 0)
+	n.walk(func(c *Node) {
+		if eq(c.value, v) {
+			ret = append(ret, RangeEntry{c.min, c.max, c.value})
+		}
+	})
+	return ret
+}
+
+// A precomputed index from values to the ranges which map to them. The index
+// is a snapshot, and doesn't follow later changes to the store.
+type ReverseIndex struct {
+	ranges map[interface{}][]RangeEntry
+}
+
+// Builds a reverse index over all of the values in the store. Values are used
+// as map keys, so they must all be comparable; otherwise ErrUnhashableValue is
+// returned.
+func NewReverseIndex(n *Node) (*ReverseIndex, error) {
+	idx := &ReverseIndex{ranges: make(map[interface{}][]RangeEntry)}
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		if c.value != nil && !reflect.TypeOf(c.value).Comparable() {
+			err = ErrUnhashableValue{c.value}
+			return
+		}
+		idx.ranges[c.value] = append(idx.ranges[c.value], RangeEntry{c.min, c.max, c.value})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Returns all of the ranges which map to v, in ascending key order
+func (idx *ReverseIndex) ReverseSearch(v interface{}) []RangeEntry {
+	if v != nil && !reflect.TypeOf(v).Comparable() {
+		return nil
+	}
+	return idx.ranges[v]
+}
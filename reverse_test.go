@@ -0,0 +1,89 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * reverse_test.go: Tests on reverse lookups
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func reverseStore() *Node {
+	n, _ := NewRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 9, "US"},
+		DefaultRangedValue{10, 19, "CA"},
+		DefaultRangedValue{20, 29, "US"},
+		DefaultRangedValue{30, 39, "MX"},
+	})
+	return n
+}
+
+func TestNode_ReverseSearch(t *testing.T) {
+	n := reverseStore()
+
+	expected := []RangeEntry{{0, 9, "US"}, {20, 29, "US"}}
+	if got := n.ReverseSearch("US", nil); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	if got := n.ReverseSearch("FR", nil); len(got) != 0 {
+		t.Fatalf("Expected no ranges, got %v", got)
+	}
+	prefix := func(a, b interface{}) bool {
+		return a.(string)[0] == b.(string)[0]
+	}
+	if got := n.ReverseSearch("Mexico", prefix); !reflect.DeepEqual(got, []RangeEntry{{30, 39, "MX"}}) {
+		t.Fatalf("Expected the value comparison function to be used, got %v", got)
+	}
+}
+
+func TestReverseIndex(t *testing.T) {
+	idx, err := NewReverseIndex(reverseStore())
+	if err != nil {
+		t.Fatalf("Error while building index: %s", err.Error())
+	}
+
+	expected := []RangeEntry{{0, 9, "US"}, {20, 29, "US"}}
+	if got := idx.ReverseSearch("US"); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	if got := idx.ReverseSearch([]int{1}); len(got) != 0 {
+		t.Fatalf("Expected no ranges for an unhashable value, got %v", got)
+	}
+}
+
+func TestReverseIndex_Unhashable(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, []int{1}}})
+
+	_, err := NewReverseIndex(n)
+	if err == nil {
+		t.Fatalf("Expected an error while indexing unhashable values, got nothing")
+	}
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrUnhashableValue{}).Name() {
+		t.Fatalf("Expecting an ErrUnhashableValue, but got something else")
+	}
+	if msg := err.Error(); msg != "Value of type []int can't be used as an index key" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+}
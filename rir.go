@@ -0,0 +1,127 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rir.go: Importing RIR delegation statistics
+ */
+
+package rangestore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// The delegation of an address block, as recorded in RIR statistics files
+type Delegation struct {
+	// The registry which made the delegation, e.g. "ripencc"
+	Registry string
+	// The ISO 3166 code of the holder's country, or "ZZ" or empty where the
+	// block isn't delegated
+	Country string
+	// e.g. "allocated", "assigned", "available" or "reserved"
+	Status string
+	// Identifies the holder, only present in the extended format
+	OpaqueID string
+}
+
+// Builds a store mapping IP addresses to their Delegation from RIR statistics
+// files, in the delegated or delegated-extended formats published by the RIRs
+// and the NRO, e.g. delegated-ripencc-extended-latest. Pass the files of every
+// registry to cover the whole address space. The version and summary lines are
+// skipped, as are AS number records. Blocks of every status are kept, so
+// unallocated space can be told apart from space missing from the files.
+// Returns an ErrMalformedRecord for the first line which can't be parsed, with
+// its line number within its file.
+func LoadRIRDelegations(files ...io.Reader) (*GeoIPStore, error) {
+	v4 := make([]Ranged, 0)
+	v6 := make([]BigRangeEntry, 0)
+	for _, f := range files {
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line += 1 {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || text[0] == '#' {
+				continue
+			}
+			if err := parseDelegation(strings.Split(text, "|"), &v4, &v6); err != nil {
+				return nil, ErrMalformedRecord{line, err.Error()}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return newGeoIPStore(v4, v6)
+}
+
+// Parses a record of the form registry|cc|type|start|value|date|status, with
+// an opaque id and extensions after it in the extended format
+func parseDelegation(fields []string, v4 *[]Ranged, v6 *[]BigRangeEntry) error {
+	// The version line starts with the format version rather than a registry
+	if _, err := strconv.ParseFloat(fields[0], 64); err == nil {
+		return nil
+	}
+	if len(fields) >= 6 && fields[5] == "summary" {
+		return nil
+	}
+	if len(fields) < 7 {
+		return fmt.Errorf("expected at least 7 fields, got %d", len(fields))
+	}
+	d := Delegation{Registry: fields[0], Country: fields[1], Status: fields[6]}
+	if len(fields) > 7 {
+		d.OpaqueID = fields[7]
+	}
+
+	switch fields[2] {
+	case "ipv4":
+		ip := net.ParseIP(fields[3]).To4()
+		if ip == nil {
+			return fmt.Errorf("bad IPv4 address %q", fields[3])
+		}
+		min := uint64(ip[0])<<24 | uint64(ip[1])<<16 | uint64(ip[2])<<8 | uint64(ip[3])
+		count, err := strconv.ParseUint(fields[4], 10, 64)
+		// Blocks needn't be a power of two in size, but must stay within the
+		// address space
+		if err != nil || count == 0 || count > 1<<32-min {
+			return fmt.Errorf("bad address count %q", fields[4])
+		}
+		*v4 = append(*v4, RangeEntry{min, min + count - 1, d})
+	case "ipv6":
+		ip := net.ParseIP(fields[3])
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("bad IPv6 address %q", fields[3])
+		}
+		prefix, err := strconv.ParseUint(fields[4], 10, 8)
+		if err != nil || prefix > 128 {
+			return fmt.Errorf("bad prefix length %q", fields[4])
+		}
+		min := new(big.Int).SetBytes(ip.To16())
+		max := new(big.Int).Lsh(big.NewInt(1), uint(128-prefix))
+		max.Add(max, min).Sub(max, big.NewInt(1))
+		*v6 = append(*v6, BigRangeEntry{min, max, d})
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * rir_test.go: Tests on the RIR delegation importer
+ */
+
+package rangestore
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ripeDelegations = `2|ripencc|1697583599|4|19830705|20231017|+0100
+ripencc|*|ipv4|*|3|summary
+ripencc|*|asn|*|1|summary
+ripencc|EU|asn|7|1|19930901|allocated|8c1e6a95-3d33-4a9c-9a37-5b5c9dd6f1fc
+ripencc|NL|ipv4|2.56.8.0|512|20190905|allocated|a1b2
+ripencc|NL|ipv4|2.56.10.0|768|20190905|allocated|a1b2
+ripencc|ZZ|ipv4|2.56.13.0|256||available|
+ripencc|DE|ipv6|2001:67c::|32|20030409|allocated|c3d4
+`
+
+const apnicDelegations = `# APNIC statistics
+2.3|apnic|20231017|2|19830613|20231016|+1000
+apnic|JP|ipv4|1.0.16.0|4096|20110412|allocated
+apnic|AU|ipv6|2001:200::|35|19990813|allocated
+`
+
+func TestLoadRIRDelegations(t *testing.T) {
+	s, err := LoadRIRDelegations(strings.NewReader(ripeDelegations), strings.NewReader(apnicDelegations))
+	if err != nil {
+		t.Fatalf("Error while loading delegations: %s", err.Error())
+	}
+	nl := Delegation{"ripencc", "NL", "allocated", "a1b2"}
+	for addr, expected := range map[string]Delegation{
+		"2.56.8.0":     nl,
+		"2.56.12.255":  nl,
+		"2.56.13.1":    {"ripencc", "ZZ", "available", ""},
+		"1.0.31.255":   {"apnic", "JP", "allocated", ""},
+		"2001:67c:1::": {"ripencc", "DE", "allocated", "c3d4"},
+		"2001:200::1":  {"apnic", "AU", "allocated", ""},
+	} {
+		v, err := s.Lookup(net.ParseIP(addr))
+		if err != nil || v != expected {
+			t.Fatalf("Wrong value for %s: %v, %v", addr, v, err)
+		}
+	}
+	if _, err := s.Lookup(net.ParseIP("2001:2000::")); reflect.TypeOf(err).Name() != "ErrBigOutOfRange" {
+		t.Fatalf("Expected an ErrBigOutOfRange, got %v", err)
+	}
+	// The two adjacent blocks of the same holder are merged
+	if s.V4().Len() != 3 {
+		t.Fatalf("Wrong ranges: %v", s.V4().Ranges())
+	}
+}
+
+func TestLoadRIRDelegations_Malformed(t *testing.T) {
+	for _, input := range []string{
+		"arin|US|ipv4|10.0.0.0\n",
+		"arin|US|ipv4|10.0.0|256|20200101|allocated\n",
+		"arin|US|ipv4|255.255.255.0|257|20200101|allocated\n",
+		"arin|US|ipv6|2001::|129|20200101|allocated\n",
+	} {
+		_, err := LoadRIRDelegations(strings.NewReader(input))
+		if reflect.TypeOf(err).Name() != "ErrMalformedRecord" {
+			t.Fatalf("Expected an ErrMalformedRecord for %q, got %v", input, err)
+		}
+	}
+	_, err := LoadRIRDelegations(strings.NewReader("arin|US|ipv4|10.0.0.0|512|20200101|allocated\narin|US|ipv4|10.0.1.0|256|20200101|assigned\n"))
+	if reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected an ErrOverlap, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * sample.go: Random samples of covered keys
+ */
+
+package rangestore
+
+import (
+	"sort"
+)
+
+// Returns n distinct keys picked uniformly at random from the keys covered by
+// the store, in ascending order, e.g. to probe a sparse store with keys which are
+// known to be covered. If the store covers n keys or fewer, all of them are
+// returned. The nodes don't record the size of their subtrees, so this builds a
+// RankIndex first, in a single walk; build one with NewRankIndex to sample many
+// times.
+func (n *Node) SampleKeys(r RandSource, count int) []uint64 {
+	ri, err := NewRankIndex(n)
+	if err != nil {
+		return make([]uint64, 0)
+	}
+	return ri.SampleKeys(r, count)
+}
+
+// Same as (*Node).SampleKeys, with each key picked in O(log n)
+func (ri *RankIndex) SampleKeys(r RandSource, count int) []uint64 {
+	ret := make([]uint64, 0)
+	if count <= 0 {
+		return ret
+	}
+
+	// Picks the ranks of the keys in ascending order, then the keys with them
+	var ranks []uint64
+	switch {
+	case ri.full:
+		// Every key is its own rank, and collisions are all but impossible
+		seen := make(map[uint64]bool, count)
+		for len(ranks) < count {
+			if k := r.Uint64(); !seen[k] {
+				seen[k] = true
+				ranks = append(ranks, k)
+			}
+		}
+		ret = append(ret, ranks...)
+		sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+		return ret
+	case uint64(count) >= ri.total:
+		for i := uint64(0); i < ri.total; i += 1 {
+			ranks = append(ranks, i)
+		}
+	default:
+		// Floyd's algorithm, picking count distinct ranks below total
+		seen := make(map[uint64]bool, count)
+		for j := ri.total - uint64(count); j < ri.total; j += 1 {
+			k := uniform(r, j+1)
+			if seen[k] {
+				k = j
+			}
+			seen[k] = true
+			ranks = append(ranks, k)
+		}
+		sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+	}
+
+	for _, rank := range ranks {
+		// Every rank is below the total, so it's always found
+		key, _ := ri.SelectKey(rank)
+		ret = append(ret, key)
+	}
+	return ret
+}
@@ -0,0 +1,102 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * sample_test.go: Tests for sampling covered keys
+ */
+
+package rangestore
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNode_SampleKeys(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{30, 34, "B"})
+	items = append(items, DefaultRangedValue{100, 100, "C"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	r := rand.New(rand.NewSource(1))
+
+	if keys := n.SampleKeys(r, 0); len(keys) != 0 {
+		t.Fatalf("Expected no keys, got %v", keys)
+	}
+	all := n.SampleKeys(r, 100)
+	if len(all) != 16 || all[0] != 10 || all[10] != 30 || all[15] != 100 {
+		t.Fatalf("Wrong keys when sampling more than are covered: %v", all)
+	}
+
+	// Each of the 16 keys should turn up about as often as the others
+	counts := make(map[uint64]int)
+	for i := 0; i < 8000; i += 1 {
+		keys := n.SampleKeys(r, 4)
+		if len(keys) != 4 {
+			t.Fatalf("Wrong number of keys: %v", keys)
+		}
+		for j, key := range keys {
+			if _, err := n.RangeSearch(key); err != nil {
+				t.Fatalf("Sampled a key which isn't covered: %d", key)
+			}
+			if j > 0 && keys[j-1] >= key {
+				t.Fatalf("Keys aren't distinct and ascending: %v", keys)
+			}
+			counts[key] += 1
+		}
+	}
+	for key, count := range counts {
+		if count < 1800 || count > 2200 {
+			t.Fatalf("Uneven samples for %d: %d [2000]", key, count)
+		}
+	}
+
+	full, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, math.MaxUint64, "A"}})
+	if keys := full.SampleKeys(r, 3); len(keys) != 3 || !(keys[0] < keys[1] && keys[1] < keys[2]) {
+		t.Fatalf("Wrong keys sampled from the entire key space: %v", keys)
+	}
+	if !reflect.DeepEqual(n.SampleKeys(rand.New(rand.NewSource(2)), 5), n.SampleKeys(rand.New(rand.NewSource(2)), 5)) {
+		t.Fatalf("Expected the same samples from the same seed")
+	}
+}
+
+func TestRankIndex_SampleKeys(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{30, 34, "B"})
+	items = append(items, DefaultRangedValue{100, 100, "C"})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	ri, _ := NewRankIndex(n)
+
+	// The index picks the same keys as the store from the same seed
+	for _, count := range []int{0, 1, 5, 16, 100} {
+		got := ri.SampleKeys(rand.New(rand.NewSource(3)), count)
+		expected := n.SampleKeys(rand.New(rand.NewSource(3)), count)
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Wrong samples of %d keys: %v [%v]", count, got, expected)
+		}
+	}
+	if keys := (*Node)(nil).SampleKeys(rand.New(rand.NewSource(3)), 5); len(keys) != 0 {
+		t.Fatalf("Expected no keys from an empty store, got %v", keys)
+	}
+}
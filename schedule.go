@@ -0,0 +1,127 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * schedule.go: Stores keyed by time
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// An entry of a schedule, covering the times from Start up to, but not
+// including, End
+type ScheduleEntry struct {
+	Start, End time.Time
+	Value      interface{}
+}
+
+// Returned by NextTransition when nothing changes after the given time
+type ErrNoTransition struct {
+	t time.Time
+}
+
+func (ex ErrNoTransition) Error() string {
+	return fmt.Sprintf("Nothing changes after %s", ex.t.Format(time.RFC3339Nano))
+}
+
+// Returns the time the search started from
+func (ex ErrNoTransition) Time() time.Time {
+	return ex.t
+}
+
+func (ex ErrNoTransition) Details() ErrorDetails {
+	return ErrorDetails{"ErrNoTransition", map[string]interface{}{"time": ex.t}}
+}
+
+// A store keyed by time, such as a schedule of tariffs or maintenance windows.
+// Times are kept at nanosecond precision, so must lie within the years 1678 to
+// 2262, as for time.Time.UnixNano.
+type Schedule struct {
+	store *Node
+}
+
+// Maps a time onto the key space, keeping the order of times before 1970
+func timeKey(t time.Time) uint64 {
+	return uint64(t.UnixNano()) ^ (1 << 63)
+}
+
+func keyTime(k uint64) time.Time {
+	return time.Unix(0, int64(k^(1<<63)))
+}
+
+// Builds a schedule from the entries, in any order. Times which no entry covers
+// are left uncovered. Returns ErrInvalidInput if an entry doesn't end after it
+// starts, or if entries overlap, and ErrEmptyInput if there are none.
+func NewSchedule(entries []ScheduleEntry) (*Schedule, error) {
+	sorted := append([]ScheduleEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	items := make([]Ranged, 0, len(sorted))
+	for idx, e := range sorted {
+		if !e.End.After(e.Start) {
+			return nil, ErrInvalidInput{fmt.Sprintf("entry starting at %s doesn't end after it", e.Start.Format(time.RFC3339Nano))}
+		}
+		if idx > 0 && e.Start.Before(sorted[idx-1].End) {
+			return nil, ErrInvalidInput{fmt.Sprintf("entries starting at %s and %s overlap", sorted[idx-1].Start.Format(time.RFC3339Nano), e.Start.Format(time.RFC3339Nano))}
+		}
+		items = append(items, RangeEntry{timeKey(e.Start), timeKey(e.End) - 1, e.Value})
+	}
+	store, err := rangeStoreFromSortedChecked(items, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Schedule{store}, nil
+}
+
+// Returns the value of the entry covering t, or ErrOutOfRange if there is none
+func (s *Schedule) SearchTime(t time.Time) (interface{}, error) {
+	return s.store.RangeSearch(timeKey(t))
+}
+
+// Returns when the value at t next changes, and the value from then on, so that
+// a timer can be set for it rather than polling SearchTime. If an entry covers
+// t, that's when it ends, and the value is that of the entry starting right
+// then, or nil if there is none. Otherwise it's when the next entry starts.
+// Returns ErrNoTransition if t is after every entry.
+func (s *Schedule) NextTransition(t time.Time) (time.Time, interface{}, error) {
+	e, dist, err := s.store.SearchCeiling(timeKey(t))
+	if err != nil {
+		return time.Time{}, nil, ErrNoTransition{t}
+	}
+	if dist > 0 {
+		return keyTime(e.Min), e.Value, nil
+	}
+	// Entries end at times which can be represented, so this doesn't overflow
+	next, _ := s.store.RangeSearch(e.Max + 1)
+	return keyTime(e.Max + 1), next, nil
+}
+
+// Returns the underlying store, keyed by time.Time.UnixNano with the sign bit
+// flipped
+func (s *Schedule) Root() *Node {
+	return s.store
+}
@@ -0,0 +1,93 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * schedule_test.go: Tests for stores keyed by time
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2017, 6, 1, h, 0, 0, 0, time.UTC)
+	}
+	s, err := NewSchedule([]ScheduleEntry{
+		{at(17), at(22), "peak"},
+		{at(0), at(7), "night"},
+		{at(7), at(17), "day"},
+	})
+	if err != nil {
+		t.Fatalf("Error while building schedule: %s", err.Error())
+	}
+	if v, err := s.SearchTime(at(12)); err != nil || v != "day" {
+		t.Fatalf("Wrong value at noon: %v", v)
+	}
+	if _, err := s.SearchTime(at(23)); err == nil {
+		t.Fatalf("Expected nothing to be scheduled at 23:00")
+	}
+	// Times before 1970 keep their order
+	if _, err := s.SearchTime(time.Date(1969, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Fatalf("Expected nothing to be scheduled in 1969")
+	}
+
+	for _, c := range []struct {
+		t     time.Time
+		when  time.Time
+		value interface{}
+	}{
+		{at(0), at(7), "day"},
+		{at(6).Add(59 * time.Minute), at(7), "day"},
+		{at(20), at(22), nil},
+		{at(0).Add(-time.Hour), at(0), "night"},
+		{time.Date(1969, 1, 1, 0, 0, 0, 0, time.UTC), at(0), "night"},
+	} {
+		when, v, err := s.NextTransition(c.t)
+		if err != nil || !when.Equal(c.when) || v != c.value {
+			t.Fatalf("Wrong transition after %s: %s, %v, %v", c.t, when, v, err)
+		}
+	}
+	if _, _, err := s.NextTransition(at(22)); err == nil || reflect.TypeOf(err).Name() != "ErrNoTransition" {
+		t.Fatalf("Expected ErrNoTransition, got %v", err)
+	}
+}
+
+func TestNewSchedule_Invalid(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2017, 6, 1, h, 0, 0, 0, time.UTC)
+	}
+	for _, entries := range [][]ScheduleEntry{
+		{{at(7), at(7), "empty"}},
+		{{at(0), at(8), "night"}, {at(7), at(17), "day"}},
+	} {
+		if _, err := NewSchedule(entries); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidInput" {
+			t.Fatalf("Expected ErrInvalidInput for %v, got %v", entries, err)
+		}
+	}
+	if _, err := NewSchedule(nil); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected ErrEmptyInput, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * segments.go: Reading across segments mapped by a store
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A part of a virtual file, covering Length bytes from Offset, which are read
+// from Reader starting at ReaderOffset, e.g. a member of an archive or an
+// extent of a sparse file
+type Segment struct {
+	Offset       int64
+	Length       int64
+	Reader       io.ReaderAt
+	ReaderOffset int64
+}
+
+// Reads a virtual file assembled from segments, looking up which segment each
+// offset falls into in a store. Bytes between segments, as in the holes of a
+// sparse file, read as zeros. Wrap it in io.NewSectionReader(r, 0, r.Size())
+// for an io.Reader and io.Seeker.
+//
+// SegmentReader is safe for concurrent use, provided the readers of the
+// segments are, as io.ReaderAt implementations should be.
+type SegmentReader struct {
+	store    *Node
+	segments []Segment
+	size     int64
+}
+
+// Builds a reader over the segments, given in any order. Returns
+// ErrInvalidInput if a segment has a negative offset, a length which isn't
+// positive, or no reader, or if segments overlap, and ErrEmptyInput if there
+// are none.
+func NewSegmentReader(segments []Segment) (*SegmentReader, error) {
+	sorted := append([]Segment(nil), segments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+	items := make([]Ranged, 0, len(sorted))
+	for idx, s := range sorted {
+		if s.Offset < 0 || s.Length <= 0 || s.Reader == nil || s.Length > (1<<63-1)-s.Offset {
+			return nil, ErrInvalidInput{fmt.Sprintf("segment at %d of length %d isn't valid", s.Offset, s.Length)}
+		}
+		if idx > 0 && s.Offset < sorted[idx-1].Offset+sorted[idx-1].Length {
+			return nil, ErrInvalidInput{fmt.Sprintf("segments at %d and %d overlap", sorted[idx-1].Offset, s.Offset)}
+		}
+		items = append(items, RangeEntry{uint64(s.Offset), uint64(s.Offset + s.Length - 1), idx})
+	}
+	store, err := rangeStoreFromSortedChecked(items, false, true)
+	if err != nil {
+		return nil, err
+	}
+	last := sorted[len(sorted)-1]
+	return &SegmentReader{store: store, segments: sorted, size: last.Offset + last.Length}, nil
+}
+
+// Returns the size of the virtual file, which ends with the last segment
+func (r *SegmentReader) Size() int64 {
+	return r.size
+}
+
+// Reads len(p) bytes from off, splitting the read between the segments it
+// spans. Returns io.EOF if the read goes past the end of the last segment, and
+// io.ErrUnexpectedEOF if a segment's reader holds fewer bytes than the segment.
+func (r *SegmentReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrInvalidInput{fmt.Sprintf("negative offset %d", off)}
+	}
+	n := 0
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		want := int64(len(p) - n)
+		e, dist, err := r.store.SearchCeiling(uint64(off))
+		if err != nil {
+			return n, io.EOF
+		}
+		if dist > 0 {
+			// A hole, up to the next segment
+			if int64(dist) < want {
+				want = int64(dist)
+			}
+			hole := p[n : n+int(want)]
+			for i := range hole {
+				hole[i] = 0
+			}
+			n += int(want)
+			off += want
+			continue
+		}
+		s := r.segments[e.Value.(int)]
+		if left := s.Offset + s.Length - off; left < want {
+			want = left
+		}
+		got, err := s.Reader.ReadAt(p[n:n+int(want)], s.ReaderOffset+off-s.Offset)
+		n += got
+		off += int64(got)
+		if int64(got) < want {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+	}
+	return n, nil
+}
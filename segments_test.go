@@ -0,0 +1,84 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * segments_test.go: Tests for reading across segments
+ */
+
+package rangestore
+
+import (
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegmentReader(t *testing.T) {
+	archive := strings.NewReader("headerHELLOpaddingWORLD")
+	r, err := NewSegmentReader([]Segment{
+		{Offset: 8, Length: 5, Reader: archive, ReaderOffset: 18},
+		{Offset: 0, Length: 5, Reader: archive, ReaderOffset: 6},
+	})
+	if err != nil {
+		t.Fatalf("Error while building segment reader: %s", err.Error())
+	}
+	if r.Size() != 13 {
+		t.Fatalf("Wrong size: %d", r.Size())
+	}
+	all, err := ioutil.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+	if err != nil || string(all) != "HELLO\x00\x00\x00WORLD" {
+		t.Fatalf("Wrong contents: %q, %v", all, err)
+	}
+
+	// Reads spanning segments and holes are split
+	buf := make([]byte, 6)
+	if n, err := r.ReadAt(buf, 3); err != nil || n != 6 || string(buf) != "LO\x00\x00\x00W" {
+		t.Fatalf("Wrong read across segments: %q, %v", buf[:n], err)
+	}
+	if n, err := r.ReadAt(buf, 10); err != io.EOF || n != 3 || string(buf[:n]) != "RLD" {
+		t.Fatalf("Expected a short read at the end: %q, %v", buf[:n], err)
+	}
+
+	// A segment claiming more than its reader holds
+	short, _ := NewSegmentReader([]Segment{{Offset: 0, Length: 10, Reader: strings.NewReader("abc")}})
+	if n, err := short.ReadAt(make([]byte, 5), 0); err != io.ErrUnexpectedEOF || n != 3 {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %d, %v", n, err)
+	}
+}
+
+func TestNewSegmentReader_Invalid(t *testing.T) {
+	src := strings.NewReader("data")
+	for _, segments := range [][]Segment{
+		{{Offset: 0, Length: 0, Reader: src}},
+		{{Offset: -1, Length: 4, Reader: src}},
+		{{Offset: 0, Length: 4}},
+		{{Offset: 0, Length: 4, Reader: src}, {Offset: 3, Length: 4, Reader: src}},
+	} {
+		if _, err := NewSegmentReader(segments); err == nil || reflect.TypeOf(err).Name() != "ErrInvalidInput" {
+			t.Fatalf("Expected ErrInvalidInput for %v, got %v", segments, err)
+		}
+	}
+	if _, err := NewSegmentReader(nil); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected ErrEmptyInput, got %v", err)
+	}
+}
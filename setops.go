@@ -0,0 +1,94 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * setops.go: Set operations over store coverage
+ */
+
+package rangestore
+
+// Returns a store covering every key covered by either a or b. Where both
+// stores cover a key, the value from a is used.
+func Union(a, b *Node) (*Node, error) {
+	return combine(a, b, func(x, y *RangeEntry) *RangeEntry {
+		if x != nil {
+			return x
+		}
+		return y
+	})
+}
+
+// Returns a store covering only the keys covered by both a and b, with the
+// values taken from a
+func Intersect(a, b *Node) (*Node, error) {
+	return combine(a, b, func(x, y *RangeEntry) *RangeEntry {
+		if x != nil && y != nil {
+			return x
+		}
+		return nil
+	})
+}
+
+// Returns a store covering the keys of a which are not covered by b
+func Subtract(a, b *Node) (*Node, error) {
+	return combine(a, b, func(x, y *RangeEntry) *RangeEntry {
+		if y == nil {
+			return x
+		}
+		return nil
+	})
+}
+
+// Returns a store covering every key in [universeMin, universeMax] which isn't
+// covered by the store, with all of the ranges mapped to fill
+func Complement(n *Node, universeMin, universeMax uint64, fill interface{}) (*Node, error) {
+	universe := []RangeEntry{{universeMin, universeMax, fill}}
+	items := make([]Ranged, 0)
+	overlay(universe, n.Ranges(), func(min, max uint64, x, y *RangeEntry) {
+		if x != nil && y == nil {
+			items = append(items, RangeEntry{min, max, fill})
+		}
+	})
+	return NewSparseRangeStoreFromSorted(items)
+}
+
+// Overlays both stores, keeping the intervals for which pick returns an entry
+// and using that entry's value. Results in ErrEmptyInput if nothing is kept.
+func combine(a, b *Node, pick func(x, y *RangeEntry) *RangeEntry) (*Node, error) {
+	items := make([]Ranged, 0)
+	var last *RangeEntry
+	overlay(a.Ranges(), b.Ranges(), func(min, max uint64, x, y *RangeEntry) {
+		e := pick(x, y)
+		if e == nil {
+			last = nil
+			return
+		}
+		// Stitch back together pieces of the same source range which the
+		// overlay split up
+		if e == last && items[len(items)-1].GetMax()+1 == min {
+			items[len(items)-1] = RangeEntry{items[len(items)-1].GetMin(), max, e.Value}
+			return
+		}
+		items = append(items, RangeEntry{min, max, e.Value})
+		last = e
+	})
+	return NewSparseRangeStoreFromSorted(items)
+}
@@ -0,0 +1,111 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * setops_test.go: Tests on set operations
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func setOperands() (*Node, *Node) {
+	a, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 9, "A"},
+		DefaultRangedValue{20, 29, "B"},
+	})
+	b, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{5, 24, "X"},
+		DefaultRangedValue{40, 49, "Y"},
+	})
+	return a, b
+}
+
+func TestUnion(t *testing.T) {
+	a, b := setOperands()
+	n, err := Union(a, b)
+	if err != nil {
+		t.Fatalf("Error while computing union: %s", err.Error())
+	}
+	expected := []RangeEntry{
+		{0, 9, "A"},
+		{10, 19, "X"},
+		{20, 29, "B"},
+		{40, 49, "Y"},
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	a, b := setOperands()
+	n, err := Intersect(a, b)
+	if err != nil {
+		t.Fatalf("Error while computing intersection: %s", err.Error())
+	}
+	expected := []RangeEntry{
+		{5, 9, "A"},
+		{20, 24, "B"},
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	c, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{100, 109, "C"}})
+	_, err = Intersect(a, c)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput for a disjoint intersection, but got something else")
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	a, b := setOperands()
+	n, err := Subtract(a, b)
+	if err != nil {
+		t.Fatalf("Error while computing difference: %s", err.Error())
+	}
+	expected := []RangeEntry{
+		{0, 4, "A"},
+		{25, 29, "B"},
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
+
+func TestComplement(t *testing.T) {
+	a, _ := setOperands()
+	n, err := Complement(a, 5, 99, "free")
+	if err != nil {
+		t.Fatalf("Error while computing complement: %s", err.Error())
+	}
+	expected := []RangeEntry{
+		{10, 19, "free"},
+		{30, 99, "free"},
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+}
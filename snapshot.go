@@ -0,0 +1,190 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * snapshot.go: Saving and restoring stores
+ */
+
+package rangestore
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Snapshots are a gob stream of a header followed by the nodes in pre-order,
+// which preserves the shape of the tree, e.g. one built from frequencies
+const snapshotMagic = "RNGSNAP"
+
+type snapshotHeader struct {
+	Magic   string
+	Version int
+	Count   int
+}
+
+type snapshotNode struct {
+	Min, Max    uint64
+	Value       interface{}
+	Left, Right bool
+}
+
+// Writes the store to the file at path, replacing it atomically: the snapshot
+// is written to a temporary file in the same directory, which is then renamed,
+// so the file at path is always either the old snapshot or the new one in full.
+// Values are encoded with encoding/gob, so any of their types which aren't
+// basic types must be registered with gob.Register.
+func (n *Node) SaveSnapshot(path string) error {
+	return n.SaveSnapshotCodec(path, nil)
+}
+
+// Same as SaveSnapshot, but encodes the values with codec, so that types gob
+// can't handle can be stored. The snapshot must be read by LoadSnapshotCodec
+// with the same codec. A nil codec gives the same snapshot as SaveSnapshot.
+func (n *Node) SaveSnapshotCodec(path string, codec ValueCodec) (err error) {
+	if n == nil {
+		return ErrEmptyStore{}
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	if err = enc.Encode(snapshotHeader{snapshotMagic, 1, n.Len()}); err != nil {
+		return err
+	}
+	if err = n.encodeSnapshot(enc, codec); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (n *Node) encodeSnapshot(enc *gob.Encoder, codec ValueCodec) error {
+	value := n.value
+	if codec != nil {
+		b, err := codec.Encode(n.value)
+		if err != nil {
+			return err
+		}
+		value = b
+	}
+	if err := enc.Encode(snapshotNode{n.min, n.max, value, n.left != nil, n.right != nil}); err != nil {
+		return err
+	}
+	if n.left != nil {
+		if err := n.left.encodeSnapshot(enc, codec); err != nil {
+			return err
+		}
+	}
+	if n.right != nil {
+		return n.right.encodeSnapshot(enc, codec)
+	}
+	return nil
+}
+
+// Reads a store written by SaveSnapshot, with the same shape it was saved with.
+// Returns an ErrCorruptStore if the file isn't a valid snapshot.
+func LoadSnapshot(path string) (*Node, error) {
+	return LoadSnapshotCodec(path, nil)
+}
+
+// Reads a store written by SaveSnapshotCodec, decoding the values with codec
+func LoadSnapshotCodec(path string, codec ValueCodec) (*Node, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil || header.Magic != snapshotMagic {
+		return nil, ErrCorruptStore{"not a snapshot"}
+	}
+	if header.Version != 1 {
+		return nil, ErrUnsupportedVersion{uint32(header.Version)}
+	}
+	remaining := header.Count
+	n, err := decodeSnapshot(dec, codec, &remaining)
+	if err != nil {
+		return nil, err
+	}
+	if remaining != 0 {
+		return nil, ErrCorruptStore{"node count doesn't match the header"}
+	}
+	// Make sure the ranges are still in order before handing the tree out
+	if _, err := cumulativeWidths(nodeRanged(n), true, true); err != nil {
+		return nil, ErrCorruptStore{err.Error()}
+	}
+	return n, nil
+}
+
+func decodeSnapshot(dec *gob.Decoder, codec ValueCodec, remaining *int) (*Node, error) {
+	if *remaining <= 0 {
+		return nil, ErrCorruptStore{"node count doesn't match the header"}
+	}
+	*remaining -= 1
+	var rec snapshotNode
+	if err := dec.Decode(&rec); err != nil {
+		return nil, ErrCorruptStore{err.Error()}
+	}
+	n := &Node{min: rec.Min, max: rec.Max, value: rec.Value}
+	var err error
+	if codec != nil {
+		b, ok := rec.Value.([]byte)
+		if !ok {
+			return nil, ErrCorruptStore{"value wasn't encoded with a codec"}
+		}
+		if n.value, err = codec.Decode(b); err != nil {
+			return nil, err
+		}
+	}
+	if rec.Left {
+		if n.left, err = decodeSnapshot(dec, codec, remaining); err != nil {
+			return nil, err
+		}
+	}
+	if rec.Right {
+		if n.right, err = decodeSnapshot(dec, codec, remaining); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,114 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * snapshot_test.go: Tests on snapshots
+ */
+
+package rangestore
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type snapshotValue struct {
+	Name  string
+	Score int
+}
+
+func TestNode_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.snap")
+
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, 42})
+	items = append(items, DefaultRangedValue{20, 999, snapshotValue{"C", 3}})
+	items = append(items, DefaultRangedValue{1000, 1009, nil})
+	// Skew the shape so that rebuilding from the ranges would differ
+	n, err := NewRangeStoreFromSortedWithFrequencies(items, []uint64{1, 1, 1, 100})
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+
+	if err := n.SaveSnapshot(path); err == nil {
+		t.Fatalf("Expected unregistered value types to be rejected")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Failed snapshot left a file behind")
+	}
+
+	gob.Register(snapshotValue{})
+	if err := n.SaveSnapshot(path); err != nil {
+		t.Fatalf("Error while saving snapshot: %s", err.Error())
+	}
+	// Saving again replaces the snapshot
+	if err := n.SaveSnapshot(path); err != nil {
+		t.Fatalf("Error while saving snapshot: %s", err.Error())
+	}
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("Temporary files were left behind: %d files", len(entries))
+	}
+
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("Error while loading snapshot: %s", err.Error())
+	}
+	if !sameTree(n, loaded) {
+		t.Fatalf("Loaded snapshot differs:\n%s", loaded.String())
+	}
+}
+
+func TestLoadSnapshot_Corrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.snap")
+
+	n, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 9, "A"}, DefaultRangedValue{10, 19, "B"}})
+	if err := n.SaveSnapshot(path); err != nil {
+		t.Fatalf("Error while saving snapshot: %s", err.Error())
+	}
+	data, _ := ioutil.ReadFile(path)
+
+	for name, b := range map[string][]byte{
+		"garbage":   []byte("not a snapshot"),
+		"truncated": data[:len(data)-4],
+	} {
+		ioutil.WriteFile(path, b, 0644)
+		_, err := LoadSnapshot(path)
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrCorruptStore{}).Name() {
+			t.Fatalf("Expecting an ErrCorruptStore for %s, but got %v", name, err)
+		}
+	}
+}
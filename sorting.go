@@ -0,0 +1,75 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * sorting.go: Sorting and searching slices of ranges
+ */
+
+package rangestore
+
+import (
+	"sort"
+)
+
+// Sorts ranges the way the constructors expect them: by their minimum, then by
+// their maximum. Implements sort.Interface.
+type RangedSlice []Ranged
+
+func (s RangedSlice) Len() int {
+	return len(s)
+}
+
+func (s RangedSlice) Less(i, j int) bool {
+	return lessRanged(s[i], s[j])
+}
+
+func (s RangedSlice) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Orders ranges by their minimum, then by their maximum
+func lessRanged(a, b Ranged) bool {
+	if a.GetMin() != b.GetMin() {
+		return a.GetMin() < b.GetMin()
+	}
+	return a.GetMax() < b.GetMax()
+}
+
+// Sorts the items in place into the order NewRangeStoreFromSorted expects, by
+// their minimum, then by their maximum. The sort is stable, so ranges with the
+// same bounds keep their relative order, as they do in Builder. Overlaps aren't
+// resolved; the constructors still report them.
+func SortRanged(items []Ranged) {
+	sort.Stable(RangedSlice(items))
+}
+
+// Reports whether the items are in the order SortRanged puts them in
+func IsSortedRanged(items []Ranged) bool {
+	return sort.IsSorted(RangedSlice(items))
+}
+
+// Returns the index of the first of the sorted items whose minimum is at least
+// min, or len(items) if there's none, as sort.Search does
+func SearchRangedMin(items []Ranged, min uint64) int {
+	return sort.Search(len(items), func(i int) bool {
+		return items[i].GetMin() >= min
+	})
+}
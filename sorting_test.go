@@ -0,0 +1,63 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * sorting_test.go: Tests on the sorting helpers
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSortRanged(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B2"})
+	items = append(items, DefaultRangedValue{10, 14, "B1"})
+	items = append(items, DefaultRangedValue{10, 19, "B3"})
+	if IsSortedRanged(items) {
+		t.Fatalf("Expected the items not to be sorted")
+	}
+	SortRanged(items)
+	values := make([]interface{}, 0)
+	for _, item := range items {
+		values = append(values, item.GetValue())
+	}
+	// Ties on the minimum go to the smaller maximum, and identical bounds keep
+	// their order
+	if !reflect.DeepEqual(values, []interface{}{"A", "B1", "B2", "B3", "C"}) {
+		t.Fatalf("Wrong order: %v", values)
+	}
+	if !IsSortedRanged(items) || !sort.IsSorted(RangedSlice(items)) {
+		t.Fatalf("Expected the items to be sorted")
+	}
+
+	for min, expected := range map[uint64]int{0: 0, 5: 1, 10: 1, 11: 4, 20: 4, 30: 5} {
+		if idx := SearchRangedMin(items, min); idx != expected {
+			t.Fatalf("Wrong index for %d: %d [%d]", min, idx, expected)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * split.go: Splitting, truncating and trimming stores
+ */
+
+package rangestore
+
+// Splits the store into two stores, the first covering [min, at] and the second
+// covering [at+1, max]. A range straddling at is split in two, with both halves
+// keeping its value. The key must lie within the bounds of the store, and not
+// be the maximum, otherwise ErrOutOfRange is returned.
+func (n *Node) Split(at uint64) (left, right *Node, err error) {
+	if n == nil {
+		return nil, nil, ErrEmptyStore{}
+	}
+	min, max := n.Bounds()
+	if at < min || at >= max {
+		return nil, nil, ErrOutOfRange{at}
+	}
+	// The ranges came from a valid store, so there's no need to check them again
+	left, _ = rangeStoreFromSortedChecked(n.clipped(min, at), false, true)
+	right, _ = rangeStoreFromSortedChecked(n.clipped(at+1, max), false, true)
+	return left, right, nil
+}
+
+// Returns a new store restricted to the keys in [lo, hi], splitting the ranges
+// straddling either bound, e.g. to hand each worker its own shard of a big store.
+// Only the subtrees which intersect the interval are visited. Returns
+// ErrInvertedRange if lo is greater than hi, and ErrEmptyInput if the store
+// covers none of the keys.
+func (n *Node) Slice(lo, hi uint64) (*Node, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	if lo > hi {
+		return nil, ErrInvertedRange{lo, hi}
+	}
+	return rangeStoreFromSortedChecked(n.clipped(lo, hi), false, true)
+}
+
+// Returns the ranges of the store which intersect [lo, hi], in ascending order,
+// with the first and last ones trimmed to fit
+func (n *Node) clipped(lo, hi uint64) []Ranged {
+	ret := make([]Ranged, 0)
+	n.walkBetween(lo, hi, func(c *Node) {
+		e := RangeEntry{c.min, c.max, c.value}
+		if e.Min < lo {
+			e.Min = lo
+		}
+		if e.Max > hi {
+			e.Max = hi
+		}
+		ret = append(ret, e)
+	})
+	return ret
+}
+
+// Calls fn on the nodes intersecting [lo, hi] in ascending order, skipping the
+// subtrees which lie entirely outside of it
+func (n *Node) walkBetween(lo, hi uint64, fn func(*Node)) {
+	if n == nil {
+		return
+	}
+	if n.left != nil && n.min > lo {
+		n.left.walkBetween(lo, hi, fn)
+	}
+	if n.max >= lo && n.min <= hi {
+		fn(n)
+	}
+	if n.right != nil && n.max < hi {
+		n.right.walkBetween(lo, hi, fn)
+	}
+}
+
+// Returns a new store with every key above max removed, splitting the range
+// straddling max if needed. Returns ErrEmptyInput if nothing would remain.
+func (n *Node) TruncateAbove(max uint64) (*Node, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	min, _ := n.Bounds()
+	return rangeStoreFromSortedChecked(n.clipped(min, max), false, true)
+}
+
+// Returns a new store with every key below min removed, splitting the range
+// straddling min if needed. Returns ErrEmptyInput if nothing would remain.
+func (n *Node) TrimBelow(min uint64) (*Node, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	_, max := n.Bounds()
+	return rangeStoreFromSortedChecked(n.clipped(min, max), false, true)
+}
@@ -0,0 +1,175 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * split_test.go: Tests on splitting, truncating and trimming stores
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_Split(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	left, right, err := n.Split(14)
+	if err != nil {
+		t.Fatalf("Error while splitting: %s", err.Error())
+	}
+	if got := left.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{0, 9, "A"}, {10, 14, "B"}}) {
+		t.Fatalf("Wrong left ranges: %v", got)
+	}
+	if got := right.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{15, 19, "B"}, {20, 29, "C"}}) {
+		t.Fatalf("Wrong right ranges: %v", got)
+	}
+
+	// Splitting on a boundary shouldn't split any ranges
+	left, right, err = n.Split(9)
+	if err != nil {
+		t.Fatalf("Error while splitting: %s", err.Error())
+	}
+	if left.Len() != 1 || right.Len() != 2 {
+		t.Fatalf("Expected the split to fall on the range boundary")
+	}
+}
+
+func TestNode_SplitOutOfRange(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	for _, at := range []uint64{9, 19, 30} {
+		_, _, err := n.Split(at)
+		if err == nil {
+			t.Fatalf("Expected an error splitting at %d, got nothing", at)
+		}
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+			t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+		}
+	}
+}
+
+func TestNode_TruncateAbove(t *testing.T) {
+	n, _ := NewSparseRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 9, "A"},
+		DefaultRangedValue{10, 19, "B"},
+		DefaultRangedValue{30, 39, "C"},
+	})
+
+	c, err := n.TruncateAbove(14)
+	if err != nil {
+		t.Fatalf("Error while truncating: %s", err.Error())
+	}
+	if got := c.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{0, 9, "A"}, {10, 14, "B"}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	c, err = n.TruncateAbove(25)
+	if err != nil {
+		t.Fatalf("Error while truncating: %s", err.Error())
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Expected truncating in a gap to keep both lower ranges")
+	}
+	if n.Len() != 3 {
+		t.Fatalf("Expected the original store to be untouched")
+	}
+}
+
+func TestNode_TrimBelow(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{10, 19, "A"},
+		DefaultRangedValue{20, 29, "B"},
+	})
+
+	c, err := n.TrimBelow(15)
+	if err != nil {
+		t.Fatalf("Error while trimming: %s", err.Error())
+	}
+	if got := c.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{15, 19, "A"}, {20, 29, "B"}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	_, err = n.TrimBelow(30)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+	_, err = n.TruncateAbove(5)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+}
+
+func TestNode_Slice(t *testing.T) {
+	n, err := NewRangeStoreFromSorted(arenaItems(100))
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	s, err := n.Slice(255, 301)
+	if err != nil {
+		t.Fatalf("Error while slicing: %s", err.Error())
+	}
+	expected := []RangeEntry{{255, 259, 25}, {260, 269, 26}, {270, 279, 27}, {280, 289, 28}, {290, 299, 29}, {300, 301, 30}}
+	if got := s.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges in the slice: %v", got)
+	}
+	if got := n.Ranges(); len(got) != 100 || got[25].Min != 250 {
+		t.Fatalf("Slicing shouldn't modify the original store")
+	}
+
+	// Slices over each part of the tree should match filtering every range
+	for lo := uint64(0); lo < 1000; lo += 37 {
+		hi := lo + 123
+		s, err := n.Slice(lo, hi)
+		if err != nil {
+			t.Fatalf("Error while slicing [%d, %d]: %s", lo, hi, err.Error())
+		}
+		min, max := s.Bounds()
+		if min != lo || (max != hi && !(hi > 999 && max == 999)) {
+			t.Fatalf("Wrong bounds for slice [%d, %d]: [%d, %d]", lo, hi, min, max)
+		}
+	}
+
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{50, 59, "B"})
+	sparse, _ := NewSparseRangeStoreFromSorted(items)
+	if _, err := sparse.Slice(20, 40); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected ErrEmptyInput for a slice of a gap, got %v", err)
+	}
+	if _, err := sparse.Slice(40, 20); err == nil || reflect.TypeOf(err).Name() != "ErrInvertedRange" {
+		t.Fatalf("Expected ErrInvertedRange, got %v", err)
+	}
+}
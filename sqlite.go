@@ -0,0 +1,145 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * sqlite.go: Exporting stores to SQL tables and importing them back
+ */
+
+package rangestore
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type ErrKeyTooLarge struct {
+	key uint64
+}
+
+func (ex ErrKeyTooLarge) Error() string {
+	return fmt.Sprintf("Key %d doesn't fit in a signed 64 bit integer", ex.key)
+}
+
+// Returns the key which doesn't fit
+func (ex ErrKeyTooLarge) Key() uint64 {
+	return ex.key
+}
+
+func (ex ErrKeyTooLarge) Details() ErrorDetails {
+	return ErrorDetails{"ErrKeyTooLarge", map[string]interface{}{"key": ex.key}}
+}
+
+// Quotes a table or index name for use in SQL
+func quoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// Writes the ranges of the store to a table in the database, with min, max and
+// value columns, replacing the table if it exists. Both bounds get a unique
+// index, so that the range containing a key can be found with
+//
+//	SELECT value FROM ranges WHERE max >= ? AND min <= ? ORDER BY max LIMIT 1
+//
+// Values are encoded by codec, or StringCodec if it's nil, and stored as text.
+// SQLite integers are signed, so keys above math.MaxInt64 give an
+// ErrKeyTooLarge. Everything is written in a single transaction. Though meant
+// for SQLite, only standard SQL is used.
+func (n *Node) ExportSQLite(db *sql.DB, table string, codec ValueCodec) error {
+	if n == nil {
+		return ErrEmptyStore{}
+	}
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	ranges := n.Ranges()
+	if max := ranges[len(ranges)-1].Max; max > math.MaxInt64 {
+		return ErrKeyTooLarge{max}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	name := quoteIdentifier(table)
+	for _, stmt := range []string{
+		"DROP TABLE IF EXISTS " + name,
+		"CREATE TABLE " + name + " (min INTEGER NOT NULL, max INTEGER NOT NULL, value TEXT NOT NULL, CHECK (min <= max))",
+		"CREATE UNIQUE INDEX " + quoteIdentifier(table+"_min") + " ON " + name + " (min)",
+		"CREATE UNIQUE INDEX " + quoteIdentifier(table+"_max") + " ON " + name + " (max)",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	insert, err := tx.Prepare("INSERT INTO " + name + " (min, max, value) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, r := range ranges {
+		encoded, err := codec.Encode(r.Value)
+		if err != nil {
+			return err
+		}
+		if _, err := insert.Exec(int64(r.Min), int64(r.Max), string(encoded)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Reads a table written by ExportSQLite, which may have been edited since, and
+// builds a sparse store from it, decoding the values with codec, or StringCodec
+// if it's nil. Rows with negative keys give an ErrMalformedRecord, numbered
+// from 1 in ascending order of min, and overlapping rows an ErrOverlap.
+func ImportSQLite(db *sql.DB, table string, codec ValueCodec) (*Node, error) {
+	if codec == nil {
+		codec = StringCodec{}
+	}
+	rows, err := db.Query("SELECT min, max, value FROM " + quoteIdentifier(table) + " ORDER BY min")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Ranged, 0)
+	for record := 1; rows.Next(); record += 1 {
+		var min, max int64
+		var value []byte
+		if err := rows.Scan(&min, &max, &value); err != nil {
+			return nil, err
+		}
+		if min < 0 || max < 0 {
+			return nil, ErrMalformedRecord{record, "negative key"}
+		}
+		v, err := codec.Decode(value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, RangeEntry{uint64(min), uint64(max), v})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rangeStoreFromSortedChecked(items, true, true)
+}
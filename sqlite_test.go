@@ -0,0 +1,192 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * sqlite_test.go: Tests on the SQL export and import
+ */
+
+package rangestore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// A driver understanding just the statements used by ExportSQLite and
+// ImportSQLite, keeping the tables in memory
+type tableDriver struct {
+	mu     sync.Mutex
+	tables map[string][][]driver.Value
+	log    []string
+}
+
+var tableName = regexp.MustCompile(`(?:TABLE(?: IF EXISTS)?|INTO|FROM) "((?:[^"]|"")*)"`)
+
+func (d *tableDriver) Open(name string) (driver.Conn, error) {
+	return tableConn{d}, nil
+}
+
+type tableConn struct {
+	d *tableDriver
+}
+
+func (c tableConn) Prepare(query string) (driver.Stmt, error) {
+	return tableStmt{c.d, query}, nil
+}
+
+func (c tableConn) Close() error {
+	return nil
+}
+
+func (c tableConn) Begin() (driver.Tx, error) {
+	return tableTx{}, nil
+}
+
+type tableTx struct{}
+
+func (tableTx) Commit() error   { return nil }
+func (tableTx) Rollback() error { return nil }
+
+type tableStmt struct {
+	d     *tableDriver
+	query string
+}
+
+func (s tableStmt) Close() error  { return nil }
+func (s tableStmt) NumInput() int { return -1 }
+
+func (s tableStmt) table() string {
+	m := tableName.FindStringSubmatch(s.query)
+	if m == nil {
+		return ""
+	}
+	return strings.Replace(m[1], `""`, `"`, -1)
+}
+
+func (s tableStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.log = append(s.d.log, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "DROP TABLE"):
+		delete(s.d.tables, s.table())
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+		s.d.tables[s.table()] = nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.d.tables[s.table()] = append(s.d.tables[s.table()], args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s tableStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	rows, ok := s.d.tables[s.table()]
+	if !ok {
+		return nil, errors.New("no such table")
+	}
+	sorted := append([][]driver.Value(nil), rows...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i][0].(int64) < sorted[j][0].(int64)
+	})
+	return &tableRows{rows: sorted}, nil
+}
+
+type tableRows struct {
+	rows [][]driver.Value
+}
+
+func (r *tableRows) Columns() []string {
+	return []string{"min", "max", "value"}
+}
+
+func (r *tableRows) Close() error {
+	return nil
+}
+
+func (r *tableRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var testTables = &tableDriver{tables: make(map[string][][]driver.Value)}
+
+func init() {
+	sql.Register("rangestoretest", testTables)
+}
+
+func TestNode_ExportSQLite(t *testing.T) {
+	db, err := sql.Open("rangestoretest", "")
+	if err != nil {
+		t.Fatalf("Error while opening database: %s", err.Error())
+	}
+	defer db.Close()
+	if err := mappedTestStore(t).ExportSQLite(db, `my "ranges"`, nil); err != nil {
+		t.Fatalf("Error while exporting store: %s", err.Error())
+	}
+	if !strings.Contains(strings.Join(testTables.log, "\n"), `CREATE UNIQUE INDEX "my ""ranges""_max" ON "my ""ranges""" (max)`) {
+		t.Fatalf("Missing index in %v", testTables.log)
+	}
+	n, err := ImportSQLite(db, `my "ranges"`, nil)
+	if err != nil {
+		t.Fatalf("Error while importing store: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 9, "A"}, {10, 99, "B"}, {150, 199, "C"}, {200, 299, "A"}}
+	if !reflect.DeepEqual(n.Ranges(), expected) {
+		t.Fatalf("Wrong ranges: %v", n.Ranges())
+	}
+
+	// Rows edited into an overlap are caught on import
+	testTables.tables[`my "ranges"`][1][1] = int64(160)
+	if _, err := ImportSQLite(db, `my "ranges"`, nil); reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected an ErrOverlap, got %v", err)
+	}
+	testTables.tables[`my "ranges"`][1][0] = int64(-5)
+	if _, err := ImportSQLite(db, `my "ranges"`, nil); reflect.TypeOf(err).Name() != "ErrMalformedRecord" {
+		t.Fatalf("Expected an ErrMalformedRecord, got %v", err)
+	}
+}
+
+func TestNode_ExportSQLite_KeyTooLarge(t *testing.T) {
+	db, err := sql.Open("rangestoretest", "")
+	if err != nil {
+		t.Fatalf("Error while opening database: %s", err.Error())
+	}
+	defer db.Close()
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 1 << 63, "A"})
+	n, _ := NewRangeStoreFromSorted(items)
+	if err := n.ExportSQLite(db, "big", nil); reflect.TypeOf(err).Name() != "ErrKeyTooLarge" {
+		t.Fatalf("Expected an ErrKeyTooLarge, got %v", err)
+	}
+}
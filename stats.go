@@ -0,0 +1,95 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * stats.go: Shape statistics of trees
+ */
+
+package rangestore
+
+import (
+	"math"
+)
+
+// Statistics describing the shape of a tree, for checking how well it's balanced.
+// Weights are the number of keys covered, which is what the construction
+// balances on unless frequencies are supplied.
+type TreeStats struct {
+	Nodes  int
+	Leaves int
+	// Leaf depths, counting the root as 1
+	MinLeafDepth int
+	MaxLeafDepth int
+	AvgLeafDepth float64
+	// For each level of the tree, starting with the root, the worst weight
+	// imbalance of any node on it: the difference between the weights of its
+	// left and right subtrees, as a fraction of the weight of its own subtree.
+	// 0 is perfectly balanced and 1 is as lopsided as can be.
+	LevelImbalance []float64
+	// The average number of nodes visited by a lookup, with every covered key
+	// equally likely to be looked up
+	ExpectedCost float64
+}
+
+// Computes the shape statistics of the tree
+func (n *Node) Stats() TreeStats {
+	if n == nil {
+		return TreeStats{}
+	}
+	ret := TreeStats{MinLeafDepth: math.MaxInt32}
+	leafDepths, weightedDepths := 0, 0.0
+	var visit func(c *Node, depth int) float64
+	visit = func(c *Node, depth int) float64 {
+		if c == nil {
+			return 0
+		}
+		ret.Nodes += 1
+		self := float64(c.max-c.min) + 1
+		weightedDepths += self * float64(depth)
+		if c.left == nil && c.right == nil {
+			ret.Leaves += 1
+			leafDepths += depth
+			if depth < ret.MinLeafDepth {
+				ret.MinLeafDepth = depth
+			}
+			if depth > ret.MaxLeafDepth {
+				ret.MaxLeafDepth = depth
+			}
+		}
+		l, r := visit(c.left, depth+1), visit(c.right, depth+1)
+		total := l + r + self
+		for len(ret.LevelImbalance) < depth {
+			ret.LevelImbalance = append(ret.LevelImbalance, 0)
+		}
+		if imbalance := math.Abs(l-r) / total; imbalance > ret.LevelImbalance[depth-1] {
+			ret.LevelImbalance[depth-1] = imbalance
+		}
+		return total
+	}
+	total := visit(n, 1)
+	if ret.Leaves > 0 {
+		ret.AvgLeafDepth = float64(leafDepths) / float64(ret.Leaves)
+	}
+	if total > 0 {
+		ret.ExpectedCost = weightedDepths / total
+	}
+	return ret
+}
@@ -0,0 +1,69 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * stats_test.go: Tests on tree statistics
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_Stats(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, DefaultRangedValue{20, 29, "C"})
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	expected := TreeStats{
+		Nodes:          3,
+		Leaves:         2,
+		MinLeafDepth:   2,
+		MaxLeafDepth:   2,
+		AvgLeafDepth:   2,
+		LevelImbalance: []float64{0, 0},
+		ExpectedCost:   50.0 / 30,
+	}
+	if got := n.Stats(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong stats: %+v", got)
+	}
+}
+
+func TestNode_StatsLopsided(t *testing.T) {
+	// A chain leaning to the right
+	n := &Node{min: 0, max: 9, value: "A", right: &Node{min: 10, max: 19, value: "B", right: &Node{min: 20, max: 29, value: "C"}}}
+	got := n.Stats()
+	if got.Leaves != 1 || got.MinLeafDepth != 3 || got.MaxLeafDepth != 3 {
+		t.Fatalf("Wrong leaf stats: %+v", got)
+	}
+	if !reflect.DeepEqual(got.LevelImbalance, []float64{20.0 / 30, 10.0 / 20, 0}) {
+		t.Fatalf("Wrong imbalance: %v", got.LevelImbalance)
+	}
+	if got.ExpectedCost != 2 {
+		t.Fatalf("Wrong expected cost: %f", got.ExpectedCost)
+	}
+}
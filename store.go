@@ -0,0 +1,154 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * store.go: Store level wrapper around the tree
+ */
+
+package rangestore
+
+// A range store, owning the root of the tree along with store level metadata
+// such as the bounds, span and number of ranges. The tree itself is available
+// through Root() for those who need it, and all of the *Node methods continue
+// to work on it.
+type RangeStore struct {
+	root     *Node
+	search   RangeSearcher
+	min, max uint64
+	span     uint64
+	count    int
+	opts     options
+	cache    *lookupCache
+	filter   *missFilter
+	// Appends since the shape was last checked, see WithRebuildThreshold
+	appended int
+}
+
+// Builds a range store from the items. Without any options, the items must
+// satisfy the same constraints as NewRangeStoreFromSorted; see Builder for
+// the options which relax them.
+func NewRangeStore(items []Ranged, opts ...Option) (*RangeStore, error) {
+	return NewBuilder(opts...).AddRanged(items...).Build()
+}
+
+// Returns a store without any ranges, for data sources which legitimately have
+// none. Every search misses with ErrOutOfRange, or returns the value given with
+// WithDefault. Ranges can be added with Append, and Root returns nil, which
+// Merge treats as an empty store.
+func NewEmptyRangeStore(opts ...Option) *RangeStore {
+	s := WrapNode(nil)
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+	s.useBackend()
+	return s
+}
+
+// Answers the searches of a store without any ranges
+type emptySearcher struct{}
+
+func (emptySearcher) RangeSearch(val uint64) (interface{}, error) {
+	return nil, ErrOutOfRange{val}
+}
+
+// Wraps an already built tree in a RangeStore, computing the store level metadata
+func WrapNode(n *Node) *RangeStore {
+	s := &RangeStore{root: n, search: n}
+	s.min, s.max = n.Bounds()
+	n.walk(func(c *Node) {
+		s.count += 1
+		s.span += (c.max - c.min) + 1
+	})
+	return s
+}
+
+// Returns the root of the underlying tree
+func (s *RangeStore) Root() *Node {
+	return s.root
+}
+
+// Returns the number of ranges in the store
+func (s *RangeStore) Len() int {
+	return s.count
+}
+
+// Returns the smallest and largest keys covered by the store
+func (s *RangeStore) Bounds() (min, max uint64) {
+	return s.min, s.max
+}
+
+// Returns the total number of keys covered by the store. Since the construction
+// rejects stores whose total width overflows, the only way to get a span of 0 is
+// a single range covering the entire uint64 key space.
+func (s *RangeStore) Span() uint64 {
+	return s.span
+}
+
+// Searches for the range which contains the specified key
+// and returns the associated value, or an error if the
+// value is out of range. If the store was built with a
+// default value, that is returned instead of the error.
+// Stores built with MultiValue return ErrAmbiguousValue
+// for keys mapping to more than one value.
+func (s *RangeStore) RangeSearch(val uint64) (interface{}, error) {
+	if s.cache == nil {
+		return s.lookup(val)
+	}
+	if e, ok := s.cache.get(val); ok {
+		return e.value, e.err
+	}
+	v, err := s.lookup(val)
+	s.cache.add(val, v, err)
+	return v, err
+}
+
+func (s *RangeStore) lookup(val uint64) (interface{}, error) {
+	var v interface{}
+	var err error
+	if s.filter != nil && !s.filter.mayContain(val) {
+		err = ErrOutOfRange{val}
+	} else {
+		v, err = s.search.RangeSearch(val)
+	}
+	if err != nil && s.opts.hasDefault {
+		if _, ok := err.(ErrOutOfRange); ok {
+			return s.opts.def, nil
+		}
+	}
+	if err == nil && s.opts.multi {
+		vs := v.([]interface{})
+		if len(vs) > 1 {
+			return nil, ErrAmbiguousValue{val, len(vs)}
+		}
+		return vs[0], nil
+	}
+	return v, err
+}
+
+// Returns the tree shaped string representation of the store
+func (s *RangeStore) String() string {
+	return s.root.String()
+}
+
+// Returns all of the ranges in the store, in ascending order
+func (s *RangeStore) Ranges() []RangeEntry {
+	return s.root.Ranges()
+}
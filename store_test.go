@@ -0,0 +1,137 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * store_test.go: Tests on the store wrapper
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeStore_Basic(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	items = append(items, DefaultRangedValue{20, 29, "B"})
+	items = append(items, DefaultRangedValue{30, 39, "C"})
+
+	s, err := NewRangeStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+
+	if s.Len() != 3 {
+		t.Fatalf("Expected 3 ranges, got %d", s.Len())
+	}
+	if s.Span() != 30 {
+		t.Fatalf("Expected a span of 30, got %d", s.Span())
+	}
+	min, max := s.Bounds()
+	if min != 10 || max != 39 {
+		t.Fatalf("Expected bounds of [10, 39], got [%d, %d]", min, max)
+	}
+	if got := s.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{10, 19, "A"}, {20, 29, "B"}, {30, 39, "C"}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	if s.Root().value != "B" {
+		t.Fatalf("Expected B at the root")
+	}
+	v, err := s.RangeSearch(25)
+	if err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	if v != "B" {
+		t.Fatalf("Got invalid value back %s [%s]", v, "B")
+	}
+	if s.String() != s.Root().String() {
+		t.Fatalf("Expected the string form of the store to match the tree")
+	}
+}
+
+func TestRangeStore_Empty(t *testing.T) {
+	_, err := NewRangeStore(make([]Ranged, 0))
+	if err == nil {
+		t.Fatalf("Error while constructing range store: Expected an error, but none generated")
+	}
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+}
+
+func TestNewEmptyRangeStore(t *testing.T) {
+	for _, backend := range []Backend{TreeBackend, LookupTableBackend, FlatBackend} {
+		s := NewEmptyRangeStore(WithBackend(backend), WithMissFilter(), WithLookupCache(4))
+		if s.Len() != 0 || s.Span() != 0 || s.Root() != nil || len(s.Ranges()) != 0 {
+			t.Fatalf("Expected an empty store")
+		}
+		if _, err := s.RangeSearch(5); reflect.TypeOf(err).Name() != "ErrOutOfRange" {
+			t.Fatalf("Expecting an ErrOutOfRange, but got %v", err)
+		}
+		if vs := s.RangeSearchAll(5); vs != nil {
+			t.Fatalf("Expected no values, got %v", vs)
+		}
+		if err := s.CheckInvariants(); err != nil {
+			t.Fatalf("Error while checking the empty store: %s", err.Error())
+		}
+
+		// The store grows from its first append
+		if err := s.Append(DefaultRangedValue{10, 19, "A"}); err != nil {
+			t.Fatalf("Error while appending: %s", err.Error())
+		}
+		if err := s.Append(DefaultRangedValue{20, 29, "B"}); err != nil {
+			t.Fatalf("Error while appending: %s", err.Error())
+		}
+		if v, err := s.RangeSearch(25); err != nil || v != "B" {
+			t.Fatalf("Wrong value after appending: %v", v)
+		}
+		if min, max := s.Bounds(); min != 10 || max != 29 || s.Len() != 2 || s.Span() != 20 {
+			t.Fatalf("Wrong metadata after appending: [%d, %d], %d ranges", min, max, s.Len())
+		}
+		if err := s.CheckInvariants(); err != nil {
+			t.Fatalf("Error while checking the grown store: %s", err.Error())
+		}
+	}
+
+	s := NewEmptyRangeStore(WithDefault("none"))
+	if v, err := s.RangeSearch(5); err != nil || v != "none" {
+		t.Fatalf("Expected the default value, got %v", v)
+	}
+	if err := s.Append(DefaultRangedValue{10, 9, "A"}); reflect.TypeOf(err).Name() != "ErrInvertedRange" {
+		t.Fatalf("Expecting an ErrInvertedRange, but got %v", err)
+	}
+
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{10, 19, "A"})
+	n, _ := NewRangeStoreFromSorted(items)
+	merged, err := Merge(s.Root(), n, nil)
+	if err != nil {
+		t.Fatalf("Error while merging into an empty store: %s", err.Error())
+	}
+	if !reflect.DeepEqual(merged.Ranges(), n.Ranges()) {
+		t.Fatalf("Wrong ranges after merging: %v", merged.Ranges())
+	}
+	if _, err := Merge(s.Root(), nil, nil); reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expecting an ErrEmptyInput, but got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * stringstore.go: Stores keyed by strings
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A range of string keys, ordered lexicographically by byte
+type StringRanged interface {
+	GetMinKey() string
+	GetMaxKey() string
+	GetValue() interface{}
+}
+
+// A range of string keys. Implements StringRanged.
+type StringRangeEntry struct {
+	Min, Max string
+	Value    interface{}
+}
+
+func (r StringRangeEntry) GetMinKey() string {
+	return r.Min
+}
+func (r StringRangeEntry) GetMaxKey() string {
+	return r.Max
+}
+func (r StringRangeEntry) GetValue() interface{} {
+	return r.Value
+}
+
+type ErrInvalidKeyRange struct {
+	index  int
+	reason string
+}
+
+func (ex ErrInvalidKeyRange) Error() string {
+	return fmt.Sprintf("Range %d: %s", ex.index, ex.reason)
+}
+
+// Returns the index of the offending item
+func (ex ErrInvalidKeyRange) Index() int {
+	return ex.index
+}
+
+// Returns why the item was rejected
+func (ex ErrInvalidKeyRange) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrInvalidKeyRange) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidKeyRange", map[string]interface{}{"index": ex.index, "reason": ex.reason}}
+}
+
+type ErrKeyOutOfRange struct {
+	key interface{}
+}
+
+func (ex ErrKeyOutOfRange) Error() string {
+	if s, ok := ex.key.(string); ok {
+		return fmt.Sprintf("Value %s is out of range", strconv.Quote(s))
+	}
+	return fmt.Sprintf("Value %v is out of range", ex.key)
+}
+
+// Returns the key which was looked up
+func (ex ErrKeyOutOfRange) Key() interface{} {
+	return ex.key
+}
+
+func (ex ErrKeyOutOfRange) Details() ErrorDetails {
+	return ErrorDetails{"ErrKeyOutOfRange", map[string]interface{}{"key": ex.key}}
+}
+
+// A node of a store keyed by strings, such as a routing table mapping key
+// prefixes to shards. Every range is held as half open, [min, max), with an
+// unbounded range running past every string starting with min.
+type StringNode struct {
+	min, max    string
+	unbounded   bool
+	value       interface{}
+	left, right *StringNode
+}
+
+// Builds a store from ranges with inclusive bounds, which must be sorted and
+// not overlap. Since the successor of a string s is s+"\x00", ranges with
+// inclusive bounds are rarely continuous, so gaps are always permitted; use
+// NewHalfOpenStringRangeStore for a continuous key space.
+//
+// Note that an inclusive range such as "aaa".."mzz" doesn't cover "mzza"; a
+// half open range "aaa".."n" does.
+func NewStringRangeStore(items []StringRanged) (*StringNode, error) {
+	nodes := make([]StringNode, len(items))
+	for idx, item := range items {
+		if item.GetMinKey() > item.GetMaxKey() {
+			return nil, ErrInvalidKeyRange{idx, fmt.Sprintf("minimum %q is greater than maximum %q", item.GetMinKey(), item.GetMaxKey())}
+		}
+		nodes[idx] = StringNode{min: item.GetMinKey(), max: item.GetMaxKey() + "\x00", value: item.GetValue()}
+	}
+	return buildStringStore(nodes, true)
+}
+
+// Builds a store from half open ranges, [min, max), which must be sorted and
+// not overlap. An empty maximum on the last range leaves it unbounded. Unless
+// gaps are permitted, each range must start where the previous one ends, e.g.
+// ["", "h"), ["h", "p"), ["p", "").
+func NewHalfOpenStringRangeStore(items []StringRanged, gaps bool) (*StringNode, error) {
+	nodes := make([]StringNode, len(items))
+	for idx, item := range items {
+		n := StringNode{min: item.GetMinKey(), max: item.GetMaxKey(), value: item.GetValue()}
+		if n.max == "" && idx == len(items)-1 {
+			n.unbounded = true
+		} else if n.min >= n.max {
+			return nil, ErrInvalidKeyRange{idx, fmt.Sprintf("minimum %q isn't below maximum %q", n.min, n.max)}
+		}
+		nodes[idx] = n
+	}
+	return buildStringStore(nodes, gaps)
+}
+
+func buildStringStore(nodes []StringNode, gaps bool) (*StringNode, error) {
+	if len(nodes) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	for idx := 1; idx < len(nodes); idx += 1 {
+		prev, curr := nodes[idx-1], nodes[idx]
+		if curr.min < prev.max {
+			return nil, ErrInvalidKeyRange{idx, fmt.Sprintf("overlap detected between %q -> %q", prev.max, curr.min)}
+		}
+		if !gaps && curr.min != prev.max {
+			return nil, ErrInvalidKeyRange{idx, fmt.Sprintf("discontinuity detected from %q -> %q", prev.max, curr.min)}
+		}
+	}
+	return linkStringNodes(nodes), nil
+}
+
+// Links the nodes into a tree balanced by count, as there's no meaningful
+// notion of the width of a range of strings
+func linkStringNodes(nodes []StringNode) *StringNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	n := &nodes[mid]
+	n.left = linkStringNodes(nodes[:mid])
+	n.right = linkStringNodes(nodes[mid+1:])
+	return n
+}
+
+// Searches for the range which contains the key and returns the associated
+// value, or ErrKeyOutOfRange if there is none
+func (n *StringNode) RangeSearch(key string) (interface{}, error) {
+	for c := n; c != nil; {
+		if key < c.min {
+			c = c.left
+		} else if !c.unbounded && key >= c.max {
+			c = c.right
+		} else {
+			return c.value, nil
+		}
+	}
+	return nil, ErrKeyOutOfRange{key}
+}
+
+// Same as RangeSearch, for keys held as bytes
+func (n *StringNode) RangeSearchBytes(key []byte) (interface{}, error) {
+	return n.RangeSearch(string(key))
+}
+
+// Returns the ranges in ascending order, as half open ranges. The maximum of an
+// unbounded range is empty.
+func (n *StringNode) Ranges() []StringRangeEntry {
+	ret := make([]StringRangeEntry, 0)
+	var walk func(c *StringNode)
+	walk = func(c *StringNode) {
+		if c == nil {
+			return
+		}
+		walk(c.left)
+		ret = append(ret, StringRangeEntry{c.min, c.max, c.value})
+		walk(c.right)
+	}
+	walk(n)
+	return ret
+}
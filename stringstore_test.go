@@ -0,0 +1,103 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * stringstore_test.go: Tests on string keyed stores
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringRangeStore_HalfOpen(t *testing.T) {
+	items := make([]StringRanged, 0)
+	items = append(items, StringRangeEntry{"", "h", "shard1"})
+	items = append(items, StringRangeEntry{"h", "p", "shard2"})
+	items = append(items, StringRangeEntry{"p", "", "shard3"})
+	n, err := NewHalfOpenStringRangeStore(items, false)
+	if err != nil {
+		t.Fatalf("Error while constructing string store: %s", err.Error())
+	}
+	tests := map[string]string{
+		"":         "shard1",
+		"apple":    "shard1",
+		"gzzzz":    "shard1",
+		"h":        "shard2",
+		"orange":   "shard2",
+		"p":        "shard3",
+		"\xff\xff": "shard3",
+	}
+	for key, expected := range tests {
+		if v, err := n.RangeSearch(key); err != nil || v != expected {
+			t.Fatalf("Wrong value for %q: %v", key, v)
+		}
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, []StringRangeEntry{{"", "h", "shard1"}, {"h", "p", "shard2"}, {"p", "", "shard3"}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	items[1] = StringRangeEntry{"i", "p", "shard2"}
+	_, err = NewHalfOpenStringRangeStore(items, false)
+	if err == nil || err.Error() != `Range 1: discontinuity detected from "h" -> "i"` {
+		t.Fatalf("Expecting a discontinuity, got %v", err)
+	}
+	sparse, err := NewHalfOpenStringRangeStore(items, true)
+	if err != nil {
+		t.Fatalf("Error while constructing sparse store: %s", err.Error())
+	}
+	_, err = sparse.RangeSearch("hello")
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrKeyOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrKeyOutOfRange, but got something else")
+	}
+	if err.Error() != `Value "hello" is out of range` {
+		t.Fatalf("Wrong error message: %s", err.Error())
+	}
+}
+
+func TestStringRangeStore_Inclusive(t *testing.T) {
+	items := make([]StringRanged, 0)
+	items = append(items, StringRangeEntry{"aaa", "mzz", "shard1"})
+	items = append(items, StringRangeEntry{"n", "zzz", "shard2"})
+	n, err := NewStringRangeStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing string store: %s", err.Error())
+	}
+	if v, err := n.RangeSearch("mzz"); err != nil || v != "shard1" {
+		t.Fatalf("Wrong value for the inclusive maximum: %v", v)
+	}
+	if v, err := n.RangeSearchBytes([]byte("zzz")); err != nil || v != "shard2" {
+		t.Fatalf("Wrong value for the inclusive maximum: %v", v)
+	}
+	for _, key := range []string{"mzza", "zzza", "a"} {
+		if _, err := n.RangeSearch(key); err == nil {
+			t.Fatalf("Expected %q to be out of range", key)
+		}
+	}
+
+	items = append(items, StringRangeEntry{"zzz", "zzzz", "shard3"})
+	_, err = NewStringRangeStore(items)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvalidKeyRange{}).Name() {
+		t.Fatalf("Expecting an ErrInvalidKeyRange, but got something else")
+	}
+}
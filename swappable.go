@@ -0,0 +1,157 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * swappable.go: Atomically replaceable stores
+ */
+
+package rangestore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Describes the data served by a SwappableStore at some point, so that results
+// can be traced back to where they came from
+type StoreVersion struct {
+	// The number of swaps before this store was served, 0 for the first one
+	Generation uint64
+	// Where the data came from, such as a file path or URL
+	Source string
+	// When the store was built
+	BuiltAt time.Time
+	// Anything else worth recording, such as a commit hash
+	Meta map[string]string
+}
+
+// The store being served and its version, which are swapped together so that
+// lookups always see a matching pair
+type swappableState struct {
+	store   *RangeStore
+	version StoreVersion
+}
+
+// Holds a store which can be replaced wholesale while lookups are running, e.g.
+// when a table is rebuilt from fresh data. Lookups never block, and always see
+// either the old store or the new one in full.
+//
+// SwappableStore is safe for concurrent use.
+type SwappableStore struct {
+	mu        sync.Mutex
+	current   atomic.Value
+	listeners []func(s *RangeStore, v StoreVersion)
+}
+
+// Creates a swappable store serving s
+func NewSwappableStore(s *RangeStore) *SwappableStore {
+	return NewVersionedSwappableStore(s, StoreVersion{})
+}
+
+// Creates a swappable store serving s, described by v. The generation of v is
+// ignored, and set to 0.
+func NewVersionedSwappableStore(s *RangeStore, v StoreVersion) *SwappableStore {
+	w := &SwappableStore{}
+	v.Generation = 0
+	w.current.Store(&swappableState{s, v})
+	return w
+}
+
+func (w *SwappableStore) state() *swappableState {
+	return w.current.Load().(*swappableState)
+}
+
+// Returns the store currently being served
+func (w *SwappableStore) Load() *RangeStore {
+	return w.state().store
+}
+
+// Replaces the store being served, returning the previous one. Lookups already
+// in progress finish against the previous store. The new store has no version
+// metadata besides its generation; see SwapVersioned.
+func (w *SwappableStore) Swap(s *RangeStore) *RangeStore {
+	return w.SwapVersioned(s, StoreVersion{})
+}
+
+// Same as Swap, recording v as the version of the new store. The generation of
+// v is ignored, and set to one more than that of the previous store.
+func (w *SwappableStore) SwapVersioned(s *RangeStore, v StoreVersion) *RangeStore {
+	w.mu.Lock()
+	old := w.state()
+	v.Generation = old.version.Generation + 1
+	w.current.Store(&swappableState{s, v})
+	listeners := w.listeners
+	w.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(s, v)
+	}
+	return old.store
+}
+
+// Calls fn with the new store and its version after every swap, once lookups
+// are already being served by the new store. fn runs on the goroutine which
+// swapped, so it should return quickly; it may load or swap other stores, but
+// mustn't swap this one.
+func (w *SwappableStore) OnSwap(fn func(s *RangeStore, v StoreVersion)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners[:len(w.listeners):len(w.listeners)], fn)
+}
+
+// Returns the number of times the store has been swapped
+func (w *SwappableStore) Generation() uint64 {
+	return w.state().version.Generation
+}
+
+// Returns the version of the store currently being served
+func (w *SwappableStore) Version() StoreVersion {
+	return w.state().version
+}
+
+// Searches the current store, also returning the version of the store which
+// answered, even if the search fails
+func (w *SwappableStore) RangeSearchVersioned(val uint64) (interface{}, StoreVersion, error) {
+	st := w.state()
+	v, err := st.store.RangeSearch(val)
+	return v, st.version, err
+}
+
+// Searches the current store
+func (w *SwappableStore) RangeSearch(val uint64) (interface{}, error) {
+	return w.Load().RangeSearch(val)
+}
+
+// Returns the ranges of the current store
+func (w *SwappableStore) Ranges() []RangeEntry {
+	return w.Load().Ranges()
+}
+
+// Returns the number of ranges in the current store
+func (w *SwappableStore) Len() int {
+	return w.Load().Len()
+}
+
+// Returns the bounds of the current store
+func (w *SwappableStore) Bounds() (min, max uint64) {
+	return w.Load().Bounds()
+}
@@ -0,0 +1,113 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * swappable_test.go: Tests on swappable stores
+ */
+
+package rangestore
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSwappableStore_Swap(t *testing.T) {
+	a, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "A"}})
+	b, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 19, "B"}})
+
+	s := NewSwappableStore(a)
+	if v, err := s.RangeSearch(5); err != nil || v != "A" {
+		t.Fatalf("Wrong value before swapping")
+	}
+	if _, err := s.RangeSearch(15); err == nil {
+		t.Fatalf("Expected a miss before swapping")
+	}
+	if old := s.Swap(b); old != a {
+		t.Fatalf("Swap didn't return the previous store")
+	}
+	if v, err := s.RangeSearch(15); err != nil || v != "B" {
+		t.Fatalf("Wrong value after swapping")
+	}
+	if s.Load() != b || s.Len() != 1 || len(s.Ranges()) != 1 {
+		t.Fatalf("Wrong store after swapping")
+	}
+	if min, max := s.Bounds(); min != 0 || max != 19 {
+		t.Fatalf("Wrong bounds after swapping: %d, %d", min, max)
+	}
+}
+
+func TestSwappableStore_Concurrent(t *testing.T) {
+	a, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "A"}})
+	b, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "B"}})
+	s := NewSwappableStore(a)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j += 1 {
+				v, err := s.RangeSearch(5)
+				if err != nil || (v != "A" && v != "B") {
+					t.Errorf("Wrong value while swapping: %v", v)
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 100; j += 1 {
+		if j%2 == 0 {
+			s.Swap(b)
+		} else {
+			s.Swap(a)
+		}
+	}
+	wg.Wait()
+}
+
+func TestSwappableStore_Versioned(t *testing.T) {
+	a, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "A"}})
+	b, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 9, "B"}})
+	built := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	s := NewVersionedSwappableStore(a, StoreVersion{Generation: 7, Source: "a.csv", BuiltAt: built})
+	v, version, err := s.RangeSearchVersioned(5)
+	if err != nil || v != "A" || version.Source != "a.csv" || !version.BuiltAt.Equal(built) || version.Generation != 0 {
+		t.Fatalf("Wrong result before swapping: %v, %+v, %v", v, version, err)
+	}
+
+	s.SwapVersioned(b, StoreVersion{Source: "b.csv", Meta: map[string]string{"commit": "abc"}})
+	v, version, err = s.RangeSearchVersioned(5)
+	if err != nil || v != "B" || version.Source != "b.csv" || version.Meta["commit"] != "abc" || version.Generation != 1 {
+		t.Fatalf("Wrong result after swapping: %v, %+v, %v", v, version, err)
+	}
+	// A failed search still reports the version which answered it
+	if _, version, err := s.RangeSearchVersioned(50); err == nil || version.Generation != 1 {
+		t.Fatalf("Wrong result for a miss: %+v, %v", version, err)
+	}
+
+	s.Swap(a)
+	if version := s.Version(); version.Generation != 2 || version.Source != "" || s.Generation() != 2 {
+		t.Fatalf("Wrong version after an unversioned swap: %+v", version)
+	}
+}
@@ -0,0 +1,123 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * tagged.go: Ranges carrying metadata tags
+ */
+
+package rangestore
+
+// A range carrying tags, such as the file it was imported from, which describe
+// the range rather than being part of its value
+type Tagged interface {
+	Ranged
+	GetTags() map[string]string
+}
+
+// A range with its value and tags. Implements Tagged.
+type TaggedEntry struct {
+	Min, Max uint64
+	Value    interface{}
+	Tags     map[string]string
+}
+
+func (e TaggedEntry) GetMin() uint64 {
+	return e.Min
+}
+
+func (e TaggedEntry) GetMax() uint64 {
+	return e.Max
+}
+
+func (e TaggedEntry) GetValue() interface{} {
+	return e.Value
+}
+
+func (e TaggedEntry) GetTags() map[string]string {
+	return e.Tags
+}
+
+// A store whose ranges carry tags alongside their values. Plain searches return
+// just the value, so tags can be attached without changing the value payload.
+type TaggedStore struct {
+	root *Node
+}
+
+// Wraps each stored value with its tags
+type taggedValue struct {
+	value interface{}
+	tags  map[string]string
+}
+
+// Creates a tagged store from the items, which must be sorted and not overlap,
+// but may leave gaps. Items implementing Tagged keep their tags; any others
+// have none.
+func NewTaggedStore(items []Ranged) (*TaggedStore, error) {
+	wrapped := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		tv := &taggedValue{value: item.GetValue()}
+		if t, ok := item.(Tagged); ok {
+			tv.tags = t.GetTags()
+		}
+		wrapped = append(wrapped, RangeEntry{item.GetMin(), item.GetMax(), tv})
+	}
+	n, err := NewSparseRangeStoreFromSorted(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return &TaggedStore{root: n}, nil
+}
+
+// Returns the value of the range containing the key
+func (s *TaggedStore) RangeSearch(val uint64) (interface{}, error) {
+	v, _, err := s.RangeSearchWithMeta(val)
+	return v, err
+}
+
+// Returns the value and the tags of the range containing the key. The tags are
+// shared with the store and must not be modified.
+func (s *TaggedStore) RangeSearchWithMeta(val uint64) (interface{}, map[string]string, error) {
+	v, err := s.root.RangeSearch(val)
+	if err != nil {
+		return nil, nil, err
+	}
+	tv := v.(*taggedValue)
+	return tv.value, tv.tags, nil
+}
+
+// Returns the ranges tagged with key set to value, in ascending order
+func (s *TaggedStore) RangesByTag(key, value string) []TaggedEntry {
+	ret := make([]TaggedEntry, 0)
+	s.root.walk(func(c *Node) {
+		tv := c.value.(*taggedValue)
+		if v, ok := tv.tags[key]; ok && v == value {
+			ret = append(ret, TaggedEntry{c.min, c.max, tv.value, tv.tags})
+		}
+	})
+	return ret
+}
+
+// Returns a copy of the store without the tags, with the same shape
+func (s *TaggedStore) Untagged() *Node {
+	return s.root.CloneFunc(func(v interface{}) interface{} {
+		return v.(*taggedValue).value
+	})
+}
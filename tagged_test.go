@@ -0,0 +1,83 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * tagged_test.go: Tests on tagged stores
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTaggedStore_Search(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, TaggedEntry{0, 9, "A", map[string]string{"source": "a.csv"}})
+	items = append(items, DefaultRangedValue{10, 19, "B"})
+	items = append(items, TaggedEntry{30, 39, "C", map[string]string{"source": "b.csv", "date": "2018-01-01"}})
+
+	s, err := NewTaggedStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing tagged store: %s", err.Error())
+	}
+	v, tags, err := s.RangeSearchWithMeta(35)
+	if err != nil {
+		t.Fatalf("Got an error while searching: %s", err.Error())
+	}
+	if v != "C" || tags["source"] != "b.csv" || tags["date"] != "2018-01-01" {
+		t.Fatalf("Wrong value or tags: %v, %v", v, tags)
+	}
+	v, tags, err = s.RangeSearchWithMeta(15)
+	if err != nil || v != "B" || tags != nil {
+		t.Fatalf("Expected an untagged value: %v, %v", v, tags)
+	}
+	if v, err := s.RangeSearch(5); err != nil || v != "A" {
+		t.Fatalf("Wrong value from a plain search: %v", v)
+	}
+	_, _, err = s.RangeSearchWithMeta(25)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+}
+
+func TestTaggedStore_RangesByTag(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, TaggedEntry{0, 9, "A", map[string]string{"source": "a.csv"}})
+	items = append(items, TaggedEntry{10, 19, "B", map[string]string{"source": "b.csv"}})
+	items = append(items, TaggedEntry{20, 29, "C", map[string]string{"source": "a.csv"}})
+	s, _ := NewTaggedStore(items)
+
+	got := s.RangesByTag("source", "a.csv")
+	if len(got) != 2 || got[0].Value != "A" || got[1].Value != "C" {
+		t.Fatalf("Wrong ranges by tag: %v", got)
+	}
+	if got := s.RangesByTag("source", "c.csv"); len(got) != 0 {
+		t.Fatalf("Expected no ranges: %v", got)
+	}
+
+	plain := s.Untagged()
+	expected := []RangeEntry{{0, 9, "A"}, {10, 19, "B"}, {20, 29, "C"}}
+	if got := plain.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong untagged ranges: %v", got)
+	}
+}
@@ -0,0 +1,231 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * text.go: Text encoding of stores for configuration files
+ */
+
+package rangestore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Encodes the store as one line per range, in ascending key order, of the form
+//
+//	min-max=value
+//
+// so that stores can be embedded in configuration formats which use
+// encoding.TextMarshaler, such as JSON, TOML and YAML. Values must be strings,
+// byte slices, or implement encoding.TextMarshaler, and otherwise give an
+// ErrUnserializableValue. Values with line breaks, or leading or trailing
+// spaces or quotes, are written as quoted Go string literals.
+func (n *Node) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		var value string
+		if value, err = textValue(c.value); err != nil {
+			return
+		}
+		fmt.Fprintf(&buf, "%d-%d=%s\n", c.min, c.max, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Formats a value as MarshalText writes it
+func textValue(v interface{}) (string, error) {
+	var value string
+	switch x := v.(type) {
+	case string:
+		value = x
+	case []byte:
+		value = string(x)
+	case encoding.TextMarshaler:
+		b, err := x.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		value = string(b)
+	default:
+		return "", ErrUnserializableValue{v}
+	}
+	if strings.ContainsAny(value, "\r\n") || strings.TrimSpace(value) != value || strings.HasPrefix(value, `"`) {
+		value = strconv.Quote(value)
+	}
+	return value, nil
+}
+
+// Replaces the store with the ranges in text written by MarshalText, or by hand.
+// The values are read as strings. A range of a single key may be written as
+// key=value, and blank lines and lines starting with # are skipped. The ranges
+// may be in any order, and may leave gaps. Returns an ErrMalformedRecord for
+// the first line which can't be parsed, or the usual errors if ranges overlap.
+// The store must not be in use while it's replaced, and a nil store gives an
+// ErrEmptyStore, as there's nothing to replace.
+func (n *Node) UnmarshalText(text []byte) error {
+	if n == nil {
+		return ErrEmptyStore{}
+	}
+	items := make([]Ranged, 0)
+	scanner := newTextScanner(text)
+	for line := 1; scanner.Scan(); line += 1 {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" || s[0] == '#' {
+			continue
+		}
+		e, err := parseTextRange(s)
+		if err != nil {
+			return ErrMalformedRecord{line, err.Error()}
+		}
+		items = append(items, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	SortRanged(items)
+	built, err := rangeStoreFromSortedChecked(items, true, true)
+	if err != nil {
+		return err
+	}
+	*n = *built
+	return nil
+}
+
+// Returns a scanner over the lines of text. The whole text is already in memory,
+// so lines may be as long as the text rather than bufio's default limit.
+func newTextScanner(text []byte) *bufio.Scanner {
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+	scanner.Buffer(nil, len(text)+1)
+	return scanner
+}
+
+func parseTextRange(s string) (RangeEntry, error) {
+	var e RangeEntry
+	eq := strings.IndexByte(s, '=')
+	if eq < 0 {
+		return e, fmt.Errorf("missing '=' in %q", s)
+	}
+	keys := strings.TrimSpace(s[:eq])
+	lo, hi := keys, keys
+	if dash := strings.IndexByte(keys, '-'); dash >= 0 {
+		lo, hi = strings.TrimSpace(keys[:dash]), strings.TrimSpace(keys[dash+1:])
+	}
+	var err error
+	if e.Min, err = strconv.ParseUint(lo, 0, 64); err != nil {
+		return e, fmt.Errorf("bad minimum %q", lo)
+	}
+	if e.Max, err = strconv.ParseUint(hi, 0, 64); err != nil {
+		return e, fmt.Errorf("bad maximum %q", hi)
+	}
+	if e.Min > e.Max {
+		return e, ErrInvertedRange{e.Min, e.Max}
+	}
+	if e.Value, err = parseTextValue(s[eq+1:]); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// Reads a value written by textValue
+func parseTextValue(s string) (string, error) {
+	value := strings.TrimSpace(s)
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("bad quoted value %s", s)
+		}
+		return unquoted, nil
+	}
+	return value, nil
+}
+
+// Encodes the items of a weighted store as one line per item, in order, of the
+// form
+//
+//	weight=value
+//
+// which, unlike MarshalText of the root, keeps the weights as they were given,
+// so that they can be edited and read back with UnmarshalWeighted into the same
+// ranges. Values follow the same rules as for MarshalText. Whether the keys
+// start from 0 isn't recorded, so WithZeroBasedWeights needs passing to
+// UnmarshalWeighted again.
+func (w *WeightedStore) MarshalWeighted() ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	w.root.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		var value string
+		if value, err = textValue(c.value); err != nil {
+			return
+		}
+		fmt.Fprintf(&buf, "%d=%s\n", c.max-c.min+1, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Builds a weighted store from text written by MarshalWeighted, or by hand, with
+// the values read as strings. Blank lines and lines starting with # are skipped.
+// Returns an ErrMalformedRecord for the first line which can't be parsed, or the
+// errors of NewWeightedStore.
+func UnmarshalWeighted(text []byte, opts ...Option) (*WeightedStore, error) {
+	items := make([]Weighted, 0)
+	scanner := newTextScanner(text)
+	for line := 1; scanner.Scan(); line += 1 {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" || s[0] == '#' {
+			continue
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return nil, ErrMalformedRecord{line, fmt.Sprintf("missing '=' in %q", s)}
+		}
+		weight, err := strconv.ParseUint(strings.TrimSpace(s[:eq]), 0, 64)
+		if err != nil {
+			return nil, ErrMalformedRecord{line, fmt.Sprintf("bad weight %q", strings.TrimSpace(s[:eq]))}
+		}
+		value, err := parseTextValue(s[eq+1:])
+		if err != nil {
+			return nil, ErrMalformedRecord{line, err.Error()}
+		}
+		items = append(items, DefaultWeightedValue{weight, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewWeightedStore(items, opts...)
+}
@@ -0,0 +1,173 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * text_test.go: Tests on the text encoding
+ */
+
+package rangestore
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNode_MarshalText(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, "A"})
+	items = append(items, DefaultRangedValue{10, 10, []byte("two\nlines")})
+	items = append(items, DefaultRangedValue{20, 29, net.ParseIP("10.0.0.1")})
+	items = append(items, DefaultRangedValue{30, 39, " padded"})
+	n, err := NewSparseRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	text, err := n.MarshalText()
+	if err != nil {
+		t.Fatalf("Error while encoding store: %s", err.Error())
+	}
+	expected := "0-9=A\n10-10=\"two\\nlines\"\n20-29=10.0.0.1\n30-39=\" padded\"\n"
+	if string(text) != expected {
+		t.Fatalf("Wrong text:\n%s", text)
+	}
+
+	var back Node
+	if err := back.UnmarshalText(text); err != nil {
+		t.Fatalf("Error while decoding store: %s", err.Error())
+	}
+	want := []RangeEntry{{0, 9, "A"}, {10, 10, "two\nlines"}, {20, 29, "10.0.0.1"}, {30, 39, " padded"}}
+	if !reflect.DeepEqual(back.Ranges(), want) {
+		t.Fatalf("Wrong ranges: %v", back.Ranges())
+	}
+
+	items = append(items, DefaultRangedValue{40, 49, 5})
+	n, _ = NewSparseRangeStoreFromSorted(items)
+	if _, err := n.MarshalText(); reflect.TypeOf(err).Name() != "ErrUnserializableValue" {
+		t.Fatalf("Expected an ErrUnserializableValue, got %v", err)
+	}
+}
+
+func TestNode_UnmarshalText(t *testing.T) {
+	var n Node
+	text := "# ports\n\n80 = http\n0x1bb=https\n  6000-6063=x11  \n"
+	if err := n.UnmarshalText([]byte(text)); err != nil {
+		t.Fatalf("Error while decoding store: %s", err.Error())
+	}
+	want := []RangeEntry{{80, 80, "http"}, {443, 443, "https"}, {6000, 6063, "x11"}}
+	if !reflect.DeepEqual(n.Ranges(), want) {
+		t.Fatalf("Wrong ranges: %v", n.Ranges())
+	}
+
+	for _, bad := range []string{"5", "a-9=x", "9-5=x", "1-2=\"open"} {
+		if err := n.UnmarshalText([]byte(bad)); reflect.TypeOf(err).Name() != "ErrMalformedRecord" {
+			t.Fatalf("Expected an ErrMalformedRecord for %q, got %v", bad, err)
+		}
+	}
+	if err := n.UnmarshalText([]byte("1-5=a\n3-9=b\n")); reflect.TypeOf(err).Name() != "ErrOverlap" {
+		t.Fatalf("Expected an ErrOverlap, got %v", err)
+	}
+	// A failed decode leaves the store as it was
+	if len(n.Ranges()) != 3 {
+		t.Fatalf("Store was modified by a failed decode")
+	}
+}
+
+func TestNode_UnmarshalTextLongLine(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	var n Node
+	if err := n.UnmarshalText([]byte("1-5=" + long + "\n")); err != nil {
+		t.Fatalf("Error while decoding a long line: %s", err.Error())
+	}
+	if v, err := n.RangeSearch(3); err != nil || v != long {
+		t.Fatalf("Wrong value for a long line")
+	}
+	if _, err := UnmarshalWeighted([]byte("5=" + long)); err != nil {
+		t.Fatalf("Error while decoding a long weighted line: %s", err.Error())
+	}
+
+	var nilStore *Node
+	if err := nilStore.UnmarshalText([]byte("1-5=a")); reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+		t.Fatalf("Expected an ErrEmptyStore for a nil store, got %v", err)
+	}
+}
+
+func TestNode_TextInJSON(t *testing.T) {
+	var config struct {
+		Table *Node
+	}
+	if err := json.Unmarshal([]byte(`{"Table": "0-9=low\n10-99=high\n"}`), &config); err != nil {
+		t.Fatalf("Error while decoding configuration: %s", err.Error())
+	}
+	if v, err := config.Table.RangeSearch(50); err != nil || v != "high" {
+		t.Fatalf("Wrong value: %v, %v", v, err)
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Error while encoding configuration: %s", err.Error())
+	}
+	if string(b) != `{"Table":"0-9=low\n10-99=high\n"}` {
+		t.Fatalf("Wrong configuration: %s", b)
+	}
+}
+
+func TestWeightedStore_MarshalWeighted(t *testing.T) {
+	w := testWeightedStore(t, WithZeroBasedWeights())
+	text, err := w.MarshalWeighted()
+	if err != nil {
+		t.Fatalf("Error while marshalling: %s", err.Error())
+	}
+	if string(text) != "25=A\n70=B\n5=C\n" {
+		t.Fatalf("Wrong text: %q", text)
+	}
+
+	// The ranges come back the same
+	restored, err := UnmarshalWeighted(text, WithZeroBasedWeights())
+	if err != nil {
+		t.Fatalf("Error while unmarshalling: %s", err.Error())
+	}
+	if !reflect.DeepEqual(restored.Root().Ranges(), w.Root().Ranges()) || restored.Total() != 100 {
+		t.Fatalf("Wrong ranges after the round trip: %v", restored.Root().Ranges())
+	}
+
+	// Edited by hand
+	restored, err = UnmarshalWeighted([]byte("# upstreams\n1 = \" a \"\n\n0x3=b\n"))
+	if err != nil {
+		t.Fatalf("Error while unmarshalling: %s", err.Error())
+	}
+	if v, _ := restored.RangeSearch(1); v != " a " {
+		t.Fatalf("Wrong quoted value: %q", v)
+	}
+	if v, _ := restored.RangeSearch(4); v != "b" || restored.Total() != 4 {
+		t.Fatalf("Wrong value for a hexadecimal weight: %v", v)
+	}
+
+	for _, bad := range []string{"A", "x=A", "-1=A", "1=\"A"} {
+		if _, err := UnmarshalWeighted([]byte(bad)); reflect.TypeOf(err).Name() != "ErrMalformedRecord" {
+			t.Fatalf("Expected an ErrMalformedRecord for %q, got %v", bad, err)
+		}
+	}
+	if _, err := UnmarshalWeighted([]byte("0=A")); reflect.TypeOf(err).Name() != "ErrZeroWeight" {
+		t.Fatalf("Expected an ErrZeroWeight, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * transform.go: Key and value transformations
+ */
+
+package rangestore
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type ErrShiftOverflow struct {
+	key   uint64
+	delta int64
+}
+
+func (ex ErrShiftOverflow) Error() string {
+	return fmt.Sprintf("Shifting %d by %d leaves the key space", ex.key, ex.delta)
+}
+
+// Returns the key which would leave the key space
+func (ex ErrShiftOverflow) Key() uint64 {
+	return ex.key
+}
+
+// Returns the shift which was applied
+func (ex ErrShiftOverflow) Delta() int64 {
+	return ex.delta
+}
+
+func (ex ErrShiftOverflow) Details() ErrorDetails {
+	return ErrorDetails{"ErrShiftOverflow", map[string]interface{}{"key": ex.key, "delta": ex.delta}}
+}
+
+type ErrInvalidScale struct {
+	num, den uint64
+}
+
+func (ex ErrInvalidScale) Error() string {
+	return fmt.Sprintf("Invalid scale factor %d/%d", ex.num, ex.den)
+}
+
+// Returns the scale factor as a fraction
+func (ex ErrInvalidScale) Factor() (num, den uint64) {
+	return ex.num, ex.den
+}
+
+func (ex ErrInvalidScale) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidScale", map[string]interface{}{"num": ex.num, "den": ex.den}}
+}
+
+type ErrScaleOverflow struct {
+	key, num, den uint64
+}
+
+func (ex ErrScaleOverflow) Error() string {
+	return fmt.Sprintf("Scaling %d by %d/%d leaves the key space", ex.key, ex.num, ex.den)
+}
+
+// Returns the key which would leave the key space
+func (ex ErrScaleOverflow) Key() uint64 {
+	return ex.key
+}
+
+// Returns the scale factor as a fraction
+func (ex ErrScaleOverflow) Factor() (num, den uint64) {
+	return ex.num, ex.den
+}
+
+func (ex ErrScaleOverflow) Details() ErrorDetails {
+	return ErrorDetails{"ErrScaleOverflow", map[string]interface{}{"key": ex.key, "num": ex.num, "den": ex.den}}
+}
+
+// Returns a new store with every range moved by delta keys. Returns an
+// ErrShiftOverflow if any key would move outside of the uint64 key space.
+func (n *Node) Shift(delta int64) (*Node, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	items := make([]Ranged, 0)
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		min, ok := shiftKey(c.min, delta)
+		if !ok {
+			err = ErrShiftOverflow{c.min, delta}
+			return
+		}
+		max, ok := shiftKey(c.max, delta)
+		if !ok {
+			err = ErrShiftOverflow{c.max, delta}
+			return
+		}
+		items = append(items, RangeEntry{min, max, c.value})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rangeStoreFromSortedChecked(items, false, true)
+}
+
+func shiftKey(k uint64, delta int64) (uint64, bool) {
+	if delta >= 0 {
+		r := k + uint64(delta)
+		return r, r >= k
+	}
+	d := uint64(-(delta + 1)) + 1
+	return k - d, k >= d
+}
+
+// Returns a new store with the key space scaled by num/den. Each range [min, max]
+// becomes [min*num/den, (max+1)*num/den - 1], rounding down, so continuous stores
+// stay continuous. When scaling down, ranges which end up narrower than a single
+// key are dropped. Returns an ErrInvalidScale if num or den is zero, and an
+// ErrScaleOverflow if any key would end up beyond the key space.
+func (n *Node) Scale(num, den uint64) (*Node, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	if num == 0 || den == 0 {
+		return nil, ErrInvalidScale{num, den}
+	}
+	bnum := new(big.Int).SetUint64(num)
+	bden := new(big.Int).SetUint64(den)
+	limit := new(big.Int).Lsh(big.NewInt(1), 64)
+	one := big.NewInt(1)
+
+	items := make([]Ranged, 0)
+	var err error
+	n.walk(func(c *Node) {
+		if err != nil {
+			return
+		}
+		// Scale the half open interval [min, max+1)
+		start := new(big.Int).SetUint64(c.min)
+		start.Mul(start, bnum).Quo(start, bden)
+		end := new(big.Int).SetUint64(c.max)
+		end.Add(end, one).Mul(end, bnum).Quo(end, bden)
+		if end.Cmp(limit) > 0 {
+			err = ErrScaleOverflow{c.max, num, den}
+			return
+		}
+		if end.Cmp(start) <= 0 {
+			return
+		}
+		end.Sub(end, one)
+		items = append(items, RangeEntry{start.Uint64(), end.Uint64(), c.value})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rangeStoreFromSortedChecked(items, false, true)
+}
+
+// Returns a new store with the same ranges, and the same shape, with every value
+// replaced by the result of f
+func (n *Node) MapValues(f func(v interface{}) interface{}) *Node {
+	return n.CloneFunc(f)
+}
+
+// Returns a new store holding only the ranges for which keep returns true. The
+// result is sparse wherever ranges were dropped. Returns ErrEmptyInput if no
+// range is kept.
+func (n *Node) FilterRanges(keep func(min, max uint64, v interface{}) bool) (*Node, error) {
+	if n == nil {
+		return nil, ErrEmptyStore{}
+	}
+	items := make([]Ranged, 0)
+	n.walk(func(c *Node) {
+		if keep(c.min, c.max, c.value) {
+			items = append(items, RangeEntry{c.min, c.max, c.value})
+		}
+	})
+	return rangeStoreFromSortedChecked(items, false, true)
+}
@@ -0,0 +1,163 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * transform_test.go: Tests on key transformations
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNode_Shift(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{10, 19, "A"},
+		DefaultRangedValue{20, 29, "B"},
+	})
+
+	up, err := n.Shift(1000)
+	if err != nil {
+		t.Fatalf("Error while shifting: %s", err.Error())
+	}
+	if got := up.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{1010, 1019, "A"}, {1020, 1029, "B"}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	down, err := n.Shift(-10)
+	if err != nil {
+		t.Fatalf("Error while shifting: %s", err.Error())
+	}
+	if got := down.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{0, 9, "A"}, {10, 19, "B"}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	_, err = n.Shift(-11)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrShiftOverflow{}).Name() {
+		t.Fatalf("Expecting an ErrShiftOverflow, but got something else")
+	}
+	if msg := err.Error(); msg != "Shifting 10 by -11 leaves the key space" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+	_, err = n.Shift(math.MaxInt64)
+	if err != nil {
+		t.Fatalf("Error while shifting: %s", err.Error())
+	}
+	top, _ := NewRangeStoreFromSorted([]Ranged{AtLeast(math.MaxUint64-5, "A")})
+	if _, err = top.Shift(1); err == nil {
+		t.Fatalf("Expected an error shifting past the top of the key space")
+	}
+	if _, err = top.Shift(math.MinInt64); err != nil {
+		t.Fatalf("Error while shifting: %s", err.Error())
+	}
+}
+
+func TestNode_Scale(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 9, "A"},
+		DefaultRangedValue{10, 10, "B"},
+		DefaultRangedValue{11, 19, "C"},
+	})
+
+	up, err := n.Scale(3, 1)
+	if err != nil {
+		t.Fatalf("Error while scaling: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 29, "A"}, {30, 32, "B"}, {33, 59, "C"}}
+	if got := up.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	// B is too narrow to survive halving
+	down, err := n.Scale(1, 2)
+	if err != nil {
+		t.Fatalf("Error while scaling: %s", err.Error())
+	}
+	expected = []RangeEntry{{0, 4, "A"}, {5, 9, "C"}}
+	if got := down.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	for _, f := range [][2]uint64{{1, 0}, {0, 1}} {
+		_, err = n.Scale(f[0], f[1])
+		if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrInvalidScale{}).Name() {
+			t.Fatalf("Expecting an ErrInvalidScale for %d/%d, but got something else", f[0], f[1])
+		}
+	}
+	_, err = n.Scale(math.MaxUint64, 1)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrScaleOverflow{}).Name() {
+		t.Fatalf("Expecting an ErrScaleOverflow, but got something else")
+	}
+	if num, den := err.(ErrScaleOverflow).Factor(); num != math.MaxUint64 || den != 1 {
+		t.Fatalf("Wrong factor in the overflow: %d/%d", num, den)
+	}
+	full, _ := NewRangeStoreFromSorted([]Ranged{AtLeast(0, "A")})
+	if _, err := full.Scale(1, 1); err != nil {
+		t.Fatalf("Error while scaling the whole key space: %s", err.Error())
+	}
+}
+
+func TestNode_MapValues(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 9, 1},
+		DefaultRangedValue{10, 19, 2},
+	})
+
+	m := n.MapValues(func(v interface{}) interface{} {
+		return v.(int) * 10
+	})
+	if got := m.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{0, 9, 10}, {10, 19, 20}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{0, 9, 1}, {10, 19, 2}}) {
+		t.Fatalf("Original store was modified: %v", got)
+	}
+}
+
+func TestNode_FilterRanges(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted([]Ranged{
+		DefaultRangedValue{0, 9, "keep"},
+		DefaultRangedValue{10, 19, "drop"},
+		DefaultRangedValue{20, 29, "keep"},
+	})
+
+	f, err := n.FilterRanges(func(min, max uint64, v interface{}) bool {
+		return v == "keep"
+	})
+	if err != nil {
+		t.Fatalf("Error while filtering: %s", err.Error())
+	}
+	if got := f.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{0, 9, "keep"}, {20, 29, "keep"}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+	if _, err := f.RangeSearch(15); err == nil {
+		t.Fatalf("Expected a miss in the dropped range")
+	}
+
+	_, err = n.FilterRanges(func(min, max uint64, v interface{}) bool {
+		return false
+	})
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
+}
@@ -0,0 +1,92 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * traverse.go: Traversals of the tree for external tooling
+ */
+
+package rangestore
+
+// Called for each node visited by a traversal, along with its depth, counting
+// the root as 1. Returning false stops the traversal.
+type Visitor func(n *Node, depth int) bool
+
+// Visits each node before its subtrees, left before right, which is the order
+// needed to print or serialize the shape of the tree
+func (n *Node) WalkPreOrder(visit Visitor) {
+	n.preOrder(visit, 1)
+}
+
+func (n *Node) preOrder(visit Visitor, depth int) bool {
+	if n == nil {
+		return true
+	}
+	return visit(n, depth) && n.left.preOrder(visit, depth+1) && n.right.preOrder(visit, depth+1)
+}
+
+// Visits the nodes in ascending key order
+func (n *Node) WalkInOrder(visit Visitor) {
+	n.inOrder(visit, 1)
+}
+
+func (n *Node) inOrder(visit Visitor, depth int) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.inOrder(visit, depth+1) && visit(n, depth) && n.right.inOrder(visit, depth+1)
+}
+
+// Visits each node after its subtrees, left before right, so that the results
+// for the children are ready when their parent is visited
+func (n *Node) WalkPostOrder(visit Visitor) {
+	n.postOrder(visit, 1)
+}
+
+func (n *Node) postOrder(visit Visitor, depth int) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.postOrder(visit, depth+1) && n.right.postOrder(visit, depth+1) && visit(n, depth)
+}
+
+// Visits the nodes level by level, from the root down and left to right within
+// each level
+func (n *Node) WalkBFS(visit Visitor) {
+	if n == nil {
+		return
+	}
+	level := []*Node{n}
+	for depth := 1; len(level) > 0; depth += 1 {
+		next := make([]*Node, 0, 2*len(level))
+		for _, c := range level {
+			if !visit(c, depth) {
+				return
+			}
+			if c.left != nil {
+				next = append(next, c.left)
+			}
+			if c.right != nil {
+				next = append(next, c.right)
+			}
+		}
+		level = next
+	}
+}
@@ -0,0 +1,85 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * traverse_test.go: Tests for the tree traversals
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_Walk(t *testing.T) {
+	items := make([]Ranged, 0)
+	for i, v := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		items = append(items, DefaultRangedValue{uint64(i * 10), uint64(i*10 + 9), v})
+	}
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	// Equal widths give a perfectly balanced tree
+	order := func(walk func(Visitor)) (string, []int) {
+		values, depths := "", make([]int, 0)
+		walk(func(c *Node, depth int) bool {
+			values += c.value.(string)
+			depths = append(depths, depth)
+			return true
+		})
+		return values, depths
+	}
+	for name, expected := range map[string]struct {
+		walk   func(Visitor)
+		values string
+		depths []int
+	}{
+		"pre":  {n.WalkPreOrder, "DBACFEG", []int{1, 2, 3, 3, 2, 3, 3}},
+		"in":   {n.WalkInOrder, "ABCDEFG", []int{3, 2, 3, 1, 3, 2, 3}},
+		"post": {n.WalkPostOrder, "ACBEGFD", []int{3, 3, 2, 3, 3, 2, 1}},
+		"bfs":  {n.WalkBFS, "DBFACEG", []int{1, 2, 2, 3, 3, 3, 3}},
+	} {
+		values, depths := order(expected.walk)
+		if values != expected.values || !reflect.DeepEqual(depths, expected.depths) {
+			t.Fatalf("Wrong %s-order traversal: %s %v", name, values, depths)
+		}
+	}
+
+	// Returning false stops each of the traversals
+	for _, walk := range []func(Visitor){n.WalkPreOrder, n.WalkInOrder, n.WalkPostOrder, n.WalkBFS} {
+		count := 0
+		walk(func(c *Node, depth int) bool {
+			count += 1
+			return count < 3
+		})
+		if count != 3 {
+			t.Fatalf("Expected the traversal to stop after 3 nodes, visited %d", count)
+		}
+	}
+
+	var empty *Node
+	empty.WalkBFS(func(c *Node, depth int) bool {
+		t.Fatalf("Expected no nodes to be visited")
+		return true
+	})
+}
@@ -0,0 +1,193 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * ttl.go: Ranges which expire
+ */
+
+package rangestore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A range which stops matching at Expires. A zero Expires never expires.
+type ExpiringEntry struct {
+	Min, Max uint64
+	Value    interface{}
+	Expires  time.Time
+}
+
+func (e ExpiringEntry) GetMin() uint64 {
+	return e.Min
+}
+
+func (e ExpiringEntry) GetMax() uint64 {
+	return e.Max
+}
+
+func (e ExpiringEntry) GetValue() interface{} {
+	return e.Value
+}
+
+// A store whose ranges expire, for things like dynamic blocklists. Searches
+// ignore expired ranges straight away, while the memory they take up is only
+// reclaimed by Compact, either called by hand or periodically through
+// CompactEvery.
+//
+// TTLStore is safe for concurrent use.
+type TTLStore struct {
+	root atomic.Value
+	mu   sync.Mutex
+	now  func() time.Time
+	// Close the stop channel of the background compaction to end it, which
+	// then closes done
+	stop, done chan struct{}
+}
+
+// Wraps each stored value with its expiry
+type ttlValue struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Creates a store from the items, which must be sorted and not overlap, but may
+// leave gaps
+func NewTTLStore(items []ExpiringEntry) (*TTLStore, error) {
+	wrapped := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		wrapped = append(wrapped, RangeEntry{item.Min, item.Max, &ttlValue{item.Value, item.Expires}})
+	}
+	n, err := NewSparseRangeStoreFromSorted(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	s := &TTLStore{now: time.Now}
+	s.root.Store(n)
+	return s, nil
+}
+
+func (v *ttlValue) expired(now time.Time) bool {
+	return !v.expires.IsZero() && !now.Before(v.expires)
+}
+
+// Returns the value of the range containing the key, or ErrOutOfRange if there
+// is no such range or it has expired
+func (s *TTLStore) RangeSearch(val uint64) (interface{}, error) {
+	n := s.root.Load().(*Node)
+	if n == nil {
+		return nil, ErrOutOfRange{val}
+	}
+	v, err := n.RangeSearch(val)
+	if err != nil {
+		return nil, err
+	}
+	tv := v.(*ttlValue)
+	if tv.expired(s.now()) {
+		return nil, ErrOutOfRange{val}
+	}
+	return tv.value, nil
+}
+
+// Returns the ranges which haven't expired yet, in ascending order
+func (s *TTLStore) Ranges() []ExpiringEntry {
+	ret := make([]ExpiringEntry, 0)
+	n := s.root.Load().(*Node)
+	if n == nil {
+		return ret
+	}
+	now := s.now()
+	n.walk(func(c *Node) {
+		if tv := c.value.(*ttlValue); !tv.expired(now) {
+			ret = append(ret, ExpiringEntry{c.min, c.max, tv.value, tv.expires})
+		}
+	})
+	return ret
+}
+
+// Rebuilds the tree without the expired ranges, returning how many were dropped.
+// Searches carry on against the old tree while the new one is built.
+func (s *TTLStore) Compact() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.root.Load().(*Node)
+	if old == nil {
+		return 0
+	}
+	now := s.now()
+	live := make([]Ranged, 0)
+	dropped := 0
+	old.walk(func(c *Node) {
+		if c.value.(*ttlValue).expired(now) {
+			dropped += 1
+			return
+		}
+		live = append(live, RangeEntry{c.min, c.max, c.value})
+	})
+	if dropped == 0 {
+		return 0
+	}
+	// The ranges came from a valid store, so there's no need to check them again.
+	// Should nothing be left, the store is left empty.
+	n, _ := rangeStoreFromSortedChecked(live, false, true)
+	s.root.Store(n)
+	return dropped
+}
+
+// Calls Compact every interval in the background, until the store is closed.
+// Calling it again has no effect until then; once closed, it can be restarted.
+func (s *TTLStore) CompactEvery(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Compact()
+			}
+		}
+	}(s.stop, s.done)
+}
+
+// Stops the background compaction started by CompactEvery, if any. The store
+// can still be searched and compacted by hand. Calling it again has no effect.
+func (s *TTLStore) Close() error {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
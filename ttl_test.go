@@ -0,0 +1,153 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * ttl_test.go: Tests on expiring ranges
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTTLStore_Search(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []ExpiringEntry{
+		{0, 9, "A", start.Add(time.Minute)},
+		{10, 19, "B", time.Time{}},
+		{30, 39, "C", start.Add(time.Hour)},
+	}
+	s, err := NewTTLStore(items)
+	if err != nil {
+		t.Fatalf("Error while constructing TTL store: %s", err.Error())
+	}
+	now := start
+	s.now = func() time.Time { return now }
+
+	if v, err := s.RangeSearch(5); err != nil || v != "A" {
+		t.Fatalf("Wrong value before expiry: %v", v)
+	}
+	now = start.Add(time.Minute)
+	_, err = s.RangeSearch(5)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange for an expired range, but got something else")
+	}
+	if v, err := s.RangeSearch(35); err != nil || v != "C" {
+		t.Fatalf("Wrong value: %v", v)
+	}
+	if got := s.Ranges(); len(got) != 2 || got[0].Value != "B" {
+		t.Fatalf("Wrong live ranges: %v", got)
+	}
+}
+
+func TestTTLStore_Compact(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []ExpiringEntry{
+		{0, 9, "A", start.Add(time.Minute)},
+		{10, 19, "B", time.Time{}},
+		{30, 39, "C", start.Add(time.Hour)},
+	}
+	s, _ := NewTTLStore(items)
+	now := start
+	s.now = func() time.Time { return now }
+
+	if dropped := s.Compact(); dropped != 0 {
+		t.Fatalf("Nothing should have been dropped: %d", dropped)
+	}
+	now = start.Add(time.Minute)
+	if dropped := s.Compact(); dropped != 1 {
+		t.Fatalf("Expected one range to be dropped: %d", dropped)
+	}
+	if s.root.Load().(*Node).Len() != 2 {
+		t.Fatalf("Expected the tree to be rebuilt")
+	}
+	if v, err := s.RangeSearch(15); err != nil || v != "B" {
+		t.Fatalf("Wrong value after compaction: %v", v)
+	}
+
+	now = start.Add(time.Hour)
+	s.Compact()
+	if v, err := s.RangeSearch(15); err != nil || v != "B" {
+		t.Fatalf("Ranges without expiry should survive: %v", v)
+	}
+}
+
+func TestTTLStore_CompactAll(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, _ := NewTTLStore([]ExpiringEntry{{0, 9, "A", start}})
+	s.now = func() time.Time { return start }
+	if dropped := s.Compact(); dropped != 1 {
+		t.Fatalf("Expected one range to be dropped: %d", dropped)
+	}
+	_, err := s.RangeSearch(5)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+	if got := s.Ranges(); len(got) != 0 {
+		t.Fatalf("Expected an empty store: %v", got)
+	}
+	if dropped := s.Compact(); dropped != 0 {
+		t.Fatalf("Nothing should have been dropped: %d", dropped)
+	}
+}
+
+func TestTTLStore_CompactEvery(t *testing.T) {
+	s, _ := NewTTLStore([]ExpiringEntry{
+		{0, 9, "A", time.Now().Add(-time.Second)},
+		{10, 19, "B", time.Time{}},
+	})
+	s.CompactEvery(time.Millisecond)
+	defer s.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.root.Load().(*Node).Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Background compaction didn't happen")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTTLStore_CloseTwice(t *testing.T) {
+	s, _ := NewTTLStore([]ExpiringEntry{{0, 9, "A", time.Time{}}, {10, 19, "B", time.Now().Add(time.Hour)}})
+	s.CompactEvery(time.Millisecond)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Error while closing: %s", err.Error())
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Error while closing again: %s", err.Error())
+	}
+
+	// Compaction can be restarted once closed
+	// Nothing runs in the background any more, so the clock can be moved safely
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	s.CompactEvery(time.Millisecond)
+	defer s.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.root.Load().(*Node).Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Background compaction didn't restart")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,97 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * unbounded_test.go: Tests on open ended ranges
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRangeStoreFromSorted_Unbounded(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, AtMost(99, "low"))
+	items = append(items, AtLeast(100, "high"))
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	for key, expected := range map[uint64]string{0: "low", 99: "low", 100: "high", math.MaxUint64: "high"} {
+		v, err := n.RangeSearch(key)
+		if err != nil {
+			t.Fatalf("Got an error while searching %d: %s", key, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back %s [%s]", v, expected)
+		}
+	}
+	if n.value != "high" {
+		t.Fatalf("Expected the much wider high range at the root")
+	}
+}
+
+func TestRangeStoreFromSorted_UnboundedBalanced(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, AtMost(1<<62-1, "A"))
+	items = append(items, DefaultRangedValue{1 << 62, 1<<63 - 1, "B"})
+	items = append(items, DefaultRangedValue{1 << 63, 1<<63 + 1<<62 - 1, "C"})
+	items = append(items, AtLeast(1<<63+1<<62, "D"))
+
+	n, err := NewRangeStoreFromSorted(items)
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	// Four equal quarters of the key space
+	if n.value != "B" || n.left.value != "A" || n.right.value != "C" || n.right.right.value != "D" {
+		t.Fatalf("Wrong tree shape:\n%s", n.String())
+	}
+	if v, _ := n.RangeSearch(math.MaxUint64); v != "D" {
+		t.Fatalf("Got invalid value back %s [%s]", v, "D")
+	}
+}
+
+func TestRangeStoreFromSorted_BeyondKeySpace(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, AtMost(1<<63, "A"))
+	items = append(items, AtLeast(1<<63, "B"))
+
+	_, err := rangeStoreFromSortedChecked(items, false, false)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrUnsignedIntegerOverflow{}).Name() {
+		t.Fatalf("Expecting an ErrUnsignedIntegerOverflow, but got something else")
+	}
+}
+
+func TestRangeStoreFromSorted_WholeKeySpaceTwice(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, AtLeast(0, "A"))
+	items = append(items, AtLeast(0, "B"))
+
+	_, err := rangeStoreFromSortedChecked(items, false, false)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrUnsignedIntegerOverflow{}).Name() {
+		t.Fatalf("Expecting an ErrUnsignedIntegerOverflow, but got something else")
+	}
+}
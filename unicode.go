@@ -0,0 +1,79 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * unicode.go: Building stores from Unicode range tables
+ */
+
+package rangestore
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Builds a sparse store mapping code points to the names of the tables which
+// contain them, e.g. from unicode.Scripts or unicode.Categories, so that a single
+// lookup answers which script or category a rune belongs to. Entries with a
+// stride greater than 1 become one range per code point, and adjacent ranges
+// from the same table are merged. The tables must not overlap, so e.g. "L" and
+// "Lu" can't be used together; ErrOverlap, or ErrDuplicateRange where both
+// tables hold exactly the same range, is returned if they do.
+func NewRangeStoreFromUnicodeTables(tables map[string]*unicode.RangeTable) (*Node, error) {
+	items := make([]RangeEntry, 0)
+	add := func(lo, hi, stride uint64, name string) {
+		if stride == 1 {
+			items = append(items, RangeEntry{lo, hi, name})
+			return
+		}
+		for cp := lo; cp <= hi; cp += stride {
+			items = append(items, RangeEntry{cp, cp, name})
+		}
+	}
+	for name, table := range tables {
+		for _, r := range table.R16 {
+			add(uint64(r.Lo), uint64(r.Hi), uint64(r.Stride), name)
+		}
+		for _, r := range table.R32 {
+			add(uint64(r.Lo), uint64(r.Hi), uint64(r.Stride), name)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Min != items[j].Min {
+			return items[i].Min < items[j].Min
+		}
+		return items[i].Value.(string) < items[j].Value.(string)
+	})
+
+	merged := make([]Ranged, 0, len(items))
+	for _, item := range items {
+		if last := len(merged) - 1; last >= 0 {
+			prev := merged[last].(RangeEntry)
+			if prev.Value == item.Value && prev.Max+1 == item.Min {
+				prev.Max = item.Max
+				merged[last] = prev
+				continue
+			}
+		}
+		merged = append(merged, item)
+	}
+	return rangeStoreFromSortedChecked(merged, true, true)
+}
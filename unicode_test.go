@@ -0,0 +1,81 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * unicode_test.go: Tests on Unicode range tables
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+func TestNewRangeStoreFromUnicodeTables(t *testing.T) {
+	n, err := NewRangeStoreFromUnicodeTables(unicode.Scripts)
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	for r, expected := range map[rune]string{'a': "Latin", 'Z': "Latin", 'ж': "Cyrillic", 'λ': "Greek", '漢': "Han", 'ا': "Arabic", '1': "Common"} {
+		v, err := n.RangeSearch(uint64(r))
+		if err != nil {
+			t.Fatalf("Got an error while searching %q: %s", r, err.Error())
+		}
+		if v != expected {
+			t.Fatalf("Got invalid value back for %q: %s [%s]", r, v, expected)
+		}
+	}
+	// Every code point in the store agrees with the table it came from
+	n.walk(func(c *Node) {
+		table := unicode.Scripts[c.value.(string)]
+		if !unicode.Is(table, rune(c.min)) || !unicode.Is(table, rune(c.max)) {
+			t.Fatalf("Range [%d, %d] doesn't belong to %s", c.min, c.max, c.value)
+		}
+	})
+
+	_, err = n.RangeSearch(0x10ffff)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrOutOfRange{}).Name() {
+		t.Fatalf("Expecting an ErrOutOfRange, but got something else")
+	}
+}
+
+func TestNewRangeStoreFromUnicodeTables_Stride(t *testing.T) {
+	table := &unicode.RangeTable{
+		R16: []unicode.Range16{{Lo: 0x100, Hi: 0x106, Stride: 2}, {Lo: 0x107, Hi: 0x109, Stride: 1}},
+	}
+	n, err := NewRangeStoreFromUnicodeTables(map[string]*unicode.RangeTable{"T": table})
+	if err != nil {
+		t.Fatalf("Error while building range store: %s", err.Error())
+	}
+	expected := []RangeEntry{{0x100, 0x100, "T"}, {0x102, 0x102, "T"}, {0x104, 0x104, "T"}, {0x106, 0x109, "T"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	_, err = NewRangeStoreFromUnicodeTables(map[string]*unicode.RangeTable{"L": unicode.L, "Lu": unicode.Lu})
+	switch err.(type) {
+	case ErrOverlap, ErrDuplicateRange:
+	default:
+		t.Fatalf("Expecting an ErrOverlap or ErrDuplicateRange, but got %v", err)
+	}
+}
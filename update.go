@@ -0,0 +1,95 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * update.go: In place updates of values over key intervals
+ */
+
+package rangestore
+
+// Replaces the value of every range intersecting [a, b] with the result of f,
+// e.g. to apply a configuration override to a block of keys. Ranges which
+// straddle a or b are split, so that the keys outside of the interval keep
+// their old value; keys which aren't covered stay uncovered. Returns
+// ErrInvertedRange if a is greater than b.
+//
+// When no range needs splitting the values are replaced where they are, which
+// only visits the ranges in the interval and the path to them. Otherwise the
+// tree is rebuilt, balanced by the widths of the ranges, which takes time linear
+// in the size of the store. Either way the root node remains the root of the
+// store. UpdateOver must not be called concurrently with searches.
+func (n *Node) UpdateOver(a, b uint64, f func(v interface{}) interface{}) error {
+	if n == nil {
+		return ErrEmptyStore{}
+	}
+	if a > b {
+		return ErrInvertedRange{a, b}
+	}
+	split := false
+	n.walkBetween(a, b, func(c *Node) {
+		if c.min < a || c.max > b {
+			split = true
+		}
+	})
+	if !split {
+		n.walkBetween(a, b, func(c *Node) {
+			c.value = f(c.value)
+		})
+		return nil
+	}
+
+	items := make([]Ranged, 0)
+	n.walk(func(c *Node) {
+		if c.max < a || c.min > b {
+			items = append(items, RangeEntry{c.min, c.max, c.value})
+			return
+		}
+		inside := RangeEntry{c.min, c.max, f(c.value)}
+		if c.min < a {
+			items = append(items, RangeEntry{c.min, a - 1, c.value})
+			inside.Min = a
+		}
+		if c.max > b {
+			inside.Max = b
+		}
+		items = append(items, inside)
+		if c.max > b {
+			items = append(items, RangeEntry{b + 1, c.max, c.value})
+		}
+	})
+	rebuilt, err := rangeStoreFromSortedChecked(items, false, true)
+	if err != nil {
+		return err
+	}
+	*n = *rebuilt
+	return nil
+}
+
+// Replaces the values over [a, b] as (*Node).UpdateOver does, updating the store
+// metadata and rebuilding the lookup backend
+func (s *RangeStore) UpdateOver(a, b uint64, f func(v interface{}) interface{}) error {
+	if err := s.root.UpdateOver(a, b, f); err != nil {
+		return err
+	}
+	s.count = s.root.Len()
+	s.useBackend()
+	return nil
+}
@@ -0,0 +1,93 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * update_test.go: Tests for in place updates over key intervals
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNode_UpdateOver(t *testing.T) {
+	items := make([]Ranged, 0)
+	items = append(items, DefaultRangedValue{0, 9, 1})
+	items = append(items, DefaultRangedValue{10, 19, 2})
+	items = append(items, DefaultRangedValue{30, 39, 3})
+	n, _ := NewSparseRangeStoreFromSorted(items)
+	root := n
+	double := func(v interface{}) interface{} { return v.(int) * 2 }
+
+	// Whole ranges are updated in place
+	if err := n.UpdateOver(10, 39, double); err != nil {
+		t.Fatalf("Error while updating: %s", err.Error())
+	}
+	if got := n.Ranges(); !reflect.DeepEqual(got, []RangeEntry{{0, 9, 1}, {10, 19, 4}, {30, 39, 6}}) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	// Straddled ranges are split
+	if err := n.UpdateOver(5, 34, double); err != nil {
+		t.Fatalf("Error while updating: %s", err.Error())
+	}
+	want := []RangeEntry{{0, 4, 1}, {5, 9, 2}, {10, 19, 8}, {30, 34, 12}, {35, 39, 6}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Wrong ranges after splitting: %v", got)
+	}
+	if n != root {
+		t.Fatalf("Expected the root to remain the root of the store")
+	}
+	if err := n.CheckInvariants(); err != nil {
+		t.Fatalf("Invariants broken after splitting: %s", err.Error())
+	}
+	if _, err := n.RangeSearch(25); err == nil {
+		t.Fatalf("Expected the gap to stay uncovered")
+	}
+
+	if err := n.UpdateOver(9, 8, double); err == nil || reflect.TypeOf(err).Name() != "ErrInvertedRange" {
+		t.Fatalf("Expected ErrInvertedRange, got %v", err)
+	}
+	var empty *Node
+	if err := empty.UpdateOver(0, 1, double); err == nil || reflect.TypeOf(err).Name() != "ErrEmptyStore" {
+		t.Fatalf("Expected ErrEmptyStore, got %v", err)
+	}
+}
+
+func TestRangeStore_UpdateOver(t *testing.T) {
+	s, _ := NewRangeStore([]Ranged{DefaultRangedValue{0, 99, "default"}}, WithBackend(FlatBackend), WithLookupCache(4))
+	if v, _ := s.RangeSearch(50); v != "default" {
+		t.Fatalf("Wrong value before the update: %v", v)
+	}
+	if err := s.UpdateOver(40, 59, func(v interface{}) interface{} { return "override" }); err != nil {
+		t.Fatalf("Error while updating: %s", err.Error())
+	}
+	if s.Len() != 3 {
+		t.Fatalf("Expected 3 ranges after the update, got %d", s.Len())
+	}
+	for key, want := range map[uint64]string{39: "default", 40: "override", 50: "override", 59: "override", 60: "default"} {
+		if v, err := s.RangeSearch(key); err != nil || v != want {
+			t.Fatalf("Wrong value for %d: %v", key, v)
+		}
+	}
+}
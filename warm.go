@@ -0,0 +1,119 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * warm.go: Pre-touching stores before they take traffic
+ */
+
+package rangestore
+
+import (
+	"context"
+	"os"
+)
+
+// Implemented by stores whose first lookups are slow, such as memory mapped
+// stores, which page their data in from disk as it's touched, and LazyStore,
+// which fetches its regions on demand. Warming such a store during a deploy,
+// before it takes traffic, moves that cost out of the lookups.
+type Warmer interface {
+	// Looks up each of sampleKeys, or if none are given, touches the whole
+	// store where that's possible. Returns the context's error if it's done
+	// before warming finishes.
+	Warm(ctx context.Context, sampleKeys []uint64) error
+}
+
+// How many pages or lookups are warmed between checks of the context
+const warmCheckInterval = 256
+
+// Keeps the reads made while touching pages from being optimized away
+var warmSink byte
+
+// Reads a byte of every page of data, so that a mapped file is paged in
+func touchPages(ctx context.Context, data []byte) error {
+	page := os.Getpagesize()
+	sum := byte(0)
+	for i, n := 0, 0; i < len(data); i, n = i+page, n+1 {
+		if n%warmCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		sum += data[i]
+	}
+	warmSink = sum
+	return nil
+}
+
+// Looks up each of the keys, returning the first error other than ErrOutOfRange
+func warmKeys(ctx context.Context, keys []uint64, search func(val uint64) error) error {
+	for i, key := range keys {
+		if i%warmCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := search(key); err != nil {
+			if _, ok := err.(ErrOutOfRange); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Pages in the parts of the file the lookups of sampleKeys go through, or if
+// none are given, the whole file. Returns the first ErrCorruptStore the lookups
+// run into.
+func (m *MappedStore) Warm(ctx context.Context, sampleKeys []uint64) error {
+	if len(sampleKeys) == 0 {
+		return touchPages(ctx, m.data)
+	}
+	return warmKeys(ctx, sampleKeys, func(val uint64) error {
+		_, err := m.RangeSearchBytes(val)
+		return err
+	})
+}
+
+// Pages in the parts of the file the lookups of sampleKeys go through, or if
+// none are given, the whole file. Returns the first ErrCorruptStore the lookups
+// run into.
+func (p *PackedStore) Warm(ctx context.Context, sampleKeys []uint64) error {
+	if len(sampleKeys) == 0 {
+		return touchPages(ctx, p.data)
+	}
+	return warmKeys(ctx, sampleKeys, func(val uint64) error {
+		_, err := p.RangeSearchBytes(val)
+		return err
+	})
+}
+
+// Loads the regions holding sampleKeys, returning the first error from fetching
+// or building one. The key space is usually far too large to load whole, so
+// nothing is loaded if no keys are given. Only as many regions as the store
+// keeps stay loaded, so the keys should come from the regions expected to be
+// busiest.
+func (s *LazyStore) Warm(ctx context.Context, sampleKeys []uint64) error {
+	return warmKeys(ctx, sampleKeys, func(val uint64) error {
+		_, err := s.RangeSearch(val)
+		return err
+	})
+}
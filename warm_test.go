@@ -0,0 +1,88 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * warm_test.go: Tests for warming stores
+ */
+
+package rangestore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWarm(t *testing.T) {
+	n, _ := NewRangeStoreFromSorted(arenaItems(1000))
+	n = n.MapValues(func(v interface{}) interface{} {
+		return fmt.Sprint(v)
+	})
+	var mapped, packed bytes.Buffer
+	if err := WriteMapped(&mapped, n); err != nil {
+		t.Fatalf("Error while mapping store: %s", err.Error())
+	}
+	if err := WritePacked(&packed, n, nil); err != nil {
+		t.Fatalf("Error while packing store: %s", err.Error())
+	}
+	m, _ := NewMappedStoreFromBytes(mapped.Bytes())
+	p, _ := NewPackedStore(packed.Bytes())
+
+	_, max := n.Bounds()
+	for name, w := range map[string]Warmer{"mapped": m, "packed": p} {
+		if err := w.Warm(context.Background(), nil); err != nil {
+			t.Fatalf("Error while warming the whole %s store: %s", name, err.Error())
+		}
+		// Keys beyond the store aren't errors
+		if err := w.Warm(context.Background(), []uint64{0, max / 2, max + 1}); err != nil {
+			t.Fatalf("Error while warming the %s store: %s", name, err.Error())
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := w.Warm(ctx, nil); err != context.Canceled {
+			t.Fatalf("Expected the %s store to stop once cancelled, got %v", name, err)
+		}
+	}
+}
+
+func TestLazyStore_Warm(t *testing.T) {
+	backend := &lazyBackend{items: arenaItems(1000)}
+	s := NewLazyStore(backend.fetch, 64, 4)
+	if err := s.Warm(context.Background(), []uint64{0, 1, 64, 200}); err != nil {
+		t.Fatalf("Error while warming: %s", err.Error())
+	}
+	if f := atomic.LoadUint64(&backend.fetches); f != 3 {
+		t.Fatalf("Expected 3 regions to be fetched, got %d", f)
+	}
+	// Warmed regions are served without fetching
+	s.RangeSearch(10)
+	s.RangeSearch(210)
+	if f := atomic.LoadUint64(&backend.fetches); f != 3 {
+		t.Fatalf("Expected lookups in warmed regions not to fetch, got %d fetches", f)
+	}
+
+	backend.fail = true
+	if err := s.Warm(context.Background(), []uint64{1000}); err == nil {
+		t.Fatalf("Expected the fetch error from warming")
+	}
+}
@@ -0,0 +1,191 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * watcher.go: Reloading stores when their source file changes
+ */
+
+package rangestore
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// The interval at which WatchFile checks the file, unless configured otherwise
+const DefaultPollInterval = 5 * time.Second
+
+// Configures WatchFile
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval time.Duration
+	onReload func(*RangeStore)
+	onError  func(error)
+	build    []Option
+}
+
+// Sets how often the file is checked for changes
+func PollInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = d
+	}
+}
+
+// Calls f with every store successfully loaded after the first one
+func OnReload(f func(*RangeStore)) WatchOption {
+	return func(o *watchOptions) {
+		o.onReload = f
+	}
+}
+
+// Calls f whenever the file changes but can't be loaded. The previous store
+// stays in place.
+func OnError(f func(error)) WatchOption {
+	return func(o *watchOptions) {
+		o.onError = f
+	}
+}
+
+// Builds the stores with the given options, see NewRangeStore
+func BuildOptions(opts ...Option) WatchOption {
+	return func(o *watchOptions) {
+		o.build = opts
+	}
+}
+
+// Serves a store loaded from a file, reloading it and swapping it in whenever
+// the file changes. Changes are detected by polling the modification time and
+// size of the file, which works on every platform and with files replaced by
+// renaming. Searches are answered by the most recently loaded store.
+type Watcher struct {
+	*SwappableStore
+	path    string
+	loader  func(io.Reader) ([]Ranged, error)
+	opts    watchOptions
+	modTime time.Time
+	size    int64
+	missing bool
+	stop    chan struct{}
+	stopped sync.Once
+	done    sync.WaitGroup
+}
+
+// Loads the store from the file at path using loader, and starts watching the
+// file for changes. Returns an error if the initial load fails. The watcher must
+// be closed once it's no longer needed.
+func WatchFile(path string, loader func(io.Reader) ([]Ranged, error), opts ...WatchOption) (*Watcher, error) {
+	w := &Watcher{
+		path:   path,
+		loader: loader,
+		opts:   watchOptions{interval: DefaultPollInterval},
+		stop:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	s, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	w.SwappableStore = NewVersionedSwappableStore(s, w.version())
+
+	w.done.Add(1)
+	go w.poll()
+	return w, nil
+}
+
+// Stops watching the file. The watcher keeps serving the last store it loaded.
+// Closing a watcher more than once has no further effect.
+func (w *Watcher) Close() error {
+	w.stopped.Do(func() { close(w.stop) })
+	w.done.Wait()
+	return nil
+}
+
+func (w *Watcher) poll() {
+	defer w.done.Done()
+	ticker := time.NewTicker(w.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(w.path)
+		if err != nil {
+			// Only report the file going missing once
+			if !w.missing {
+				w.missing = true
+				w.failed(err)
+			}
+			continue
+		}
+		w.missing = false
+		if fi.ModTime().Equal(w.modTime) && fi.Size() == w.size {
+			continue
+		}
+		s, err := w.load()
+		if err != nil {
+			w.failed(err)
+			continue
+		}
+		w.SwapVersioned(s, w.version())
+		if w.opts.onReload != nil {
+			w.opts.onReload(s)
+		}
+	}
+}
+
+// Describes the file as it was last loaded
+func (w *Watcher) version() StoreVersion {
+	return StoreVersion{Source: w.path, BuiltAt: time.Now()}
+}
+
+// Loads the file, remembering its modification time and size. Those are
+// remembered even if loading fails, so that a broken file is reported once
+// rather than on every poll.
+func (w *Watcher) load() (*RangeStore, error) {
+	f, err := os.Open(w.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+	items, err := w.loader(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewRangeStore(items, w.opts.build...)
+}
+
+func (w *Watcher) failed(err error) {
+	if w.opts.onError != nil {
+		w.opts.onError(err)
+	}
+}
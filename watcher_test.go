@@ -0,0 +1,165 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * watcher_test.go: Tests on the file watcher
+ */
+
+package rangestore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Reads "min max value" lines
+func lineLoader(r io.Reader) ([]Ranged, error) {
+	items := make([]Ranged, 0)
+	for {
+		var e RangeEntry
+		var v string
+		_, err := fmt.Fscanln(r, &e.Min, &e.Max, &v)
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		e.Value = v
+		items = append(items, e)
+	}
+}
+
+// Replaces the file by renaming, so the watcher never sees it half written
+func writeLines(t *testing.T, path, content string, mod time.Time) {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatalf("Error while writing file: %s", err.Error())
+	}
+	if err := os.Chtimes(tmp, mod, mod); err != nil {
+		t.Fatalf("Error while setting file times: %s", err.Error())
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("Error while replacing file: %s", err.Error())
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ranges.txt")
+	start := time.Now().Add(-time.Hour)
+	writeLines(t, path, "0 9 A\n10 19 B\n", start)
+
+	reloads := make(chan *RangeStore, 1)
+	failures := make(chan error, 1)
+	w, err := WatchFile(path, lineLoader,
+		PollInterval(5*time.Millisecond),
+		OnReload(func(s *RangeStore) { reloads <- s }),
+		OnError(func(err error) { failures <- err }),
+	)
+	if err != nil {
+		t.Fatalf("Error while watching file: %s", err.Error())
+	}
+	defer w.Close()
+	if v, err := w.RangeSearch(15); err != nil || v != "B" {
+		t.Fatalf("Wrong value before reloading")
+	}
+
+	writeLines(t, path, "0 19 C\n", start.Add(time.Minute))
+	select {
+	case s := <-reloads:
+		if s.Len() != 1 {
+			t.Fatalf("Wrong store reloaded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("File wasn't reloaded")
+	}
+	if v, version, err := w.RangeSearchVersioned(15); err != nil || v != "C" || version.Source != path || version.Generation != 1 {
+		t.Fatalf("Wrong value after reloading")
+	}
+
+	// A broken file is reported, and the previous store stays in place
+	writeLines(t, path, "0 19 D\n10 29 E\n", start.Add(2*time.Minute))
+	select {
+	case err := <-failures:
+		if _, ok := err.(ErrOverlap); !ok {
+			t.Fatalf("Expected an ErrOverlap, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Broken file wasn't reported")
+	}
+	if v, err := w.RangeSearch(15); err != nil || v != "C" {
+		t.Fatalf("Wrong value after a failed reload")
+	}
+}
+
+func TestWatchFile_InitialFailure(t *testing.T) {
+	_, err := WatchFile("/nonexistent/ranges.txt", lineLoader)
+	if !os.IsNotExist(err) {
+		t.Fatalf("Expected a missing file error, got %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ranges.txt")
+	writeLines(t, path, "0 9 A\n", time.Now())
+	broken := errors.New("broken")
+	_, err = WatchFile(path, func(io.Reader) ([]Ranged, error) { return nil, broken })
+	if err != broken {
+		t.Fatalf("Expected the loader error, got %v", err)
+	}
+}
+
+func TestWatcher_CloseTwice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangestore")
+	if err != nil {
+		t.Fatalf("Error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ranges.txt")
+	writeLines(t, path, "0 9 A\n", time.Now())
+	w, err := WatchFile(path, lineLoader, PollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Error while watching file: %s", err.Error())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error while closing watcher: %s", err.Error())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error while closing watcher again: %s", err.Error())
+	}
+	if v, err := w.RangeSearch(5); err != nil || v != "A" {
+		t.Fatalf("Closed watcher stopped serving its store: %v, %v", v, err)
+	}
+}
@@ -1,3 +1,6 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
 /**
  * Go Range Store
  *
@@ -102,13 +105,54 @@ func TestRangeStoreFromWeighted_Overflow(t *testing.T) {
 	_, err := NewRangeStoreFromWeighted(items)
 
 	if err == nil {
-		t.Fatalf("Expecting integer overflow error and got none")
+		t.Fatalf("Expecting a weight space error and got none")
 	}
-	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrUnsignedIntegerOverflow{}).Name() {
-		t.Fatalf("Expecting an ErrUnsignedIntegerOverflow, but got something else")
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrWeightSpaceExhausted{}).Name() {
+		t.Fatalf("Expecting an ErrWeightSpaceExhausted, but got something else")
 	}
 	msg := err.Error()
-	if msg != "Overflow adding 9223372036854775808 + 9223372036854775808" {
+	if msg != "Item 1 has a weight of 9223372036854775808, but only 9223372036854775807 remains before the total exceeds the key space" {
+		t.Fatalf("Wrong error message: %s", msg)
+	}
+
+	// Weights adding up to exactly the maximum still fit
+	items[1] = DefaultWeightedValue{MaxTotalWeight() - 1<<63, "B"}
+	for _, opts := range [][]Option{nil, {WithZeroBasedWeights()}} {
+		n, err := NewRangeStoreFromWeighted(items, opts...)
+		if err != nil {
+			t.Fatalf("Error while constructing range store: %s", err.Error())
+		}
+		if min, max := n.Bounds(); max-min != MaxTotalWeight()-1 {
+			t.Fatalf("Wrong bounds for the largest total weight: %d, %d", min, max)
+		}
+	}
+}
+
+func TestRangeStoreFromWeighted_ZeroWeight(t *testing.T) {
+	items := make([]Weighted, 0)
+	items = append(items, DefaultWeightedValue{25, "A"})
+	items = append(items, DefaultWeightedValue{0, "B"})
+	items = append(items, DefaultWeightedValue{75, "C"})
+
+	_, err := NewRangeStoreFromWeighted(items)
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrZeroWeight{}).Name() {
+		t.Fatalf("Expecting an ErrZeroWeight, but got something else")
+	}
+	if msg := err.Error(); msg != "Item 1 has a zero weight" {
 		t.Fatalf("Wrong error message: %s", msg)
 	}
+
+	n, err := NewRangeStoreFromWeighted(items, WithDropZeroWeights())
+	if err != nil {
+		t.Fatalf("Error while constructing range store: %s", err.Error())
+	}
+	expected := []RangeEntry{{1, 25, "A"}, {26, 100, "C"}}
+	if got := n.Ranges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong ranges: %v", got)
+	}
+
+	_, err = NewRangeStoreFromWeighted(items[1:2], WithDropZeroWeights())
+	if reflect.TypeOf(err).Name() != reflect.TypeOf(ErrEmptyInput{}).Name() {
+		t.Fatalf("Expecting an ErrEmptyInput, but got something else")
+	}
 }
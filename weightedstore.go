@@ -0,0 +1,436 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * weightedstore.go: Stores built from weights, used as distributions
+ */
+
+package rangestore
+
+import (
+	"math"
+	"math/bits"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// A store built from weighted values, as by NewRangeStoreFromWeighted, which
+// keeps track of the total weight so that it can be treated as a discrete
+// distribution over the values. Item i covers the keys from the total weight of
+// the items before it, plus one, up to the total weight including it; with
+// WithZeroBasedWeights every key is one lower. KeyBounds returns the keys which
+// are covered either way.
+//
+// Values can be disabled, e.g. to drain an upstream server, which takes them
+// out of Pick without rebuilding the store.
+type WeightedStore struct {
+	// Counts changes to the disabled values, so that adjustments built before
+	// one are rebuilt
+	disabledGen uint64
+	root        *Node
+	base        uint64
+	total       uint64
+	hasher      KeyHasher
+	mu          sync.Mutex
+	disabled    []interface{}
+	// The enabled items while any are disabled, as a *weightedSubset
+	enabled atomic.Value
+	// The *weightAdjustment last used by PickWithOverrides
+	adjusted atomic.Value
+}
+
+// Returned by PickExcluding when every value is excluded or disabled
+type ErrNothingToPick struct{}
+
+func (ex ErrNothingToPick) Error() string {
+	return "Every value is excluded"
+}
+
+func (ex ErrNothingToPick) Details() ErrorDetails {
+	return ErrorDetails{"ErrNothingToPick", map[string]interface{}{}}
+}
+
+// The items still enabled, with their cumulative weights
+type weightedSubset struct {
+	cum    []uint64
+	values []interface{}
+}
+
+// Builds a weighted store from the items, with the same constraints as
+// NewRangeStoreFromWeighted
+func NewWeightedStore(items []Weighted, opts ...Option) (*WeightedStore, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	n, err := NewRangeStoreFromWeighted(items, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if o.keyHasher == nil {
+		o.keyHasher = fnvKeyHasher
+	}
+	min, max := n.Bounds()
+	return &WeightedStore{root: n, base: min, total: max - min + 1, hasher: o.keyHasher}, nil
+}
+
+// Returns the root of the underlying tree
+func (w *WeightedStore) Root() *Node {
+	return w.root
+}
+
+// Returns the total weight of the items
+func (w *WeightedStore) Total() uint64 {
+	return w.total
+}
+
+// Returns the first and last keys covered by the items, which are 1 and the
+// total weight unless the store was built with WithZeroBasedWeights
+func (w *WeightedStore) KeyBounds() (min, max uint64) {
+	return w.base, w.base + w.total - 1
+}
+
+// Returns the value of the item covering the key
+func (w *WeightedStore) RangeSearch(val uint64) (interface{}, error) {
+	return w.root.RangeSearch(val)
+}
+
+// Returns the value of the item whose share of the cumulative weight contains
+// the p-th quantile, i.e. the first item for which the total weight up to and
+// including it is at least p times the total. p is clamped to [0, 1], with NaN
+// treated as 0. For totals above 2^53 the result is subject to the precision of
+// float64.
+func (w *WeightedStore) Quantile(p float64) interface{} {
+	key := uint64(1)
+	if p >= 1 {
+		key = w.total
+	} else if p > 0 {
+		if k := uint64(math.Ceil(p * float64(w.total))); k > 1 {
+			key = k
+		}
+		if key > w.total {
+			key = w.total
+		}
+	}
+	v, _ := w.root.RangeSearch(w.base + key - 1)
+	return v
+}
+
+// Returns the fraction of the total weight held by the items up to and including
+// the last one holding value, compared with reflect.DeepEqual, or 0 if no item
+// holds it. This is the cumulative distribution function of the store, taking the
+// items in the order they were given.
+func (w *WeightedStore) CDF(value interface{}) float64 {
+	cum := uint64(0)
+	w.root.walk(func(c *Node) {
+		if reflect.DeepEqual(c.value, value) {
+			cum = c.max - w.base + 1
+		}
+	})
+	return float64(cum) / float64(w.total)
+}
+
+// Returns how many of the keys in [lo, hi] each value covers, e.g. to attribute
+// a sampled slice of the weight space to the values it landed on. Keys outside
+// of KeyBounds are ignored, and the counts are exact. Dividing by the counts for
+// the whole of KeyBounds gives the fraction of each value's weight covered.
+// Values which can't be used as map keys are left out. Returns an empty map if
+// lo is greater than hi, and ErrSumOverflow if the count of a value doesn't fit
+// a uint64, which only happens when it covers every key.
+func (w *WeightedStore) Attribution(lo, hi uint64) (map[interface{}]uint64, error) {
+	ret := make(map[interface{}]uint64)
+	if lo > hi {
+		return ret, nil
+	}
+	overflow := false
+	w.root.walkBetween(lo, hi, func(c *Node) {
+		if overflow {
+			return
+		}
+		if t := reflect.TypeOf(c.value); t != nil && !t.Comparable() {
+			return
+		}
+		min, max := c.min, c.max
+		if min < lo {
+			min = lo
+		}
+		if max > hi {
+			max = hi
+		}
+		// Adds the width as max-min, then 1, since the width itself may be 2^64
+		count, c1 := bits.Add64(ret[c.value], max-min, 0)
+		count, c2 := bits.Add64(count, 1, 0)
+		overflow = c1|c2 != 0
+		ret[c.value] = count
+	})
+	if overflow {
+		return nil, ErrSumOverflow{lo, hi}
+	}
+	return ret, nil
+}
+
+// Picks a value at random, with each item chosen in proportion to its weight.
+// Disabled values are never picked; the others are picked in proportion to
+// their share of the weight which remains, or nil is returned if every value
+// is disabled.
+func (w *WeightedStore) Pick(r RandSource) interface{} {
+	if subset, _ := w.enabled.Load().(*weightedSubset); subset != nil {
+		return subset.pick(r)
+	}
+	min, max := w.KeyBounds()
+	v, _ := w.root.RangeSearch(uniformBetween(r, min, max))
+	return v
+}
+
+// Picks a value as Pick does, but always the same one for the same key, e.g. to
+// keep the requests of a session on the same upstream. The keys are hashed with
+// FNV-1a unless the store was built with WithKeyHasher, and the hash seeds the
+// draws, so the assignment is as proportional to the weights as Pick's. Disabling
+// or enabling values moves keys between the values which stay enabled as well.
+func (w *WeightedStore) PickSticky(key []byte) interface{} {
+	return w.Pick(&hashSource{w.hasher(key)})
+}
+
+func (s *weightedSubset) pick(r RandSource) interface{} {
+	if len(s.values) == 0 {
+		return nil
+	}
+	key := uniform(r, s.cum[len(s.cum)-1]) + 1
+	return s.values[sort.Search(len(s.cum), func(i int) bool {
+		return s.cum[i] >= key
+	})]
+}
+
+// Collects the items whose values don't match excluded
+func (w *WeightedStore) subset(excluded func(v interface{}) bool) *weightedSubset {
+	s := &weightedSubset{}
+	total := uint64(0)
+	w.root.walk(func(c *Node) {
+		if !excluded(c.value) {
+			total += c.max - c.min + 1
+			s.cum = append(s.cum, total)
+			s.values = append(s.values, c.value)
+		}
+	})
+	return s
+}
+
+// Stops Pick from returning the value, compared with reflect.DeepEqual, until it
+// is enabled again. Lookups by key, Quantile and CDF are unaffected.
+func (w *WeightedStore) Disable(value interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, d := range w.disabled {
+		if reflect.DeepEqual(d, value) {
+			return
+		}
+	}
+	w.disabled = append(w.disabled, value)
+	w.updateEnabled()
+}
+
+// Lets Pick return a disabled value again
+func (w *WeightedStore) Enable(value interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, d := range w.disabled {
+		if reflect.DeepEqual(d, value) {
+			// Copied, since PickExcluding reads the old list without the lock
+			disabled := make([]interface{}, 0, len(w.disabled)-1)
+			w.disabled = append(append(disabled, w.disabled[:i]...), w.disabled[i+1:]...)
+			w.updateEnabled()
+			return
+		}
+	}
+}
+
+// Number of picks PickExcluding tries before falling back to picking from the
+// items which remain
+const excludingAttempts = 8
+
+// Picks a value as Pick does, but never one for which excluded returns true,
+// e.g. to retry a request against a different upstream than the ones which
+// failed. The remaining values are picked in proportion to their weights.
+// Returns ErrNothingToPick if every value is excluded or disabled.
+//
+// While the excluded values only hold a small share of the weight, a few plain
+// picks find one which isn't excluded; otherwise the remaining items are
+// collected, which takes time linear in the number of items.
+func (w *WeightedStore) PickExcluding(r RandSource, excluded func(v interface{}) bool) (interface{}, error) {
+	for i := 0; i < excludingAttempts; i += 1 {
+		v := w.Pick(r)
+		if v == nil {
+			return nil, ErrNothingToPick{}
+		}
+		if !excluded(v) {
+			return v, nil
+		}
+	}
+
+	w.mu.Lock()
+	disabled := w.disabled
+	w.mu.Unlock()
+	subset := w.subset(func(v interface{}) bool {
+		for _, d := range disabled {
+			if reflect.DeepEqual(d, v) {
+				return true
+			}
+		}
+		return excluded(v)
+	})
+	if len(subset.values) == 0 {
+		return nil, ErrNothingToPick{}
+	}
+	return subset.pick(r), nil
+}
+
+// Rebuilds the enabled items after the disabled values have changed
+func (w *WeightedStore) updateEnabled() {
+	atomic.AddUint64(&w.disabledGen, 1)
+	if len(w.disabled) == 0 {
+		w.enabled.Store((*weightedSubset)(nil))
+		return
+	}
+	w.enabled.Store(w.subset(func(v interface{}) bool {
+		for _, d := range w.disabled {
+			if reflect.DeepEqual(d, v) {
+				return true
+			}
+		}
+		return false
+	}))
+}
+
+// The weights of a store with some of them overridden, see PickWithOverrides
+type weightAdjustment struct {
+	gen       uint64
+	overrides map[interface{}]uint64
+	// The ranges of the items whose values are overridden or disabled, in
+	// ascending order, which picks from the rest of the store skip over
+	excluded []RangeEntry
+	// The total weight of the rest of the store
+	rest uint64
+	// The overridden values which are in the store and enabled, with the
+	// cumulative sums of their new weights
+	cum    []uint64
+	values []interface{}
+}
+
+// Picks a value as Pick does, but with the weights of some of the values
+// replaced, e.g. to double the weight of a canary for a share of the requests,
+// without changing the store. Each value in overrides gets the given weight in
+// total, shared by all of the items holding it, and 0 stops it from being
+// picked; values which aren't in the store are ignored. Disabled values stay
+// disabled. Returns nil if nothing is left to pick. The adjusted weights must
+// add up to no more than MaxTotalWeight.
+//
+// Working out the adjusted weights takes time linear in the number of items,
+// but the result is kept for as long as the same overrides keep being passed,
+// after which a pick takes time logarithmic in the number of items plus linear
+// in the number of overridden values.
+func (w *WeightedStore) PickWithOverrides(r RandSource, overrides map[interface{}]uint64) interface{} {
+	a, _ := w.adjusted.Load().(*weightAdjustment)
+	if a == nil || a.gen != atomic.LoadUint64(&w.disabledGen) || !sameOverrides(a.overrides, overrides) {
+		a = w.adjust(overrides)
+		w.adjusted.Store(a)
+	}
+
+	total := a.rest
+	if len(a.cum) > 0 {
+		total += a.cum[len(a.cum)-1]
+	}
+	if total == 0 {
+		return nil
+	}
+	u := uniform(r, total)
+	if u >= a.rest {
+		u -= a.rest
+		return a.values[sort.Search(len(a.cum), func(i int) bool {
+			return a.cum[i] > u
+		})]
+	}
+	// Map u onto the keys of the store, skipping the excluded ranges
+	key := w.base + u
+	for _, e := range a.excluded {
+		if e.Min > key {
+			break
+		}
+		key += e.Max - e.Min + 1
+	}
+	v, _ := w.root.RangeSearch(key)
+	return v
+}
+
+func sameOverrides(a, b map[interface{}]uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if x, ok := b[k]; !ok || x != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Works out the weights of the store with the overrides applied
+func (w *WeightedStore) adjust(overrides map[interface{}]uint64) *weightAdjustment {
+	w.mu.Lock()
+	gen := atomic.LoadUint64(&w.disabledGen)
+	disabled := w.disabled
+	w.mu.Unlock()
+
+	a := &weightAdjustment{gen: gen, overrides: make(map[interface{}]uint64, len(overrides))}
+	for k, v := range overrides {
+		a.overrides[k] = v
+	}
+	seen := make(map[interface{}]bool)
+	w.root.walk(func(c *Node) {
+		overridden := false
+		if t := reflect.TypeOf(c.value); t == nil || t.Comparable() {
+			_, overridden = overrides[c.value]
+		}
+		off := false
+		for _, d := range disabled {
+			if reflect.DeepEqual(d, c.value) {
+				off = true
+				break
+			}
+		}
+		if !overridden && !off {
+			a.rest += c.max - c.min + 1
+			return
+		}
+		a.excluded = append(a.excluded, RangeEntry{c.min, c.max, c.value})
+		if overridden && !off && !seen[c.value] && overrides[c.value] > 0 {
+			seen[c.value] = true
+			total := overrides[c.value]
+			if len(a.cum) > 0 {
+				total += a.cum[len(a.cum)-1]
+			}
+			a.cum = append(a.cum, total)
+			a.values = append(a.values, c.value)
+		}
+	})
+	return a
+}
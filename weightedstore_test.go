@@ -0,0 +1,152 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * weightedstore_test.go: Tests on weighted stores
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func testWeightedStore(t *testing.T, opts ...Option) *WeightedStore {
+	vals := make([]Weighted, 0)
+	vals = append(vals, &DefaultWeightedValue{Weight: 25, Value: "A"})
+	vals = append(vals, &DefaultWeightedValue{Weight: 70, Value: "B"})
+	vals = append(vals, &DefaultWeightedValue{Weight: 5, Value: "C"})
+	w, err := NewWeightedStore(vals, opts...)
+	if err != nil {
+		t.Fatalf("Error while constructing weighted store: %s", err.Error())
+	}
+	return w
+}
+
+func TestWeightedStore_Quantile(t *testing.T) {
+	w := testWeightedStore(t)
+	if w.Total() != 100 {
+		t.Fatalf("Wrong total weight: %d", w.Total())
+	}
+	for p, expected := range map[float64]string{
+		-1: "A", 0: "A", 0.1: "A", 0.25: "A", 0.251: "B", 0.5: "B", 0.95: "B", 0.951: "C", 1: "C", 2: "C", math.NaN(): "A",
+	} {
+		if v := w.Quantile(p); v != expected {
+			t.Fatalf("Wrong value for quantile %f: %s [%s]", p, v, expected)
+		}
+	}
+}
+
+func TestWeightedStore_CDF(t *testing.T) {
+	w := testWeightedStore(t)
+	for value, expected := range map[string]float64{"A": 0.25, "B": 0.95, "C": 1, "D": 0} {
+		if got := w.CDF(value); got != expected {
+			t.Fatalf("Wrong CDF for %s: %f [%f]", value, got, expected)
+		}
+	}
+
+	if v, err := w.RangeSearch(26); err != nil || v != "B" {
+		t.Fatalf("Wrong value from searching the weighted store")
+	}
+	if w.Root().Len() != 3 {
+		t.Fatalf("Wrong number of ranges in the underlying tree")
+	}
+}
+
+func TestWeightedStore_Attribution(t *testing.T) {
+	w := testWeightedStore(t)
+
+	// A covers 1-25, B 26-95 and C 96-100
+	for bounds, expected := range map[[2]uint64]map[interface{}]uint64{
+		{1, 100}:   {"A": 25, "B": 70, "C": 5},
+		{20, 30}:   {"A": 6, "B": 5},
+		{26, 26}:   {"B": 1},
+		{90, 1000}: {"B": 6, "C": 5},
+		{0, 0}:     {},
+		{30, 20}:   {},
+	} {
+		if got, err := w.Attribution(bounds[0], bounds[1]); err != nil || !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Wrong attribution for %v: %v [%v]", bounds, got, expected)
+		}
+	}
+
+	vals := make([]Weighted, 0)
+	vals = append(vals, &DefaultWeightedValue{Weight: 10, Value: "A"})
+	vals = append(vals, &DefaultWeightedValue{Weight: 10, Value: []string{"unhashable"}})
+	vals = append(vals, &DefaultWeightedValue{Weight: 10, Value: "A"})
+	w, _ = NewWeightedStore(vals, WithZeroBasedWeights())
+	if got, err := w.Attribution(w.KeyBounds()); err != nil || !reflect.DeepEqual(got, map[interface{}]uint64{"A": 20}) {
+		t.Fatalf("Wrong attribution with a repeated value: %v", got)
+	}
+
+	// Counts covering every key don't fit, whether in one range or several
+	for _, items := range [][]Ranged{
+		{DefaultRangedValue{0, math.MaxUint64, "A"}},
+		{DefaultRangedValue{0, 1<<63 - 1, "A"}, DefaultRangedValue{1 << 63, math.MaxUint64, "A"}},
+	} {
+		n, _ := NewRangeStoreFromSorted(items)
+		w = &WeightedStore{root: n}
+		if _, err := w.Attribution(0, math.MaxUint64); reflect.TypeOf(err).Name() != "ErrSumOverflow" {
+			t.Fatalf("Expected ErrSumOverflow, got %v", err)
+		}
+	}
+	n, _ := NewRangeStoreFromSorted([]Ranged{DefaultRangedValue{0, 1<<63 - 1, "A"}, DefaultRangedValue{1 << 63, math.MaxUint64, "B"}})
+	w = &WeightedStore{root: n}
+	if got, err := w.Attribution(0, math.MaxUint64); err != nil || got["A"] != 1<<63 || got["B"] != 1<<63 {
+		t.Fatalf("Wrong attribution of the whole key space: %v, %v", got, err)
+	}
+}
+
+func TestWeightedStore_ZeroBased(t *testing.T) {
+	w := testWeightedStore(t)
+	if min, max := w.KeyBounds(); min != 1 || max != 100 {
+		t.Fatalf("Wrong key bounds: [%d, %d]", min, max)
+	}
+	if _, err := w.RangeSearch(0); err == nil {
+		t.Fatalf("Expected key 0 to be out of range by default")
+	}
+
+	w = testWeightedStore(t, WithZeroBasedWeights())
+	if min, max := w.KeyBounds(); min != 0 || max != 99 || w.Total() != 100 {
+		t.Fatalf("Wrong key bounds: [%d, %d]", min, max)
+	}
+	for key, expected := range map[uint64]string{0: "A", 24: "A", 25: "B", 94: "B", 95: "C", 99: "C"} {
+		if v, err := w.RangeSearch(key); err != nil || v != expected {
+			t.Fatalf("Wrong value for %d: %v [%s]", key, v, expected)
+		}
+	}
+	if _, err := w.RangeSearch(100); err == nil {
+		t.Fatalf("Expected the total weight to be out of range")
+	}
+	for p, expected := range map[float64]string{0: "A", 0.25: "A", 0.251: "B", 0.951: "C", 1: "C"} {
+		if v := w.Quantile(p); v != expected {
+			t.Fatalf("Wrong value for quantile %f: %s [%s]", p, v, expected)
+		}
+	}
+	if got := w.CDF("B"); got != 0.95 {
+		t.Fatalf("Wrong CDF for B: %f", got)
+	}
+	if v := w.Pick(&sequenceSource{values: []uint64{125}}); v != "B" {
+		t.Fatalf("Wrong pick: %v", v)
+	}
+}
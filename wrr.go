@@ -0,0 +1,109 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * wrr.go: Smooth weighted round robin
+ */
+
+package rangestore
+
+import (
+	"math"
+	"sync"
+)
+
+// Cycles through weighted values deterministically, as nginx balances between
+// upstream servers: over each run of total weight calls, every value is
+// returned exactly as many times as its weight, and the picks of each value are
+// spread out as evenly as possible rather than bunched together. With weights
+// 5, 1 and 1 for a, b and c, the sequence is a a b a c a a.
+//
+// SmoothWRR is safe for concurrent use.
+type SmoothWRR struct {
+	mu      sync.Mutex
+	values  []interface{}
+	weights []int64
+	current []int64
+	total   int64
+}
+
+// Creates a round robin over the items, which take the same options and are
+// subject to the same constraints as for NewRangeStoreFromWeighted. Since the
+// algorithm works with signed running totals, the total weight may be at most
+// math.MaxInt64; ErrUnsignedIntegerOverflow is returned beyond that.
+func NewSmoothWRR(items []Weighted, opts ...Option) (*SmoothWRR, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	w := &SmoothWRR{}
+	for idx, item := range items {
+		weight := item.GetWeight()
+		if weight == 0 {
+			if o.dropZeroWeights {
+				continue
+			}
+			return nil, ErrZeroWeight{idx}
+		}
+		if weight > math.MaxInt64-uint64(w.total) {
+			return nil, ErrUnsignedIntegerOverflow{uint64(w.total), weight}
+		}
+		w.total += int64(weight)
+		w.values = append(w.values, item.GetValue())
+		w.weights = append(w.weights, int64(weight))
+	}
+	if len(w.values) < 1 {
+		return nil, ErrEmptyInput{}
+	}
+	w.current = make([]int64, len(w.values))
+	return w, nil
+}
+
+// Returns the next value in the sequence
+func (w *SmoothWRR) Next() interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	best := 0
+	for i, weight := range w.weights {
+		// The running totals always sum to zero, so each lies within the
+		// total weight of zero and can't overflow
+		w.current[i] += weight
+		if w.current[i] > w.current[best] {
+			best = i
+		}
+	}
+	w.current[best] -= w.total
+	return w.values[best]
+}
+
+// Returns the total weight, which is the length of the repeating sequence
+func (w *SmoothWRR) Total() uint64 {
+	return uint64(w.total)
+}
+
+// Starts the sequence again from the beginning
+func (w *SmoothWRR) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.current {
+		w.current[i] = 0
+	}
+}
@@ -0,0 +1,94 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * wrr_test.go: Tests on the smooth weighted round robin
+ */
+
+package rangestore
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSmoothWRR_Next(t *testing.T) {
+	items := make([]Weighted, 0)
+	items = append(items, DefaultWeightedValue{5, "a"})
+	items = append(items, DefaultWeightedValue{1, "b"})
+	items = append(items, DefaultWeightedValue{1, "c"})
+	w, err := NewSmoothWRR(items)
+	if err != nil {
+		t.Fatalf("Error while building round robin: %s", err.Error())
+	}
+	seq := make([]string, 0)
+	for i := 0; i < 14; i++ {
+		seq = append(seq, w.Next().(string))
+	}
+	if strings.Join(seq, "") != "aabacaaaabacaa" {
+		t.Fatalf("Wrong sequence: %v", seq)
+	}
+	w.Reset()
+	if v := w.Next(); v != "a" {
+		t.Fatalf("Wrong value after reset: %v", v)
+	}
+	if w.Total() != 7 {
+		t.Fatalf("Wrong total: %d", w.Total())
+	}
+}
+
+func TestSmoothWRR_Counts(t *testing.T) {
+	items := make([]Weighted, 0)
+	weights := []uint64{3, 7, 1, 12, 2}
+	for i, weight := range weights {
+		items = append(items, DefaultWeightedValue{weight, i})
+	}
+	w, err := NewSmoothWRR(items)
+	if err != nil {
+		t.Fatalf("Error while building round robin: %s", err.Error())
+	}
+	counts := make([]uint64, len(weights))
+	for i := uint64(0); i < 2*w.Total(); i++ {
+		counts[w.Next().(int)] += 1
+	}
+	for i := range weights {
+		if counts[i] != 2*weights[i] {
+			t.Fatalf("Value %d was picked %d times", i, counts[i])
+		}
+	}
+}
+
+func TestNewSmoothWRR_Errors(t *testing.T) {
+	items := make([]Weighted, 0)
+	items = append(items, DefaultWeightedValue{0, "a"})
+	if _, err := NewSmoothWRR(items); reflect.TypeOf(err).Name() != "ErrZeroWeight" {
+		t.Fatalf("Expected an ErrZeroWeight, got %v", err)
+	}
+	if _, err := NewSmoothWRR(items, WithDropZeroWeights()); reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected an ErrEmptyInput, got %v", err)
+	}
+	items = append(items, DefaultWeightedValue{math.MaxInt64, "b"}, DefaultWeightedValue{1, "c"})
+	if _, err := NewSmoothWRR(items, WithDropZeroWeights()); reflect.TypeOf(err).Name() != "ErrUnsignedIntegerOverflow" {
+		t.Fatalf("Expected an ErrUnsignedIntegerOverflow, got %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * yaml.go: Loading stores from YAML configuration
+ */
+
+package rangestore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ErrYAMLLine struct {
+	line   int
+	reason string
+}
+
+func (ex ErrYAMLLine) Error() string {
+	return fmt.Sprintf("Line %d: %s", ex.line, ex.reason)
+}
+
+// Returns the line the error was found on, counting from 1
+func (ex ErrYAMLLine) Line() int {
+	return ex.line
+}
+
+// Returns why the entry was rejected
+func (ex ErrYAMLLine) Reason() string {
+	return ex.reason
+}
+
+func (ex ErrYAMLLine) Details() ErrorDetails {
+	return ErrorDetails{"ErrYAMLLine", map[string]interface{}{"line": ex.line, "reason": ex.reason}}
+}
+
+type ErrInvalidYAML struct {
+	errs []error
+}
+
+func (ex ErrInvalidYAML) Error() string {
+	return fmt.Sprintf("%d invalid entries, the first being: %s", len(ex.errs), ex.errs[0].Error())
+}
+
+// Returns the errors for each of the invalid entries, in line order
+func (ex ErrInvalidYAML) Errors() []error {
+	return ex.errs
+}
+
+func (ex ErrInvalidYAML) Details() ErrorDetails {
+	return ErrorDetails{"ErrInvalidYAML", map[string]interface{}{"errors": detailsOf(ex.errs)}}
+}
+
+// An entry of a YAML configuration, and the line it starts on
+type yamlEntry struct {
+	line   int
+	fields map[string]string
+	lines  map[string]int
+}
+
+// Builds a store from a YAML document holding either a list of ranges:
+//
+//	ranges:
+//	  - range: [0, 9]
+//	    value: low
+//	  - min: 10
+//	    max: 99
+//	    value: high
+//
+// which may be in any order and may leave gaps, or a list of weights, which
+// builds the same store as NewRangeStoreFromWeighted:
+//
+//	weights:
+//	  - weight: 3
+//	    value: a
+//
+// Values are read as strings. Only this block style subset of YAML is
+// understood, with plain or quoted scalars and comments. Rather than stopping
+// at the first problem, every invalid entry, including overlapping ranges, is
+// reported in a single ErrInvalidYAML holding an ErrYAMLLine for each.
+func LoadYAML(r io.Reader) (*Node, error) {
+	kind, entries, errs, err := parseYAMLEntries(r)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "" && len(errs) == 0 {
+		return nil, ErrEmptyInput{}
+	}
+
+	if kind == "weights" {
+		items := make([]Weighted, 0, len(entries))
+		for _, e := range entries {
+			weight, err := e.number("weight")
+			if err == nil && weight == 0 {
+				err = ErrYAMLLine{e.lines["weight"], "weight must be positive"}
+			}
+			if err == nil {
+				err = e.checkKeys("weight", "value")
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			items = append(items, DefaultWeightedValue{weight, e.fields["value"]})
+		}
+		if len(errs) > 0 {
+			return nil, ErrInvalidYAML{errs}
+		}
+		return NewRangeStoreFromWeighted(items)
+	}
+
+	type located struct {
+		RangeEntry
+		line int
+	}
+	ranges := make([]located, 0, len(entries))
+	for _, e := range entries {
+		var r located
+		var err error
+		r.line, r.Value = e.line, e.fields["value"]
+		if _, ok := e.fields["range"]; ok {
+			if err = e.checkKeys("range", "value"); err == nil {
+				r.Min, r.Max, err = e.pair("range")
+			}
+		} else if err = e.checkKeys("min", "max", "value"); err == nil {
+			if r.Min, err = e.number("min"); err == nil {
+				r.Max, err = e.number("max")
+			}
+		}
+		if err == nil && r.Min > r.Max {
+			err = ErrYAMLLine{e.line, ErrInvertedRange{r.Min, r.Max}.Error()}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Min < ranges[j].Min
+	})
+	items := make([]Ranged, 0, len(ranges))
+	for i, r := range ranges {
+		if i > 0 && r.Min <= ranges[i-1].Max {
+			errs = append(errs, ErrYAMLLine{r.line, fmt.Sprintf("range overlaps the one on line %d", ranges[i-1].line)})
+		}
+		items = append(items, r.RangeEntry)
+	}
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool {
+			return yamlErrLine(errs[i]) < yamlErrLine(errs[j])
+		})
+		return nil, ErrInvalidYAML{errs}
+	}
+	return rangeStoreFromSortedChecked(items, true, true)
+}
+
+func yamlErrLine(err error) int {
+	if e, ok := err.(ErrYAMLLine); ok {
+		return e.line
+	}
+	return 0
+}
+
+// Splits the document into the entries of its single top level list, which is
+// either "ranges" or "weights"
+func parseYAMLEntries(r io.Reader) (kind string, entries []*yamlEntry, errs []error, err error) {
+	scanner := bufio.NewScanner(r)
+	var current *yamlEntry
+	for line := 1; scanner.Scan(); line += 1 {
+		text := stripYAMLComment(scanner.Text())
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		if strings.Contains(text[:indent+1], "\t") {
+			errs = append(errs, ErrYAMLLine{line, "tabs can't be used for indentation"})
+			continue
+		}
+
+		if indent == 0 && !strings.HasPrefix(trimmed, "-") {
+			key := strings.TrimSuffix(trimmed, ":")
+			switch {
+			case key == trimmed:
+				errs = append(errs, ErrYAMLLine{line, fmt.Sprintf("expected a key, got %q", trimmed)})
+			case key != "ranges" && key != "weights":
+				errs = append(errs, ErrYAMLLine{line, fmt.Sprintf("unknown key %q, expected ranges or weights", key)})
+			case kind != "":
+				errs = append(errs, ErrYAMLLine{line, fmt.Sprintf("%s given after %s", key, kind)})
+			default:
+				kind = key
+			}
+			current = nil
+			continue
+		}
+		if kind == "" {
+			errs = append(errs, ErrYAMLLine{line, "entry outside of ranges or weights"})
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			current = &yamlEntry{line: line, fields: make(map[string]string), lines: make(map[string]int)}
+			entries = append(entries, current)
+			trimmed = strings.TrimSpace(trimmed[1:])
+			if trimmed == "" {
+				continue
+			}
+		} else if current == nil {
+			errs = append(errs, ErrYAMLLine{line, "expected a list entry starting with -"})
+			continue
+		}
+
+		colon := strings.Index(trimmed, ":")
+		if colon < 0 {
+			errs = append(errs, ErrYAMLLine{line, fmt.Sprintf("expected key: value, got %q", trimmed)})
+			continue
+		}
+		key := strings.TrimSpace(trimmed[:colon])
+		value, err := unquoteYAML(strings.TrimSpace(trimmed[colon+1:]))
+		if err != nil {
+			errs = append(errs, ErrYAMLLine{line, err.Error()})
+			continue
+		}
+		if _, ok := current.fields[key]; ok {
+			errs = append(errs, ErrYAMLLine{line, fmt.Sprintf("duplicate key %q", key)})
+			continue
+		}
+		current.fields[key], current.lines[key] = value, line
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, nil, err
+	}
+	return kind, entries, errs, nil
+}
+
+// Drops a comment, which starts with a # at the beginning of the line or after
+// a space, outside of quotes
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func unquoteYAML(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) {
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("bad quoted string %s", s)
+		}
+		return v, nil
+	}
+	if strings.HasPrefix(s, "'") {
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("bad quoted string %s", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	return s, nil
+}
+
+// Checks that the entry has a value and no keys besides those given
+func (e *yamlEntry) checkKeys(keys ...string) error {
+	if _, ok := e.fields["value"]; !ok {
+		return ErrYAMLLine{e.line, "missing value"}
+	}
+	for key := range e.fields {
+		found := false
+		for _, k := range keys {
+			found = found || k == key
+		}
+		if !found {
+			return ErrYAMLLine{e.lines[key], fmt.Sprintf("unexpected key %q", key)}
+		}
+	}
+	return nil
+}
+
+func (e *yamlEntry) number(key string) (uint64, error) {
+	s, ok := e.fields[key]
+	if !ok {
+		return 0, ErrYAMLLine{e.line, fmt.Sprintf("missing %s", key)}
+	}
+	v, err := strconv.ParseUint(strings.Replace(s, "_", "", -1), 0, 64)
+	if err != nil {
+		return 0, ErrYAMLLine{e.lines[key], fmt.Sprintf("bad %s %q", key, s)}
+	}
+	return v, nil
+}
+
+// Parses a flow sequence of two integers, [min, max]
+func (e *yamlEntry) pair(key string) (min, max uint64, err error) {
+	s := e.fields[key]
+	bad := ErrYAMLLine{e.lines[key], fmt.Sprintf("bad %s %q, expected [min, max]", key, s)}
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return 0, 0, bad
+	}
+	parts := strings.Split(s[1:len(s)-1], ",")
+	if len(parts) != 2 {
+		return 0, 0, bad
+	}
+	if min, err = strconv.ParseUint(strings.TrimSpace(parts[0]), 0, 64); err != nil {
+		return 0, 0, bad
+	}
+	if max, err = strconv.ParseUint(strings.TrimSpace(parts[1]), 0, 64); err != nil {
+		return 0, 0, bad
+	}
+	return min, max, nil
+}
@@ -0,0 +1,114 @@
+//go:build !rangestore_core
+// +build !rangestore_core
+
+/**
+ * Go Range Store
+ *
+ *    Copyright 2017 Tenta, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * For any questions, please contact developer@tenta.io
+ *
+ * yaml_test.go: Tests on the YAML loader
+ */
+
+package rangestore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadYAML_Ranges(t *testing.T) {
+	doc := `# Latency tiers
+ranges:
+  - range: [100, 999]
+    value: slow
+  - min: 0
+    max: 99   # inclusive
+    value: "fast # really"
+  - range: [0x400, 10_000]
+    value: 'very ''slow'''
+`
+	n, err := LoadYAML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Error while loading configuration: %s", err.Error())
+	}
+	expected := []RangeEntry{{0, 99, "fast # really"}, {100, 999, "slow"}, {1024, 10000, "very 'slow'"}}
+	if !reflect.DeepEqual(n.Ranges(), expected) {
+		t.Fatalf("Wrong ranges: %v", n.Ranges())
+	}
+}
+
+func TestLoadYAML_Weights(t *testing.T) {
+	doc := "weights:\n  - weight: 3\n    value: a\n  -\n    weight: 1\n    value: b\n"
+	n, err := LoadYAML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Error while loading configuration: %s", err.Error())
+	}
+	expected := []RangeEntry{{1, 3, "a"}, {4, 4, "b"}}
+	if !reflect.DeepEqual(n.Ranges(), expected) {
+		t.Fatalf("Wrong ranges: %v", n.Ranges())
+	}
+}
+
+func TestLoadYAML_Errors(t *testing.T) {
+	doc := `ranges:
+  - range: [0, 9]
+    value: a
+  - range: [5, 20]
+    value: b
+  - range: [30]
+    value: c
+  - min: 40
+    value: d
+  - range: [50, 59]
+    colour: red
+    value: e
+  - range: [70, 60]
+    value: f
+  - range: [80, 89]
+`
+	_, err := LoadYAML(strings.NewReader(doc))
+	e, ok := err.(ErrInvalidYAML)
+	if !ok {
+		t.Fatalf("Expected an ErrInvalidYAML, got %v", err)
+	}
+	lines := make([]int, 0)
+	for _, err := range e.Errors() {
+		lines = append(lines, err.(ErrYAMLLine).Line())
+	}
+	if !reflect.DeepEqual(lines, []int{4, 6, 8, 11, 13, 15}) {
+		t.Fatalf("Wrong lines %v in %v", lines, e.Errors())
+	}
+	if !strings.Contains(e.Errors()[0].Error(), "line 2") {
+		t.Fatalf("Overlap doesn't name the other entry: %s", e.Errors()[0].Error())
+	}
+
+	for _, doc := range []string{
+		"ranges:\n  - range: [0, 9]\n    value: a\nweights:\n",
+		"tiers:\n  - range: [0, 9]\n",
+		"  - range: [0, 9]\n",
+		"weights:\n  - weight: 0\n    value: a\n",
+		"ranges:\n\t- range: [0, 9]\n",
+	} {
+		if _, err := LoadYAML(strings.NewReader(doc)); reflect.TypeOf(err).Name() != "ErrInvalidYAML" {
+			t.Fatalf("Expected an ErrInvalidYAML for %q, got %v", doc, err)
+		}
+	}
+	if _, err := LoadYAML(strings.NewReader("# nothing\n")); reflect.TypeOf(err).Name() != "ErrEmptyInput" {
+		t.Fatalf("Expected an ErrEmptyInput, got %v", err)
+	}
+}